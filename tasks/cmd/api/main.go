@@ -11,10 +11,24 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/moabdelazem/microservices/tasks/internal/buildinfo"
 	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/escalation"
 	"github.com/moabdelazem/microservices/tasks/internal/handlers"
+	"github.com/moabdelazem/microservices/tasks/internal/metrics"
 	"github.com/moabdelazem/microservices/tasks/internal/middleware"
+	"github.com/moabdelazem/microservices/tasks/internal/openapi"
+	"github.com/moabdelazem/microservices/tasks/internal/overdue"
 	"github.com/moabdelazem/microservices/tasks/internal/rabbitmq"
+	"github.com/moabdelazem/microservices/tasks/internal/recurrence"
+	"github.com/moabdelazem/microservices/tasks/internal/reminders"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/snooze"
+	"github.com/moabdelazem/microservices/tasks/internal/storage"
+	"github.com/moabdelazem/microservices/tasks/internal/stream"
+	"github.com/moabdelazem/microservices/tasks/internal/trash"
+	"github.com/moabdelazem/microservices/tasks/internal/versioning"
+	"github.com/moabdelazem/microservices/tasks/internal/webhooks"
 )
 
 func main() {
@@ -45,6 +59,35 @@ func main() {
 		log.Fatalf("❌ Failed to start RabbitMQ consumer: %v", err)
 	}
 
+	// Publish task events on the same connection
+	publisher, err := rabbitmq.NewPublisher(consumer.Connection())
+	if err != nil {
+		log.Fatalf("❌ Failed to create RabbitMQ publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	// Record events for the SSE streams and queue them for webhooks on their
+	// way to RabbitMQ
+	events := stream.NewRecorder(db, webhooks.NewEnqueuer(db, publisher))
+	stream.NewPruner(db).Start(ctx)
+	webhooks.NewWorker(db).Start(ctx)
+
+	// Create the next occurrence of completed recurring tasks
+	indexer := search.New()
+	recurrence.NewScheduler(db, indexer, events).Start(ctx)
+
+	// Publish task.reminder.due events when reminders come due
+	reminders.NewWorker(db, events).Start(ctx)
+
+	// Raise the priority of tasks that stay overdue
+	escalation.NewWorker(db, indexer, events).Start(ctx)
+	overdue.NewWorker(db, indexer, events).Start(ctx)
+	snooze.NewSweeper(db, indexer, events).Start(ctx)
+
+	// Permanently delete tasks once they have been in the trash too long
+	store := storage.New()
+	trash.NewPurger(db, store).Start(ctx)
+
 	// Setup Gin
 	if os.Getenv("ENV") == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -54,24 +97,31 @@ func main() {
 	router.Use(gin.Recovery())
 	router.Use(middleware.Logger())
 	router.Use(middleware.CORS())
+	router.Use(middleware.PrettyJSON())
 
 	// Initialize handlers
-	taskHandler := handlers.NewTaskHandler(db)
+	taskHandler := handlers.NewTaskHandler(db, indexer, events, store)
+	readinessHandler := handlers.NewReadinessHandler(db, consumer)
+	consumerHandler := handlers.NewConsumerHandler(consumer)
 
-	// Public routes
+	// Unversioned operational routes
 	router.GET("/health", taskHandler.Health)
+	router.GET("/ready", readinessHandler.Ready)
+	router.GET("/metrics", metrics.Handler())
 
-	// Protected routes
-	api := router.Group("/api/tasks")
-	api.Use(middleware.AuthMiddleware(db))
-	{
-		api.POST("", taskHandler.CreateTask)
-		api.GET("", taskHandler.GetTasks)
-		api.GET("/:id", taskHandler.GetTask)
-		api.PUT("/:id", taskHandler.UpdateTask)
-		api.DELETE("/:id", taskHandler.DeleteTask)
-		api.GET("/stats/summary", taskHandler.GetStats)
-	}
+	// The API is served under /api/v1; the unversioned /api paths are
+	// aliases of v1
+	apiRouter := versioning.Mount(router, 1, versioning.Version{
+		Number: 1,
+		Routes: func(root *gin.RouterGroup) {
+			registerV1(root, db, taskHandler, consumerHandler)
+		},
+	})
+
+	// API docs, built from the routes registered above
+	spec := openapi.Build(openapi.Info{Title: "Tasks Service API", Version: buildinfo.Version}, router.Routes(), "/api/v1", apiOperations)
+	router.GET("/openapi.json", spec.Handler())
+	router.GET("/docs", openapi.UI("Tasks Service API", "/openapi.json"))
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -81,8 +131,10 @@ func main() {
 
 	srv := &http.Server{
 		Addr:    ":" + port,
-		Handler: router,
+		Handler: apiRouter,
 	}
+	// Event streams never finish on their own, so end them on shutdown
+	srv.RegisterOnShutdown(taskHandler.CloseStreams)
 
 	// Graceful shutdown
 	go func() {
@@ -112,3 +164,135 @@ func main() {
 
 	log.Println("✅ Server exited gracefully")
 }
+
+// registerV1 registers version 1 of the API on its root
+func registerV1(root *gin.RouterGroup, db *database.DB, taskHandler *handlers.TaskHandler, consumerHandler *handlers.ConsumerHandler) {
+	// Public routes
+	root.GET("/tasks/shared/:token", taskHandler.GetSharedTask)
+	// Calendar apps cannot send a JWT, so the feed also accepts ?token=
+	root.GET("/tasks/export.ics", taskHandler.CalendarAuth(middleware.AuthMiddleware(db)), taskHandler.ExportCalendar)
+
+	// Protected routes
+	api := root.Group("/tasks")
+	api.Use(middleware.AuthMiddleware(db))
+	{
+		api.POST("", taskHandler.Idempotent(), taskHandler.CreateTask)
+		api.GET("", taskHandler.GetTasks)
+		api.DELETE("", taskHandler.DeleteTasks)
+		api.GET("/stream", taskHandler.StreamTasks)
+		api.POST("/bulk", taskHandler.CreateTasksBulk)
+		api.PATCH("/bulk", taskHandler.UpdateTasksBulk)
+		api.GET("/:id", taskHandler.GetTask)
+		api.PUT("/:id", taskHandler.UpdateTask)
+		api.PATCH("/:id", taskHandler.PatchTask)
+		api.DELETE("/:id", taskHandler.DeleteTask)
+		api.GET("/stats/summary", taskHandler.GetStats)
+		api.GET("/stats/overview", taskHandler.GetStatsOverview)
+		api.GET("/stats/trends", taskHandler.GetStatsTrends)
+		api.GET("/stats/compare", taskHandler.GetStatsCompare)
+		api.GET("/week", taskHandler.GetWeekPlan)
+		api.GET("/calendar", taskHandler.GetCalendar)
+		api.GET("/trash", taskHandler.GetTrash)
+		api.GET("/tags", taskHandler.GetTags)
+		api.GET("/tags/:tag/tasks", taskHandler.GetTasksByTag)
+		api.GET("/due/:date", taskHandler.GetTasksDueOn)
+		api.POST("/templates", taskHandler.CreateTemplate)
+		api.GET("/templates", taskHandler.GetTemplates)
+		api.POST("/templates/:id/instantiate", taskHandler.InstantiateTemplate)
+		api.POST("/views", taskHandler.CreateView)
+		api.GET("/views", taskHandler.GetViews)
+		api.DELETE("/views/:id", taskHandler.DeleteView)
+		api.GET("/views/:id/tasks", taskHandler.GetViewTasks)
+		api.POST("/import", taskHandler.ImportTasks)
+		api.POST("/import/csv", taskHandler.ImportTasksCSV)
+		api.GET("/export", taskHandler.ExportTasks)
+		api.POST("/calendar/token", taskHandler.CreateCalendarToken)
+		api.DELETE("/calendar/token", taskHandler.RevokeCalendarToken)
+		api.POST("/:id/complete", taskHandler.CompleteTask)
+		api.POST("/:id/reopen", taskHandler.ReopenTask)
+		api.POST("/:id/duplicate", taskHandler.DuplicateTask)
+		api.POST("/:id/move", taskHandler.MoveTask)
+		api.POST("/:id/timer/start", taskHandler.StartTimer)
+		api.POST("/:id/timer/stop", taskHandler.StopTimer)
+		api.POST("/:id/restore", taskHandler.RestoreTask)
+		api.POST("/:id/archive", taskHandler.ArchiveTask)
+		api.POST("/:id/unarchive", taskHandler.UnarchiveTask)
+		api.POST("/:id/pin", taskHandler.PinTask)
+		api.POST("/:id/unpin", taskHandler.UnpinTask)
+		api.POST("/:id/snooze", taskHandler.SnoozeTask)
+		api.DELETE("/:id/snooze", taskHandler.UnsnoozeTask)
+		api.POST("/:id/recurrence/pause", taskHandler.PauseRecurrence)
+		api.POST("/:id/recurrence/resume", taskHandler.ResumeRecurrence)
+		api.POST("/:id/dependencies", taskHandler.AddDependency)
+		api.GET("/:id/dependencies", taskHandler.GetDependencies)
+		api.DELETE("/:id/dependencies/:blockerId", taskHandler.RemoveDependency)
+		api.POST("/:id/subtasks", taskHandler.CreateSubtask)
+		api.GET("/:id/subtasks", taskHandler.GetSubtasks)
+		api.GET("/:id/history", taskHandler.GetTaskHistory)
+		api.GET("/:id/deadline-changes", taskHandler.GetDeadlineChanges)
+		api.POST("/:id/checklist", taskHandler.AddChecklistItem)
+		api.GET("/:id/checklist", taskHandler.GetChecklist)
+		api.POST("/:id/checklist/:itemId/toggle", taskHandler.ToggleChecklistItem)
+		api.POST("/:id/checklist/:itemId/move", taskHandler.MoveChecklistItem)
+		api.DELETE("/:id/checklist/:itemId", taskHandler.DeleteChecklistItem)
+		api.POST("/:id/comments", taskHandler.CreateComment)
+		api.GET("/:id/comments", taskHandler.GetComments)
+		api.DELETE("/:id/comments/:commentId", taskHandler.DeleteComment)
+		api.POST("/:id/attachments", taskHandler.UploadAttachment)
+		api.GET("/:id/attachments", taskHandler.GetAttachments)
+		api.GET("/:id/attachments/:attachmentId", taskHandler.DownloadAttachment)
+		api.DELETE("/:id/attachments/:attachmentId", taskHandler.DeleteAttachment)
+		api.POST("/:id/links", taskHandler.CreateLink)
+		api.GET("/:id/links", taskHandler.GetLinks)
+		api.DELETE("/:id/links/:linkId", taskHandler.DeleteLink)
+		api.POST("/:id/reminders", taskHandler.CreateReminder)
+		api.GET("/:id/reminders", taskHandler.GetReminders)
+		api.DELETE("/:id/reminders/:reminderId", taskHandler.DeleteReminder)
+		api.GET("/:id/share", taskHandler.ShareTask)
+		api.POST("/:id/share", taskHandler.ShareTaskWithUser)
+		api.DELETE("/:id/share/:userId", taskHandler.UnshareTask)
+		api.POST("/:id/watch", taskHandler.WatchTask)
+		api.DELETE("/:id/watch", taskHandler.UnwatchTask)
+		api.GET("/:id/watchers", taskHandler.GetWatchers)
+		api.POST("/:id/transfer", taskHandler.TransferTask)
+		api.GET("/transfers/pending", taskHandler.GetPendingTransfers)
+		api.POST("/transfers/:transferId/accept", taskHandler.AcceptTransfer)
+		api.POST("/transfers/:transferId/reject", taskHandler.RejectTransfer)
+		api.POST("/:id/delegate", taskHandler.DelegateTask)
+		api.GET("/delegated/pending", taskHandler.GetPendingDelegations)
+		api.POST("/delegations/:delegationId/accept", taskHandler.AcceptDelegation)
+		api.POST("/delegations/:delegationId/decline", taskHandler.DeclineDelegation)
+	}
+
+	projects := root.Group("/projects")
+	projects.Use(middleware.AuthMiddleware(db))
+	{
+		projects.POST("", taskHandler.CreateProject)
+		projects.GET("", taskHandler.GetProjects)
+		projects.GET("/:id", taskHandler.GetProject)
+		projects.GET("/:id/summary", taskHandler.GetProjectSummary)
+		projects.PUT("/:id", taskHandler.UpdateProject)
+		projects.DELETE("/:id", taskHandler.DeleteProject)
+	}
+
+	hooks := root.Group("/webhooks")
+	hooks.Use(middleware.AuthMiddleware(db))
+	{
+		hooks.POST("", taskHandler.CreateWebhook)
+		hooks.GET("", taskHandler.GetWebhooks)
+		hooks.PUT("/:id", taskHandler.UpdateWebhook)
+		hooks.DELETE("/:id", taskHandler.DeleteWebhook)
+		hooks.GET("/:id/deliveries", taskHandler.GetWebhookDeliveries)
+	}
+
+	// Admin routes
+	admin := api.Group("/admin")
+	admin.Use(middleware.RequireAdmin())
+	{
+		admin.POST("/stats", taskHandler.GetUsersStats)
+		admin.POST("/consumer/pause", consumerHandler.Pause)
+		admin.POST("/consumer/resume", consumerHandler.Resume)
+		admin.GET("/consumer/dead-letters", consumerHandler.InspectDeadLetters)
+		admin.POST("/consumer/dead-letters/requeue", consumerHandler.RequeueDeadLetters)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/openapi"
+	"github.com/moabdelazem/microservices/tasks/internal/rabbitmq"
+)
+
+// Shared shapes of the documented responses
+var (
+	pageInfo  = openapi.Object{"page": 0, "limit": 0, "total": 0}
+	listInfo  = openapi.Object{"page": 0, "limit": 0, "total": 0, "next_cursor": (*string)(nil), "prev_cursor": (*string)(nil)}
+	taskList  = openapi.Object{"tasks": []models.Task{}, "counts": map[string]int{}, "pagination": listInfo}
+	taskBody  = openapi.Object{"message": "", "task": models.Task{}}
+	message   = openapi.Object{"message": ""}
+	shareLink = openapi.Object{"token": "", "path": "", "expires_at": (*string)(nil)}
+
+	pageParams = []openapi.Param{
+		{Name: "page", Type: "integer", Description: "Page number, from 1"},
+		{Name: "limit", Type: "integer", Description: "Page size, up to PAGINATION_MAX_LIMIT"},
+	}
+	tzParam       = openapi.Param{Name: "tz", Description: "IANA timezone for day boundaries; X-Timezone also works"}
+	forceParam    = openapi.Param{Name: "force", Type: "boolean", Description: "Ignore open blockers"}
+	deadLetterMax = openapi.Param{Name: "max", Type: "integer", Description: "Messages to handle, up to RABBITMQ_DEAD_LETTER_MAX"}
+	listParams    = []openapi.Param{
+		{Name: "cursor", Description: "Cursor pagination; empty for the first page, then a next_cursor or prev_cursor"},
+		{Name: "fields", Description: "Comma-separated task fields to return"},
+		{Name: "expand", Description: "Comma-separated related data to embed: subtasks, tags"},
+		{Name: "near", Description: "<latitude>,<longitude> to match tasks within radius_km"},
+		{Name: "render", Description: "html to add description_html"},
+		tzParam,
+	}
+)
+
+// apiOperations documents the routes of version 1 for /openapi.json, keyed
+// by method and path relative to /api/v1. Routes missing here are still
+// listed, with a summary taken from their handler's name.
+var apiOperations = map[string]openapi.Operation{
+	"GET /health":  {Summary: "Health check with build information", Public: true, Response: openapi.Object{"status": "", "service": "", "version": "", "commit": "", "go_version": "", "started_at": time.Time{}, "uptime_seconds": int64(0)}},
+	"GET /ready":   {Summary: "Readiness of the database and consumer", Public: true, Response: openapi.Object{"status": "", "service": "", "database": "", "consumer": openapi.Object{"circuit_breaker": "", "paused": false}}},
+	"GET /metrics": {Summary: "Prometheus metrics", Public: true, ResponseType: "text/plain"},
+
+	"GET /tasks/shared/:token": {Summary: "Read a task through a share link", Public: true, Response: openapi.Object{"task": models.SharedTask{}, "expires_at": time.Time{}}},
+	"GET /tasks/export.ics": {
+		Summary:      "iCalendar feed of due tasks",
+		Description:  "Authenticated with a JWT or a calendar feed token in ?token=.",
+		Public:       true,
+		Params:       []openapi.Param{{Name: "token", Description: "Calendar feed token"}},
+		ResponseType: "text/calendar",
+	},
+
+	"POST /tasks": {
+		Summary:     "Create a task",
+		Description: "Send an Idempotency-Key header to make retries return the original response.",
+		Request:     models.CreateTaskRequest{},
+		Params:      []openapi.Param{{Name: "expand", Description: "subtasks, tags"}},
+		Status:      http.StatusCreated,
+		Response:    taskBody,
+	},
+	"GET /tasks": {
+		Summary:     "List tasks",
+		Description: "Custom fields are filtered with meta.<key>=<value>. Responses carry an ETag for If-None-Match.",
+		Query:       models.TaskFilters{},
+		Params:      listParams,
+		Response:    taskList,
+	},
+	"DELETE /tasks": {
+		Summary:  "Trash the tasks matching filters",
+		Query:    models.TaskFilters{},
+		Params:   []openapi.Param{{Name: "confirm", Type: "boolean", Description: "Required to delete"}},
+		Response: openapi.Object{"message": "", "result": models.BulkDeleteResult{}},
+	},
+	"POST /tasks/bulk": {
+		Summary:  "Create tasks in one transaction",
+		Request:  []models.CreateTaskRequest{},
+		Status:   http.StatusCreated,
+		Response: openapi.Object{"message": "", "tasks": []models.Task{}},
+	},
+	"PATCH /tasks/bulk": {
+		Summary:  "Update the tasks matching a filter or list of ids",
+		Request:  models.BulkUpdateRequest{},
+		Params:   []openapi.Param{forceParam},
+		Response: openapi.Object{"message": "", "updated": 0, "results": []models.BulkUpdateResult{}},
+	},
+	"GET /tasks/:id": {
+		Summary:  "Get a task",
+		Params:   []openapi.Param{{Name: "fields", Description: "Comma-separated task fields to return"}, {Name: "expand", Description: "subtasks, tags"}, {Name: "render", Description: "html to add description_html"}},
+		Response: openapi.Object{"task": models.Task{}},
+	},
+	"PUT /tasks/:id": {Summary: "Update a task", Request: models.UpdateTaskRequest{}, Params: []openapi.Param{forceParam}, Response: taskBody},
+	"PATCH /tasks/:id": {
+		Summary:     "Apply a JSON Merge Patch to a task",
+		Description: "Fields set to null are removed; metadata is merged key by key.",
+		Request:     models.UpdateTaskRequest{},
+		RequestType: "application/merge-patch+json",
+		Params:      []openapi.Param{forceParam},
+		Response:    taskBody,
+	},
+	"DELETE /tasks/:id": {Summary: "Move a task to the trash", Response: message},
+	"GET /tasks/stream": {
+		Summary:      "Server-Sent Events stream of task events",
+		Description:  "Reconnect with Last-Event-ID to receive missed events. At most STREAM_MAX_PER_USER open streams per user.",
+		Params:       []openapi.Param{{Name: "last_event_id", Type: "integer", Description: "Resume after this event, for clients that cannot send Last-Event-ID"}},
+		ResponseType: "text/event-stream",
+	},
+
+	"GET /tasks/stats/summary":  {Summary: "Task statistics", Params: []openapi.Param{tzParam}, Response: openapi.Object{"stats": models.TaskStats{}}},
+	"GET /tasks/stats/overview": {Summary: "Dashboard overview", Params: []openapi.Param{tzParam}, Response: openapi.Object{"overview": models.TaskStatsOverview{}}},
+	"GET /tasks/stats/trends": {
+		Summary:  "Created and completed counts over time",
+		Params:   []openapi.Param{{Name: "granularity", Description: "day or week"}, {Name: "range", Description: "e.g. 30d or 12w"}, tzParam},
+		Response: openapi.Object{"trends": models.TaskTrends{}},
+	},
+	"GET /tasks/stats/compare": {Summary: "This week compared with last week", Params: []openapi.Param{tzParam}, Response: openapi.Object{"comparison": models.TaskStatsComparison{}}},
+	"GET /tasks/week":          {Summary: "Weekly plan", Params: []openapi.Param{{Name: "start", Description: "Any day of the week, YYYY-MM-DD"}, tzParam}, Response: openapi.Object{"week": models.WeekPlan{}}},
+	"GET /tasks/calendar": {
+		Summary:  "Calendar of due tasks and recurring occurrences",
+		Params:   []openapi.Param{{Name: "from", Description: "YYYY-MM-DD"}, {Name: "to", Description: "YYYY-MM-DD"}, tzParam},
+		Response: openapi.Object{"calendar": models.Calendar{}},
+	},
+	"GET /tasks/trash":           {Summary: "List trashed tasks", Params: pageParams, Response: openapi.Object{"tasks": []models.Task{}, "retention": "", "pagination": pageInfo}},
+	"GET /tasks/tags":            {Summary: "List tags with task counts", Response: openapi.Object{"tags": []models.TagCount{}}},
+	"GET /tasks/tags/:tag/tasks": {Summary: "List tasks with a tag", Query: models.TaskFilters{}, Params: listParams, Response: taskList},
+	"GET /tasks/due/:date":       {Summary: "List tasks due on a date", Query: models.DueOnFilters{}, Params: []openapi.Param{tzParam}, Response: openapi.Object{"date": "", "tasks": []models.Task{}, "pagination": pageInfo}},
+
+	"POST /tasks/templates":                 {Summary: "Save a task template", Request: models.CreateTemplateRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "template": models.TaskTemplate{}}},
+	"GET /tasks/templates":                  {Summary: "List task templates", Response: openapi.Object{"templates": []models.TaskTemplate{}}},
+	"POST /tasks/templates/:id/instantiate": {Summary: "Create a task from a template", Request: models.InstantiateTemplateRequest{}, Status: http.StatusCreated, Response: taskBody},
+	"POST /tasks/views":                     {Summary: "Save a view of list filters", Request: models.CreateSavedViewRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "view": models.SavedView{}}},
+	"GET /tasks/views":                      {Summary: "List saved views", Response: openapi.Object{"views": []models.SavedView{}}},
+	"DELETE /tasks/views/:id":               {Summary: "Delete a saved view", Response: message},
+	"GET /tasks/views/:id/tasks":            {Summary: "List the tasks of a saved view", Params: append(append([]openapi.Param{}, pageParams...), listParams...), Response: taskList},
+
+	"POST /tasks/import": {
+		Summary:     "Import tasks from a CSV or JSON upload",
+		RequestType: "multipart/form-data",
+		Request:     openapi.Object{"file": ""},
+		Params:      []openapi.Param{{Name: "format", Description: "csv or json; detected from the file by default"}, {Name: "dry_run", Type: "boolean"}, {Name: "blank_titles", Description: "skip or untitled"}},
+		Response:    openapi.Object{"message": "", "result": models.ImportResult{}},
+	},
+	"POST /tasks/import/csv": {
+		Summary:     "Import tasks from a CSV upload",
+		RequestType: "multipart/form-data",
+		Request:     openapi.Object{"file": ""},
+		Response:    openapi.Object{"message": "", "result": models.ImportResult{}},
+	},
+	"GET /tasks/export":            {Summary: "Export tasks as CSV", Query: models.TaskFilters{}, Params: []openapi.Param{{Name: "format", Description: "csv"}}, ResponseType: "text/csv"},
+	"POST /tasks/calendar/token":   {Summary: "Issue a calendar feed token", Response: openapi.Object{"token": "", "path": ""}},
+	"DELETE /tasks/calendar/token": {Summary: "Revoke the calendar feed token", Response: message},
+
+	"POST /tasks/:id/complete":                  {Summary: "Complete a task", Params: []openapi.Param{forceParam}, Response: taskBody},
+	"POST /tasks/:id/reopen":                    {Summary: "Reopen a task", Request: models.ReopenTaskRequest{}, Response: taskBody},
+	"POST /tasks/:id/duplicate":                 {Summary: "Copy a task as a new pending task", Request: models.DuplicateTaskRequest{}, Status: http.StatusCreated, Response: taskBody},
+	"POST /tasks/:id/move":                      {Summary: "Move a task on the kanban board", Request: models.MoveTaskRequest{}, Params: []openapi.Param{forceParam}, Response: taskBody},
+	"POST /tasks/:id/timer/start":               {Summary: "Start a timer", Status: http.StatusCreated, Response: openapi.Object{"message": "", "entry": models.TimeEntry{}}},
+	"POST /tasks/:id/timer/stop":                {Summary: "Stop the running timer", Response: openapi.Object{"message": "", "entry": models.TimeEntry{}, "duration_seconds": int64(0), "tracked_seconds": int64(0)}},
+	"POST /tasks/:id/restore":                   {Summary: "Restore a trashed task", Response: taskBody},
+	"POST /tasks/:id/archive":                   {Summary: "Archive a task and its subtasks", Response: taskBody},
+	"POST /tasks/:id/unarchive":                 {Summary: "Unarchive a task and its subtasks", Response: taskBody},
+	"POST /tasks/:id/pin":                       {Summary: "Pin a task", Response: taskBody},
+	"POST /tasks/:id/unpin":                     {Summary: "Unpin a task", Response: taskBody},
+	"POST /tasks/:id/snooze":                    {Summary: "Snooze a task", Request: models.SnoozeTaskRequest{}, Response: taskBody},
+	"DELETE /tasks/:id/snooze":                  {Summary: "Wake a snoozed task", Response: taskBody},
+	"POST /tasks/:id/recurrence/pause":          {Summary: "Pause a recurrence", Response: taskBody},
+	"POST /tasks/:id/recurrence/resume":         {Summary: "Resume a recurrence", Response: taskBody},
+	"POST /tasks/:id/dependencies":              {Summary: "Add a blocker", Request: models.AddDependencyRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "dependency": models.TaskDependency{}}},
+	"GET /tasks/:id/dependencies":               {Summary: "List blockers and blocked tasks", Response: openapi.Object{"blocked_by": []models.Task{}, "blocking": []models.Task{}}},
+	"DELETE /tasks/:id/dependencies/:blockerId": {Summary: "Remove a blocker", Response: message},
+	"POST /tasks/:id/subtasks":                  {Summary: "Create a subtask", Request: models.CreateTaskRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "task": models.Task{}, "parent": models.Task{}}},
+	"GET /tasks/:id/subtasks":                   {Summary: "List subtasks", Response: openapi.Object{"subtasks": []models.Task{}}},
+	"GET /tasks/:id/history": {
+		Summary:  "Task change history",
+		Params:   append([]openapi.Param{{Name: "include_deleted_relations", Type: "boolean", Description: "Include the history of subtasks in the trash"}}, pageParams...),
+		Response: openapi.Object{"history": []models.TaskHistoryEntry{}, "pagination": pageInfo},
+	},
+	"GET /tasks/:id/deadline-changes": {Summary: "Due date change history", Params: pageParams, Response: openapi.Object{"deadline_changes": []models.DeadlineChange{}, "pagination": pageInfo}},
+
+	"POST /tasks/:id/checklist":                   {Summary: "Add a checklist item", Request: models.CreateChecklistItemRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "item": models.ChecklistItem{}, "task": models.Task{}}},
+	"GET /tasks/:id/checklist":                    {Summary: "List checklist items", Response: openapi.Object{"checklist": []models.ChecklistItem{}, "progress": (*int)(nil)}},
+	"POST /tasks/:id/checklist/:itemId/toggle":    {Summary: "Toggle a checklist item", Response: openapi.Object{"message": "", "item": models.ChecklistItem{}, "task": models.Task{}}},
+	"POST /tasks/:id/checklist/:itemId/move":      {Summary: "Move a checklist item", Request: models.MoveChecklistItemRequest{}, Response: openapi.Object{"message": "", "checklist": []models.ChecklistItem{}}},
+	"DELETE /tasks/:id/checklist/:itemId":         {Summary: "Delete a checklist item", Response: taskBody},
+	"POST /tasks/:id/comments":                    {Summary: "Comment on a task", Request: models.CreateCommentRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "comment": models.TaskComment{}}},
+	"GET /tasks/:id/comments":                     {Summary: "List comments", Params: pageParams, Response: openapi.Object{"comments": []models.TaskComment{}, "pagination": pageInfo}},
+	"DELETE /tasks/:id/comments/:commentId":       {Summary: "Delete a comment", Response: message},
+	"POST /tasks/:id/attachments":                 {Summary: "Upload an attachment", RequestType: "multipart/form-data", Request: openapi.Object{"file": ""}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "attachment": models.TaskAttachment{}}},
+	"GET /tasks/:id/attachments":                  {Summary: "List attachments", Response: openapi.Object{"attachments": []models.TaskAttachment{}}},
+	"GET /tasks/:id/attachments/:attachmentId":    {Summary: "Get a download URL for an attachment", Response: openapi.Object{"attachment": models.TaskAttachment{}, "url": "", "expires_at": time.Time{}}},
+	"DELETE /tasks/:id/attachments/:attachmentId": {Summary: "Delete an attachment", Response: message},
+	"POST /tasks/:id/links":                       {Summary: "Link an external page", Request: models.CreateLinkRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "link": models.TaskLink{}}},
+	"GET /tasks/:id/links":                        {Summary: "List links", Response: openapi.Object{"links": []models.TaskLink{}}},
+	"DELETE /tasks/:id/links/:linkId":             {Summary: "Delete a link", Response: message},
+	"POST /tasks/:id/reminders":                   {Summary: "Schedule a reminder", Request: models.CreateReminderRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "reminder": models.TaskReminder{}}},
+	"GET /tasks/:id/reminders":                    {Summary: "List reminders", Response: openapi.Object{"reminders": []models.TaskReminder{}}},
+	"DELETE /tasks/:id/reminders/:reminderId":     {Summary: "Delete a reminder", Response: message},
+
+	"GET /tasks/:id/share":                          {Summary: "Create a read-only share link", Params: []openapi.Param{{Name: "ttl", Description: "Link lifetime, e.g. 48h, up to SHARE_TOKEN_MAX_TTL"}}, Response: shareLink},
+	"POST /tasks/:id/share":                         {Summary: "Share a task with a user", Request: models.ShareTaskRequest{}, Response: openapi.Object{"message": "", "share": models.TaskShare{}}},
+	"DELETE /tasks/:id/share/:userId":               {Summary: "Stop sharing a task with a user", Response: message},
+	"POST /tasks/:id/watch":                         {Summary: "Watch a shared task", Response: openapi.Object{"message": "", "watcher": models.TaskWatcher{}}},
+	"DELETE /tasks/:id/watch":                       {Summary: "Stop watching a task", Response: message},
+	"GET /tasks/:id/watchers":                       {Summary: "List watchers", Response: openapi.Object{"watchers": []models.TaskWatcher{}}},
+	"POST /tasks/:id/transfer":                      {Summary: "Offer task ownership to a user", Request: models.CreateTransferRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "transfer": models.TaskTransfer{}}},
+	"GET /tasks/transfers/pending":                  {Summary: "List transfers waiting for you", Response: openapi.Object{"transfers": []models.TaskTransfer{}}},
+	"POST /tasks/transfers/:transferId/accept":      {Summary: "Accept a transfer", Response: openapi.Object{"message": "", "transfer": models.TaskTransfer{}}},
+	"POST /tasks/transfers/:transferId/reject":      {Summary: "Reject a transfer", Response: openapi.Object{"message": "", "transfer": models.TaskTransfer{}}},
+	"POST /tasks/:id/delegate":                      {Summary: "Delegate a task to a user", Request: models.DelegateTaskRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "delegation": models.TaskDelegation{}}},
+	"GET /tasks/delegated/pending":                  {Summary: "List delegations waiting for you", Response: openapi.Object{"delegations": []models.TaskDelegation{}}},
+	"POST /tasks/delegations/:delegationId/accept":  {Summary: "Accept a delegation", Response: openapi.Object{"message": "", "delegation": models.TaskDelegation{}}},
+	"POST /tasks/delegations/:delegationId/decline": {Summary: "Decline a delegation", Response: openapi.Object{"message": "", "delegation": models.TaskDelegation{}}},
+
+	"POST /projects":            {Summary: "Create a project", Request: models.CreateProjectRequest{}, Status: http.StatusCreated, Response: openapi.Object{"message": "", "project": models.Project{}}},
+	"GET /projects":             {Summary: "List projects", Params: pageParams, Response: openapi.Object{"projects": []models.Project{}, "pagination": pageInfo}},
+	"GET /projects/:id":         {Summary: "Get a project", Response: openapi.Object{"project": models.Project{}}},
+	"GET /projects/:id/summary": {Summary: "Project progress and deadlines", Response: openapi.Object{"summary": models.ProjectSummary{}}},
+	"PUT /projects/:id":         {Summary: "Update a project", Request: models.UpdateProjectRequest{}, Response: openapi.Object{"message": "", "project": models.Project{}}},
+	"DELETE /projects/:id":      {Summary: "Delete a project", Response: message},
+
+	"POST /webhooks": {
+		Summary:     "Register a webhook",
+		Description: "Deliveries are signed with the returned secret, which is not shown again. At most WEBHOOK_MAX_PER_USER webhooks per user.",
+		Request:     models.CreateWebhookRequest{},
+		Status:      http.StatusCreated,
+		Response:    openapi.Object{"message": "", "webhook": models.Webhook{}},
+	},
+	"GET /webhooks": {Summary: "List webhooks", Response: openapi.Object{"webhooks": []models.Webhook{}}},
+	"PUT /webhooks/:id": {
+		Summary:     "Update a webhook",
+		Description: "A new URL is checked against WEBHOOK_ALLOWED_HOSTS and WEBHOOK_DENIED_CIDRS as on registration.",
+		Request:     models.UpdateWebhookRequest{},
+		Response:    openapi.Object{"message": "", "webhook": models.Webhook{}},
+	},
+	"DELETE /webhooks/:id": {Summary: "Delete a webhook", Response: message},
+	"GET /webhooks/:id/deliveries": {
+		Summary:  "Webhook delivery log",
+		Params:   append([]openapi.Param{{Name: "status", Description: "pending, succeeded or failed"}}, pageParams...),
+		Response: openapi.Object{"deliveries": []models.WebhookDelivery{}, "pagination": pageInfo},
+	},
+
+	"POST /tasks/admin/stats":           {Summary: "Statistics for several users", Description: "Requires the admin role.", Request: models.UsersStatsRequest{}, Response: openapi.Object{"stats": map[string]models.TaskStats{}}},
+	"POST /tasks/admin/consumer/pause":  {Summary: "Pause the user sync consumer", Description: "Requires the admin role.", Response: openapi.Object{"message": "", "paused": false}},
+	"POST /tasks/admin/consumer/resume": {Summary: "Resume the user sync consumer", Description: "Requires the admin role.", Response: openapi.Object{"message": "", "paused": false}},
+	"GET /tasks/admin/consumer/dead-letters": {
+		Summary:     "Inspect dead-lettered user events",
+		Description: "Requires the admin role. Messages stay in the dead-letter queue.",
+		Params:      []openapi.Param{deadLetterMax},
+		Response:    openapi.Object{"dead_letters": []rabbitmq.DeadLetter{}, "count": 0},
+	},
+	"POST /tasks/admin/consumer/dead-letters/requeue": {
+		Summary:     "Requeue dead-lettered user events",
+		Description: "Requires the admin role. Republishes messages to the user events exchange with their original routing keys.",
+		Params:      []openapi.Param{deadLetterMax},
+		Response:    openapi.Object{"message": "", "requeued": 0, "remaining": 0},
+	},
+}
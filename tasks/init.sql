@@ -1,4 +1,8 @@
 -- Tasks Service Database Schema
+--
+-- Safe to run again on an existing database: tables, indexes and functions
+-- are created if missing, columns added since a table was first created are
+-- added to it, and triggers are replaced. `make migrate` applies it.
 
 -- Create users cache table (synced from auth service via RabbitMQ)
 CREATE TABLE IF NOT EXISTS tasks_users (
@@ -11,26 +15,439 @@ CREATE TABLE IF NOT EXISTS tasks_users (
 
 -- Create index on email for faster lookups
 CREATE INDEX IF NOT EXISTS idx_tasks_users_email ON tasks_users(email);
+-- @mentions resolve usernames case-insensitively
+CREATE INDEX IF NOT EXISTS idx_tasks_users_username ON tasks_users(LOWER(username));
+
+-- Create user settings table; timezone comes from user events or the
+-- X-Timezone header and is used when a request does not name one
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id UUID PRIMARY KEY REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    timezone VARCHAR(64),
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create projects table; deleting a project keeps its tasks
+CREATE TABLE IF NOT EXISTS projects (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects(user_id, name);
 
 -- Create tasks table
 CREATE TABLE IF NOT EXISTS tasks (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    parent_task_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
     title VARCHAR(255) NOT NULL,
     description TEXT,
     status VARCHAR(50) DEFAULT 'pending' CHECK (status IN ('pending', 'in_progress', 'completed', 'cancelled')),
     priority VARCHAR(50) DEFAULT 'medium' CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
     due_date TIMESTAMP,
+    origin VARCHAR(50) NOT NULL DEFAULT 'api' CHECK (origin IN ('api', 'import', 'recurring', 'template')),
+    progress INTEGER NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    -- Share of checklist items done; NULL while the task has no checklist
+    checklist_progress INTEGER CHECK (checklist_progress BETWEEN 0 AND 100),
+    position INTEGER NOT NULL DEFAULT 0,
+    color VARCHAR(20),
+    recurrence VARCHAR(100),
+    recurrence_paused BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Custom fields; a flat object of strings, numbers and booleans
+    metadata JSONB NOT NULL DEFAULT '{}',
+    archived_at TIMESTAMP,
+    -- Planned and actual effort in minutes
+    estimate_minutes INTEGER CHECK (estimate_minutes >= 0),
+    actual_minutes INTEGER CHECK (actual_minutes >= 0),
+    -- Snoozed tasks are hidden from the task list until this time passes
+    snoozed_until TIMESTAMP,
+    -- Pinned tasks list first unless another sort is asked for
+    pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    -- When the task was last completed, cleared when it is reopened
+    completed_at TIMESTAMP,
+    -- Set by the overdue worker once an open task passes its due date
+    overdue BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Last automatic priority escalation of an overdue task
+    escalated_at TIMESTAMP,
+    -- Optional location for field work; coordinates are set together
+    latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    place_name VARCHAR(255),
+    deleted_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT tasks_location_check CHECK ((latitude IS NULL) = (longitude IS NULL))
 );
 
+-- Columns added since the tasks table was first created, for databases
+-- created from an older version of this file
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS parent_task_id UUID REFERENCES tasks(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS origin VARCHAR(50) NOT NULL DEFAULT 'api' CHECK (origin IN ('api', 'import', 'recurring', 'template')),
+    ADD COLUMN IF NOT EXISTS progress INTEGER NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    ADD COLUMN IF NOT EXISTS checklist_progress INTEGER CHECK (checklist_progress BETWEEN 0 AND 100),
+    ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS color VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS recurrence VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS recurrence_paused BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS estimate_minutes INTEGER CHECK (estimate_minutes >= 0),
+    ADD COLUMN IF NOT EXISTS actual_minutes INTEGER CHECK (actual_minutes >= 0),
+    ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS overdue BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION CHECK (latitude BETWEEN -90 AND 90),
+    ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION CHECK (longitude BETWEEN -180 AND 180),
+    ADD COLUMN IF NOT EXISTS place_name VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+DO $$
+BEGIN
+    ALTER TABLE tasks ADD CONSTRAINT tasks_location_check CHECK ((latitude IS NULL) = (longitude IS NULL));
+EXCEPTION WHEN duplicate_object THEN NULL;
+END;
+$$;
+
 -- Create indexes for better query performance
 CREATE INDEX IF NOT EXISTS idx_tasks_user_id ON tasks(user_id);
 CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status);
 CREATE INDEX IF NOT EXISTS idx_tasks_priority ON tasks(priority);
 CREATE INDEX IF NOT EXISTS idx_tasks_due_date ON tasks(due_date);
 CREATE INDEX IF NOT EXISTS idx_tasks_created_at ON tasks(created_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_origin ON tasks(origin);
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_project_id ON tasks(project_id);
+CREATE INDEX IF NOT EXISTS idx_tasks_archived_at ON tasks(user_id, archived_at);
+-- Calendar range queries over a user's due dates
+CREATE INDEX IF NOT EXISTS idx_tasks_user_due ON tasks(user_id, due_date) WHERE deleted_at IS NULL;
+-- Cursor pagination over a user's tasks by (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_tasks_user_cursor ON tasks(user_id, created_at, id) WHERE deleted_at IS NULL;
+-- Kanban ordering within each of a user's status columns
+CREATE INDEX IF NOT EXISTS idx_tasks_position ON tasks(user_id, status, position);
+-- Snoozed tasks the snooze sweeper will wake
+CREATE INDEX IF NOT EXISTS idx_tasks_snoozed_until ON tasks(snoozed_until) WHERE snoozed_until IS NOT NULL;
+-- Open tasks the overdue worker has yet to flag
+CREATE INDEX IF NOT EXISTS idx_tasks_overdue_due ON tasks(due_date)
+    WHERE NOT overdue AND status IN ('pending', 'in_progress') AND deleted_at IS NULL;
+-- Open overdue tasks the escalation worker may bump
+CREATE INDEX IF NOT EXISTS idx_tasks_escalation_due ON tasks(due_date)
+    WHERE status IN ('pending', 'in_progress') AND priority <> 'urgent' AND deleted_at IS NULL;
+-- Trashed tasks waiting to be purged
+CREATE INDEX IF NOT EXISTS idx_tasks_deleted_at ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
+-- Full-text search; queries must use exactly this expression to hit the index
+CREATE INDEX IF NOT EXISTS idx_tasks_search ON tasks USING GIN (
+    (setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))
+);
+-- Bounding-box prefilter for ?near= queries over located tasks
+CREATE INDEX IF NOT EXISTS idx_tasks_location ON tasks(latitude, longitude) WHERE latitude IS NOT NULL;
+-- Completed occurrences waiting for the recurrence scheduler
+CREATE INDEX IF NOT EXISTS idx_tasks_recurrence_due ON tasks(updated_at)
+    WHERE recurrence IS NOT NULL AND status = 'completed' AND NOT recurrence_paused;
+
+-- Create tags tables; tags are shared names and ownership comes from the task
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tags_tag_id ON task_tags(tag_id);
+
+-- Create checklist items table; lightweight steps within a task
+CREATE TABLE IF NOT EXISTS checklist_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    text VARCHAR(255) NOT NULL,
+    done BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_checklist_items_task_id ON checklist_items(task_id, position);
+
+-- Create task history table; one row per recorded change, with a NULL
+-- user_id for changes the service made on its own
+CREATE TABLE IF NOT EXISTS task_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES tasks_users(user_id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_history_task_id ON task_history(task_id, created_at);
+
+-- Create deadline changes table; every change to a task's due date, recorded
+-- by a trigger with the actor and reason the change was made with
+CREATE TABLE IF NOT EXISTS deadline_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES tasks_users(user_id) ON DELETE SET NULL,
+    old_due_date TIMESTAMP,
+    new_due_date TIMESTAMP,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_deadline_changes_task_id ON deadline_changes(task_id, created_at);
+
+-- Create task comments table
+CREATE TABLE IF NOT EXISTS task_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_comments_task_id ON task_comments(task_id, created_at);
+
+-- Create comment mentions table; users @mentioned in a comment
+CREATE TABLE IF NOT EXISTS comment_mentions (
+    comment_id UUID NOT NULL REFERENCES task_comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_comment_mentions_user_id ON comment_mentions(user_id);
+
+-- Create task attachments table (files live in object storage)
+CREATE TABLE IF NOT EXISTS task_attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_attachments_task_id ON task_attachments(task_id);
+
+-- Create task links table; external URLs with the title and favicon fetched
+-- from the page when the link was added
+CREATE TABLE IF NOT EXISTS task_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    title VARCHAR(255),
+    page_title VARCHAR(255),
+    favicon_url TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (task_id, url)
+);
+
+-- Create task templates table; checklist items become subtasks on instantiation
+CREATE TABLE IF NOT EXISTS task_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    description TEXT,
+    priority VARCHAR(50) NOT NULL DEFAULT 'medium' CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+    checklist TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_templates_user_id ON task_templates(user_id, created_at);
+
+-- Create saved views table; named task list filters a user can reapply
+CREATE TABLE IF NOT EXISTS saved_views (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    status VARCHAR(50) CHECK (status IN ('pending', 'in_progress', 'completed', 'cancelled')),
+    priority VARCHAR(50) CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    sort VARCHAR(50),
+    sort_order VARCHAR(4),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+);
+
+-- Create reminders table (fired by the reminder worker)
+CREATE TABLE IF NOT EXISTS reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    remind_at TIMESTAMP NOT NULL,
+    channels TEXT[] NOT NULL,
+    fired_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminders_task_id ON reminders(task_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_due ON reminders(remind_at) WHERE fired_at IS NULL;
+
+-- Create task shares table; grants another user read or write access to a task
+CREATE TABLE IF NOT EXISTS task_shares (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    permission VARCHAR(10) NOT NULL CHECK (permission IN ('read', 'write')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_shares_user_id ON task_shares(user_id);
+
+-- Create task watchers table; watchers are named on the task's events so
+-- they are notified of its changes while it stays shared with them
+CREATE TABLE IF NOT EXISTS task_watchers (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (task_id, user_id)
+);
+
+-- Create task dependencies table; the blocker must be done before the
+-- blocked task can be completed
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    blocker_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_dependencies_blocked_id ON task_dependencies(blocked_id);
+
+-- Create time entries table; stopped_at is NULL while the timer runs
+CREATE TABLE IF NOT EXISTS time_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    started_at TIMESTAMP NOT NULL,
+    stopped_at TIMESTAMP,
+    CHECK (stopped_at IS NULL OR stopped_at >= started_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_entries_task_id ON time_entries(task_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_user_id ON time_entries(user_id);
+-- Only one running timer per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_running ON time_entries(user_id) WHERE stopped_at IS NULL;
+
+-- Create calendar tokens table; one feed token per user, replaced on rotation
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+    user_id UUID PRIMARY KEY REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    token_id UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create task ownership transfers table
+CREATE TABLE IF NOT EXISTS task_transfers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'rejected')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_transfers_to_user ON task_transfers(to_user_id, status);
+-- Only one pending transfer per task
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_transfers_pending ON task_transfers(task_id) WHERE status = 'pending';
+
+-- Create task delegations table; the owner keeps a delegated task and the
+-- delegatee gets write access to it on accepting
+CREATE TABLE IF NOT EXISTS task_delegations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    from_user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    to_user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined')),
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_delegations_to_user ON task_delegations(to_user_id, status);
+-- Only one pending delegation per task
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_delegations_pending ON task_delegations(task_id) WHERE status = 'pending';
+
+-- Create idempotency keys table; the response to a request sent with an
+-- Idempotency-Key, replayed when the request is retried
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    -- NULL while the first request is still being handled
+    status_code INTEGER,
+    response TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, key)
+);
+
+-- Create stream events table; task events as delivered to each user they
+-- concern, numbered so a Server-Sent Events client can resume where it left off
+CREATE TABLE IF NOT EXISTS stream_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    -- Not a foreign key: events outlive the tasks they describe
+    task_id UUID NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for reading a user's stream from a given event
+CREATE INDEX IF NOT EXISTS idx_stream_events_user ON stream_events(user_id, id);
+
+-- Index for pruning old events
+CREATE INDEX IF NOT EXISTS idx_stream_events_created_at ON stream_events(created_at);
+
+-- Create webhooks table; URLs users registered to receive task events of
+-- the listed types, signed with the webhook's secret
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES tasks_users(user_id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    event_types TEXT[] NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id);
+
+-- Create webhook deliveries table; each event sent to a webhook, retried with
+-- backoff until it succeeds or runs out of attempts
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    -- The outcome of the last attempt: the response status, or why there was none
+    response_status INTEGER,
+    error TEXT,
+    next_attempt_at TIMESTAMP,
+    last_attempt_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for the delivery log of a webhook
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at);
+
+-- Index for claiming deliveries that are due
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
 
 -- Create function to update updated_at timestamp
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -42,8 +459,112 @@ END;
 $$ language 'plpgsql';
 
 -- Create triggers to auto-update updated_at
+DROP TRIGGER IF EXISTS update_tasks_users_updated_at ON tasks_users;
 CREATE TRIGGER update_tasks_users_updated_at BEFORE UPDATE ON tasks_users
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+DROP TRIGGER IF EXISTS update_tasks_updated_at ON tasks;
 CREATE TRIGGER update_tasks_updated_at BEFORE UPDATE ON tasks
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+DROP TRIGGER IF EXISTS update_projects_updated_at ON projects;
+CREATE TRIGGER update_projects_updated_at BEFORE UPDATE ON projects
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Record when a task is completed, whichever path completes it, and clear it
+-- when the task leaves completed
+CREATE OR REPLACE FUNCTION set_completed_at()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status <> 'completed' THEN
+        NEW.completed_at = NULL;
+    ELSIF TG_OP = 'INSERT' THEN
+        NEW.completed_at = COALESCE(NEW.completed_at, CURRENT_TIMESTAMP);
+    ELSIF OLD.status <> 'completed' THEN
+        NEW.completed_at = CURRENT_TIMESTAMP;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS set_tasks_completed_at ON tasks;
+CREATE TRIGGER set_tasks_completed_at BEFORE INSERT OR UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION set_completed_at();
+
+-- Clear the overdue flag when a task is rescheduled or closed; the overdue
+-- worker flags it again if it is still past due
+CREATE OR REPLACE FUNCTION clear_overdue_flag()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.overdue AND (NEW.due_date IS DISTINCT FROM OLD.due_date OR NEW.status IN ('completed', 'cancelled')) THEN
+        NEW.overdue = FALSE;
+    END IF;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS clear_tasks_overdue ON tasks;
+CREATE TRIGGER clear_tasks_overdue BEFORE UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION clear_overdue_flag();
+
+-- Record due date changes, whichever path makes them. The repository sets
+-- the acting user and the reason on the transaction; changes made without
+-- them are recorded with neither.
+CREATE OR REPLACE FUNCTION record_deadline_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO deadline_changes (task_id, user_id, old_due_date, new_due_date, reason)
+    VALUES (
+        NEW.id,
+        NULLIF(current_setting('tasks.actor_id', true), '')::uuid,
+        OLD.due_date,
+        NEW.due_date,
+        NULLIF(current_setting('tasks.deadline_reason', true), '')
+    );
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS record_tasks_deadline_change ON tasks;
+CREATE TRIGGER record_tasks_deadline_change AFTER UPDATE OF due_date ON tasks
+    FOR EACH ROW WHEN (OLD.due_date IS DISTINCT FROM NEW.due_date)
+    EXECUTE FUNCTION record_deadline_change();
+
+-- Record status, title, owner and trash changes in task history, whichever
+-- path makes them, with the acting user the repository sets on the
+-- transaction, or none for changes the service makes on its own
+CREATE OR REPLACE FUNCTION record_task_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    actor UUID := NULLIF(current_setting('tasks.actor_id', true), '')::uuid;
+BEGIN
+    IF OLD.status IS DISTINCT FROM NEW.status THEN
+        INSERT INTO task_history (task_id, user_id, action, field, old_value, new_value)
+        VALUES (NEW.id, actor, 'updated', 'status', OLD.status, NEW.status);
+    END IF;
+    IF OLD.title IS DISTINCT FROM NEW.title THEN
+        INSERT INTO task_history (task_id, user_id, action, field, old_value, new_value)
+        VALUES (NEW.id, actor, 'updated', 'title', OLD.title, NEW.title);
+    END IF;
+    IF OLD.user_id IS DISTINCT FROM NEW.user_id THEN
+        INSERT INTO task_history (task_id, user_id, action, field, old_value, new_value)
+        VALUES (NEW.id, actor, 'transferred', 'user_id', OLD.user_id::text, NEW.user_id::text);
+    END IF;
+    IF OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL THEN
+        INSERT INTO task_history (task_id, user_id, action, field, old_value, new_value)
+        VALUES (NEW.id, actor, 'deleted', 'deleted_at', NULL, NEW.deleted_at::text);
+    ELSIF OLD.deleted_at IS NOT NULL AND NEW.deleted_at IS NULL THEN
+        INSERT INTO task_history (task_id, user_id, action, field, old_value, new_value)
+        VALUES (NEW.id, actor, 'restored', 'deleted_at', OLD.deleted_at::text, NULL);
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+DROP TRIGGER IF EXISTS record_tasks_change ON tasks;
+CREATE TRIGGER record_tasks_change AFTER UPDATE OF status, title, user_id, deleted_at ON tasks
+    FOR EACH ROW WHEN (
+        OLD.status IS DISTINCT FROM NEW.status OR OLD.title IS DISTINCT FROM NEW.title OR
+        OLD.user_id IS DISTINCT FROM NEW.user_id OR OLD.deleted_at IS DISTINCT FROM NEW.deleted_at
+    )
+    EXECUTE FUNCTION record_task_change();
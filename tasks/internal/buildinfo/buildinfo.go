@@ -0,0 +1,31 @@
+package buildinfo
+
+import (
+	"runtime"
+	"time"
+)
+
+// Version and Commit are injected at build time, e.g.
+// -ldflags "-X github.com/moabdelazem/microservices/tasks/internal/buildinfo.Version=1.2.0"
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
+// startedAt records when the process started
+var startedAt = time.Now()
+
+// GoVersion returns the Go version the binary was built with
+func GoVersion() string {
+	return runtime.Version()
+}
+
+// StartedAt returns the process start time
+func StartedAt() time.Time {
+	return startedAt
+}
+
+// Uptime returns how long the process has been running
+func Uptime() time.Duration {
+	return time.Since(startedAt)
+}
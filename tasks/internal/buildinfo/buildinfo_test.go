@@ -0,0 +1,25 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUptimeIncreases(t *testing.T) {
+	first := Uptime()
+	time.Sleep(10 * time.Millisecond)
+	second := Uptime()
+
+	assert.Greater(t, second, first)
+	assert.GreaterOrEqual(t, second-first, 10*time.Millisecond)
+	assert.WithinDuration(t, StartedAt().Add(second), time.Now(), time.Second)
+}
+
+func TestDefaults(t *testing.T) {
+	assert.Equal(t, "dev", Version, "without -ldflags")
+	assert.Equal(t, "unknown", Commit)
+	assert.Equal(t, runtime.Version(), GoVersion())
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String returns the value of an environment variable or a default
+func String(key, fallback string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return fallback
+}
+
+// Int returns an integer environment variable, falling back to the default
+// when the variable is unset or malformed
+func Int(key string, fallback int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// Int64 returns a 64-bit integer environment variable or a default
+func Int64(key string, fallback int64) int64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// Bool returns a boolean environment variable or a default
+func Bool(key string, fallback bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// Duration returns a duration environment variable (e.g. "500ms", "5s") or a default
+func Duration(key string, fallback time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(val)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
+// List returns a comma-separated environment variable as a trimmed slice
+func List(key string) []string {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
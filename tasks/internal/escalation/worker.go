@@ -0,0 +1,117 @@
+package escalation
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+)
+
+// batchSize bounds how many tasks are escalated per query
+const batchSize = 100
+
+const defaultOverdueDays = 3
+
+// ladder lists the priorities in the order tasks are escalated through
+var ladder = []string{"low", "medium", "high", "urgent"}
+
+// Publisher publishes task change events
+type Publisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// Worker raises the priority of open tasks one step for every
+// ESCALATION_OVERDUE_DAYS they are overdue, up to ESCALATION_MAX_PRIORITY.
+// Running it on several instances is safe: each task is escalated by
+// whichever instance locks it first.
+type Worker struct {
+	tasks    *repository.TaskRepository
+	indexer  search.Indexer
+	events   Publisher
+	interval time.Duration
+	overdue  time.Duration
+	from     []string
+}
+
+// NewWorker creates a worker that runs every ESCALATION_INTERVAL
+func NewWorker(db *database.DB, indexer search.Indexer, events Publisher) *Worker {
+	return &Worker{
+		tasks:    repository.NewTaskRepository(db),
+		indexer:  indexer,
+		events:   events,
+		interval: config.Duration("ESCALATION_INTERVAL", 0),
+		overdue:  time.Duration(config.Int("ESCALATION_OVERDUE_DAYS", defaultOverdueDays)) * 24 * time.Hour,
+		from:     escalatable(config.String("ESCALATION_MAX_PRIORITY", "urgent")),
+	}
+}
+
+// escalatable returns the priorities below max, which are the ones that can
+// still be raised
+func escalatable(max string) []string {
+	for i, priority := range ladder {
+		if priority == max {
+			return ladder[:i]
+		}
+	}
+	log.Printf("⚠️  Unknown ESCALATION_MAX_PRIORITY %q, using urgent", max)
+	return ladder[:len(ladder)-1]
+}
+
+// Start runs the worker in the background until ctx is done. A zero interval,
+// the default, disables it.
+func (w *Worker) Start(ctx context.Context) {
+	if w.interval <= 0 || w.overdue <= 0 || len(w.from) == 0 {
+		log.Println("⚠️  Priority escalation disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.run(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping escalation worker...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Escalation worker running every %s for tasks %s overdue", w.interval, w.overdue)
+}
+
+// run escalates overdue tasks until none are left
+func (w *Worker) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		tasks, entries, err := w.tasks.EscalateOverdue(ctx, time.Now().Add(-w.overdue), w.from, batchSize)
+		if err != nil {
+			log.Printf("❌ Failed to escalate overdue tasks: %v\n", err)
+			return
+		}
+
+		for i := range tasks {
+			task := &tasks[i]
+			log.Printf("⏫ Escalated task %s from %s to %s\n", task.ID, *entries[i].OldValue, task.Priority)
+			w.indexer.Index(ctx, *task)
+			event := models.TaskEvent{
+				EventType: models.EventTaskEscalated,
+				TaskID:    task.ID,
+				UserID:    task.UserID,
+				Task:      task,
+				Change:    &entries[i],
+			}
+			if err := w.events.Publish(ctx, event); err != nil {
+				log.Printf("❌ Failed to publish escalation of task %s: %v\n", task.ID, err)
+			}
+		}
+		if len(tasks) < batchSize {
+			return
+		}
+	}
+}
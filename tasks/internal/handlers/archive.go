@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// ArchiveTask hides a task and its subtasks from the task list without
+// deleting them
+func (h *TaskHandler) ArchiveTask(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// UnarchiveTask returns an archived task and its subtasks to the task list
+func (h *TaskHandler) UnarchiveTask(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *TaskHandler) setArchived(c *gin.Context, archived bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to update task")
+		return
+	}
+	if task.ParentTaskID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtasks are archived together with their parent"})
+		return
+	}
+
+	message := "Task unarchived successfully"
+	if archived {
+		message = "Task archived successfully"
+	}
+
+	changed, err := h.tasks.SetArchived(c.Request.Context(), taskID, userID, archived)
+	if err != nil {
+		respondError(c, err, "Failed to update task")
+		return
+	}
+	for i := range changed {
+		changedTask := &changed[i]
+		if changedTask.ID == taskID {
+			task = changedTask
+		}
+		h.indexer.Index(c.Request.Context(), *changedTask)
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, userID, changedTask.ID, changedTask)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"task":    task,
+	})
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/storage"
+)
+
+const (
+	defaultAttachmentMaxSize = 10 << 20 // 10 MB
+	defaultDownloadURLExpiry = 15 * time.Minute
+)
+
+// respondStorageError writes a 503 when storage is not configured and a 502
+// when the storage backend fails
+func respondStorageError(c *gin.Context, err error, message string) {
+	if errors.Is(err, storage.ErrDisabled) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Attachments are not enabled on this server"})
+		return
+	}
+	log.Printf("❌ %s: %v\n", message, err)
+	c.JSON(http.StatusBadGateway, gin.H{"error": message})
+}
+
+// UploadAttachment stores a file from the multipart "file" field and attaches
+// it to the task. Uploads are capped at ATTACHMENT_MAX_SIZE bytes.
+func (h *TaskHandler) UploadAttachment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	maxSize := config.Int64("ATTACHMENT_MAX_SIZE", defaultAttachmentMaxSize)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds maximum size of %d bytes", maxSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required in the 'file' form field"})
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to upload attachment")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	contentType := fileHeader.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	attachmentID := uuid.New()
+	attachment := models.TaskAttachment{
+		ID:          attachmentID,
+		TaskID:      taskID,
+		UserID:      userID,
+		Filename:    attachmentFilename(fileHeader.Filename),
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		StorageKey:  fmt.Sprintf("tasks/%s/%s", taskID, attachmentID),
+		CreatedAt:   time.Now(),
+	}
+
+	if err := h.storage.Put(c.Request.Context(), attachment.StorageKey, file, attachment.Size, contentType); err != nil {
+		respondStorageError(c, err, "Failed to upload attachment")
+		return
+	}
+
+	if err := h.attachments.Create(c.Request.Context(), &attachment); err != nil {
+		// Don't leave an orphaned file behind
+		h.deleteStoredFiles(context.WithoutCancel(c.Request.Context()), []string{attachment.StorageKey})
+		respondResourceError(c, err, "Attachment", "Failed to save attachment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Attachment uploaded successfully",
+		"attachment": attachment,
+	})
+}
+
+// GetAttachments lists a task's attachments
+func (h *TaskHandler) GetAttachments(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to fetch attachments")
+		return
+	}
+
+	attachments, err := h.attachments.List(c.Request.Context(), taskID)
+	if err != nil {
+		respondResourceError(c, err, "Attachment", "Failed to fetch attachments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// DownloadAttachment returns a presigned URL that downloads the file directly
+// from storage. URLs expire after ATTACHMENT_URL_EXPIRY.
+func (h *TaskHandler) DownloadAttachment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, attachmentID, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to fetch attachment")
+		return
+	}
+
+	attachment, err := h.attachments.Get(c.Request.Context(), attachmentID, taskID)
+	if err != nil {
+		respondResourceError(c, err, "Attachment", "Failed to fetch attachment")
+		return
+	}
+
+	expiry := config.Duration("ATTACHMENT_URL_EXPIRY", defaultDownloadURLExpiry)
+	url, err := h.storage.PresignGet(c.Request.Context(), attachment.StorageKey, attachment.Filename, expiry)
+	if err != nil {
+		respondStorageError(c, err, "Failed to create download link")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachment": attachment,
+		"url":        url,
+		"expires_at": time.Now().Add(expiry).UTC(),
+	})
+}
+
+// DeleteAttachment removes an attachment and its stored file
+func (h *TaskHandler) DeleteAttachment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, attachmentID, ok := parseAttachmentParams(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to delete attachment")
+		return
+	}
+
+	attachment, err := h.attachments.Delete(c.Request.Context(), attachmentID, taskID)
+	if err != nil {
+		respondResourceError(c, err, "Attachment", "Failed to delete attachment")
+		return
+	}
+	h.deleteStoredFiles(c.Request.Context(), []string{attachment.StorageKey})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// deleteStoredFiles removes files from storage, logging failures. The
+// metadata is already gone, so a failure only leaves an unreachable file.
+func (h *TaskHandler) deleteStoredFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := h.storage.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrDisabled) {
+			log.Printf("⚠️  Failed to delete stored attachment %s: %v\n", key, err)
+		}
+	}
+}
+
+// parseAttachmentParams parses the task and attachment IDs from the path
+func parseAttachmentParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	attachmentID, err := uuid.Parse(c.Param("attachmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return taskID, attachmentID, true
+}
+
+// attachmentFilename strips any client-supplied directories and bounds the
+// name to the column size
+func attachmentFilename(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		name = "file"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
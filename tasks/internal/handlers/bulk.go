@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// defaultBulkMaxItems bounds how many tasks one bulk request may touch
+const defaultBulkMaxItems = 500
+
+// CreateTasksBulk creates every task in a JSON array in one transaction.
+// Items are validated individually; if any is invalid nothing is created and
+// the errors are reported by array index.
+func (h *TaskHandler) CreateTasksBulk(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	// Decode items separately so one bad item doesn't hide the others' errors
+	var items []json.RawMessage
+	if err := c.ShouldBindJSON(&items); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be a JSON array of tasks"})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No tasks to create"})
+		return
+	}
+	maxItems := config.Int("BULK_MAX_ITEMS", defaultBulkMaxItems)
+	if len(items) > maxItems {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("At most %d tasks can be created at once", maxItems)})
+		return
+	}
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tasks := make([]models.Task, 0, len(items))
+	var itemErrors []models.BulkItemError
+	// Each project is looked up once however many items use it
+	projects := make(map[uuid.UUID]bool)
+	for i, item := range items {
+		task, err := decodeNewTask(item, userID, loc)
+		if err != nil {
+			itemErrors = append(itemErrors, models.BulkItemError{Index: i, Error: err.Error()})
+			continue
+		}
+		if task.ProjectID != nil {
+			exists, checked := projects[*task.ProjectID]
+			if !checked {
+				exists, err = h.projects.Exists(c.Request.Context(), *task.ProjectID, userID)
+				if err != nil {
+					respondResourceError(c, err, "Project", "Failed to look up project")
+					return
+				}
+				projects[*task.ProjectID] = exists
+			}
+			if !exists {
+				itemErrors = append(itemErrors, models.BulkItemError{Index: i, Error: "project not found"})
+				continue
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	if len(itemErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  fmt.Sprintf("%d of %d tasks are invalid, nothing was created", len(itemErrors), len(items)),
+			"errors": itemErrors,
+		})
+		return
+	}
+
+	if err := h.tasks.CreateMany(c.Request.Context(), tasks); err != nil {
+		respondError(c, err, "Failed to create tasks")
+		return
+	}
+	for i := range tasks {
+		h.indexer.Index(c.Request.Context(), tasks[i])
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskCreated, userID, tasks[i].ID, &tasks[i])
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": fmt.Sprintf("Created %d tasks", len(tasks)),
+		"tasks":   tasks,
+	})
+}
+
+// decodeNewTask decodes and validates one bulk item into a new task
+func decodeNewTask(item json.RawMessage, userID uuid.UUID, loc *time.Location) (models.Task, error) {
+	var req models.CreateTaskRequest
+	if err := json.Unmarshal(item, &req); err != nil {
+		return models.Task{}, errors.New("item must be a task object")
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return models.Task{}, err
+	}
+	return newTask(req, userID, loc)
+}
+
+// UpdateTasksBulk applies a partial update to tasks selected by ID or by a
+// filter, in one transaction, reporting the rows affected per ID
+func (h *TaskHandler) UpdateTasksBulk(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.BulkUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide exactly one of ids or filter"})
+		return
+	}
+	force, err := queryBool(c, "force")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	updates, err := bulkUpdates(req.Update)
+	var colorErr invalidColorError
+	if errors.As(err, &colorErr) {
+		respondInvalidColor(c, colorErr.color)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, err := deadlineContext(c.Request.Context(), userID, req.Update.DueDate != nil, req.Update.DueDateReason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxItems := config.Int("BULK_MAX_ITEMS", defaultBulkMaxItems)
+	var taskIDs []uuid.UUID
+	if req.Filter != nil {
+		filters, err := bulkFilters(*req.Filter)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "filter"})
+			return
+		}
+		if taskIDs, err = h.matchTaskIDs(c, userID, filters, maxItems+1); err != nil {
+			respondError(c, err, "Failed to update tasks")
+			return
+		}
+	} else {
+		taskIDs = uniqueIDs(req.IDs)
+	}
+	if len(taskIDs) > maxItems {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("At most %d tasks can be updated at once", maxItems)})
+		return
+	}
+	if len(taskIDs) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "No tasks matched", "updated": 0, "results": []models.BulkUpdateResult{}})
+		return
+	}
+
+	policy := subtaskCompletionPolicy()
+	outcome, err := h.tasks.UpdateMany(ctx, userID, taskIDs, updates, repository.BulkUpdateOptions{
+		CascadeSubtasks:   policy == SubtaskPolicyCascade,
+		BlockOpenSubtasks: policy == SubtaskPolicyBlock,
+		BlockOpenBlockers: !force,
+	})
+	if err != nil {
+		respondError(c, err, "Failed to update tasks")
+		return
+	}
+
+	completed := make(map[uuid.UUID]bool, len(outcome.Completed))
+	for _, task := range outcome.Completed {
+		observeCompletion(&task)
+		completed[task.ID] = true
+	}
+	for i := range outcome.Updated {
+		task := &outcome.Updated[i]
+		h.indexer.Index(c.Request.Context(), *task)
+		h.publishTaskUpdate(c.Request.Context(), userID, task, completed[task.ID])
+	}
+
+	updated := 0
+	for _, result := range outcome.Results {
+		updated += int(result.RowsAffected)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Updated %d of %d tasks", updated, len(taskIDs)),
+		"updated": updated,
+		"results": outcome.Results,
+	})
+}
+
+// bulkUpdates validates a bulk update into column updates
+func bulkUpdates(update models.BulkTaskUpdate) (map[string]interface{}, error) {
+	updates := make(map[string]interface{})
+	if update.Status != nil {
+		if !isValidStatus(*update.Status) {
+			return nil, errors.New("Invalid status")
+		}
+		updates["status"] = *update.Status
+	}
+	if update.Priority != nil {
+		if !isValidPriority(*update.Priority) {
+			return nil, errors.New("Invalid priority")
+		}
+		updates["priority"] = *update.Priority
+	}
+	if update.DueDate != nil {
+		updates["due_date"] = *update.DueDate
+	}
+	if update.Color != nil {
+		if *update.Color == "" {
+			updates["color"] = nil
+		} else {
+			normalized, ok := normalizeColor(*update.Color)
+			if !ok {
+				return nil, invalidColorError{color: *update.Color}
+			}
+			updates["color"] = normalized
+		}
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("No fields to update")
+	}
+	return updates, nil
+}
+
+// bulkFilters validates a bulk filter into task list filters
+func bulkFilters(filter models.BulkTaskFilter) (models.TaskFilters, error) {
+	if filter.Status != "" && !isValidStatus(filter.Status) {
+		return models.TaskFilters{}, errors.New("Invalid status filter")
+	}
+	if filter.Priority != "" && !isValidPriority(filter.Priority) {
+		return models.TaskFilters{}, errors.New("Invalid priority filter")
+	}
+	if filter.Origin != "" && !isValidOrigin(filter.Origin) {
+		return models.TaskFilters{}, errors.New("Invalid origin filter")
+	}
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return models.TaskFilters{}, err
+	}
+	return models.TaskFilters{
+		Status:   filter.Status,
+		Priority: filter.Priority,
+		Origin:   filter.Origin,
+		Tags:     tags,
+	}, nil
+}
+
+// matchTaskIDs returns up to limit IDs of the user's tasks matching filters,
+// oldest first
+func (h *TaskHandler) matchTaskIDs(c *gin.Context, userID uuid.UUID, filters models.TaskFilters, limit int) ([]uuid.UUID, error) {
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	where := buildTaskWhere(userID, filters)
+	query := "SELECT id FROM tasks" + where.sql() + " ORDER BY created_at, id LIMIT " + where.arg(limit)
+
+	var taskIDs []uuid.UUID
+	if err := h.db.SelectContext(ctx, &taskIDs, query, where.args...); err != nil {
+		return nil, repository.Translate(err)
+	}
+	return taskIDs, nil
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// DeleteTasks moves the caller's tasks matching the list filters to the
+// trash, such as old cancelled tasks (?status=cancelled&before=2024-01-01). It requires
+// ?confirm=true and at least one filter, and deletes at most BULK_MAX_ITEMS
+// tasks per call, oldest first.
+func (h *TaskHandler) DeleteTasks(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	filters, err := parseTaskFilters(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	// before is shorthand for created_before
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before, err := queryTime(c, "before", loc)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	if before != nil {
+		filters.CreatedBefore = before
+	}
+	confirm, err := queryBool(c, "confirm")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	if filters.Query == "" && filters.Status == "" && filters.Priority == "" && filters.Origin == "" &&
+		filters.MinProgress == nil && filters.MinEstimate == nil && filters.MaxEstimate == nil && len(filters.Tags) == 0 && filters.DueAfter == nil && filters.DueBefore == nil &&
+		filters.CreatedAfter == nil && filters.CreatedBefore == nil && len(filters.Metadata) == 0 && filters.Near == nil && !filters.Overdue && !filters.Pinned {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one filter is required to delete tasks in bulk"})
+		return
+	}
+	if filters.Scope == ScopeShared {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only your own tasks can be deleted in bulk", "field": "scope"})
+		return
+	}
+	if !confirm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Add confirm=true to delete every matching task", "field": "confirm"})
+		return
+	}
+
+	maxItems := config.Int("BULK_MAX_ITEMS", defaultBulkMaxItems)
+	taskIDs, err := h.matchTaskIDs(c, userID, filters, maxItems+1)
+	if err != nil {
+		respondError(c, err, "Failed to delete tasks")
+		return
+	}
+	result := models.BulkDeleteResult{HasMore: len(taskIDs) > maxItems}
+	if result.HasMore {
+		taskIDs = taskIDs[:maxItems]
+	}
+
+	if len(taskIDs) > 0 {
+		outcome, err := h.tasks.DeleteMany(c.Request.Context(), userID, taskIDs)
+		if err != nil {
+			respondError(c, err, "Failed to delete tasks")
+			return
+		}
+		for _, task := range append(outcome.Subtasks, outcome.Deleted...) {
+			h.indexer.Delete(c.Request.Context(), task.ID)
+			h.publishTaskEvent(c.Request.Context(), models.EventTaskDeleted, userID, task.ID, nil)
+		}
+		for i := range outcome.Parents {
+			parent := &outcome.Parents[i]
+			h.indexer.Index(c.Request.Context(), *parent)
+			h.publishTaskUpdate(c.Request.Context(), userID, parent, false)
+		}
+		result.Deleted = len(outcome.Deleted)
+		result.SubtasksDeleted = len(outcome.Subtasks)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Deleted %d tasks", result.Deleted),
+		"result":  result,
+	})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateTasksBulkRejectsRequest(t *testing.T) {
+	t.Setenv("BULK_MAX_ITEMS", "2")
+	router := newRouter(uuid.New(), http.MethodPatch, "/tasks/bulk", (&TaskHandler{}).UpdateTasksBulk)
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	for name, tc := range map[string]struct {
+		body   map[string]interface{}
+		status int
+	}{
+		"ids and filter":   {map[string]interface{}{"ids": ids[:1], "filter": map[string]interface{}{"status": "pending"}, "update": map[string]interface{}{"priority": "high"}}, http.StatusBadRequest},
+		"neither":          {map[string]interface{}{"update": map[string]interface{}{"priority": "high"}}, http.StatusBadRequest},
+		"invalid status":   {map[string]interface{}{"ids": ids[:1], "update": map[string]interface{}{"status": "sleeping"}}, http.StatusBadRequest},
+		"invalid color":    {map[string]interface{}{"ids": ids[:1], "update": map[string]interface{}{"color": "mauve-ish"}}, http.StatusUnprocessableEntity},
+		"too many tasks":   {map[string]interface{}{"ids": ids, "update": map[string]interface{}{"priority": "high"}}, http.StatusRequestEntityTooLarge},
+		"invalid priority": {map[string]interface{}{"ids": ids[:1], "update": map[string]interface{}{"priority": "someday"}}, http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, router, http.MethodPatch, "/tasks/bulk", tc.body)
+			assert.Equal(t, tc.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+// TestUpdateTasksBulkReportsPartialFailures updates a batch in which some
+// tasks can't be updated: those are reported per ID and the rest still
+// change
+func TestUpdateTasksBulkReportsPartialFailures(t *testing.T) {
+	db := testdb.Open(t)
+	owner, other := uuid.New(), uuid.New()
+	done, blocked, blocker, othersTask, missing := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'owner', 'owner@example.com')", []interface{}{owner}},
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'other', 'other@example.com')", []interface{}{other}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Done')", []interface{}{done, owner}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Blocked')", []interface{}{blocked, owner}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Blocker')", []interface{}{blocker, owner}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Not yours')", []interface{}{othersTask, other}},
+		{"INSERT INTO task_dependencies (blocker_id, blocked_id) VALUES ($1, $2)", []interface{}{blocker, blocked}},
+	} {
+		_, err := db.Exec(q.query, q.args...)
+		require.NoError(t, err, q.query)
+	}
+
+	events := &recordedEvents{}
+	h := NewTaskHandler(db, search.NoopIndexer{}, events, nil)
+	router := newRouter(owner, http.MethodPatch, "/tasks/bulk", h.UpdateTasksBulk)
+
+	w := serve(t, router, http.MethodPatch, "/tasks/bulk", map[string]interface{}{
+		"ids":    []uuid.UUID{done, blocked, othersTask, missing},
+		"update": map[string]interface{}{"status": "completed"},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	body := decode(t, w)
+	assert.Equal(t, float64(1), body["updated"])
+	assert.Equal(t, "Updated 1 of 4 tasks", body["message"])
+
+	results := map[string]map[string]interface{}{}
+	for _, r := range body["results"].([]interface{}) {
+		result := r.(map[string]interface{})
+		results[result["id"].(string)] = result
+	}
+	require.Len(t, results, 4)
+	assert.Equal(t, float64(1), results[done.String()]["rows_affected"])
+	assert.Nil(t, results[done.String()]["error"])
+	assert.Equal(t, "task is blocked by open tasks", results[blocked.String()]["error"])
+	assert.Equal(t, "task not found", results[othersTask.String()]["error"], "another user's task is not found")
+	assert.Equal(t, "task not found", results[missing.String()]["error"])
+
+	statuses := map[uuid.UUID]string{}
+	rows, err := db.Query("SELECT id, status FROM tasks")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id uuid.UUID
+		var status string
+		require.NoError(t, rows.Scan(&id, &status))
+		statuses[id] = status
+	}
+	assert.Equal(t, map[uuid.UUID]string{
+		done: "completed", blocked: "pending", blocker: "pending", othersTask: "pending",
+	}, statuses)
+	assert.Equal(t, []string{models.EventTaskCompleted}, events.types())
+
+	// Forcing past open blockers completes the rest
+	w = serve(t, router, http.MethodPatch, "/tasks/bulk?force=true", map[string]interface{}{
+		"ids":    []uuid.UUID{blocked},
+		"update": map[string]interface{}{"status": "completed"},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(1), decode(t, w)["updated"])
+}
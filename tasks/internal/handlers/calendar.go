@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// calendarAudience keeps calendar feed tokens from being mistaken for other JWTs
+const calendarAudience = "task-calendar"
+
+// icsTimeFormat is the iCalendar UTC date-time form
+const icsTimeFormat = "20060102T150405Z"
+
+// icsLineOctets is the longest content line RFC 5545 allows before folding
+const icsLineOctets = 75
+
+// icsStatus maps task statuses to VTODO statuses
+var icsStatus = map[string]string{
+	"pending":     "NEEDS-ACTION",
+	"in_progress": "IN-PROCESS",
+	"completed":   "COMPLETED",
+	"cancelled":   "CANCELLED",
+}
+
+// icsPriority maps task priorities to iCalendar priorities, where 1 is the
+// highest and 9 the lowest
+var icsPriority = map[string]int{
+	"urgent": 1,
+	"high":   3,
+	"medium": 5,
+	"low":    9,
+}
+
+// calendarSecret signs calendar tokens, falling back to the auth JWT secret
+func calendarSecret() []byte {
+	return []byte(config.String("CALENDAR_TOKEN_SECRET", config.String("JWT_SECRET", "")))
+}
+
+// CreateCalendarToken issues a token for subscribing to the caller's calendar
+// feed from apps that cannot send an Authorization header. Tokens do not
+// expire; issuing a new one revokes the previous token.
+func (h *TaskHandler) CreateCalendarToken(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	tokenID, err := h.calendar.Rotate(c.Request.Context(), userID)
+	if err != nil {
+		respondResourceError(c, err, "Calendar token", "Failed to create calendar token")
+		return
+	}
+
+	claims := jwt.RegisteredClaims{
+		ID:       tokenID.String(),
+		Subject:  userID.String(),
+		Audience: jwt.ClaimStrings{calendarAudience},
+		IssuedAt: jwt.NewNumericDate(time.Now()),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(calendarSecret())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create calendar token"})
+		return
+	}
+
+	log.Printf("📅 Calendar token issued for user %s\n", userID)
+	c.JSON(http.StatusOK, gin.H{
+		"token": token,
+		"path":  "/api/v1/tasks/export.ics?token=" + token,
+	})
+}
+
+// RevokeCalendarToken revokes the caller's calendar token, cutting off any
+// subscribed calendars
+func (h *TaskHandler) RevokeCalendarToken(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	if err := h.calendar.Revoke(c.Request.Context(), userID); err != nil {
+		respondResourceError(c, err, "Calendar token", "Failed to revoke calendar token")
+		return
+	}
+
+	log.Printf("📅 Calendar token revoked for user %s\n", userID)
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar token revoked"})
+}
+
+// CalendarAuth authenticates calendar feed requests by their ?token= and
+// falls back to jwtAuth when no token is given
+func (h *TaskHandler) CalendarAuth(jwtAuth gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := c.Query("token")
+		if tokenString == "" {
+			jwtAuth(c)
+			return
+		}
+
+		var claims jwt.RegisteredClaims
+		_, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return calendarSecret(), nil
+		}, jwt.WithAudience(calendarAudience))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid calendar token"})
+			return
+		}
+
+		userID, err := uuid.Parse(claims.Subject)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid calendar token"})
+			return
+		}
+
+		current, err := h.calendar.Current(c.Request.Context(), userID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && current.String() != claims.ID) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Calendar token has been revoked"})
+			return
+		}
+		if err != nil {
+			respondResourceError(c, err, "Calendar token", "Failed to verify calendar token")
+			c.Abort()
+			return
+		}
+
+		c.Set("userID", userID)
+		c.Next()
+	}
+}
+
+// ExportCalendar streams the caller's tasks that have a due date as an
+// iCalendar feed of VTODO entries. The task list filters apply, so a
+// subscription URL can be narrowed to e.g. one project.
+func (h *TaskHandler) ExportCalendar(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	filters, err := parseTaskFilters(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	where := buildTaskWhere(userID, filters)
+	where.add("due_date IS NOT NULL")
+	query := exportSelect + where.sql() + " ORDER BY due_date ASC, created_at ASC"
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), config.Duration("EXPORT_TIMEOUT", defaultExportTimeout))
+	defer cancel()
+
+	rows, err := h.db.QueryxContext(ctx, query, where.args...)
+	if err != nil {
+		respondError(c, err, "Failed to export calendar")
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="tasks.ics"`)
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriter(c.Writer)
+	writeICSLine(w, "BEGIN", "VCALENDAR")
+	writeICSLine(w, "VERSION", "2.0")
+	writeICSLine(w, "PRODID", "-//moabdelazem//tasks//EN")
+	writeICSLine(w, "CALSCALE", "GREGORIAN")
+	writeICSLine(w, "METHOD", "PUBLISH")
+	writeICSLine(w, "X-WR-CALNAME", "Tasks")
+
+	stamp := time.Now().UTC().Format(icsTimeFormat)
+	count := 0
+	for rows.Next() {
+		var row exportRow
+		if err := rows.StructScan(&row); err != nil {
+			log.Printf("❌ Failed to read task for calendar: %v\n", err)
+			return
+		}
+		writeVTodo(w, row, stamp)
+		count++
+		if count%exportFlushRows == 0 {
+			if err := w.Flush(); err != nil {
+				log.Printf("❌ Failed to write calendar: %v\n", err)
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("❌ Calendar export for user %s stopped after %d tasks: %v\n", userID, count, err)
+		return
+	}
+
+	writeICSLine(w, "END", "VCALENDAR")
+	if err := w.Flush(); err != nil {
+		log.Printf("❌ Failed to write calendar: %v\n", err)
+	}
+}
+
+// writeVTodo writes a task as a VTODO component
+func writeVTodo(w *bufio.Writer, row exportRow, stamp string) {
+	task := row.Task
+	writeICSLine(w, "BEGIN", "VTODO")
+	writeICSLine(w, "UID", task.ID.String()+"@tasks")
+	writeICSLine(w, "DTSTAMP", stamp)
+	writeICSLine(w, "CREATED", task.CreatedAt.UTC().Format(icsTimeFormat))
+	writeICSLine(w, "LAST-MODIFIED", task.UpdatedAt.UTC().Format(icsTimeFormat))
+	writeICSLine(w, "SUMMARY", escapeICSText(task.Title))
+	if task.Description != nil && *task.Description != "" {
+		writeICSLine(w, "DESCRIPTION", escapeICSText(*task.Description))
+	}
+	writeICSLine(w, "DUE", task.DueDate.UTC().Format(icsTimeFormat))
+	writeICSLine(w, "STATUS", icsStatus[task.Status])
+	writeICSLine(w, "PRIORITY", strconv.Itoa(icsPriority[task.Priority]))
+	writeICSLine(w, "PERCENT-COMPLETE", strconv.Itoa(task.Progress))
+	if len(row.TagNames) > 0 {
+		names := make([]string, len(row.TagNames))
+		for i, name := range row.TagNames {
+			names[i] = escapeICSText(name)
+		}
+		writeICSLine(w, "CATEGORIES", strings.Join(names, ","))
+	}
+	if task.ParentTaskID != nil {
+		writeICSLine(w, "RELATED-TO", task.ParentTaskID.String()+"@tasks")
+	}
+	writeICSLine(w, "END", "VTODO")
+}
+
+// escapeICSText escapes a TEXT value per RFC 5545 section 3.3.11
+func escapeICSText(val string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(val)
+}
+
+// writeICSLine writes a CRLF-terminated content line, folding it at
+// icsLineOctets without splitting a UTF-8 character
+func writeICSLine(w *bufio.Writer, name, value string) {
+	line := name + ":" + value
+	limit := icsLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts toward its length
+		limit = icsLineOctets - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// reminderChannels lists the delivery channels the notification service routes
+var reminderChannels = []string{models.ChannelEmail, models.ChannelPush, models.ChannelWebhook}
+
+// normalizeChannels lowercases and de-duplicates channels, falling back to
+// REMINDER_DEFAULT_CHANNELS (email) when none are given
+func normalizeChannels(channels []string) ([]string, error) {
+	if len(channels) == 0 {
+		channels = config.List("REMINDER_DEFAULT_CHANNELS")
+		if len(channels) == 0 {
+			channels = []string{models.ChannelEmail}
+		}
+	}
+
+	seen := make(map[string]bool, len(channels))
+	normalized := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if !isValidChannel(channel) {
+			return nil, fmt.Errorf("invalid channel %q. Must be one of: %s", channel, strings.Join(reminderChannels, ", "))
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			normalized = append(normalized, channel)
+		}
+	}
+	return normalized, nil
+}
+
+func isValidChannel(channel string) bool {
+	for _, allowed := range reminderChannels {
+		if channel == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeChannels(t *testing.T) {
+	channels, err := normalizeChannels([]string{" Push", "webhook", "PUSH"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"push", "webhook"}, channels)
+
+	channels, err = normalizeChannels(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email"}, channels, "email by default")
+
+	t.Setenv("REMINDER_DEFAULT_CHANNELS", "push,webhook")
+	channels, err = normalizeChannels([]string{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"push", "webhook"}, channels)
+
+	_, err = normalizeChannels([]string{"email", "sms"})
+	assert.EqualError(t, err, `invalid channel "sms". Must be one of: email, push, webhook`)
+}
+
+func TestCreateReminderRejectsUnknownChannel(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodPost, "/tasks/:id/reminders", (&TaskHandler{}).CreateReminder)
+
+	w := serve(t, router, http.MethodPost, "/tasks/"+uuid.NewString()+"/reminders", map[string]interface{}{
+		"before":   "1h",
+		"channels": []string{"pigeon"},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	body := decode(t, w)
+	assert.Equal(t, "channels", body["field"])
+	assert.Contains(t, body["error"], `invalid channel "pigeon"`)
+}
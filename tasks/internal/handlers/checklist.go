@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// maxChecklistItemLength bounds a checklist item's text in characters
+const maxChecklistItemLength = 255
+
+// checklistItemIDs parses the task and checklist item IDs from the path
+func checklistItemIDs(c *gin.Context) (taskID, itemID uuid.UUID, ok bool) {
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	itemID, err = uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist item ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return taskID, itemID, true
+}
+
+// AddChecklistItem appends an item to a task's checklist
+func (h *TaskHandler) AddChecklistItem(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.CreateChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Checklist item text is required"})
+		return
+	}
+	if utf8.RuneCountInString(text) > maxChecklistItemLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Checklist item text must be at most 255 characters"})
+		return
+	}
+
+	current, ok := h.accessibleTask(c, taskID, userID, true, "Failed to add checklist item")
+	if !ok {
+		return
+	}
+
+	item := models.ChecklistItem{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		Text:      text,
+		CreatedAt: time.Now(),
+	}
+	task, err := h.checklist.Add(c.Request.Context(), &item)
+	if err != nil {
+		respondError(c, err, "Failed to add checklist item")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, current.UserID, task.ID, task)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Checklist item added successfully",
+		"item":    item,
+		"task":    task,
+	})
+}
+
+// GetChecklist lists a task's checklist in order with its completion percentage
+func (h *TaskHandler) GetChecklist(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, ok := h.accessibleTask(c, taskID, userID, false, "Failed to fetch checklist")
+	if !ok {
+		return
+	}
+
+	items, err := h.checklist.List(c.Request.Context(), taskID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch checklist")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"checklist": items,
+		"progress":  task.ChecklistProgress,
+	})
+}
+
+// ToggleChecklistItem flips a checklist item between done and not done
+func (h *TaskHandler) ToggleChecklistItem(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, itemID, ok := checklistItemIDs(c)
+	if !ok {
+		return
+	}
+
+	current, ok := h.accessibleTask(c, taskID, userID, true, "Failed to update checklist item")
+	if !ok {
+		return
+	}
+
+	item, task, err := h.checklist.Toggle(c.Request.Context(), taskID, itemID)
+	if err != nil {
+		respondResourceError(c, err, "Checklist item", "Failed to update checklist item")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, current.UserID, task.ID, task)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item updated successfully",
+		"item":    item,
+		"task":    task,
+	})
+}
+
+// MoveChecklistItem places a checklist item at a zero-based position
+func (h *TaskHandler) MoveChecklistItem(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, itemID, ok := checklistItemIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.MoveChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, true, "Failed to move checklist item"); !ok {
+		return
+	}
+
+	items, err := h.checklist.Move(c.Request.Context(), taskID, itemID, *req.Position)
+	if err != nil {
+		respondResourceError(c, err, "Checklist item", "Failed to move checklist item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Checklist item moved successfully",
+		"checklist": items,
+	})
+}
+
+// DeleteChecklistItem removes an item from a task's checklist
+func (h *TaskHandler) DeleteChecklistItem(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, itemID, ok := checklistItemIDs(c)
+	if !ok {
+		return
+	}
+
+	current, ok := h.accessibleTask(c, taskID, userID, true, "Failed to delete checklist item")
+	if !ok {
+		return
+	}
+
+	task, err := h.checklist.Delete(c.Request.Context(), taskID, itemID)
+	if err != nil {
+		respondResourceError(c, err, "Checklist item", "Failed to delete checklist item")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, current.UserID, task.ID, task)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item deleted successfully",
+		"task":    task,
+	})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// colorPalette lists the named colors accepted in addition to hex codes
+var colorPalette = []string{"red", "orange", "yellow", "green", "teal", "blue", "purple", "pink", "gray"}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// normalizeColor lowercases a color and reports whether it is a valid hex
+// code (#rgb or #rrggbb) or a palette name
+func normalizeColor(color string) (string, bool) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if hexColorPattern.MatchString(color) {
+		return color, true
+	}
+	for _, name := range colorPalette {
+		if name == color {
+			return color, true
+		}
+	}
+	return "", false
+}
+
+// invalidColorError reports a color that failed normalizeColor
+type invalidColorError struct {
+	color string
+}
+
+func (e invalidColorError) Error() string {
+	return "Invalid color. Must be a hex code (#rgb or #rrggbb) or a palette name"
+}
+
+// respondInvalidColor writes a structured 422 for an invalid color value
+func respondInvalidColor(c *gin.Context, color string) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":   "Invalid color. Must be a hex code (#rgb or #rrggbb) or a palette name",
+		"field":   "color",
+		"value":   color,
+		"palette": colorPalette,
+	})
+}
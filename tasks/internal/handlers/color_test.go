@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeColor(t *testing.T) {
+	for input, want := range map[string]string{
+		"#1e90ff": "#1e90ff",
+		"#1E90FF": "#1e90ff",
+		"#abc":    "#abc",
+		" Teal ":  "teal",
+		"gray":    "gray",
+		"PURPLE":  "purple",
+	} {
+		got, ok := normalizeColor(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "#12345", "#1234567", "#ggg", "1e90ff", "grey", "mauve"} {
+		_, ok := normalizeColor(input)
+		assert.False(t, ok, input)
+	}
+}
+
+func TestInvalidColorIs422(t *testing.T) {
+	h := &TaskHandler{}
+	create := newRouter(uuid.New(), http.MethodPost, "/tasks", h.CreateTask)
+	update := newRouter(uuid.New(), http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, update, http.MethodPut, "/tasks/"+uuid.NewString(), map[string]interface{}{"color": "#12"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "checked before the task is read")
+
+	w = serve(t, create, http.MethodPost, "/tasks", map[string]interface{}{"title": "Paint", "color": "mauve"})
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	body := decode(t, w)
+	assert.Equal(t, "color", body["field"])
+	assert.Equal(t, "mauve", body["value"])
+	assert.Len(t, body["palette"], len(colorPalette))
+}
+
+func TestTaskColor(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "painter")
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	create := newRouter(userID, http.MethodPost, "/tasks", h.CreateTask)
+	update := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, create, http.MethodPost, "/tasks", map[string]interface{}{"title": "Hex", "color": "#1E90FF"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	task := decode(t, w)["task"].(map[string]interface{})
+	assert.Equal(t, "#1e90ff", task["color"])
+	taskID := task["id"].(string)
+
+	w = serve(t, update, http.MethodPut, "/tasks/"+taskID, map[string]interface{}{"color": "Teal"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "teal", decode(t, w)["task"].(map[string]interface{})["color"])
+
+	w = serve(t, update, http.MethodPut, "/tasks/"+taskID, map[string]interface{}{"color": ""})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Nil(t, decode(t, w)["task"].(map[string]interface{})["color"], "an empty color clears it")
+}
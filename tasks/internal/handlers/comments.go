@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// maxCommentLength bounds a comment body in characters
+const maxCommentLength = 5000
+
+// maxCommentMentions bounds how many distinct users a comment can @mention
+const maxCommentMentions = 20
+
+// mentionPattern matches @username where usernames follow the auth service's
+// rules. The mention must not follow a word character, so emails don't match.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_@-])@([A-Za-z0-9_-]{3,50})\b`)
+
+// parseMentions returns the distinct lowercased usernames @mentioned in a
+// comment body, in order of appearance, at most maxCommentMentions of them
+func parseMentions(body string) []string {
+	var usernames []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.ToLower(m[1])
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		usernames = append(usernames, name)
+		if len(usernames) == maxCommentMentions {
+			break
+		}
+	}
+	return usernames
+}
+
+// CreateComment adds a comment to a task the caller owns
+func (h *TaskHandler) CreateComment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body is required"})
+		return
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Comment body must be at most 5000 characters"})
+		return
+	}
+
+	task, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to add comment")
+		return
+	}
+
+	comment := models.TaskComment{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		UserID:    userID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := h.comments.Create(c.Request.Context(), &comment, parseMentions(body)); err != nil {
+		respondResourceError(c, err, "Comment", "Failed to add comment")
+		return
+	}
+	for _, mentioned := range comment.Mentions {
+		h.publishMention(c.Request.Context(), task, &comment, mentioned)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Comment added successfully",
+		"comment": comment,
+	})
+}
+
+// GetComments lists a task's comments, oldest first, with pagination
+func (h *TaskHandler) GetComments(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to fetch comments")
+		return
+	}
+
+	comments, total, err := h.comments.List(c.Request.Context(), taskID, limit, (page-1)*limit)
+	if err != nil {
+		respondResourceError(c, err, "Comment", "Failed to fetch comments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// DeleteComment removes a comment. The task owner can delete any comment on
+// the task, including ones left by a previous owner.
+func (h *TaskHandler) DeleteComment(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to delete comment")
+		return
+	}
+
+	if err := h.comments.Delete(c.Request.Context(), commentID, taskID); err != nil {
+		respondResourceError(c, err, "Comment", "Failed to delete comment")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
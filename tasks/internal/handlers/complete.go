@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/metrics"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// observeCompletion records how long a just-completed task took
+func observeCompletion(task *models.Task) {
+	if task.CompletedAt != nil {
+		metrics.TaskCompletionAge.Observe(task.CompletedAt.Sub(task.CreatedAt).Seconds())
+	}
+}
+
+// CompleteTask marks a task completed, recording completed_at, and publishes
+// task.completed. Open blockers and the subtask completion policy apply as
+// for a status update; force=true completes the task despite its blockers.
+func (h *TaskHandler) CompleteTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	force, err := queryBool(c, "force")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	current, ok := h.accessibleTask(c, taskID, userID, true, "Failed to complete task")
+	if !ok {
+		return
+	}
+	// Shared editors complete the task on the owner's behalf
+	userID = current.UserID
+	if current.Status == "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task is already completed"})
+		return
+	}
+
+	if !force && !h.checkBlockers(c, taskID) {
+		return
+	}
+	cascade := false
+	if current.ParentTaskID == nil {
+		_, openSubtasks, err := h.tasks.SubtaskCounts(c.Request.Context(), taskID)
+		if err != nil {
+			respondError(c, err, "Failed to complete task")
+			return
+		}
+		var ok bool
+		if cascade, ok = applySubtaskPolicy(c, openSubtasks); !ok {
+			return
+		}
+	}
+
+	updates := map[string]interface{}{"status": "completed"}
+	var task *models.Task
+	var completedSubtasks []models.Task
+	if cascade {
+		task, completedSubtasks, err = h.tasks.UpdateCompletingSubtasks(c.Request.Context(), taskID, userID, updates, nil)
+	} else {
+		task, err = h.tasks.Update(c.Request.Context(), taskID, userID, updates)
+	}
+	if err != nil {
+		respondError(c, err, "Failed to complete task")
+		return
+	}
+
+	observeCompletion(task)
+	for i := range completedSubtasks {
+		subtask := &completedSubtasks[i]
+		observeCompletion(subtask)
+		h.indexer.Index(c.Request.Context(), *subtask)
+		h.publishTaskUpdate(c.Request.Context(), userID, subtask, true)
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskUpdate(c.Request.Context(), userID, task, true)
+	h.rollupParent(c.Request.Context(), userID, task)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task completed successfully",
+		"task":    task,
+	})
+}
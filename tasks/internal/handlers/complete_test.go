@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/metrics"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// completionAge reads the completion age histogram's samples by name, e.g.
+// tasks_completion_age_seconds_count
+func completionAge(t *testing.T) map[string]float64 {
+	t.Helper()
+	var out bytes.Buffer
+	metrics.TaskCompletionAge.Write(&out)
+	samples := map[string]float64{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		value, err := strconv.ParseFloat(fields[1], 64)
+		require.NoError(t, err, line)
+		samples[fields[0]] = value
+	}
+	return samples
+}
+
+func TestObserveCompletion(t *testing.T) {
+	created := time.Now().Add(-90 * time.Minute)
+	completed := created.Add(90 * time.Minute)
+	before := completionAge(t)
+
+	observeCompletion(&models.Task{CreatedAt: created, CompletedAt: &completed})
+	observeCompletion(&models.Task{CreatedAt: created})
+
+	after := completionAge(t)
+	assert.Equal(t, before["tasks_completion_age_seconds_count"]+1, after["tasks_completion_age_seconds_count"], "tasks without completed_at aren't observed")
+	assert.InDelta(t, before["tasks_completion_age_seconds_sum"]+5400, after["tasks_completion_age_seconds_sum"], 0.001)
+	assert.Equal(t, before[`tasks_completion_age_seconds_bucket{le="3600"}`], after[`tasks_completion_age_seconds_bucket{le="3600"}`])
+	assert.Equal(t, before[`tasks_completion_age_seconds_bucket{le="21600"}`]+1, after[`tasks_completion_age_seconds_bucket{le="21600"}`])
+}
+
+// TestCompleteTaskObservesAge completes a task created two days ago and
+// checks the histogram observes its age
+func TestCompleteTaskObservesAge(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "finisher")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title, created_at) VALUES ($1, $2, 'Slow one', NOW() - INTERVAL '2 days')", taskID, userID)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodPost, "/tasks/:id/complete", h.CompleteTask)
+	before := completionAge(t)
+
+	w := serve(t, router, http.MethodPost, "/tasks/"+taskID.String()+"/complete", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	after := completionAge(t)
+	assert.Equal(t, before["tasks_completion_age_seconds_count"]+1, after["tasks_completion_age_seconds_count"])
+	assert.InDelta(t, before["tasks_completion_age_seconds_sum"]+2*86400, after["tasks_completion_age_seconds_sum"], 60)
+	assert.Equal(t, before[`tasks_completion_age_seconds_bucket{le="86400"}`], after[`tasks_completion_age_seconds_bucket{le="86400"}`])
+	assert.Equal(t, before[`tasks_completion_age_seconds_bucket{le="259200"}`]+1, after[`tasks_completion_age_seconds_bucket{le="259200"}`])
+
+	// Completing it again is a no-op and isn't observed twice
+	serve(t, router, http.MethodPost, "/tasks/"+taskID.String()+"/complete", nil)
+	assert.Equal(t, after["tasks_completion_age_seconds_count"], completionAge(t)["tasks_completion_age_seconds_count"])
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/rabbitmq"
+)
+
+// defaultDeadLetterMax caps how many dead letters one request handles
+const defaultDeadLetterMax = 100
+
+// ConsumerControl pauses and resumes the RabbitMQ consumer and drains its
+// dead-letter queue
+type ConsumerControl interface {
+	ConsumerStatus
+	Pause() error
+	Resume() error
+	InspectDeadLetters(max int) ([]rabbitmq.DeadLetter, error)
+	RequeueDeadLetters(max int) (requeued, remaining int, err error)
+}
+
+type ConsumerHandler struct {
+	consumer ConsumerControl
+}
+
+func NewConsumerHandler(consumer ConsumerControl) *ConsumerHandler {
+	return &ConsumerHandler{consumer: consumer}
+}
+
+// Pause stops user event processing without dropping the connection
+func (h *ConsumerHandler) Pause(c *gin.Context) {
+	if err := h.consumer.Pause(); err != nil {
+		h.respond(c, err, "Failed to pause consumer")
+		return
+	}
+	log.Printf("⏸️  Consumer paused by admin %s\n", c.GetString("username"))
+	c.JSON(http.StatusOK, gin.H{"message": "Consumer paused", "paused": true})
+}
+
+// Resume restarts user event processing after a pause
+func (h *ConsumerHandler) Resume(c *gin.Context) {
+	if err := h.consumer.Resume(); err != nil {
+		h.respond(c, err, "Failed to resume consumer")
+		return
+	}
+	log.Printf("▶️  Consumer resumed by admin %s\n", c.GetString("username"))
+	c.JSON(http.StatusOK, gin.H{"message": "Consumer resumed", "paused": false})
+}
+
+// InspectDeadLetters lists messages in the dead-letter queue without
+// removing them
+func (h *ConsumerHandler) InspectDeadLetters(c *gin.Context) {
+	max, err := h.deadLetterMax(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	letters, err := h.consumer.InspectDeadLetters(max)
+	if err != nil {
+		h.respond(c, err, "Failed to inspect dead letters")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"dead_letters": letters, "count": len(letters)})
+}
+
+// RequeueDeadLetters republishes dead-lettered messages to the user events
+// exchange, once whatever made them fail has been fixed
+func (h *ConsumerHandler) RequeueDeadLetters(c *gin.Context) {
+	max, err := h.deadLetterMax(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	requeued, remaining, err := h.consumer.RequeueDeadLetters(max)
+	if err != nil {
+		// Messages requeued before the failure stay requeued
+		log.Printf("❌ Failed to requeue dead letters after %d: %v\n", requeued, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to requeue dead letters", "requeued": requeued})
+		return
+	}
+	log.Printf("🔁 %d dead letters requeued by admin %s\n", requeued, c.GetString("username"))
+	c.JSON(http.StatusOK, gin.H{"message": "Dead letters requeued", "requeued": requeued, "remaining": remaining})
+}
+
+// deadLetterMax reads the max query parameter, capped by
+// RABBITMQ_DEAD_LETTER_MAX
+func (h *ConsumerHandler) deadLetterMax(c *gin.Context) (int, error) {
+	limit := config.Int("RABBITMQ_DEAD_LETTER_MAX", defaultDeadLetterMax)
+	return queryInt(c, "max", limit, 1, limit)
+}
+
+func (h *ConsumerHandler) respond(c *gin.Context, err error, msg string) {
+	if errors.Is(err, rabbitmq.ErrConsumerPaused) || errors.Is(err, rabbitmq.ErrConsumerRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "paused": h.consumer.Paused()})
+		return
+	}
+	log.Printf("❌ %s: %v\n", msg, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": msg})
+}
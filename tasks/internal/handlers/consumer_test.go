@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/rabbitmq"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConsumer records the max each dead-letter call was given and whether
+// it is paused. Its breaker is closed unless breaker says otherwise.
+type fakeConsumer struct {
+	breaker    string
+	paused     bool
+	pauseErr   error
+	letters    []rabbitmq.DeadLetter
+	requeueErr error
+	max        int
+}
+
+func (f *fakeConsumer) Paused() bool { return f.paused }
+
+func (f *fakeConsumer) Pause() error {
+	if f.paused {
+		return rabbitmq.ErrConsumerPaused
+	}
+	if f.pauseErr != nil {
+		return f.pauseErr
+	}
+	f.paused = true
+	return nil
+}
+
+func (f *fakeConsumer) Resume() error {
+	if !f.paused {
+		return rabbitmq.ErrConsumerRunning
+	}
+	f.paused = false
+	return nil
+}
+
+func (f *fakeConsumer) BreakerState() string {
+	if f.breaker == "" {
+		return rabbitmq.BreakerClosed
+	}
+	return f.breaker
+}
+
+func (f *fakeConsumer) InspectDeadLetters(max int) ([]rabbitmq.DeadLetter, error) {
+	f.max = max
+	if len(f.letters) > max {
+		return f.letters[:max], nil
+	}
+	return f.letters, nil
+}
+
+func (f *fakeConsumer) RequeueDeadLetters(max int) (int, int, error) {
+	f.max = max
+	if f.requeueErr != nil {
+		return 1, len(f.letters) - 1, f.requeueErr
+	}
+	if len(f.letters) > max {
+		return max, len(f.letters) - max, nil
+	}
+	return len(f.letters), 0, nil
+}
+
+func TestRequeueDeadLetters(t *testing.T) {
+	t.Setenv("RABBITMQ_DEAD_LETTER_MAX", "5")
+	consumer := &fakeConsumer{letters: make([]rabbitmq.DeadLetter, 7)}
+	router := newRouter(uuid.New(), http.MethodPost, "/requeue", NewConsumerHandler(consumer).RequeueDeadLetters)
+
+	w := serve(t, router, http.MethodPost, "/requeue", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 5, consumer.max, "max defaults to RABBITMQ_DEAD_LETTER_MAX")
+	body := decode(t, w)
+	assert.Equal(t, float64(5), body["requeued"])
+	assert.Equal(t, float64(2), body["remaining"])
+
+	w = serve(t, router, http.MethodPost, "/requeue?max=2", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, consumer.max)
+
+	w = serve(t, router, http.MethodPost, "/requeue?max=6", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "max", decode(t, w)["field"])
+}
+
+func TestRequeueDeadLettersReportsPartialRequeue(t *testing.T) {
+	consumer := &fakeConsumer{letters: make([]rabbitmq.DeadLetter, 3), requeueErr: errors.New("channel closed")}
+	router := newRouter(uuid.New(), http.MethodPost, "/requeue", NewConsumerHandler(consumer).RequeueDeadLetters)
+
+	w := serve(t, router, http.MethodPost, "/requeue", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, float64(1), decode(t, w)["requeued"])
+}
+
+func TestInspectDeadLetters(t *testing.T) {
+	consumer := &fakeConsumer{letters: []rabbitmq.DeadLetter{
+		{RoutingKey: "user.created", Body: "not json", Reason: "rejected", Deaths: 1},
+		{RoutingKey: "user.updated", Body: "{}", Reason: "rejected", Deaths: 2},
+	}}
+	router := newRouter(uuid.New(), http.MethodGet, "/dead-letters", NewConsumerHandler(consumer).InspectDeadLetters)
+
+	w := serve(t, router, http.MethodGet, "/dead-letters?max=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := decode(t, w)
+	assert.Equal(t, float64(1), body["count"])
+	letters := body["dead_letters"].([]interface{})
+	assert.Equal(t, "not json", letters[0].(map[string]interface{})["body"])
+}
+
+func TestPauseAndResumeConsumer(t *testing.T) {
+	consumer := &fakeConsumer{}
+	h := NewConsumerHandler(consumer)
+	router := newRouter(uuid.New(), http.MethodPost, "/consumer/pause", h.Pause)
+	router.POST("/consumer/resume", h.Resume)
+
+	w := serve(t, router, http.MethodPost, "/consumer/resume", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, map[string]interface{}{"error": "consumer is not paused", "paused": false}, decode(t, w))
+
+	w = serve(t, router, http.MethodPost, "/consumer/pause", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, true, decode(t, w)["paused"])
+	assert.True(t, consumer.paused)
+
+	w = serve(t, router, http.MethodPost, "/consumer/pause", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, map[string]interface{}{"error": "consumer is already paused", "paused": true}, decode(t, w))
+
+	w = serve(t, router, http.MethodPost, "/consumer/resume", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, false, decode(t, w)["paused"])
+	assert.False(t, consumer.paused)
+}
+
+func TestPauseConsumerFailure(t *testing.T) {
+	consumer := &fakeConsumer{pauseErr: errors.New("channel closed")}
+	router := newRouter(uuid.New(), http.MethodPost, "/consumer/pause", NewConsumerHandler(consumer).Pause)
+
+	w := serve(t, router, http.MethodPost, "/consumer/pause", nil)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "Failed to pause consumer", decode(t, w)["error"])
+	assert.False(t, consumer.paused)
+}
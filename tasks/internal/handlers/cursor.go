@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// parseCursor reads ?cursor=, which switches the list to cursor pagination.
+// An empty cursor starts at the beginning of the list.
+func parseCursor(c *gin.Context) (*models.TaskCursor, error) {
+	raw, ok := c.GetQuery("cursor")
+	if !ok {
+		return nil, nil
+	}
+	if c.Query("page") != "" {
+		return nil, &queryParamError{field: "cursor", message: "Use either page or cursor, not both"}
+	}
+	if raw == "" {
+		return &models.TaskCursor{}, nil
+	}
+
+	cursor, err := decodeCursor(raw)
+	if err != nil {
+		return nil, &queryParamError{field: "cursor", message: "cursor is invalid; use a next_cursor or prev_cursor from a previous response"}
+	}
+	return cursor, nil
+}
+
+// encodeCursor returns the opaque cursor for the position of task
+func encodeCursor(task models.Task, before bool) *string {
+	data, _ := json.Marshal(models.TaskCursor{CreatedAt: task.CreatedAt, ID: task.ID, Before: before})
+	cursor := base64.RawURLEncoding.EncodeToString(data)
+	return &cursor
+}
+
+func decodeCursor(raw string) (*models.TaskCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cursor models.TaskCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// cursorOrder validates a cursor-paginated list's sort, which must be by
+// creation, and returns the direction the list runs in
+func cursorOrder(filters models.TaskFilters) (string, error) {
+	if filters.Query != "" {
+		return "", &queryParamError{field: "cursor", message: "cursor pagination cannot be combined with q; search results are ranked"}
+	}
+	if filters.Sort != "" && filters.Sort != "created_at" {
+		return "", &queryParamError{field: "cursor", message: "cursor pagination only supports sort=created_at"}
+	}
+	switch order := strings.ToLower(filters.Order); order {
+	case "":
+		return "desc", nil
+	case "asc", "desc":
+		return order, nil
+	default:
+		return "", &queryParamError{field: "order", message: "order must be asc or desc"}
+	}
+}
+
+// cursorPage adds the cursor's position to where and returns the ORDER BY
+// clause that reads the page from it. Pages before the position are read
+// in reverse and must be flipped back with reverseTasks.
+func cursorPage(where *whereBuilder, cursor models.TaskCursor, order string) string {
+	ascending := (order == "asc") != cursor.Before
+	if cursor.ID != uuid.Nil {
+		op := "<"
+		if ascending {
+			op = ">"
+		}
+		where.add("(created_at, id) " + op + " (" + where.arg(cursor.CreatedAt) + ", " + where.arg(cursor.ID) + ")")
+	}
+	if ascending {
+		return " ORDER BY created_at ASC, id ASC"
+	}
+	return " ORDER BY created_at DESC, id DESC"
+}
+
+// pageCursors returns the cursors either side of a page read with cursor.
+// hasMore says whether rows remained past the page in the direction read.
+func pageCursors(tasks []models.Task, cursor models.TaskCursor, hasMore bool) (next, prev *string) {
+	if len(tasks) == 0 {
+		return nil, nil
+	}
+	first, last := tasks[0], tasks[len(tasks)-1]
+	started := cursor.ID != uuid.Nil
+	if cursor.Before {
+		// A page read backwards came from the rows after it
+		next = encodeCursor(last, false)
+		if hasMore {
+			prev = encodeCursor(first, true)
+		}
+		return next, prev
+	}
+	if hasMore {
+		next = encodeCursor(last, false)
+	}
+	if started {
+		prev = encodeCursor(first, true)
+	}
+	return next, prev
+}
+
+// reverseTasks reverses tasks in place
+func reverseTasks(tasks []models.Task) {
+	for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+		tasks[i], tasks[j] = tasks[j], tasks[i]
+	}
+}
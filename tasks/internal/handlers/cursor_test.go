@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	task := models.Task{ID: uuid.New(), CreatedAt: time.Date(2026, 3, 1, 9, 30, 15, 123456000, time.UTC)}
+	for _, before := range []bool{false, true} {
+		cursor, err := decodeCursor(*encodeCursor(task, before))
+		require.NoError(t, err)
+		assert.Equal(t, models.TaskCursor{CreatedAt: task.CreatedAt, ID: task.ID, Before: before}, *cursor)
+	}
+
+	for _, raw := range []string{"not base64!", "bm90IGpzb24", "eyJ0IjoieWVzdGVyZGF5In0"} {
+		_, err := decodeCursor(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	task := models.Task{ID: uuid.New(), CreatedAt: time.Now().UTC()}
+	for query, want := range map[string]*models.TaskCursor{
+		"":                                     nil,
+		"cursor=":                              {},
+		"cursor=" + *encodeCursor(task, false): {CreatedAt: task.CreatedAt, ID: task.ID},
+	} {
+		t.Run(query, func(t *testing.T) {
+			c := testContext("/tasks?" + query)
+			cursor, err := parseCursor(c)
+			require.NoError(t, err)
+			if want == nil {
+				assert.Nil(t, cursor)
+				return
+			}
+			require.NotNil(t, cursor)
+			assert.Equal(t, want.ID, cursor.ID)
+			assert.True(t, want.CreatedAt.Equal(cursor.CreatedAt))
+		})
+	}
+
+	_, err := parseCursor(testContext("/tasks?cursor=&page=2"))
+	assert.EqualError(t, err, "Use either page or cursor, not both")
+}
+
+func TestCursorOrder(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filters models.TaskFilters
+		want    string
+		err     string
+	}{
+		"default":      {models.TaskFilters{}, "desc", ""},
+		"created_at":   {models.TaskFilters{Sort: "created_at", Order: "ASC"}, "asc", ""},
+		"other sort":   {models.TaskFilters{Sort: "title"}, "", "cursor pagination only supports sort=created_at"},
+		"search":       {models.TaskFilters{Query: "report"}, "", "cursor pagination cannot be combined with q; search results are ranked"},
+		"bad order":    {models.TaskFilters{Order: "sideways"}, "", "order must be asc or desc"},
+		"newest first": {models.TaskFilters{Order: "desc"}, "desc", ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			order, err := cursorOrder(tc.filters)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, order)
+		})
+	}
+}
+
+func TestCursorPage(t *testing.T) {
+	position := models.TaskCursor{CreatedAt: time.Now(), ID: uuid.New()}
+	for name, tc := range map[string]struct {
+		cursor    models.TaskCursor
+		order     string
+		condition string
+		orderBy   string
+	}{
+		"start, newest first":   {models.TaskCursor{}, "desc", "", " ORDER BY created_at DESC, id DESC"},
+		"start, oldest first":   {models.TaskCursor{}, "asc", "", " ORDER BY created_at ASC, id ASC"},
+		"forward, newest first": {position, "desc", "(created_at, id) < ($1, $2)", " ORDER BY created_at DESC, id DESC"},
+		"forward, oldest first": {position, "asc", "(created_at, id) > ($1, $2)", " ORDER BY created_at ASC, id ASC"},
+		"back, newest first": {models.TaskCursor{CreatedAt: position.CreatedAt, ID: position.ID, Before: true}, "desc",
+			"(created_at, id) > ($1, $2)", " ORDER BY created_at ASC, id ASC"},
+		"back, oldest first": {models.TaskCursor{CreatedAt: position.CreatedAt, ID: position.ID, Before: true}, "asc",
+			"(created_at, id) < ($1, $2)", " ORDER BY created_at DESC, id DESC"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			where := &whereBuilder{}
+			assert.Equal(t, tc.orderBy, cursorPage(where, tc.cursor, tc.order))
+			if tc.condition == "" {
+				assert.Empty(t, where.conditions)
+				return
+			}
+			assert.Equal(t, []string{tc.condition}, where.conditions)
+			assert.Equal(t, []interface{}{position.CreatedAt, position.ID}, where.args)
+		})
+	}
+}
+
+func TestPageCursors(t *testing.T) {
+	tasks := []models.Task{{ID: uuid.New()}, {ID: uuid.New()}}
+	first, last := *encodeCursor(tasks[0], true), *encodeCursor(tasks[1], false)
+	position := models.TaskCursor{ID: uuid.New()}
+	back := models.TaskCursor{ID: uuid.New(), Before: true}
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	for name, tc := range map[string]struct {
+		cursor     models.TaskCursor
+		hasMore    bool
+		next, prev string
+	}{
+		"only page":           {models.TaskCursor{}, false, "", ""},
+		"first page":          {models.TaskCursor{}, true, last, ""},
+		"middle page":         {position, true, last, first},
+		"last page":           {position, false, "", first},
+		"back, more before":   {back, true, last, first},
+		"back, reached start": {back, false, last, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			next, prev := pageCursors(tasks, tc.cursor, tc.hasMore)
+			assert.Equal(t, tc.next, deref(next), "next")
+			assert.Equal(t, tc.prev, deref(prev), "prev")
+		})
+	}
+
+	next, prev := pageCursors(nil, position, true)
+	assert.Nil(t, next)
+	assert.Nil(t, prev)
+}
+
+// TestGetTasksCursorPagination walks the list forward and back with cursors,
+// including tasks created at the same instant and one added mid-scroll
+func TestGetTasksCursorPagination(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "scroller")
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, title := range []string{"A", "B", "C", "D", "E"} {
+		mustExec(t, db, "INSERT INTO tasks (user_id, title, created_at) VALUES ($1, $2, $3)", userID, title, base.Add(time.Duration(i)*time.Hour))
+	}
+	// F and G share a creation time and are told apart by ID
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, created_at) VALUES ($1, 'F', $2), ($1, 'G', $2)", userID, base.Add(5*time.Hour))
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks", h.GetTasks)
+	page := func(query string) ([]string, map[string]interface{}) {
+		w := serve(t, router, http.MethodGet, "/tasks?limit=2&order=asc&"+query, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		body := decode(t, w)
+		return titles(body["tasks"]), body["pagination"].(map[string]interface{})
+	}
+	cursor := func(pagination map[string]interface{}, key string) string {
+		value, ok := pagination[key].(string)
+		require.True(t, ok, "%s is missing from %v", key, pagination)
+		return "cursor=" + url.QueryEscape(value)
+	}
+
+	got, pagination := page("cursor=")
+	assert.Equal(t, []string{"A", "B"}, got)
+	assert.Nil(t, pagination["prev_cursor"], "the first page has nothing before it")
+	assert.Equal(t, float64(7), pagination["total"])
+	assert.NotContains(t, pagination, "page")
+
+	got, pagination = page(cursor(pagination, "next_cursor"))
+	assert.Equal(t, []string{"C", "D"}, got)
+	middle := pagination
+
+	// A task created mid-scroll before the position doesn't shift later pages
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, created_at) VALUES ($1, 'Early', $2)", userID, base.Add(-time.Hour))
+
+	got, pagination = page(cursor(middle, "next_cursor"))
+	assert.Equal(t, []string{"E"}, got[:1])
+	rest := got[1:]
+	got, pagination = page(cursor(pagination, "next_cursor"))
+	rest = append(rest, got...)
+	assert.ElementsMatch(t, []string{"F", "G"}, rest, "tasks sharing a creation time are neither skipped nor repeated")
+	assert.Nil(t, pagination["next_cursor"], "the last page has nothing after it")
+
+	got, _ = page(cursor(middle, "prev_cursor"))
+	assert.Equal(t, []string{"A", "B"}, got, "paging back returns the previous page in order")
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+const (
+	// maxDeadlineReasonLength bounds the reason given for a due date change
+	maxDeadlineReasonLength = 500
+
+	// deadlineSlipThreshold is how many times a task's due date must be
+	// pushed back for the task to count as slipped in the stats
+	deadlineSlipThreshold = 3
+)
+
+// GetDeadlineChanges lists the changes to a task's due date, newest first,
+// with pagination
+func (h *TaskHandler) GetDeadlineChanges(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, false, "Failed to fetch deadline changes"); !ok {
+		return
+	}
+
+	changes, total, err := h.deadlines.List(c.Request.Context(), taskID, limit, (page-1)*limit)
+	if err != nil {
+		respondError(c, err, "Failed to fetch deadline changes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deadline_changes": changes,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// deadlineContext tags ctx so changes made with it are recorded as made by
+// actorID, and a due date change for the given reason. A reason is only
+// accepted alongside a new due date.
+func deadlineContext(ctx context.Context, actorID uuid.UUID, dueDateSet bool, reason *string) (context.Context, error) {
+	if reason != nil {
+		trimmed := strings.TrimSpace(*reason)
+		if !dueDateSet {
+			return nil, errors.New("due_date_reason requires a new due date")
+		}
+		if utf8.RuneCountInString(trimmed) > maxDeadlineReasonLength {
+			return nil, fmt.Errorf("due_date_reason must be at most %d characters", maxDeadlineReasonLength)
+		}
+		reason = &trimmed
+	}
+	if !dueDateSet {
+		return repository.WithActor(ctx, actorID), nil
+	}
+	return repository.WithDeadlineChange(ctx, actorID, reason), nil
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// maxDelegationNoteLength bounds the note sent with a delegation
+const maxDelegationNoteLength = 500
+
+// DelegateTask asks another user to take on a task the caller owns. They get
+// write access once they accept; the caller keeps ownership either way.
+func (h *TaskHandler) DelegateTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.DelegateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot delegate a task to yourself"})
+		return
+	}
+	var note *string
+	if req.Note != nil {
+		trimmed := strings.TrimSpace(*req.Note)
+		if utf8.RuneCountInString(trimmed) > maxDelegationNoteLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Note must be at most %d characters", maxDelegationNoteLength)})
+			return
+		}
+		if trimmed != "" {
+			note = &trimmed
+		}
+	}
+
+	task, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to delegate task")
+		return
+	}
+
+	exists, err := h.transfers.UserExists(c.Request.Context(), req.UserID)
+	if err != nil {
+		respondError(c, err, "Failed to look up delegatee")
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delegatee not found"})
+		return
+	}
+
+	delegation, err := h.delegations.Create(c.Request.Context(), taskID, userID, req.UserID, note)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task already has a pending delegation"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to delegate task")
+		return
+	}
+
+	h.publishDelegation(c.Request.Context(), models.EventTaskDelegated, delegation, task)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Task delegated, waiting for the delegatee to accept",
+		"delegation": delegation,
+	})
+}
+
+// GetPendingDelegations lists delegations waiting for the current user's
+// response, each with its task
+func (h *TaskHandler) GetPendingDelegations(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	delegations, err := h.delegations.ListPending(c.Request.Context(), userID)
+	if err != nil {
+		respondResourceError(c, err, "Delegation", "Failed to fetch delegations")
+		return
+	}
+
+	taskIDs := make([]uuid.UUID, len(delegations))
+	for i, delegation := range delegations {
+		taskIDs[i] = delegation.TaskID
+	}
+	tasks, err := h.tasks.ListByIDs(c.Request.Context(), taskIDs)
+	if err != nil {
+		respondError(c, err, "Failed to fetch delegations")
+		return
+	}
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+	for i := range delegations {
+		delegations[i].Task = byID[delegations[i].TaskID]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"delegations": delegations})
+}
+
+// AcceptDelegation accepts a pending delegation, giving the current user
+// write access to the task
+func (h *TaskHandler) AcceptDelegation(c *gin.Context) {
+	h.respondToDelegation(c, true)
+}
+
+// DeclineDelegation declines a pending delegation
+func (h *TaskHandler) DeclineDelegation(c *gin.Context) {
+	h.respondToDelegation(c, false)
+}
+
+func (h *TaskHandler) respondToDelegation(c *gin.Context, accept bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	delegationID, err := uuid.Parse(c.Param("delegationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delegation ID"})
+		return
+	}
+
+	delegation, err := h.delegations.Respond(c.Request.Context(), delegationID, userID, accept)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task ownership changed since the task was delegated"})
+		return
+	}
+	if err != nil {
+		respondResourceError(c, err, "Delegation", "Failed to update delegation")
+		return
+	}
+
+	eventType, message := models.EventTaskDelegationDeclined, "Delegation declined"
+	if accept {
+		eventType, message = models.EventTaskDelegationAccepted, "Delegation accepted, you can now edit the task"
+	}
+	task, err := h.tasks.GetByID(c.Request.Context(), delegation.TaskID, delegation.FromUserID)
+	if err != nil {
+		// The response is recorded; the event goes out without the task
+		log.Printf("⚠️  Failed to load delegated task %s: %v\n", delegation.TaskID, err)
+	}
+	h.publishDelegation(c.Request.Context(), eventType, delegation, task)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    message,
+		"delegation": delegation,
+	})
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// AddDependency declares that the task is blocked by another of the caller's
+// tasks, rejecting dependencies that would form a cycle
+func (h *TaskHandler) AddDependency(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.AddDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.BlockedBy == taskID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A task cannot block itself"})
+		return
+	}
+
+	dependency, err := h.tasks.AddDependency(c.Request.Context(), req.BlockedBy, taskID, userID)
+	switch {
+	case errors.Is(err, repository.ErrDependencyCycle):
+		c.JSON(http.StatusConflict, gin.H{"error": "Dependency would create a cycle"})
+		return
+	case errors.Is(err, repository.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Dependency already exists"})
+		return
+	case err != nil:
+		respondError(c, err, "Failed to add dependency")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Dependency added successfully",
+		"dependency": dependency,
+	})
+}
+
+// GetDependencies lists the tasks blocking a task and the tasks it blocks
+func (h *TaskHandler) GetDependencies(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to fetch dependencies")
+		return
+	}
+
+	blockedBy, blocking, err := h.tasks.ListDependencies(c.Request.Context(), taskID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch dependencies")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blocked_by": blockedBy,
+		"blocking":   blocking,
+	})
+}
+
+// RemoveDependency removes a blocker from a task
+func (h *TaskHandler) RemoveDependency(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	blockerID, err := uuid.Parse(c.Param("blockerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocker ID"})
+		return
+	}
+
+	if err := h.tasks.RemoveDependency(c.Request.Context(), blockerID, taskID, userID); err != nil {
+		respondResourceError(c, err, "Dependency", "Failed to remove dependency")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dependency removed successfully"})
+}
+
+// checkBlockers refuses to complete a task while its blockers are open,
+// writing a 409 and returning false if any are
+func (h *TaskHandler) checkBlockers(c *gin.Context, taskID uuid.UUID) bool {
+	open, err := h.tasks.OpenBlockers(c.Request.Context(), taskID)
+	if err != nil {
+		respondError(c, err, "Failed to check blockers")
+		return false
+	}
+	if open > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Task is blocked by open tasks; complete them first or add force=true",
+			"open_blockers": open,
+		})
+		return false
+	}
+	return true
+}
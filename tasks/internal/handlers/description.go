@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/markdown"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// sanitizeDescription strips unsafe HTML such as scripts and event handler
+// attributes from a markdown description before it is stored
+func sanitizeDescription(description *string) *string {
+	if description == nil {
+		return nil
+	}
+	sanitized := markdown.Sanitize(*description)
+	return &sanitized
+}
+
+// parseRender reads ?render=html, which adds each task's description rendered
+// from markdown to HTML as description_html
+func parseRender(c *gin.Context) (bool, error) {
+	switch c.Query("render") {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, &queryParamError{field: "render", message: "render must be html"}
+}
+
+// renderDescriptions sets the rendered HTML of the tasks' descriptions
+func renderDescriptions(tasks []models.Task) {
+	for i := range tasks {
+		if tasks[i].Description != nil {
+			html := markdown.Render(*tasks[i].Description)
+			tasks[i].DescriptionHTML = &html
+		}
+	}
+}
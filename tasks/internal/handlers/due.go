@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// GetTasksDueOn returns the caller's tasks due on /due/:date (YYYY-MM-DD),
+// where the day runs from local midnight to the next midnight in the
+// caller's timezone. ?exclude_completed=true drops completed tasks.
+func (h *TaskHandler) GetTasksDueOn(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", c.Param("date"), loc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date. Use YYYY-MM-DD"})
+		return
+	}
+	// AddDate keeps DST days at their real 23 or 25 hours
+	next := day.AddDate(0, 0, 1)
+
+	filters, err := parseDueOnFilters(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	offset := (filters.Page - 1) * filters.Limit
+
+	w := &whereBuilder{}
+	w.add("user_id = " + w.arg(userID))
+	w.add("deleted_at IS NULL")
+	w.add("due_date >= " + w.arg(day.UTC()))
+	w.add("due_date < " + w.arg(next.UTC()))
+	if filters.ExcludeCompleted {
+		w.add("status <> 'completed'")
+	}
+
+	args := append([]interface{}{}, w.args...)
+	query := "SELECT * FROM tasks" + w.sql() + " ORDER BY due_date ASC, created_at ASC" +
+		" LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+	args = append(args, filters.Limit, offset)
+
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	tasks := []models.Task{}
+	if err := h.db.SelectContext(ctx, &tasks, query, args...); err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch tasks due on date")
+		return
+	}
+
+	var total int
+	if err := h.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM tasks"+w.sql(), w.args...); err != nil {
+		total = 0
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":  day.Format("2006-01-02"),
+		"tasks": tasks,
+		"pagination": gin.H{
+			"page":  filters.Page,
+			"limit": filters.Limit,
+			"total": total,
+		},
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTasksDueOnRejectsQuery(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodGet, "/tasks/due/:date", (&TaskHandler{}).GetTasksDueOn)
+
+	w := serve(t, router, http.MethodGet, "/tasks/due/2026-02-30", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid date. Use YYYY-MM-DD", decode(t, w)["error"])
+
+	w = serve(t, router, http.MethodGet, "/tasks/due/2026-03-01?exclude_completed=maybe", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(t, router, http.MethodGet, "/tasks/due/2026-03-01?tz=Nowhere/Special", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestGetTasksDueOnDayBoundaries seeds tasks due around UTC midnight and
+// checks which of them fall on a day in several timezones
+func TestGetTasksDueOnDayBoundaries(t *testing.T) {
+	db := testdb.Open(t)
+	userID, other := seedUser(t, db, "day-viewer"), seedUser(t, db, "other")
+	utc := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	for title, due := range map[string]time.Time{
+		"Late on the 9th":  utc(9, 23, 30),
+		"UTC midnight":     utc(10, 0, 0),
+		"Noon on the 10th": utc(10, 12, 0),
+		"Last minute":      utc(10, 23, 59),
+		"Early 11th":       utc(11, 3, 0),
+		"Afternoon 11th":   utc(11, 15, 0),
+	} {
+		mustExec(t, db, "INSERT INTO tasks (user_id, title, due_date) VALUES ($1, $2, $3)", userID, title, due)
+	}
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, status, due_date) VALUES ($1, 'Done at noon', 'completed', $2)", userID, utc(10, 12, 30))
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, due_date, deleted_at) VALUES ($1, 'Trashed', $2, NOW())", userID, utc(10, 12, 0))
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, due_date) VALUES ($1, 'Not mine', $2)", other, utc(10, 12, 0))
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/due/:date", h.GetTasksDueOn)
+
+	for name, tc := range map[string]struct {
+		target string
+		want   []string
+	}{
+		"UTC": {"/tasks/due/2026-03-10?tz=UTC",
+			[]string{"UTC midnight", "Noon on the 10th", "Done at noon", "Last minute"}},
+		// 10 March in Tokyo (UTC+9) runs from 15:00 on the 9th to 15:00 on the 10th UTC
+		"Tokyo": {"/tasks/due/2026-03-10?tz=Asia/Tokyo",
+			[]string{"Late on the 9th", "UTC midnight", "Noon on the 10th", "Done at noon"}},
+		// 10 March in Los Angeles (UTC-7 after the DST change) runs from 07:00 UTC
+		"Los Angeles": {"/tasks/due/2026-03-10",
+			[]string{"Noon on the 10th", "Done at noon", "Last minute", "Early 11th"}},
+		"exclude completed": {"/tasks/due/2026-03-10?tz=UTC&exclude_completed=true",
+			[]string{"UTC midnight", "Noon on the 10th", "Last minute"}},
+		"second page": {"/tasks/due/2026-03-10?tz=UTC&page=2&limit=3",
+			[]string{"Last minute"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, tc.target, nil, "X-Timezone", "America/Los_Angeles")
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			body := decode(t, w)
+			assert.Equal(t, "2026-03-10", body["date"])
+			assert.Equal(t, tc.want, titles(body["tasks"]))
+		})
+	}
+
+	w := serve(t, router, http.MethodGet, "/tasks/due/2026-03-10?tz=UTC&limit=3", nil)
+	pagination := decode(t, w)["pagination"].(map[string]interface{})
+	assert.Equal(t, float64(4), pagination["total"])
+}
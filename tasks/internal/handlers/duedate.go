@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dueDateTextFormats lists the due_date_text forms, for error messages
+var dueDateTextFormats = []string{
+	"today", "tomorrow", "monday", "this friday", "next friday", "next week", "next month",
+	"in 3 days", "in 2 weeks", "in 1 month", "in 4 hours", "in 30 minutes", "2024-06-30",
+	"any of the dates followed by a time such as 5pm, 5:30pm, 17:00, noon or midnight",
+}
+
+// endOfDayHour and endOfDayMinute are the time of day given to due dates
+// without one, so a task due "tomorrow" is not overdue until tomorrow ends
+const (
+	endOfDayHour   = 23
+	endOfDayMinute = 59
+)
+
+var (
+	dueTextPattern     = regexp.MustCompile(`^(.*?)\s*(?:\bat\s+)?(\d{1,2}(?::\d{2})?\s*(?:am|pm)|\d{1,2}:\d{2}|noon|midnight)$`)
+	dueRelativePattern = regexp.MustCompile(`^in\s+(\d{1,3})\s+(minute|hour|day|week|month)s?$`)
+	dueClockPattern    = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// parseDueDateText turns a natural-language due date such as "tomorrow 5pm"
+// or "next friday" into a time, reading it relative to now and in now's
+// location. Dates without a time are due at the end of the day.
+func parseDueDateText(text string, now time.Time) (time.Time, error) {
+	text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+
+	if m := dueRelativePattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "minute":
+			return now.Add(time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(time.Duration(n) * time.Hour), nil
+		}
+	}
+
+	datePart, clockPart := text, ""
+	if m := dueTextPattern.FindStringSubmatch(text); m != nil {
+		datePart, clockPart = m[1], m[2]
+	}
+
+	day, ok := parseDueDay(datePart, now)
+	if !ok {
+		return time.Time{}, dueDateTextError(text)
+	}
+	hour, minute := endOfDayHour, endOfDayMinute
+	if clockPart != "" {
+		if hour, minute, ok = parseClock(clockPart); !ok {
+			return time.Time{}, dueDateTextError(text)
+		}
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()), nil
+}
+
+// parseDueDay resolves the date part of a due date to a day relative to now.
+// An empty date part means today, for texts that are only a time.
+func parseDueDay(text string, now time.Time) (time.Time, bool) {
+	today := startOfDay(now, now.Location())
+	switch text {
+	case "", "today":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "next week":
+		// Weeks start on Monday
+		return today.AddDate(0, 0, 7-(int(today.Weekday())+6)%7), true
+	case "next month":
+		return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, today.Location()), true
+	}
+
+	if m := dueRelativePattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "day":
+			return today.AddDate(0, 0, n), true
+		case "week":
+			return today.AddDate(0, 0, 7*n), true
+		case "month":
+			return today.AddDate(0, n, 0), true
+		}
+		return time.Time{}, false
+	}
+
+	// "friday" and "next friday" are the first Friday after today, while
+	// "this friday" may be today
+	name, includeToday := text, false
+	if rest, ok := strings.CutPrefix(text, "next "); ok {
+		name = rest
+	} else if rest, ok := strings.CutPrefix(text, "this "); ok {
+		name, includeToday = rest, true
+	}
+	if weekday, ok := weekdays[name]; ok {
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 && !includeToday {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), true
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", text, now.Location())
+	return day, err == nil
+}
+
+// parseClock parses a time of day such as "5pm", "5:30 pm", "17:00", "noon"
+// or "midnight"
+func parseClock(text string) (hour, minute int, ok bool) {
+	switch text {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	m := dueClockPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// dueDateTextError reports an unrecognized due date with the supported forms
+func dueDateTextError(text string) error {
+	return fmt.Errorf("Could not understand due_date_text %q. Supported formats: %s", text, strings.Join(dueDateTextFormats, ", "))
+}
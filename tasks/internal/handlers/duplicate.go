@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// DuplicateTask copies a task into a new pending task with fresh timestamps,
+// optionally with its subtasks, tags and checklist (with every item not
+// done). A duplicated subtask stays under the same parent.
+func (h *TaskHandler) DuplicateTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// The body is optional
+	var req models.DuplicateTaskRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	source, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to duplicate task")
+		return
+	}
+
+	title := source.Title
+	if req.Title != nil {
+		title, err = normalizeTitle(*req.Title)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	now := time.Now()
+	sources := []models.Task{*source}
+	if req.IncludeSubtasks && source.ParentTaskID == nil {
+		subtasks, err := h.tasks.ListSubtasks(c.Request.Context(), taskID, userID)
+		if err != nil {
+			respondError(c, err, "Failed to duplicate task")
+			return
+		}
+		sources = append(sources, subtasks...)
+	}
+	if req.IncludeTags {
+		if err := h.tasks.AttachTags(c.Request.Context(), sources); err != nil {
+			respondError(c, err, "Failed to duplicate task")
+			return
+		}
+	}
+
+	task := duplicateOf(sources[0], now, req.IncludeTags)
+	task.Title = title
+	subtasks := make([]models.Task, 0, len(sources)-1)
+	for _, sub := range sources[1:] {
+		copied := duplicateOf(sub, now, req.IncludeTags)
+		copied.ParentTaskID = &task.ID
+		subtasks = append(subtasks, copied)
+	}
+	checklistFrom := make(map[uuid.UUID]uuid.UUID)
+	if req.IncludeChecklist {
+		checklistFrom[task.ID] = sources[0].ID
+		for i := range subtasks {
+			checklistFrom[subtasks[i].ID] = sources[i+1].ID
+		}
+	}
+
+	parent, err := h.tasks.Duplicate(c.Request.Context(), &task, subtasks, checklistFrom)
+	if err != nil {
+		respondError(c, err, "Failed to duplicate task")
+		return
+	}
+
+	for _, created := range append([]models.Task{task}, subtasks...) {
+		h.indexer.Index(c.Request.Context(), created)
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskCreated, userID, created.ID, &created)
+	}
+	if parent != nil {
+		h.indexer.Index(c.Request.Context(), *parent)
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, userID, parent.ID, parent)
+	}
+
+	if len(subtasks) > 0 {
+		task.Subtasks = subtasks
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Task duplicated successfully",
+		"task":    task,
+	})
+}
+
+// duplicateOf copies a task's content into a new pending task created at now
+func duplicateOf(source models.Task, now time.Time, withTags bool) models.Task {
+	task := models.Task{
+		ID:           uuid.New(),
+		UserID:       source.UserID,
+		ParentTaskID: source.ParentTaskID,
+		ProjectID:    source.ProjectID,
+		Title:        source.Title,
+		Description:  source.Description,
+		Status:       "pending",
+		Priority:     source.Priority,
+		DueDate:      source.DueDate,
+		Origin:       models.OriginAPI,
+		Color:        source.Color,
+		Recurrence:   source.Recurrence,
+		Metadata:     source.Metadata,
+		Latitude:     source.Latitude,
+		Longitude:    source.Longitude,
+		PlaceName:    source.PlaceName,
+		// The copy is not done yet, so only the estimate carries over
+		EstimateMinutes: source.EstimateMinutes,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if withTags {
+		task.Tags = source.Tags
+	}
+	return task
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// statusForError maps repository errors to HTTP status codes
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, repository.ErrTransient):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, repository.ErrTimeout):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes the HTTP status for a task repository error along with
+// a caller-supplied message. Not found errors always use a consistent message.
+func respondError(c *gin.Context, err error, message string) {
+	respondResourceError(c, err, "Task", message)
+}
+
+// respondResourceError is like respondError for resources other than tasks
+func respondResourceError(c *gin.Context, err error, resource, message string) {
+	status := statusForError(err)
+	switch status {
+	case http.StatusNotFound:
+		message = resource + " not found"
+	case http.StatusServiceUnavailable:
+		message = "Service temporarily unavailable, please retry"
+	case http.StatusGatewayTimeout:
+		message = "Request timed out, please retry"
+	case http.StatusInternalServerError:
+		log.Printf("❌ %s: %v\n", message, err)
+	}
+	c.JSON(status, gin.H{"error": message})
+}
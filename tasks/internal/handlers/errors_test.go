@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespondResourceError(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err     error
+		status  int
+		message string
+	}{
+		"not found": {repository.ErrNotFound, http.StatusNotFound, "Project not found"},
+		"conflict":  {repository.ErrConflict, http.StatusConflict, "Failed to save project"},
+		"transient": {repository.ErrTransient, http.StatusServiceUnavailable, "Service temporarily unavailable, please retry"},
+		"timeout":   {repository.ErrTimeout, http.StatusGatewayTimeout, "Request timed out, please retry"},
+		"wrapped":   {fmt.Errorf("%w: %w", repository.ErrNotFound, errors.New("no rows")), http.StatusNotFound, "Project not found"},
+		"other":     {errors.New("boom"), http.StatusInternalServerError, "Failed to save project"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := newRouter(uuid.New(), http.MethodGet, "/", func(c *gin.Context) {
+				respondResourceError(c, tc.err, "Project", "Failed to save project")
+			})
+			w := serve(t, router, http.MethodGet, "/", nil)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.message, decode(t, w)["error"])
+		})
+	}
+}
+
+func TestRespondErrorNamesTask(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodGet, "/", func(c *gin.Context) {
+		respondError(c, repository.ErrNotFound, "Failed to fetch task")
+	})
+	w := serve(t, router, http.MethodGet, "/", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Task not found", decode(t, w)["error"])
+}
+
+// TestExpiredContextIs504 serves a request whose deadline has already passed,
+// as when a gateway gives up on it, and expects 504 rather than 500
+func TestExpiredContextIs504(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "impatient")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Slow')", taskID, userID)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/:id", h.GetTask)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/tasks/"+taskID.String(), nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code, w.Body.String())
+	assert.Equal(t, "Request timed out, please retry", decode(t, w)["error"])
+}
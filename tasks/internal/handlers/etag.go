@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// notModified sets the response's ETag and, when the request's
+// If-None-Match names it, answers 304 Not Modified and returns true
+func notModified(c *gin.Context, etag string) bool {
+	// Clients may cache the response but must revalidate it every time
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("ETag", etag)
+	if !etagMatches(c.GetHeader("If-None-Match"), etag) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatches applies the weak comparison If-None-Match calls for
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyETag is a strong ETag over the JSON of a response body
+func bodyETag(body interface{}) string {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// listETag is a weak ETag for a page of the task list, derived from the
+// request and the per-status counts and latest update of the tasks it
+// draws on rather than from the page itself, so it can be checked before
+// the page is read. Every change to a task bumps its updated_at, and tasks
+// that leave the list change the counts.
+func listETag(c *gin.Context, userID uuid.UUID, filters models.TaskFilters, counts map[string]int, latest *time.Time) string {
+	hash := sha256.New()
+	// The filters hold the request's dates already resolved in its timezone
+	resolved, _ := json.Marshal(filters)
+	fmt.Fprintf(hash, "%s\n%s\n%s\n%s\n", userID, c.Request.URL.Path, c.Request.URL.RawQuery, resolved)
+
+	statuses := make([]string, 0, len(counts))
+	for status := range counts {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(hash, "%s=%d\n", status, counts[status])
+	}
+	if latest != nil {
+		fmt.Fprintf(hash, "%d\n", latest.UnixMicro())
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
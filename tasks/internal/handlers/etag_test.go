@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagMatches(t *testing.T) {
+	const etag = `"abc123"`
+	for header, want := range map[string]bool{
+		"":                   false,
+		`"abc123"`:           true,
+		`W/"abc123"`:         true,
+		"*":                  true,
+		` * `:                true,
+		`"other", "abc123"`:  true,
+		`"other",W/"abc123"`: true,
+		`"other"`:            false,
+		`abc123`:             false,
+		`"abc1234"`:          false,
+	} {
+		assert.Equal(t, want, etagMatches(header, etag), header)
+	}
+	assert.True(t, etagMatches(`"abc123"`, `W/"abc123"`), "weak tags compare equal to strong ones")
+}
+
+func TestNotModified(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		"no header":   {"", false},
+		"matching":    {`"v1"`, true},
+		"stale":       {`"v0"`, false},
+		"one of many": {`"v0", "v1"`, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/tasks/1", nil)
+			if tc.ifNoneMatch != "" {
+				c.Request.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			assert.Equal(t, tc.want, notModified(c, `"v1"`))
+			c.Writer.WriteHeaderNow()
+			assert.Equal(t, `"v1"`, w.Header().Get("ETag"), "the ETag is always set")
+			assert.Equal(t, "private, no-cache", w.Header().Get("Cache-Control"))
+			if tc.want {
+				assert.Equal(t, http.StatusNotModified, w.Code)
+			}
+		})
+	}
+}
+
+func TestBodyETag(t *testing.T) {
+	task := gin.H{"task": gin.H{"title": "Write tests"}}
+	etag := bodyETag(task)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, etag, bodyETag(gin.H{"task": gin.H{"title": "Write tests"}}), "equal bodies have equal tags")
+	assert.NotEqual(t, etag, bodyETag(gin.H{"task": gin.H{"title": "Write more tests"}}))
+}
+
+func TestListETag(t *testing.T) {
+	userID := uuid.New()
+	latest := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	counts := map[string]int{"pending": 2, "completed": 1}
+	etag := func(target string, userID uuid.UUID, filters models.TaskFilters, counts map[string]int, latest *time.Time) string {
+		return listETag(testContext(target), userID, filters, counts, latest)
+	}
+
+	base := etag("/tasks?page=1", userID, models.TaskFilters{Page: 1}, counts, &latest)
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, base)
+	assert.Equal(t, base, etag("/tasks?page=1", userID, models.TaskFilters{Page: 1}, map[string]int{"completed": 1, "pending": 2}, &latest),
+		"the order of the counts doesn't matter")
+
+	later := latest.Add(time.Microsecond)
+	for name, other := range map[string]string{
+		"another user":    etag("/tasks?page=1", uuid.New(), models.TaskFilters{Page: 1}, counts, &latest),
+		"another page":    etag("/tasks?page=2", userID, models.TaskFilters{Page: 2}, counts, &latest),
+		"another path":    etag("/tasks/shared?page=1", userID, models.TaskFilters{Page: 1}, counts, &latest),
+		"changed counts":  etag("/tasks?page=1", userID, models.TaskFilters{Page: 1}, map[string]int{"pending": 1, "completed": 2}, &latest),
+		"a later update":  etag("/tasks?page=1", userID, models.TaskFilters{Page: 1}, counts, &later),
+		"no tasks":        etag("/tasks?page=1", userID, models.TaskFilters{Page: 1}, map[string]int{}, nil),
+		"resolved filter": etag("/tasks?page=1", userID, models.TaskFilters{Page: 1, Overdue: true}, counts, &latest),
+	} {
+		assert.NotEqual(t, base, other, name)
+	}
+}
+
+// TestTaskReadsAnswerIfNoneMatch fetches a task and the list, revalidates
+// with the ETags they returned, then changes the task and checks both are
+// downloaded again
+func TestTaskReadsAnswerIfNoneMatch(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "poller")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Poll me')", taskID, userID)
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/:id", h.GetTask)
+	router.GET("/tasks", h.GetTasks)
+
+	for _, target := range []string{"/tasks/" + taskID.String(), "/tasks?limit=5"} {
+		t.Run(target, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, target, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			etag := w.Header().Get("ETag")
+			require.NotEmpty(t, etag)
+
+			w = serve(t, router, http.MethodGet, target, nil, "If-None-Match", etag)
+			assert.Equal(t, http.StatusNotModified, w.Code)
+			assert.Empty(t, w.Body.String())
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+
+			mustExec(t, db, "UPDATE tasks SET title = title || '!', updated_at = NOW() WHERE id = $1", taskID)
+			w = serve(t, router, http.MethodGet, target, nil, "If-None-Match", etag)
+			assert.Equal(t, http.StatusOK, w.Code, "a changed task is sent again")
+			assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		})
+	}
+
+	// Another user's copy of the same page has its own tag
+	w := serve(t, router, http.MethodGet, "/tasks?limit=5", nil)
+	other := newRouter(seedUser(t, db, "neighbour"), http.MethodGet, "/tasks", h.GetTasks)
+	assert.Equal(t, http.StatusOK, serve(t, other, http.MethodGet, "/tasks?limit=5", nil, "If-None-Match", w.Header().Get("ETag")).Code)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// EventPublisher publishes task change events
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// publishTaskEvent publishes a task event addressed to the task's watchers as
+// well as its owner. Failures are logged rather than returned because the
+// change has already been committed.
+func (h *TaskHandler) publishTaskEvent(ctx context.Context, eventType string, userID, taskID uuid.UUID, task *models.Task) {
+	event := models.TaskEvent{
+		EventType: eventType,
+		TaskID:    taskID,
+		UserID:    userID,
+		Task:      task,
+	}
+	watchers, err := h.watchers.Recipients(ctx, taskID)
+	if err != nil {
+		// The owner should still hear about the change
+		log.Printf("⚠️  Failed to look up watchers of task %s: %v\n", taskID, err)
+	}
+	event.Watchers = watchers
+	if err := h.events.Publish(ctx, event); err != nil {
+		log.Printf("❌ Failed to publish %s for task %s: %v\n", eventType, taskID, err)
+	}
+}
+
+// publishTaskUpdate publishes task.completed for a task an update moved to
+// completed, however it was completed, and task.updated for any other change
+func (h *TaskHandler) publishTaskUpdate(ctx context.Context, userID uuid.UUID, task *models.Task, completed bool) {
+	eventType := models.EventTaskUpdated
+	if completed {
+		eventType = models.EventTaskCompleted
+	}
+	h.publishTaskEvent(ctx, eventType, userID, task.ID, task)
+}
+
+// publishMention publishes a task.mentioned event telling a user they were
+// @mentioned in a comment on a task
+func (h *TaskHandler) publishMention(ctx context.Context, task *models.Task, comment *models.TaskComment, mentioned uuid.UUID) {
+	event := models.TaskEvent{
+		EventType:       models.EventTaskMentioned,
+		TaskID:          task.ID,
+		UserID:          task.UserID,
+		Task:            task,
+		MentionedUserID: &mentioned,
+		Comment:         comment,
+	}
+	if err := h.events.Publish(ctx, event); err != nil {
+		log.Printf("❌ Failed to publish %s for user %s on task %s: %v\n", event.EventType, mentioned, task.ID, err)
+	}
+}
+
+// publishDelegation publishes a delegation event to the delegating owner. The
+// delegation names the delegatee, so the notification service can alert them.
+func (h *TaskHandler) publishDelegation(ctx context.Context, eventType string, delegation *models.TaskDelegation, task *models.Task) {
+	event := models.TaskEvent{
+		EventType:  eventType,
+		TaskID:     delegation.TaskID,
+		UserID:     delegation.FromUserID,
+		Task:       task,
+		Delegation: delegation,
+	}
+	if err := h.events.Publish(ctx, event); err != nil {
+		log.Printf("❌ Failed to publish %s for task %s: %v\n", eventType, delegation.TaskID, err)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// expandOptions lists the related data ?expand= adds to a task response
+type expandOptions struct {
+	subtasks bool
+}
+
+// parseExpand reads ?expand=subtasks. Tags are always included, so "tags" is
+// accepted as a no-op.
+func parseExpand(c *gin.Context) (expandOptions, error) {
+	var opts expandOptions
+	val := c.Query("expand")
+	if val == "" {
+		return opts, nil
+	}
+	for _, name := range strings.Split(val, ",") {
+		switch strings.TrimSpace(name) {
+		case "subtasks":
+			opts.subtasks = true
+		case "tags":
+		default:
+			return opts, &queryParamError{field: "expand", message: "expand must be a comma-separated list of: subtasks, tags"}
+		}
+	}
+	return opts, nil
+}
+
+// expandTask loads the task's tags and, if requested, its subtasks
+func (h *TaskHandler) expandTask(ctx context.Context, task *models.Task, opts expandOptions) error {
+	tasks := []models.Task{*task}
+	if err := h.tasks.AttachTags(ctx, tasks); err != nil {
+		return err
+	}
+	*task = tasks[0]
+
+	if opts.subtasks {
+		subtasks, err := h.tasks.ListSubtasks(ctx, task.ID, task.UserID)
+		if err != nil {
+			return err
+		}
+		if err := h.tasks.AttachTags(ctx, subtasks); err != nil {
+			return err
+		}
+		task.Subtasks = subtasks
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/moabdelazem/microservices/tasks/internal/middleware"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportTasksWithPrettyJSON checks ?pretty=true leaves the CSV export
+// intact
+func TestExportTasksWithPrettyJSON(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("JSON_PRETTY_ENABLED", "true")
+	userID := seedUser(t, db, "exporter")
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, description) VALUES ($1, 'Export me', '{\"not\": \"json\"}')", userID)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/export", middleware.PrettyJSON(), h.ExportTasks)
+
+	w := serve(t, router, http.MethodGet, "/tasks/export?pretty=true", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, exportColumns, records[0])
+	assert.Equal(t, "Export me", records[1][1])
+	assert.Equal(t, `{"not": "json"}`, records[1][2])
+}
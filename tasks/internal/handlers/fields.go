@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// taskFieldColumns is the whitelist of task fields ?fields= can name, with
+// the columns each is read from. Fields loaded or computed after the query
+// need no column of their own.
+var taskFieldColumns = map[string][]string{
+	"id":                 {"id"},
+	"user_id":            {"user_id"},
+	"parent_task_id":     {"parent_task_id"},
+	"project_id":         {"project_id"},
+	"title":              {"title"},
+	"description":        {"description"},
+	"status":             {"status"},
+	"priority":           {"priority"},
+	"due_date":           {"due_date"},
+	"origin":             {"origin"},
+	"progress":           {"progress"},
+	"position":           {"position"},
+	"color":              {"color"},
+	"created_at":         {"created_at"},
+	"updated_at":         {"updated_at"},
+	"completed_at":       {"completed_at"},
+	"checklist_progress": {"checklist_progress"},
+	"metadata":           {"metadata"},
+	"recurrence":         {"recurrence"},
+	"recurrence_paused":  {"recurrence_paused"},
+	"estimate_minutes":   {"estimate_minutes"},
+	"actual_minutes":     {"actual_minutes"},
+	"snoozed_until":      {"snoozed_until"},
+	"pinned":             {"pinned"},
+	"archived_at":        {"archived_at"},
+	"overdue":            {"overdue"},
+	"escalated_at":       {"escalated_at"},
+	"deleted_at":         {"deleted_at"},
+	"latitude":           {"latitude"},
+	"longitude":          {"longitude"},
+	"place_name":         {"place_name"},
+	"tags":               nil,
+	"subtasks":           nil,
+	"match":              nil,
+	"description_html":   {"description"},
+}
+
+// fieldSet is the set of task fields a response is narrowed to; nil keeps
+// every field
+type fieldSet map[string]bool
+
+// parseFields reads ?fields=, a comma-separated list of task fields. The id
+// is always included.
+func parseFields(c *gin.Context) (fieldSet, error) {
+	val := c.Query("fields")
+	if val == "" {
+		return nil, nil
+	}
+	fields := fieldSet{"id": true}
+	for _, name := range strings.Split(val, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := taskFieldColumns[name]; !ok {
+			return nil, &queryParamError{field: "fields", message: fmt.Sprintf("unknown field %q in fields", name)}
+		}
+		fields[name] = true
+	}
+	return fields, nil
+}
+
+// has reports whether the response includes the named field
+func (f fieldSet) has(name string) bool {
+	return f == nil || f[name]
+}
+
+// columns returns the select list that reads the fields. created_at is
+// always read since cursors are keyed on it.
+func (f fieldSet) columns() string {
+	if f == nil {
+		return "*"
+	}
+	selected := map[string]bool{"id": true, "created_at": true}
+	for name := range f {
+		for _, column := range taskFieldColumns[name] {
+			selected[column] = true
+		}
+	}
+	columns := make([]string, 0, len(selected))
+	for column := range selected {
+		columns = append(columns, column)
+	}
+	// Sorted so the same fields always build the same query
+	sort.Strings(columns)
+	return strings.Join(columns, ", ")
+}
+
+// shape narrows a task to the fields, or returns it whole for a nil set
+func (f fieldSet) shape(task *models.Task) interface{} {
+	if f == nil {
+		return task
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return task
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return task
+	}
+	shaped := make(map[string]json.RawMessage, len(f))
+	for name := range f {
+		if val, ok := all[name]; ok {
+			shaped[name] = val
+		}
+	}
+	return shaped
+}
+
+// shapeAll narrows each of the tasks to the fields
+func (f fieldSet) shapeAll(tasks []models.Task) interface{} {
+	if f == nil {
+		return tasks
+	}
+	shaped := make([]interface{}, len(tasks))
+	for i := range tasks {
+		shaped[i] = f.shape(&tasks[i])
+	}
+	return shaped
+}
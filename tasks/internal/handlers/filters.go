@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// whereBuilder accumulates SQL conditions and their numbered placeholder args
+type whereBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// arg registers a query argument and returns its placeholder
+func (w *whereBuilder) arg(val interface{}) string {
+	w.args = append(w.args, val)
+	return "$" + strconv.Itoa(len(w.args))
+}
+
+// add appends a condition that is ANDed with the others
+func (w *whereBuilder) add(condition string) {
+	w.conditions = append(w.conditions, condition)
+}
+
+// sql renders the WHERE clause
+func (w *whereBuilder) sql() string {
+	if len(w.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conditions, " AND ")
+}
+
+// valueFilter is a parsed multi-value filter such as
+// status=pending,in_progress, or priority=!low to exclude values
+type valueFilter struct {
+	values []string
+	negate bool
+}
+
+// parseValueFilter parses a comma-separated list of values, excluded
+// rather than matched when prefixed with "!". The prefix may be given once
+// for the whole list or on every value, but not on only some. Each value
+// must pass valid when it is non-nil.
+func parseValueFilter(raw string, valid func(string) bool) (valueFilter, bool) {
+	var filter valueFilter
+	raw, filter.negate = strings.CutPrefix(strings.TrimSpace(raw), "!")
+	seen := make(map[string]bool)
+	for _, val := range strings.Split(raw, ",") {
+		val = strings.TrimSpace(val)
+		if trimmed, negated := strings.CutPrefix(val, "!"); negated {
+			if !filter.negate {
+				return filter, false
+			}
+			val = trimmed
+		}
+		if val == "" || (valid != nil && !valid(val)) {
+			return filter, false
+		}
+		if !seen[val] {
+			seen[val] = true
+			filter.values = append(filter.values, val)
+		}
+	}
+	return filter, true
+}
+
+// condition renders the filter as a condition on column
+func (f valueFilter) condition(w *whereBuilder, column string) string {
+	if f.negate {
+		return column + " <> ALL(" + w.arg(pq.Array(f.values)) + "::text[])"
+	}
+	if len(f.values) == 1 {
+		return column + " = " + w.arg(f.values[0])
+	}
+	return column + " = ANY(" + w.arg(pq.Array(f.values)) + "::text[])"
+}
+
+// matches reports whether the filter keeps value
+func (f valueFilter) matches(value string) bool {
+	for _, val := range f.values {
+		if val == value {
+			return !f.negate
+		}
+	}
+	return f.negate
+}
+
+// buildTaskWhere builds the WHERE clause shared by task listing and counting
+func buildTaskWhere(userID uuid.UUID, filters models.TaskFilters) *whereBuilder {
+	w := &whereBuilder{}
+	if filters.Scope == ScopeShared {
+		w.add("id IN (SELECT task_id FROM task_shares WHERE user_id = " + w.arg(userID) + ")")
+	} else {
+		w.add("user_id = " + w.arg(userID))
+	}
+	w.add("deleted_at IS NULL")
+
+	if filters.Archived {
+		w.add("archived_at IS NOT NULL")
+	} else {
+		w.add("archived_at IS NULL")
+	}
+	// Snoozes are checked against the clock so tasks reappear on time, before
+	// the sweeper clears them
+	if filters.Snoozed {
+		w.add("snoozed_until > NOW()")
+	} else {
+		w.add("(snoozed_until IS NULL OR snoozed_until <= NOW())")
+	}
+	if filters.Overdue {
+		w.add("overdue")
+	}
+	if filters.Pinned {
+		w.add("pinned")
+	}
+	if filters.ProjectID != nil {
+		w.add("project_id = " + w.arg(*filters.ProjectID))
+	}
+	if filters.Query != "" {
+		w.add(searchCondition(w.arg(filters.Query)))
+	}
+	// Validated by parseTaskFilters
+	for _, param := range []struct{ column, raw string }{
+		{"status", filters.Status},
+		{"priority", filters.Priority},
+		{"origin", filters.Origin},
+	} {
+		if param.raw != "" {
+			filter, _ := parseValueFilter(param.raw, nil)
+			w.add(filter.condition(w, param.column))
+		}
+	}
+	if filters.MinProgress != nil {
+		w.add("progress >= " + w.arg(*filters.MinProgress))
+	}
+	if filters.MinEstimate != nil {
+		w.add("estimate_minutes >= " + w.arg(*filters.MinEstimate))
+	}
+	if filters.MaxEstimate != nil {
+		w.add("estimate_minutes <= " + w.arg(*filters.MaxEstimate))
+	}
+	// Ranges include their start and exclude their end
+	if filters.DueAfter != nil {
+		w.add("due_date >= " + w.arg(*filters.DueAfter))
+	}
+	if filters.DueBefore != nil {
+		w.add("due_date < " + w.arg(*filters.DueBefore))
+	}
+	if filters.CreatedAfter != nil {
+		w.add("created_at >= " + w.arg(*filters.CreatedAfter))
+	}
+	if filters.CreatedBefore != nil {
+		w.add("created_at < " + w.arg(*filters.CreatedBefore))
+	}
+	if filters.Near != nil {
+		w.add(nearCondition(w, *filters.Near, filters.RadiusKm))
+	}
+	if len(filters.Metadata) > 0 {
+		// Sorted so the same filters always build the same query
+		keys := make([]string, 0, len(filters.Metadata))
+		for key := range filters.Metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			w.add("metadata ->> " + w.arg(key) + " = " + w.arg(filters.Metadata[key]))
+		}
+	}
+	if len(filters.Tags) > 0 {
+		// Tasks must carry every requested tag; tags are already de-duplicated
+		w.add("id IN (SELECT tt.task_id FROM task_tags tt JOIN tags t ON t.id = tt.tag_id" +
+			" WHERE t.name = ANY(" + w.arg(pq.Array(filters.Tags)) + "::text[])" +
+			" GROUP BY tt.task_id HAVING COUNT(*) = " + w.arg(len(filters.Tags)) + ")")
+	}
+
+	return w
+}
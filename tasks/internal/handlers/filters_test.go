@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValueFilter(t *testing.T) {
+	for raw, want := range map[string]valueFilter{
+		"pending":                   {values: []string{"pending"}},
+		"pending,in_progress":       {values: []string{"pending", "in_progress"}},
+		" pending , in_progress ":   {values: []string{"pending", "in_progress"}},
+		"pending,pending":           {values: []string{"pending"}},
+		"!low":                      {values: []string{"low"}, negate: true},
+		"!low,medium":               {values: []string{"low", "medium"}, negate: true},
+		"!low,!medium":              {values: []string{"low", "medium"}, negate: true},
+		"completed,cancelled,!":     {},
+		"low,!medium":               {},
+		"sleeping":                  {},
+		"pending,":                  {},
+		"!":                         {},
+		"pending;DROP TABLE tasks":  {},
+		"pending,in_progress,sleep": {},
+	} {
+		t.Run(raw, func(t *testing.T) {
+			filter, ok := parseValueFilter(raw, func(v string) bool {
+				return isValidStatus(v) || isValidPriority(v)
+			})
+			if want.values == nil {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, want, filter)
+		})
+	}
+
+	_, ok := parseValueFilter("anything,at_all", nil)
+	assert.True(t, ok, "values are not checked without a validator")
+}
+
+func TestValueFilterCondition(t *testing.T) {
+	for name, tc := range map[string]struct {
+		filter    valueFilter
+		condition string
+		arg       interface{}
+	}{
+		"one value":  {valueFilter{values: []string{"pending"}}, "status = $1", "pending"},
+		"values":     {valueFilter{values: []string{"pending", "in_progress"}}, "status = ANY($1::text[])", pq.Array([]string{"pending", "in_progress"})},
+		"negated":    {valueFilter{values: []string{"low"}, negate: true}, "status <> ALL($1::text[])", pq.Array([]string{"low"})},
+		"negated 2+": {valueFilter{values: []string{"low", "medium"}, negate: true}, "status <> ALL($1::text[])", pq.Array([]string{"low", "medium"})},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := &whereBuilder{}
+			assert.Equal(t, tc.condition, tc.filter.condition(w, "status"))
+			assert.Equal(t, []interface{}{tc.arg}, w.args, "values are passed as arguments, never inlined")
+		})
+	}
+}
+
+func TestValueFilterMatches(t *testing.T) {
+	open := valueFilter{values: []string{"pending", "in_progress"}}
+	assert.True(t, open.matches("pending"))
+	assert.False(t, open.matches("completed"))
+
+	notDone := valueFilter{values: []string{"completed", "cancelled"}, negate: true}
+	assert.True(t, notDone.matches("pending"))
+	assert.False(t, notDone.matches("cancelled"))
+}
+
+func TestBuildTaskWhereValueFilters(t *testing.T) {
+	userID := uuid.New()
+	w := buildTaskWhere(userID, models.TaskFilters{Status: "pending,in_progress", Priority: "!low", Origin: "import"})
+	assert.Contains(t, w.conditions, "status = ANY($2::text[])")
+	assert.Contains(t, w.conditions, "priority <> ALL($3::text[])")
+	assert.Contains(t, w.conditions, "origin = $4")
+	assert.Equal(t, []interface{}{userID, pq.Array([]string{"pending", "in_progress"}), pq.Array([]string{"low"}), "import"}, w.args)
+}
+
+// TestGetTasksValueFilters lists tasks with multi-value and negated filters
+// and checks both the page and its total, which is summed from the status
+// counts
+func TestGetTasksValueFilters(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "filterer")
+	for _, task := range []struct{ title, status, priority string }{
+		{"Todo", "pending", "low"},
+		{"Doing", "in_progress", "high"},
+		{"Done", "completed", "urgent"},
+		{"Dropped", "cancelled", "medium"},
+	} {
+		mustExec(t, db, "INSERT INTO tasks (user_id, title, status, priority) VALUES ($1, $2, $3, $4)", userID, task.title, task.status, task.priority)
+	}
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks", h.GetTasks)
+	for query, want := range map[string][]string{
+		"status=pending,in_progress":             {"Doing", "Todo"},
+		"status=!completed,cancelled":            {"Doing", "Todo"},
+		"status=!completed,!cancelled":           {"Doing", "Todo"},
+		"priority=!low":                          {"Doing", "Done", "Dropped"},
+		"priority=high,urgent&status=!completed": {"Doing"},
+		"status=!pending&priority=!urgent":       {"Doing", "Dropped"},
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, "/tasks?sort=title&order=asc&"+query, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			body := decode(t, w)
+			assert.Equal(t, want, titles(body["tasks"]))
+			assert.Equal(t, float64(len(want)), body["pagination"].(map[string]interface{})["total"])
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newRouter routes method and path to handlers, authenticated as userID.
+// Panics become 500 responses as in the server.
+func newRouter(userID uuid.UUID, method, path string, handlers ...gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard), func(c *gin.Context) {
+		c.Set("userID", userID)
+	})
+	router.Handle(method, path, handlers...)
+	return router
+}
+
+// serve sends a request with a JSON body, or a raw one if body is a string,
+// and the given header name/value pairs
+func serve(t *testing.T, router http.Handler, method, target string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(b)
+	default:
+		encoded, err := json.Marshal(b)
+		require.NoError(t, err)
+		reader = bytes.NewReader(encoded)
+	}
+
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// testContext returns a gin context for a GET of target, for parsing its
+// query without routing it
+func testContext(target string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	return c
+}
+
+// decode unmarshals a JSON response body
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+	return body
+}
+
+// recordedEvents is an EventPublisher that keeps what it is given
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []models.TaskEvent
+}
+
+func (r *recordedEvents) Publish(ctx context.Context, event models.TaskEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// types lists the published event types in order
+func (r *recordedEvents) types() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := make([]string, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.EventType
+	}
+	return types
+}
+
+// mustExec runs a setup statement against a test database
+func mustExec(t *testing.T, db *database.DB, query string, args ...interface{}) {
+	t.Helper()
+	_, err := db.Exec(query, args...)
+	require.NoError(t, err, query)
+}
+
+// seedUser adds a user to tasks_users and returns its ID
+func seedUser(t *testing.T, db *database.DB, username string) uuid.UUID {
+	t.Helper()
+	userID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks_users (user_id, username, email) VALUES ($1, $2, $3)", userID, username, username+"@example.com")
+	return userID
+}
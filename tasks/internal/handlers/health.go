@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+)
+
+// ConsumerStatus reports the state of the RabbitMQ consumer
+type ConsumerStatus interface {
+	BreakerState() string
+	Paused() bool
+}
+
+type ReadinessHandler struct {
+	db       *database.DB
+	consumer ConsumerStatus
+}
+
+func NewReadinessHandler(db *database.DB, consumer ConsumerStatus) *ReadinessHandler {
+	return &ReadinessHandler{db: db, consumer: consumer}
+}
+
+// Ready reports whether the service can serve traffic and process events
+func (h *ReadinessHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+
+	ready := true
+	dbStatus := "up"
+	if err := h.db.PingContext(ctx); err != nil {
+		ready = false
+		dbStatus = "down"
+	}
+
+	// An operator pause is deliberate and the API keeps serving, so it is
+	// reported without failing readiness
+	breakerState := h.consumer.BreakerState()
+	if breakerState != "closed" {
+		ready = false
+	}
+
+	status := http.StatusOK
+	statusText := "ready"
+	if !ready {
+		status = http.StatusServiceUnavailable
+		statusText = "not_ready"
+	}
+
+	c.JSON(status, gin.H{
+		"status":   statusText,
+		"service":  "tasks-service",
+		"database": dbStatus,
+		"consumer": gin.H{
+			"circuit_breaker": breakerState,
+			"paused":          h.consumer.Paused(),
+		},
+	})
+}
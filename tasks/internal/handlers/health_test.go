@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/buildinfo"
+	"github.com/moabdelazem/microservices/tasks/internal/rabbitmq"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyReportsBreakerState(t *testing.T) {
+	db := testdb.Open(t)
+
+	for state, status := range map[string]int{
+		rabbitmq.BreakerClosed:   http.StatusOK,
+		rabbitmq.BreakerOpen:     http.StatusServiceUnavailable,
+		rabbitmq.BreakerHalfOpen: http.StatusServiceUnavailable,
+	} {
+		t.Run(state, func(t *testing.T) {
+			h := NewReadinessHandler(db, &fakeConsumer{breaker: state})
+			router := newRouter(uuid.New(), http.MethodGet, "/ready", h.Ready)
+
+			w := serve(t, router, http.MethodGet, "/ready", nil)
+			assert.Equal(t, status, w.Code)
+			consumer := decode(t, w)["consumer"].(map[string]interface{})
+			assert.Equal(t, state, consumer["circuit_breaker"])
+		})
+	}
+}
+
+// TestReadyReportsPaused checks a paused consumer is reported without
+// failing readiness
+func TestReadyReportsPaused(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewReadinessHandler(db, &fakeConsumer{paused: true})
+	router := newRouter(uuid.New(), http.MethodGet, "/ready", h.Ready)
+
+	w := serve(t, router, http.MethodGet, "/ready", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	consumer := decode(t, w)["consumer"].(map[string]interface{})
+	assert.Equal(t, true, consumer["paused"])
+}
+
+func TestHealthReportsBuildAndUptime(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodGet, "/health", (&TaskHandler{}).Health)
+
+	w := serve(t, router, http.MethodGet, "/health", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	body := decode(t, w)
+	assert.Equal(t, "healthy", body["status"])
+	assert.Equal(t, buildinfo.Version, body["version"])
+	assert.Equal(t, buildinfo.Commit, body["commit"])
+	assert.Equal(t, runtime.Version(), body["go_version"])
+
+	startedAt, err := time.Parse(time.RFC3339Nano, body["started_at"].(string))
+	require.NoError(t, err)
+	assert.True(t, startedAt.Equal(buildinfo.StartedAt()))
+	uptime := body["uptime_seconds"].(float64)
+	assert.GreaterOrEqual(t, uptime, float64(0))
+	assert.LessOrEqual(t, uptime, time.Since(startedAt).Seconds())
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTaskHistory lists the recorded changes of a task and its subtasks,
+// newest first, with pagination. Subtasks in the trash are left out unless
+// ?include_deleted_relations=true.
+func (h *TaskHandler) GetTaskHistory(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	includeDeleted, err := queryBool(c, "include_deleted_relations")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, false, "Failed to fetch task history"); !ok {
+		return
+	}
+
+	entries, total, err := h.history.List(c.Request.Context(), taskID, includeDeleted, limit, (page-1)*limit)
+	if err != nil {
+		respondError(c, err, "Failed to fetch task history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"history": entries,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+const (
+	// idempotencyKeyHeader names a request so retries of it are answered
+	// with the original response
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLength  = 255
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+	// defaultIdempotencyMaxBodySize bounds the bodies read and hashed for
+	// requests with an Idempotency-Key
+	defaultIdempotencyMaxBodySize = 1 << 20
+)
+
+// IdempotencyStore records which Idempotency-Keys are in use and the
+// responses to their requests
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, userID uuid.UUID, key, requestHash string, expiry time.Time) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, response string) error
+	Release(ctx context.Context, userID uuid.UUID, key string) error
+}
+
+// recordingWriter passes the response through while keeping a copy of it
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes a route safe to retry. A request sent with an
+// Idempotency-Key is handled once; retries with the same key within
+// IDEMPOTENCY_KEY_TTL get the original response back instead of repeating
+// it. Their bodies are capped at IDEMPOTENCY_MAX_BODY_SIZE bytes. Requests
+// without the header are handled as usual.
+func (h *TaskHandler) Idempotent() gin.HandlerFunc {
+	ttl := config.Duration("IDEMPOTENCY_KEY_TTL", defaultIdempotencyKeyTTL)
+	maxSize := config.Int64("IDEMPOTENCY_MAX_BODY_SIZE", defaultIdempotencyMaxBodySize)
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+		userID := c.MustGet("userID").(uuid.UUID)
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSize))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("Request body exceeds maximum size of %d bytes", maxSize)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		// The query is part of the request since it shapes the response
+		sum := sha256.Sum256(append([]byte(c.Request.URL.RawQuery+"\n"), body...))
+		hash := hex.EncodeToString(sum[:])
+
+		existing, err := h.idempotency.Reserve(c.Request.Context(), userID, key, hash, time.Now().Add(-ttl))
+		if err != nil {
+			respondError(c, err, "Failed to check idempotency key")
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != hash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case existing.StatusCode == nil:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(*existing.StatusCode, "application/json; charset=utf-8", []byte(*existing.Response))
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		// Deferred so a panicking handler can't leave the key claimed
+		defer func() {
+			c.Writer = writer.ResponseWriter
+			recovered := recover()
+
+			// The outcome is stored even if the client has gone away, since
+			// that is when it will retry
+			ctx := context.WithoutCancel(c.Request.Context())
+			// Server errors may be temporary, so the key is freed for a
+			// retry rather than pinned to the failure
+			if recovered != nil || writer.Status() >= http.StatusInternalServerError {
+				if err := h.idempotency.Release(ctx, userID, key); err != nil {
+					log.Printf("⚠️  Failed to release idempotency key for user %s: %v\n", userID, err)
+				}
+				if recovered != nil {
+					panic(recovered)
+				}
+				return
+			}
+			if err := h.idempotency.Complete(ctx, userID, key, writer.Status(), writer.body.String()); err != nil {
+				log.Printf("⚠️  Failed to store idempotent response for user %s: %v\n", userID, err)
+			}
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyStore keeps idempotency keys in memory
+type memoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]*models.IdempotencyKey
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{keys: make(map[string]*models.IdempotencyKey)}
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, userID uuid.UUID, key, requestHash string, expiry time.Time) (*models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := userID.String() + "/" + key
+	if existing, ok := s.keys[id]; ok && !existing.CreatedAt.Before(expiry) {
+		copied := *existing
+		return &copied, nil
+	}
+	s.keys[id] = &models.IdempotencyKey{UserID: userID, Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, userID uuid.UUID, key string, statusCode int, response string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if k, ok := s.keys[userID.String()+"/"+key]; ok {
+		k.StatusCode, k.Response = &statusCode, &response
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, userID uuid.UUID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := userID.String() + "/" + key
+	if k, ok := s.keys[id]; ok && k.StatusCode == nil {
+		delete(s.keys, id)
+	}
+	return nil
+}
+
+// idempotentRouter serves POST /tasks through Idempotent and handler
+func idempotentRouter(store IdempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	h := &TaskHandler{idempotency: store}
+	return newRouter(uuid.New(), http.MethodPost, "/tasks", h.Idempotent(), handler)
+}
+
+func TestIdempotentReplaysResponse(t *testing.T) {
+	calls := 0
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	first := serve(t, router, http.MethodPost, "/tasks", `{"title":"a"}`, "Idempotency-Key", "k1")
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	retry := serve(t, router, http.MethodPost, "/tasks", `{"title":"a"}`, "Idempotency-Key", "k1")
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// Without a key, or with another one, the request is handled again
+	serve(t, router, http.MethodPost, "/tasks", `{"title":"a"}`)
+	serve(t, router, http.MethodPost, "/tasks", `{"title":"a"}`, "Idempotency-Key", "k2")
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotentRejectsDifferentRequest(t *testing.T) {
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	serve(t, router, http.MethodPost, "/tasks", `{"title":"a"}`, "Idempotency-Key", "k")
+	w := serve(t, router, http.MethodPost, "/tasks", `{"title":"b"}`, "Idempotency-Key", "k")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	w = serve(t, router, http.MethodPost, "/tasks?expand=subtasks", `{"title":"a"}`, "Idempotency-Key", "k")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "the query is part of the request")
+}
+
+func TestIdempotentConflictsWhileInProgress(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	started, finish := make(chan struct{}), make(chan struct{})
+	router := idempotentRouter(store, func(c *gin.Context) {
+		close(started)
+		<-finish
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	done := make(chan int)
+	go func() {
+		done <- serve(t, router, http.MethodPost, "/tasks", `{}`, "Idempotency-Key", "k").Code
+	}()
+	<-started
+
+	w := serve(t, router, http.MethodPost, "/tasks", `{}`, "Idempotency-Key", "k")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(finish)
+	assert.Equal(t, http.StatusCreated, <-done)
+}
+
+func TestIdempotentReleasesKeyOnServerError(t *testing.T) {
+	fail := true
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		if fail {
+			c.JSON(http.StatusServiceUnavailable, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(t, router, http.MethodPost, "/tasks", `{}`, "Idempotency-Key", "k").Code)
+	fail = false
+	w := serve(t, router, http.MethodPost, "/tasks", `{}`, "Idempotency-Key", "k")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotentReleasesKeyOnPanic(t *testing.T) {
+	panicking := true
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		if panicking {
+			panic("boom")
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	w := serve(t, router, http.MethodPost, "/tasks", `{}`, "Idempotency-Key", "k")
+	assert.Equal(t, http.StatusInternalServerError, w.Code, "the panic reaches the recovery middleware")
+
+	panicking = false
+	w = serve(t, router, http.MethodPost, "/tasks", `{}`, "Idempotency-Key", "k")
+	assert.Equal(t, http.StatusCreated, w.Code, "a retry is handled rather than refused with 409")
+}
+
+func TestIdempotentRejectsLongKey(t *testing.T) {
+	router := idempotentRouter(newMemoryIdempotencyStore(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+	long := make([]byte, maxIdempotencyKeyLength+1)
+	for i := range long {
+		long[i] = 'k'
+	}
+	w := serve(t, router, http.MethodPost, "/tasks", `{}`, "Idempotency-Key", string(long))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIdempotentRejectsOversizedBody(t *testing.T) {
+	t.Setenv("IDEMPOTENCY_MAX_BODY_SIZE", "16")
+	store := newMemoryIdempotencyStore()
+	calls := 0
+	router := idempotentRouter(store, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	w := serve(t, router, http.MethodPost, "/tasks", `{"title":"far too long"}`, "Idempotency-Key", "big")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "Request body exceeds maximum size of 16 bytes", decode(t, w)["error"])
+	assert.Zero(t, calls)
+	assert.Empty(t, store.keys, "no key is reserved for a rejected body")
+
+	w = serve(t, router, http.MethodPost, "/tasks", `{"title":"okay"}`, "Idempotency-Key", "small")
+	assert.Equal(t, http.StatusCreated, w.Code, "a body at the limit is accepted")
+
+	// Without a key the body isn't read here, so the handler's own limits apply
+	w = serve(t, router, http.MethodPost, "/tasks", `{"title":"far too long"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
@@ -0,0 +1,524 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+const (
+	defaultImportMaxFileSize = 5 << 20 // 5 MB
+	// Imported created_at values older than this are out of range
+	defaultImportCreatedAtMaxAge = 10 * 365 * 24 * time.Hour
+)
+
+// Policies for out-of-range imported created_at values
+const (
+	createdAtPolicyClamp  = "clamp"
+	createdAtPolicyReject = "reject"
+)
+
+// Policies for imported rows whose title is blank after trimming
+const (
+	blankTitlePolicySkip   = "skip"
+	blankTitlePolicyReject = "reject"
+)
+
+// errBlankTitlesRejected fails a whole import that contains blank-titled rows
+// under the reject policy
+var errBlankTitlesRejected = errors.New("import rejected: some rows have a blank title")
+
+// importOptions controls how imported rows are validated
+type importOptions struct {
+	createdAt         createdAtPolicy
+	rejectBlankTitles bool
+}
+
+// loadImportOptions reads the import policies, letting ?blank_titles=
+// override IMPORT_BLANK_TITLE_POLICY for a single import
+func loadImportOptions(c *gin.Context) (importOptions, error) {
+	policy := c.DefaultQuery("blank_titles", config.String("IMPORT_BLANK_TITLE_POLICY", blankTitlePolicySkip))
+	if policy != blankTitlePolicySkip && policy != blankTitlePolicyReject {
+		return importOptions{}, fmt.Errorf("invalid blank_titles %q: use %s or %s", policy, blankTitlePolicySkip, blankTitlePolicyReject)
+	}
+	return importOptions{
+		createdAt:         loadCreatedAtPolicy(),
+		rejectBlankTitles: policy == blankTitlePolicyReject,
+	}, nil
+}
+
+// createdAtPolicy bounds imported created_at values to [now-maxAge, now]
+type createdAtPolicy struct {
+	reject bool
+	maxAge time.Duration
+}
+
+// loadCreatedAtPolicy reads IMPORT_CREATED_AT_POLICY and IMPORT_CREATED_AT_MAX_AGE
+func loadCreatedAtPolicy() createdAtPolicy {
+	policy := config.String("IMPORT_CREATED_AT_POLICY", createdAtPolicyClamp)
+	if policy != createdAtPolicyClamp && policy != createdAtPolicyReject {
+		log.Printf("⚠️  Unknown IMPORT_CREATED_AT_POLICY %q, using %s", policy, createdAtPolicyClamp)
+		policy = createdAtPolicyClamp
+	}
+	return createdAtPolicy{
+		reject: policy == createdAtPolicyReject,
+		maxAge: config.Duration("IMPORT_CREATED_AT_MAX_AGE", defaultImportCreatedAtMaxAge),
+	}
+}
+
+// apply clamps createdAt into range, or rejects it under the reject policy.
+// A zero maxAge disables the lower bound.
+func (p createdAtPolicy) apply(createdAt, now time.Time) (time.Time, error) {
+	if createdAt.After(now) {
+		if p.reject {
+			return time.Time{}, fmt.Errorf("created_at is in the future")
+		}
+		return now, nil
+	}
+	if p.maxAge > 0 {
+		if oldest := now.Add(-p.maxAge); createdAt.Before(oldest) {
+			if p.reject {
+				return time.Time{}, fmt.Errorf("created_at is older than %s", p.maxAge)
+			}
+			return oldest, nil
+		}
+	}
+	return createdAt, nil
+}
+
+// ImportTasksCSV creates tasks from an uploaded CSV file.
+// The first row must be a header naming the columns; only "title" is required.
+// An optional created_at column is bounded by IMPORT_CREATED_AT_POLICY.
+// Rows with a blank title are skipped and reported, or with
+// ?blank_titles=reject fail the whole import.
+// Valid rows are inserted in a single transaction and invalid rows are
+// reported with their line number.
+func (h *TaskHandler) ImportTasksCSV(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	opts, err := loadImportOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, _, ok := openImportFile(c, "A CSV file is required in the 'file' form field")
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	rows, rowErrors, err := parseTasksCSV(file, userID, opts)
+	if errors.Is(err, errBlankTitlesRejected) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  err.Error(),
+			"errors": rowErrors,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tasks, ok := h.createImportedTasks(c, userID, rows)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Imported %d of %d rows", len(tasks), len(tasks)+len(rowErrors)),
+		"result": models.ImportResult{
+			Imported: len(tasks),
+			Failed:   len(rowErrors),
+			Errors:   rowErrors,
+		},
+	})
+}
+
+// ImportTasks creates tasks from an uploaded CSV or JSON file in the 'file'
+// form field. The format comes from ?format= or the file extension; JSON
+// files hold an array of objects with the CSV column names as keys, and
+// their errors report the 1-based array position as the line. Rows
+// repeating the title and due date of an existing task or an earlier row are
+// skipped and reported as duplicates. The import is all or nothing: any
+// invalid row fails it with 422. ?dry_run=true only reports what would happen.
+func (h *TaskHandler) ImportTasks(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	dryRun, err := queryBool(c, "dry_run")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	file, fileHeader, ok := openImportFile(c, "A CSV or JSON file is required in the 'file' form field")
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+
+	// Blank titles are plain row errors here, since any error fails the import
+	opts := importOptions{createdAt: loadCreatedAtPolicy()}
+	var rows []importRow
+	var rowErrors []models.ImportRowError
+	switch format {
+	case "csv":
+		rows, rowErrors, err = parseTasksCSV(file, userID, opts)
+	case "json":
+		rows, rowErrors, err = parseTasksJSON(file, userID, opts)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown file format. Upload a .csv or .json file or set format=csv|json", "field": "format"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, duplicates, err := h.dropDuplicateRows(c.Request.Context(), userID, rows)
+	if err != nil {
+		respondError(c, err, "Failed to check for duplicate tasks")
+		return
+	}
+
+	total := len(rows) + len(rowErrors) + len(duplicates)
+	result := models.ImportResult{
+		Imported:   len(rows),
+		Failed:     len(rowErrors),
+		Errors:     rowErrors,
+		Duplicates: duplicates,
+		DryRun:     dryRun,
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"message": fmt.Sprintf("Dry run: %d of %d rows would be imported", len(rows), total),
+			"result":  result,
+		})
+		return
+	}
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":  fmt.Sprintf("%d of %d rows are invalid, nothing was imported", len(rowErrors), total),
+			"errors": rowErrors,
+		})
+		return
+	}
+
+	if _, ok := h.createImportedTasks(c, userID, rows); !ok {
+		return
+	}
+
+	log.Printf("📥 Imported %d tasks for user %s (%d duplicates skipped)\n", len(rows), userID, len(duplicates))
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Imported %d of %d rows", len(rows), total),
+		"result":  result,
+	})
+}
+
+// importRow is a validated imported task and the line it came from
+type importRow struct {
+	line int
+	task models.Task
+}
+
+// openImportFile opens the uploaded 'file' form field within
+// IMPORT_MAX_FILE_SIZE, responding with missingMsg when there is none
+func openImportFile(c *gin.Context, missingMsg string) (multipart.File, *multipart.FileHeader, bool) {
+	maxSize := config.Int64("IMPORT_MAX_FILE_SIZE", defaultImportMaxFileSize)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File exceeds maximum size of %d bytes", maxSize)})
+			return nil, nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": missingMsg})
+		return nil, nil, false
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return nil, nil, false
+	}
+	return file, fileHeader, true
+}
+
+// createImportedTasks inserts the rows' tasks in one transaction, then
+// indexes them and publishes their events
+func (h *TaskHandler) createImportedTasks(c *gin.Context, userID uuid.UUID, rows []importRow) ([]models.Task, bool) {
+	tasks := make([]models.Task, len(rows))
+	for i, row := range rows {
+		tasks[i] = row.task
+	}
+	if len(tasks) == 0 {
+		return tasks, true
+	}
+
+	if err := h.tasks.CreateMany(c.Request.Context(), tasks); err != nil {
+		respondError(c, err, "Failed to import tasks")
+		return nil, false
+	}
+	for i := range tasks {
+		h.indexer.Index(c.Request.Context(), tasks[i])
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskCreated, userID, tasks[i].ID, &tasks[i])
+	}
+	return tasks, true
+}
+
+// duplicateKey identifies a task by its case-insensitive title and due date
+func duplicateKey(title string, dueDate *time.Time) string {
+	key := strings.ToLower(title) + "\x00"
+	if dueDate != nil {
+		key += dueDate.UTC().Format(time.RFC3339Nano)
+	}
+	return key
+}
+
+// dropDuplicateRows removes rows with the same title and due date as one of
+// the user's tasks or an earlier row, returning the rows left and the
+// duplicates removed
+func (h *TaskHandler) dropDuplicateRows(ctx context.Context, userID uuid.UUID, rows []importRow) ([]importRow, []models.ImportDuplicate, error) {
+	if len(rows) == 0 {
+		return rows, nil, nil
+	}
+
+	titles := make([]string, len(rows))
+	for i, row := range rows {
+		titles[i] = strings.ToLower(row.task.Title)
+	}
+	existing, err := h.tasks.ListByTitles(ctx, userID, titles)
+	if err != nil {
+		return nil, nil, err
+	}
+	existingIDs := make(map[string]uuid.UUID, len(existing))
+	for _, task := range existing {
+		existingIDs[duplicateKey(task.Title, task.DueDate)] = task.ID
+	}
+
+	kept := make([]importRow, 0, len(rows))
+	var duplicates []models.ImportDuplicate
+	seen := make(map[string]int, len(rows))
+	for _, row := range rows {
+		key := duplicateKey(row.task.Title, row.task.DueDate)
+		dup := models.ImportDuplicate{Line: row.line, Title: row.task.Title, DueDate: row.task.DueDate}
+		if id, ok := existingIDs[key]; ok {
+			dup.TaskID = &id
+			duplicates = append(duplicates, dup)
+			continue
+		}
+		if line, ok := seen[key]; ok {
+			dup.DuplicateLine = line
+			duplicates = append(duplicates, dup)
+			continue
+		}
+		seen[key] = row.line
+		kept = append(kept, row)
+	}
+	return kept, duplicates, nil
+}
+
+// parseTasksJSON reads and validates a JSON array of objects into tasks
+// owned by userID. Values must be strings or null.
+func parseTasksJSON(r io.Reader, userID uuid.UUID, opts importOptions) ([]importRow, []models.ImportRowError, error) {
+	var items []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, nil, fmt.Errorf("JSON file must hold an array of task objects: %v", err)
+	}
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("JSON file is empty")
+	}
+
+	var rows []importRow
+	var rowErrors []models.ImportRowError
+	for i, item := range items {
+		line := i + 1
+		fields := make(map[string]string, len(item))
+		var invalid []string
+		for name, val := range item {
+			switch val := val.(type) {
+			case nil:
+			case string:
+				fields[strings.ToLower(name)] = strings.TrimSpace(val)
+			default:
+				invalid = append(invalid, name)
+			}
+		}
+		if len(invalid) > 0 {
+			sort.Strings(invalid)
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: fmt.Sprintf("%s must be strings or null", strings.Join(invalid, ", "))})
+			continue
+		}
+
+		task, err := taskFromImportRow(func(name string) string { return fields[name] }, userID, opts.createdAt)
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, importRow{line: line, task: task})
+	}
+	return rows, rowErrors, nil
+}
+
+// parseTasksCSV reads and validates CSV rows into tasks owned by userID.
+// When blank titles are rejected and any are found, it returns only the
+// blank-title row errors with errBlankTitlesRejected.
+func parseTasksCSV(r io.Reader, userID uuid.UUID, opts importOptions) ([]importRow, []models.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("CSV file is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, nil, fmt.Errorf("CSV header must include a 'title' column")
+	}
+
+	var rows []importRow
+	var rowErrors, blankErrors []models.ImportRowError
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// FieldPos panics after a failed read, so the line comes from
+			// the error
+			line := 0
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.StartLine
+			}
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		task, err := taskFromImportRow(field, userID, opts.createdAt)
+		if errors.Is(err, errBlankTitle) && opts.rejectBlankTitles {
+			blankErrors = append(blankErrors, models.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, models.ImportRowError{Line: line, Error: err.Error()})
+			continue
+		}
+		rows = append(rows, importRow{line: line, task: task})
+	}
+
+	if len(blankErrors) > 0 {
+		return nil, blankErrors, errBlankTitlesRejected
+	}
+	return rows, rowErrors, nil
+}
+
+// taskFromImportRow validates a single imported row and builds a task from it
+func taskFromImportRow(field func(string) string, userID uuid.UUID, policy createdAtPolicy) (models.Task, error) {
+	title, err := normalizeTitle(field("title"))
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	status := "pending"
+	if val := field("status"); val != "" {
+		status = val
+	}
+	if !isValidStatus(status) {
+		return models.Task{}, fmt.Errorf("invalid status %q", status)
+	}
+
+	priority := "medium"
+	if val := field("priority"); val != "" {
+		priority = val
+	}
+	if !isValidPriority(priority) {
+		return models.Task{}, fmt.Errorf("invalid priority %q", priority)
+	}
+
+	now := time.Now()
+	task := models.Task{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Title:     title,
+		Status:    status,
+		Priority:  priority,
+		Origin:    models.OriginImport,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if val := field("description"); val != "" {
+		task.Description = sanitizeDescription(&val)
+	}
+
+	if val := field("due_date"); val != "" {
+		dueDate, err := parseImportDate(val)
+		if err != nil {
+			return models.Task{}, fmt.Errorf("invalid due_date %q: use RFC3339 or YYYY-MM-DD", val)
+		}
+		task.DueDate = &dueDate
+	}
+
+	if val := field("created_at"); val != "" {
+		createdAt, err := parseImportDate(val)
+		if err != nil {
+			return models.Task{}, fmt.Errorf("invalid created_at %q: use RFC3339 or YYYY-MM-DD", val)
+		}
+		createdAt, err = policy.apply(createdAt, now)
+		if err != nil {
+			return models.Task{}, err
+		}
+		task.CreatedAt = createdAt
+	}
+
+	return task, nil
+}
+
+// parseImportDate accepts RFC3339 timestamps or plain YYYY-MM-DD dates
+func parseImportDate(val string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, val); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", val)
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadCSV serves a multipart upload of content in the 'file' field
+func uploadCSV(t *testing.T, h *TaskHandler, userID uuid.UUID, target, content string) map[string]interface{} {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "tasks.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	router := newRouter(userID, http.MethodPost, "/tasks/import/csv", h.ImportTasksCSV)
+	w := serve(t, router, http.MethodPost, target, body.String(), "Content-Type", form.FormDataContentType())
+	result := decode(t, w)
+	result["status"] = float64(w.Code)
+	return result
+}
+
+func TestParseTasksCSV(t *testing.T) {
+	userID := uuid.New()
+	csv := "Title, Status ,priority,due_date,description,created_at\n" +
+		"Write report,in_progress,high,2026-03-01,Quarterly,2026-01-15T09:00:00Z\n" +
+		"Plain task,,,,,\n"
+
+	rows, rowErrors, err := parseTasksCSV(strings.NewReader(csv), userID, importOptions{createdAt: createdAtPolicy{}})
+	require.NoError(t, err)
+	assert.Empty(t, rowErrors)
+	require.Len(t, rows, 2)
+
+	task := rows[0].task
+	assert.Equal(t, 2, rows[0].line)
+	assert.Equal(t, userID, task.UserID)
+	assert.Equal(t, "Write report", task.Title)
+	assert.Equal(t, "in_progress", task.Status)
+	assert.Equal(t, "high", task.Priority)
+	assert.Equal(t, models.OriginImport, task.Origin)
+	require.NotNil(t, task.DueDate)
+	assert.Equal(t, "2026-03-01", task.DueDate.Format("2006-01-02"))
+	require.NotNil(t, task.Description)
+	assert.Equal(t, "Quarterly", *task.Description)
+	assert.Equal(t, time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC), task.CreatedAt.UTC())
+
+	assert.Equal(t, 3, rows[1].line)
+	assert.Equal(t, "pending", rows[1].task.Status)
+	assert.Equal(t, "medium", rows[1].task.Priority)
+}
+
+func TestParseTasksCSVReportsInvalidRows(t *testing.T) {
+	csv := "title,status,priority,due_date\n" +
+		"Good,pending,low,\n" +
+		"Bad status,sleeping,,\n" +
+		"Bad priority,,whenever,\n" +
+		"Bad date,,,next week\n" +
+		"   ,,,\n" +
+		"\"unterminated,,,\n"
+
+	rows, rowErrors, err := parseTasksCSV(strings.NewReader(csv), uuid.New(), importOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Good", rows[0].task.Title)
+
+	lines := make([]int, len(rowErrors))
+	for i, rowErr := range rowErrors {
+		lines[i] = rowErr.Line
+	}
+	assert.Equal(t, []int{3, 4, 5, 6, 7}, lines)
+	assert.Contains(t, rowErrors[0].Error, `invalid status "sleeping"`)
+	assert.Contains(t, rowErrors[1].Error, `invalid priority "whenever"`)
+	assert.Contains(t, rowErrors[2].Error, "invalid due_date")
+}
+
+func TestParseTasksCSVRejectsBlankTitles(t *testing.T) {
+	csv := "title\nGood\n\"  \"\nAlso good\n"
+
+	rows, rowErrors, err := parseTasksCSV(strings.NewReader(csv), uuid.New(), importOptions{rejectBlankTitles: true})
+	assert.ErrorIs(t, err, errBlankTitlesRejected)
+	assert.Nil(t, rows)
+	require.Len(t, rowErrors, 1)
+	assert.Equal(t, 3, rowErrors[0].Line)
+}
+
+func TestParseTasksCSVRejectsFile(t *testing.T) {
+	for name, csv := range map[string]string{
+		"empty":           "",
+		"no title column": "name,status\nTask,pending\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := parseTasksCSV(strings.NewReader(csv), uuid.New(), importOptions{})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestImportTasksCSVRejectsBeforeWriting(t *testing.T) {
+	h := &TaskHandler{}
+
+	result := uploadCSV(t, h, uuid.New(), "/tasks/import/csv", "name\nTask\n")
+	assert.Equal(t, float64(http.StatusBadRequest), result["status"])
+	assert.Contains(t, result["error"], "'title' column")
+
+	result = uploadCSV(t, h, uuid.New(), "/tasks/import/csv?blank_titles=reject", "title\nTask\n,\n")
+	assert.Equal(t, float64(http.StatusUnprocessableEntity), result["status"])
+	assert.Len(t, result["errors"], 1)
+
+	result = uploadCSV(t, h, uuid.New(), "/tasks/import/csv?blank_titles=sometimes", "title\nTask\n")
+	assert.Equal(t, float64(http.StatusBadRequest), result["status"])
+}
+
+func TestImportTasksCSV(t *testing.T) {
+	db := testdb.Open(t)
+	userID := uuid.New()
+	_, err := db.Exec("INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'importer', 'importer@example.com')", userID)
+	require.NoError(t, err)
+	events := &recordedEvents{}
+	h := NewTaskHandler(db, search.NoopIndexer{}, events, nil)
+
+	result := uploadCSV(t, h, userID, "/tasks/import/csv",
+		"title,priority\nFirst,high\nSecond,urgent\nThird,extreme\n")
+	assert.Equal(t, float64(http.StatusOK), result["status"], result)
+	assert.Equal(t, "Imported 2 of 3 rows", result["message"])
+	importResult := result["result"].(map[string]interface{})
+	assert.Equal(t, float64(2), importResult["imported"])
+	assert.Equal(t, float64(1), importResult["failed"])
+
+	var titles []string
+	require.NoError(t, db.Select(&titles, "SELECT title FROM tasks WHERE user_id = $1 AND origin = $2 ORDER BY title", userID, models.OriginImport))
+	assert.Equal(t, []string{"First", "Second"}, titles)
+	assert.Equal(t, []string{models.EventTaskCreated, models.EventTaskCreated}, events.types())
+}
+
+func TestCreatedAtPolicyApply(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	maxAge := 365 * 24 * time.Hour
+	future := now.Add(time.Hour)
+	ancient := now.Add(-2 * maxAge)
+	inRange := now.Add(-24 * time.Hour)
+
+	for name, tc := range map[string]struct {
+		policy    createdAtPolicy
+		createdAt time.Time
+		want      time.Time
+		err       string
+	}{
+		"clamp future":         {createdAtPolicy{maxAge: maxAge}, future, now, ""},
+		"clamp ancient":        {createdAtPolicy{maxAge: maxAge}, ancient, now.Add(-maxAge), ""},
+		"clamp in range":       {createdAtPolicy{maxAge: maxAge}, inRange, inRange, ""},
+		"no lower bound":       {createdAtPolicy{}, ancient, ancient, ""},
+		"reject future":        {createdAtPolicy{reject: true, maxAge: maxAge}, future, time.Time{}, "created_at is in the future"},
+		"reject ancient":       {createdAtPolicy{reject: true, maxAge: maxAge}, ancient, time.Time{}, "created_at is older than 8760h0m0s"},
+		"reject keeps valid":   {createdAtPolicy{reject: true, maxAge: maxAge}, inRange, inRange, ""},
+		"reject without bound": {createdAtPolicy{reject: true}, ancient, ancient, ""},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.policy.apply(tc.createdAt, now)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestLoadCreatedAtPolicy(t *testing.T) {
+	assert.Equal(t, createdAtPolicy{maxAge: defaultImportCreatedAtMaxAge}, loadCreatedAtPolicy(), "clamping by default")
+
+	t.Setenv("IMPORT_CREATED_AT_POLICY", "reject")
+	t.Setenv("IMPORT_CREATED_AT_MAX_AGE", "720h")
+	assert.Equal(t, createdAtPolicy{reject: true, maxAge: 720 * time.Hour}, loadCreatedAtPolicy())
+
+	t.Setenv("IMPORT_CREATED_AT_POLICY", "ignore")
+	assert.False(t, loadCreatedAtPolicy().reject, "unknown policies clamp")
+}
+
+// TestParseTasksCreatedAtPolicy imports future and ancient created_at values
+// from CSV and JSON under each policy
+func TestParseTasksCreatedAtPolicy(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	csvBody := "title,created_at\nFuture," + future + "\nAncient,1970-01-01\nRecent,2026-01-15T09:00:00Z\n"
+	jsonBody := `[{"title": "Future", "created_at": "` + future + `"}, {"title": "Ancient", "created_at": "1970-01-01"}, {"title": "Recent", "created_at": "2026-01-15T09:00:00Z"}]`
+	parsers := map[string]func(importOptions) ([]importRow, []models.ImportRowError, error){
+		"csv": func(opts importOptions) ([]importRow, []models.ImportRowError, error) {
+			return parseTasksCSV(strings.NewReader(csvBody), uuid.New(), opts)
+		},
+		"json": func(opts importOptions) ([]importRow, []models.ImportRowError, error) {
+			return parseTasksJSON(strings.NewReader(jsonBody), uuid.New(), opts)
+		},
+	}
+
+	for format, parse := range parsers {
+		t.Run(format+" clamp", func(t *testing.T) {
+			maxAge := 10 * 365 * 24 * time.Hour
+			start := time.Now()
+			rows, rowErrors, err := parse(importOptions{createdAt: createdAtPolicy{maxAge: maxAge}})
+			require.NoError(t, err)
+			assert.Empty(t, rowErrors)
+			require.Len(t, rows, 3)
+			assert.WithinRange(t, rows[0].task.CreatedAt, start, time.Now(), "future dates are clamped to now")
+			assert.WithinRange(t, rows[1].task.CreatedAt, start.Add(-maxAge), time.Now().Add(-maxAge), "ancient dates are clamped to the max age")
+			assert.Equal(t, time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC), rows[2].task.CreatedAt.UTC())
+		})
+
+		t.Run(format+" reject", func(t *testing.T) {
+			rows, rowErrors, err := parse(importOptions{createdAt: createdAtPolicy{reject: true, maxAge: 10 * 365 * 24 * time.Hour}})
+			require.NoError(t, err)
+			require.Len(t, rows, 1)
+			assert.Equal(t, "Recent", rows[0].task.Title)
+			require.Len(t, rowErrors, 2)
+			assert.Equal(t, "created_at is in the future", rowErrors[0].Error)
+			assert.Equal(t, "created_at is older than 87600h0m0s", rowErrors[1].Error)
+		})
+	}
+}
+
+func TestParseTasksSkipsBlankTitles(t *testing.T) {
+	csvBody := "title,description\nFirst,\n\"   \",only spaces\n\"\t  \",tabs\n\"Multi\nline\",\n,empty\nLast,\n"
+	rows, rowErrors, err := parseTasksCSV(strings.NewReader(csvBody), uuid.New(), importOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "First", rows[0].task.Title)
+	assert.Equal(t, "Multi\nline", rows[1].task.Title)
+	assert.Equal(t, "Last", rows[2].task.Title)
+	assert.Equal(t, []models.ImportRowError{
+		{Line: 3, Error: "title is required"},
+		{Line: 4, Error: "title is required"},
+		{Line: 7, Error: "title is required"},
+	}, rowErrors, "lines count from the header, multi-line rows included")
+
+	jsonBody := `[{"title": "First"}, {"title": "  "}, {"description": "no title"}, {"title": "Last"}]`
+	rows, rowErrors, err = parseTasksJSON(strings.NewReader(jsonBody), uuid.New(), importOptions{})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []models.ImportRowError{
+		{Line: 2, Error: "title is required"},
+		{Line: 3, Error: "title is required"},
+	}, rowErrors)
+}
+
+func TestParseTasksCSVRejectsAllBlankTitles(t *testing.T) {
+	csvBody := "title,priority\nGood,low\n\" \",high\nBad priority,whenever\n\"\t\",\n"
+	rows, rowErrors, err := parseTasksCSV(strings.NewReader(csvBody), uuid.New(), importOptions{rejectBlankTitles: true})
+	assert.ErrorIs(t, err, errBlankTitlesRejected)
+	assert.Nil(t, rows)
+	assert.Equal(t, []models.ImportRowError{
+		{Line: 3, Error: "title is required"},
+		{Line: 5, Error: "title is required"},
+	}, rowErrors, "only the blank titles are reported")
+}
+
+func TestLoadImportOptionsBlankTitles(t *testing.T) {
+	load := func(target string) (importOptions, error) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+		return loadImportOptions(c)
+	}
+
+	opts, err := load("/tasks/import/csv")
+	require.NoError(t, err)
+	assert.False(t, opts.rejectBlankTitles, "blank titles are skipped by default")
+
+	t.Setenv("IMPORT_BLANK_TITLE_POLICY", "reject")
+	opts, err = load("/tasks/import/csv")
+	require.NoError(t, err)
+	assert.True(t, opts.rejectBlankTitles)
+
+	opts, err = load("/tasks/import/csv?blank_titles=skip")
+	require.NoError(t, err)
+	assert.False(t, opts.rejectBlankTitles, "the query overrides the default")
+
+	_, err = load("/tasks/import/csv?blank_titles=sometimes")
+	assert.EqualError(t, err, `invalid blank_titles "sometimes": use skip or reject`)
+}
+
+// TestImportTasksCSVBlankTitlePolicy imports the same file under each policy
+// and checks what is written
+func TestImportTasksCSVBlankTitlePolicy(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "blank-importer")
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	content := "title\nKept\n\"  \"\nAlso kept\n"
+	count := func() int {
+		var n int
+		require.NoError(t, db.Get(&n, "SELECT COUNT(*) FROM tasks WHERE user_id = $1", userID))
+		return n
+	}
+
+	result := uploadCSV(t, h, userID, "/tasks/import/csv?blank_titles=reject", content)
+	assert.Equal(t, float64(http.StatusUnprocessableEntity), result["status"])
+	assert.Equal(t, "import rejected: some rows have a blank title", result["error"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"line": float64(3), "error": "title is required"}}, result["errors"])
+	assert.Zero(t, count(), "nothing is written when the import is rejected")
+
+	result = uploadCSV(t, h, userID, "/tasks/import/csv", content)
+	assert.Equal(t, float64(http.StatusOK), result["status"], result)
+	assert.Equal(t, "Imported 2 of 3 rows", result["message"])
+	importResult := result["result"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"line": float64(3), "error": "title is required"}}, importResult["errors"])
+	assert.Equal(t, 2, count())
+
+	var blank int
+	require.NoError(t, db.Get(&blank, "SELECT COUNT(*) FROM tasks WHERE btrim(title) = ''"))
+	assert.Zero(t, blank)
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/linkmeta"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+const (
+	maxLinkURLLength   = 2048
+	maxLinkTitleLength = 255
+
+	defaultLinkMetadataTimeout = 5 * time.Second
+)
+
+// CreateLink adds an external link to a task. The page's title and favicon
+// are fetched within LINK_METADATA_TIMEOUT; a page that can't be read still
+// gets linked, without them.
+func (h *TaskHandler) CreateLink(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.CreateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	linkURL, err := normalizeLinkURL(req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var title *string
+	if req.Title != nil {
+		trimmed := strings.TrimSpace(*req.Title)
+		if utf8.RuneCountInString(trimmed) > maxLinkTitleLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Link title must be at most %d characters", maxLinkTitleLength)})
+			return
+		}
+		if trimmed != "" {
+			title = &trimmed
+		}
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, true, "Failed to add link"); !ok {
+		return
+	}
+
+	link := models.TaskLink{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		UserID:    userID,
+		URL:       linkURL,
+		Title:     title,
+		CreatedAt: time.Now(),
+	}
+	if config.Bool("LINK_METADATA_FETCH", true) {
+		link.PageTitle, link.FaviconURL = fetchLinkMetadata(c.Request.Context(), linkURL)
+	}
+
+	err = h.links.Create(c.Request.Context(), &link)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The task already links to this URL"})
+		return
+	}
+	if err != nil {
+		respondResourceError(c, err, "Link", "Failed to add link")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Link added successfully",
+		"link":    link,
+	})
+}
+
+// GetLinks lists a task's links, oldest first
+func (h *TaskHandler) GetLinks(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, false, "Failed to fetch links"); !ok {
+		return
+	}
+
+	links, err := h.links.List(c.Request.Context(), taskID)
+	if err != nil {
+		respondResourceError(c, err, "Link", "Failed to fetch links")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+// DeleteLink removes a link from a task
+func (h *TaskHandler) DeleteLink(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	linkID, err := uuid.Parse(c.Param("linkId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid link ID"})
+		return
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, true, "Failed to delete link"); !ok {
+		return
+	}
+
+	if err := h.links.Delete(c.Request.Context(), linkID, taskID); err != nil {
+		respondResourceError(c, err, "Link", "Failed to delete link")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Link deleted successfully"})
+}
+
+// normalizeLinkURL checks that a link is an absolute http or https URL
+func normalizeLinkURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > maxLinkURLLength {
+		return "", fmt.Errorf("Link URL must be at most %d characters", maxLinkURLLength)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("Link URL must be an absolute http or https URL")
+	}
+	return parsed.String(), nil
+}
+
+// fetchLinkMetadata reads a linked page's title and favicon, returning nil
+// for what could not be read
+func fetchLinkMetadata(ctx context.Context, linkURL string) (*string, *string) {
+	ctx, cancel := context.WithTimeout(ctx, config.Duration("LINK_METADATA_TIMEOUT", defaultLinkMetadataTimeout))
+	defer cancel()
+
+	meta, err := linkmeta.Fetch(ctx, linkURL)
+	if err != nil {
+		log.Printf("⚠️  Failed to fetch link metadata for %s: %v\n", linkURL, err)
+		return nil, nil
+	}
+	var title, favicon *string
+	if meta.Title != "" {
+		title = &meta.Title
+	}
+	if meta.FaviconURL != "" {
+		favicon = &meta.FaviconURL
+	}
+	return title, favicon
+}
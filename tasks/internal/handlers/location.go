@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+const (
+	maxPlaceNameLength = 255
+
+	defaultNearRadiusKm = 5
+	maxNearRadiusKm     = 1000
+
+	// earthRadiusKm is the mean radius used for great-circle distances
+	earthRadiusKm = 6371.0
+)
+
+// validateCoordinates checks that latitude and longitude are given together
+// and within range. Both may be nil.
+func validateCoordinates(latitude, longitude *float64) error {
+	if (latitude == nil) != (longitude == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if latitude != nil && !isValidCoordinate(*latitude, *longitude) {
+		return errors.New("Invalid location. latitude must be between -90 and 90 and longitude between -180 and 180")
+	}
+	return nil
+}
+
+// isValidCoordinate reports whether a point is within range; NaN is not
+func isValidCoordinate(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+// normalizePlaceName trims a place name and checks its length
+func normalizePlaceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxPlaceNameLength {
+		return "", fmt.Errorf("place_name must be at most %d characters", maxPlaceNameLength)
+	}
+	return name, nil
+}
+
+// parseNear reads the near=<lat>,<lng> filter and its radius_km, which
+// defaults to 5 km
+func parseNear(c *gin.Context, filters *models.TaskFilters) error {
+	val := c.Query("near")
+	if val == "" {
+		if c.Query("radius_km") != "" {
+			return &queryParamError{field: "radius_km", message: "radius_km requires near"}
+		}
+		return nil
+	}
+
+	parts := strings.Split(val, ",")
+	if len(parts) != 2 {
+		return &queryParamError{field: "near", message: "near must be <latitude>,<longitude>"}
+	}
+	latitude, latErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	longitude, lngErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if latErr != nil || lngErr != nil || !isValidCoordinate(latitude, longitude) {
+		return &queryParamError{field: "near", message: "near must be <latitude>,<longitude> with latitude between -90 and 90 and longitude between -180 and 180"}
+	}
+
+	radius := float64(defaultNearRadiusKm)
+	if raw := c.Query("radius_km"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(parsed > 0 && parsed <= maxNearRadiusKm) {
+			return &queryParamError{field: "radius_km", message: fmt.Sprintf("radius_km must be a number greater than 0 and at most %d", maxNearRadiusKm)}
+		}
+		radius = parsed
+	}
+
+	filters.Near = &models.GeoPoint{Latitude: latitude, Longitude: longitude}
+	filters.RadiusKm = radius
+	return nil
+}
+
+// nearCondition matches located tasks within radiusKm of point by haversine
+// distance. The latitude band is checked first so idx_tasks_location can
+// narrow the scan; longitude is left to the exact check since its band
+// widens towards the poles and wraps at the antimeridian.
+func nearCondition(w *whereBuilder, point models.GeoPoint, radiusKm float64) string {
+	band := radiusKm / earthRadiusKm * 180 / math.Pi
+	lat, lng := w.arg(point.Latitude), w.arg(point.Longitude)
+	distance := "2 * " + strconv.FormatFloat(earthRadiusKm, 'f', -1, 64) + " * ASIN(LEAST(1, SQRT(" +
+		"POWER(SIN(RADIANS(latitude - " + lat + ") / 2), 2) + " +
+		"COS(RADIANS(" + lat + ")) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - " + lng + ") / 2), 2))))"
+	return "latitude BETWEEN " + w.arg(point.Latitude-band) + " AND " + w.arg(point.Latitude+band) +
+		" AND " + distance + " <= " + w.arg(radiusKm)
+}
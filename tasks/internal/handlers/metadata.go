@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+const (
+	defaultMetadataMaxKeys = 20
+	// maxMetadataValueLength bounds a string value in characters
+	maxMetadataValueLength = 500
+	// metadataQueryPrefix marks task list parameters that filter on custom fields
+	metadataQueryPrefix = "meta."
+)
+
+// metadataKeyPattern keeps keys usable as meta.<key> query parameters
+var metadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// MetadataValidator checks one custom field of a task being created or
+// updated, returning an error to reject the task
+type MetadataValidator func(key string, value interface{}) error
+
+// metadataValidators run on every field after the built-in checks
+var metadataValidators = []MetadataValidator{allowedMetadataKey}
+
+// RegisterMetadataValidator adds a validation hook for custom fields, so
+// deployments can enforce their own field rules. Register hooks before the
+// server starts handling requests.
+func RegisterMetadataValidator(validator MetadataValidator) {
+	metadataValidators = append(metadataValidators, validator)
+}
+
+// allowedMetadataKey rejects keys missing from METADATA_ALLOWED_KEYS when
+// it is set
+func allowedMetadataKey(key string, _ interface{}) error {
+	allowed := config.List("METADATA_ALLOWED_KEYS")
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, name := range allowed {
+		if name == key {
+			return nil
+		}
+	}
+	return fmt.Errorf("metadata key %q is not allowed. Must be one of: %s", key, strings.Join(allowed, ", "))
+}
+
+// validateMetadata checks custom fields: at most METADATA_MAX_KEYS keys of
+// lowercase letters, digits and underscores, holding strings, numbers or
+// booleans, then runs the registered validators
+func validateMetadata(metadata models.Metadata) error {
+	if maxKeys := config.Int("METADATA_MAX_KEYS", defaultMetadataMaxKeys); len(metadata) > maxKeys {
+		return fmt.Errorf("metadata can have at most %d keys", maxKeys)
+	}
+
+	// Sorted so the first error reported is stable
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !metadataKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid metadata key %q: use up to 50 lowercase letters, digits and underscores, starting with a letter", key)
+		}
+		switch val := metadata[key].(type) {
+		case string:
+			if utf8.RuneCountInString(val) > maxMetadataValueLength {
+				return fmt.Errorf("metadata value for %q must be at most %d characters", key, maxMetadataValueLength)
+			}
+		case float64, bool:
+		default:
+			return fmt.Errorf("metadata value for %q must be a string, number or boolean", key)
+		}
+		for _, validate := range metadataValidators {
+			if err := validate(key, metadata[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseMetadataFilters reads meta.<key>=<value> parameters. Values are
+// compared as text, so meta.billable=true matches a boolean true.
+func parseMetadataFilters(c *gin.Context) (map[string]string, error) {
+	var filters map[string]string
+	for param, vals := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(param, metadataQueryPrefix)
+		if !ok {
+			continue
+		}
+		if !metadataKeyPattern.MatchString(key) {
+			return nil, &queryParamError{field: param, message: "metadata filters must be meta.<key> with a key of lowercase letters, digits and underscores"}
+		}
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[key] = vals[0]
+	}
+	return filters, nil
+}
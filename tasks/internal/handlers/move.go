@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// MoveTask places a task at a position in its kanban status column, or in
+// another column when status is given, so drag-and-drop ordering persists.
+// Moving to completed follows SUBTASK_COMPLETION_POLICY and respects blockers
+// like an update.
+func (h *TaskHandler) MoveTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	force, err := queryBool(c, "force")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	var req models.MoveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Status != nil && !isValidStatus(*req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	current, ok := h.accessibleTask(c, taskID, userID, true, "Failed to move task")
+	if !ok {
+		return
+	}
+	// Shared editors move the task on the owner's board
+	userID = current.UserID
+	if current.ArchivedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Unarchive the task before moving it"})
+		return
+	}
+
+	status := current.Status
+	if req.Status != nil {
+		status = *req.Status
+	}
+
+	completing := status == "completed" && current.Status != "completed"
+	if completing && !force && !h.checkBlockers(c, taskID) {
+		return
+	}
+	cascade := false
+	if completing && current.ParentTaskID == nil {
+		_, openSubtasks, err := h.tasks.SubtaskCounts(c.Request.Context(), taskID)
+		if err != nil {
+			respondError(c, err, "Failed to move task")
+			return
+		}
+		var ok bool
+		if cascade, ok = applySubtaskPolicy(c, openSubtasks); !ok {
+			return
+		}
+	}
+
+	task, completedSubtasks, err := h.tasks.Move(c.Request.Context(), taskID, userID, status, *req.Position, cascade)
+	if err != nil {
+		respondError(c, err, "Failed to move task")
+		return
+	}
+
+	if completing {
+		observeCompletion(task)
+	}
+	for i := range completedSubtasks {
+		subtask := &completedSubtasks[i]
+		observeCompletion(subtask)
+		h.indexer.Index(c.Request.Context(), *subtask)
+		h.publishTaskUpdate(c.Request.Context(), userID, subtask, true)
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskUpdate(c.Request.Context(), userID, task, completing)
+	if status != current.Status {
+		h.rollupParent(c.Request.Context(), userID, task)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task moved successfully",
+		"task":    task,
+	})
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// Create and update responses take the same ?expand= as GET /tasks/:id, so
+// clients get a task's tags and subtasks back without a follow-up request.
+
+// parseMutationExpand reads ?expand= for a create or update. It runs before
+// anything is written so an invalid value leaves the task untouched.
+func parseMutationExpand(c *gin.Context) (expandOptions, bool) {
+	expand, err := parseExpand(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return expand, false
+	}
+	return expand, true
+}
+
+// expandMutated loads the related data requested for a task that was just
+// created or updated, writing an error response and returning false if it
+// can't be read back
+func (h *TaskHandler) expandMutated(c *gin.Context, task *models.Task, expand expandOptions, action string) bool {
+	if err := h.expandTask(c.Request.Context(), task, expand); err != nil {
+		respondError(c, err, "Failed to fetch "+action+" task")
+		return false
+	}
+	return true
+}
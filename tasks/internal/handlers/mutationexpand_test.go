@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutationRejectsUnknownExpand(t *testing.T) {
+	h := &TaskHandler{}
+	create := newRouter(uuid.New(), http.MethodPost, "/tasks", h.CreateTask)
+	update := newRouter(uuid.New(), http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, create, http.MethodPost, "/tasks?expand=owner", map[string]interface{}{"title": "New"})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "expand", decode(t, w)["field"])
+
+	w = serve(t, update, http.MethodPut, "/tasks/"+uuid.NewString()+"?expand=subtasks,owner", map[string]interface{}{"title": "New"})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "expand", decode(t, w)["field"])
+}
+
+func TestMutationResponsesExpandTask(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "expander")
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	create := newRouter(userID, http.MethodPost, "/tasks", h.CreateTask)
+	update := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, create, http.MethodPost, "/tasks?expand=subtasks,tags", map[string]interface{}{"title": "Parent", "tags": []string{"home"}})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	parent := decode(t, w)["task"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"home"}, parent["tags"])
+	assert.Nil(t, parent["subtasks"], "a new task has no subtasks")
+	parentID := parent["id"].(string)
+
+	subtaskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, parent_task_id, title) VALUES ($1, $2, $3, 'Child')", subtaskID, userID, parentID)
+	w = serve(t, update, http.MethodPut, "/tasks/"+subtaskID.String(), map[string]interface{}{"tags": []string{"errand"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(t, update, http.MethodPut, "/tasks/"+parentID+"?expand=subtasks", map[string]interface{}{"title": "Renamed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	updated := decode(t, w)["task"].(map[string]interface{})
+	assert.Equal(t, "Renamed", updated["title"])
+	assert.Equal(t, []interface{}{"home"}, updated["tags"])
+	require.Len(t, updated["subtasks"], 1)
+	subtask := updated["subtasks"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, subtaskID.String(), subtask["id"])
+	assert.Equal(t, []interface{}{"errand"}, subtask["tags"], "subtasks come with their tags")
+
+	w = serve(t, update, http.MethodPut, "/tasks/"+parentID, map[string]interface{}{"title": "Plain"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NotContains(t, decode(t, w)["task"], "subtasks", "subtasks only when asked for")
+}
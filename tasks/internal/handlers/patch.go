@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// mergePatchContentType is the media type of a JSON Merge Patch (RFC 7396)
+const mergePatchContentType = "application/merge-patch+json"
+
+// mergePatch is what a merge patch says beyond an UpdateTaskRequest: the
+// columns it sets to null and the custom fields it merges
+type mergePatch struct {
+	nulls []string
+	// Custom fields to merge into the task's; nil values remove the field
+	metadata map[string]interface{}
+}
+
+// nullableColumns are the fields a merge patch can null out that have no
+// clearing value in UpdateTaskRequest, with their columns
+var nullableColumns = map[string]string{
+	"description":      "description",
+	"due_date":         "due_date",
+	"estimate_minutes": "estimate_minutes",
+	"actual_minutes":   "actual_minutes",
+	"latitude":         "latitude",
+	"longitude":        "longitude",
+}
+
+// clearingValues are the UpdateTaskRequest values that clear a field, used
+// when a merge patch nulls it out
+var clearingValues = map[string]json.RawMessage{
+	"color":      json.RawMessage(`""`),
+	"recurrence": json.RawMessage(`""`),
+	"project_id": json.RawMessage(`""`),
+	"place_name": json.RawMessage(`""`),
+	"tags":       json.RawMessage(`[]`),
+	"metadata":   json.RawMessage(`{}`),
+}
+
+// requiredFields are the fields a task always has, which cannot be nulled
+var requiredFields = []string{"title", "status", "priority", "progress"}
+
+// PatchTask applies a JSON Merge Patch to a task. Fields left out are
+// unchanged, fields set to null are removed and metadata is merged key by
+// key; otherwise it behaves like UpdateTask.
+func (h *TaskHandler) PatchTask(c *gin.Context) {
+	if c.ContentType() != mergePatchContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + mergePatchContentType})
+		return
+	}
+	h.updateTask(c, bindMergePatch)
+}
+
+// bindMergePatch reads a merge patch body into the UpdateTaskRequest that
+// sets its non-null fields
+func bindMergePatch(c *gin.Context) (models.UpdateTaskRequest, *mergePatch, error) {
+	var req models.UpdateTaskRequest
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return req, nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return req, nil, errors.New("A merge patch must be a JSON object")
+	}
+
+	patch := &mergePatch{}
+	// A null replaces all custom fields with none; an object is merged
+	if raw, ok := fields["metadata"]; ok && !isJSONNull(raw) {
+		if err := json.Unmarshal(raw, &patch.metadata); err != nil || patch.metadata == nil {
+			return req, nil, errors.New("metadata must be an object or null")
+		}
+		delete(fields, "metadata")
+	}
+	for _, field := range requiredFields {
+		if isJSONNull(fields[field]) {
+			return req, nil, fmt.Errorf("%s cannot be null", field)
+		}
+	}
+	for field, column := range nullableColumns {
+		if isJSONNull(fields[field]) {
+			patch.nulls = append(patch.nulls, column)
+			delete(fields, field)
+		}
+	}
+	for field, value := range clearingValues {
+		if isJSONNull(fields[field]) {
+			fields[field] = value
+		}
+	}
+	if err := checkNulledTogether(patch.nulls, fields); err != nil {
+		return req, nil, err
+	}
+
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return req, nil, err
+	}
+	if err := json.Unmarshal(rest, &req); err != nil {
+		return req, nil, err
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		return req, nil, err
+	}
+	return req, patch, nil
+}
+
+// checkNulledTogether rejects patches that remove one half of a pair of
+// fields, or remove a field while setting it another way
+func checkNulledTogether(nulls []string, fields map[string]json.RawMessage) error {
+	nulled := make(map[string]bool, len(nulls))
+	for _, column := range nulls {
+		nulled[column] = true
+	}
+	if nulled["latitude"] != nulled["longitude"] {
+		return errors.New("latitude and longitude must be removed together")
+	}
+	if nulled["due_date"] && fields["due_date_text"] != nil && !isJSONNull(fields["due_date_text"]) {
+		return errors.New("Use either due_date or due_date_text, not both")
+	}
+	return nil
+}
+
+// mergeMetadata applies a merge patch to custom fields, removing those
+// patched to nil
+func mergeMetadata(current models.Metadata, patch map[string]interface{}) models.Metadata {
+	merged := make(models.Metadata, len(current)+len(patch))
+	for key, val := range current {
+		merged[key] = val
+	}
+	for key, val := range patch {
+		if val == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = val
+		}
+	}
+	return merged
+}
+
+// isJSONNull reports whether a field was given as an explicit null
+func isJSONNull(raw json.RawMessage) bool {
+	return raw != nil && string(bytes.TrimSpace(raw)) == "null"
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bindPatch runs bindMergePatch on a raw body
+func bindPatch(t *testing.T, body string) (models.UpdateTaskRequest, *mergePatch, error) {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPatch, "/tasks/1", bytes.NewBufferString(body))
+	c.Request.Header.Set("Content-Type", mergePatchContentType)
+	return bindMergePatch(c)
+}
+
+func TestPatchTaskRequiresMergePatchContentType(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodPatch, "/tasks/:id", (&TaskHandler{}).PatchTask)
+	w := serve(t, router, http.MethodPatch, "/tasks/"+uuid.NewString(), map[string]interface{}{"title": "x"})
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+}
+
+func TestMergePatchNullsColumns(t *testing.T) {
+	req, patch, err := bindPatch(t, `{"description": null, "due_date": null, "latitude": null, "longitude": null, "title": "Kept"}`)
+	require.NoError(t, err)
+
+	sort.Strings(patch.nulls)
+	assert.Equal(t, []string{"description", "due_date", "latitude", "longitude"}, patch.nulls)
+	assert.Nil(t, req.Description)
+	assert.Nil(t, req.DueDate)
+	require.NotNil(t, req.Title)
+	assert.Equal(t, "Kept", *req.Title)
+}
+
+func TestMergePatchNullsUseClearingValues(t *testing.T) {
+	req, patch, err := bindPatch(t, `{"color": null, "tags": null, "project_id": null, "metadata": null}`)
+	require.NoError(t, err)
+
+	assert.Empty(t, patch.nulls)
+	require.NotNil(t, req.Color)
+	assert.Equal(t, "", *req.Color)
+	require.NotNil(t, req.Tags)
+	assert.Empty(t, *req.Tags)
+	require.NotNil(t, req.ProjectID)
+	assert.Equal(t, "", *req.ProjectID)
+	require.NotNil(t, req.Metadata, "a null metadata replaces all custom fields")
+	assert.Empty(t, *req.Metadata)
+}
+
+func TestMergePatchLeavesOmittedFields(t *testing.T) {
+	req, patch, err := bindPatch(t, `{"priority": "high"}`)
+	require.NoError(t, err)
+
+	assert.Empty(t, patch.nulls)
+	assert.Nil(t, patch.metadata)
+	assert.Nil(t, req.Color)
+	assert.Nil(t, req.Tags)
+	assert.Nil(t, req.Metadata)
+	require.NotNil(t, req.Priority)
+	assert.Equal(t, "high", *req.Priority)
+}
+
+func TestMergePatchMergesMetadata(t *testing.T) {
+	req, patch, err := bindPatch(t, `{"metadata": {"sprint": "12", "owner": null}}`)
+	require.NoError(t, err)
+	assert.Nil(t, req.Metadata, "an object is merged rather than replacing")
+
+	merged := mergeMetadata(models.Metadata{"owner": "sam", "team": "core"}, patch.metadata)
+	assert.Equal(t, models.Metadata{"sprint": "12", "team": "core"}, merged)
+}
+
+func TestMergePatchRejects(t *testing.T) {
+	for name, body := range map[string]string{
+		"not an object":           `[1, 2]`,
+		"null body":               `null`,
+		"required field nulled":   `{"title": null}`,
+		"status nulled":           `{"status": null}`,
+		"half a location":         `{"latitude": null}`,
+		"due date nulled and set": `{"due_date": null, "due_date_text": "tomorrow"}`,
+		"metadata not an object":  `{"metadata": [1]}`,
+		"invalid title":           `{"title": ""}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := bindPatch(t, body)
+			assert.Error(t, err)
+		})
+	}
+}
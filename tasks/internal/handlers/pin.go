@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// PinTask pins a task so it lists first in the default task order
+func (h *TaskHandler) PinTask(c *gin.Context) {
+	h.setPinned(c, true)
+}
+
+// UnpinTask returns a pinned task to its usual place in the task list
+func (h *TaskHandler) UnpinTask(c *gin.Context) {
+	h.setPinned(c, false)
+}
+
+func (h *TaskHandler) setPinned(c *gin.Context, pinned bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, err := h.tasks.SetPinned(c.Request.Context(), taskID, userID, pinned)
+	if err != nil {
+		respondError(c, err, "Failed to update task")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, userID, task.ID, task)
+
+	message := "Task unpinned successfully"
+	if pinned {
+		message = "Task pinned successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"task":    task,
+	})
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/recurrence"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// maxWeekPlanTasks bounds how many open tasks a weekly plan loads
+const maxWeekPlanTasks = 500
+
+// maxCalendarDays bounds a calendar's range, enough for a quarter. It also
+// caps the occurrences projected per recurring task.
+const maxCalendarDays = 92
+
+// maxCalendarTasks bounds how many tasks a calendar loads
+const maxCalendarTasks = 1000
+
+// GetWeekPlan returns open tasks bucketed by day for the 7 days starting at
+// ?start=YYYY-MM-DD (today by default), plus overdue and undated buckets.
+// Day boundaries follow the caller's timezone.
+func (h *TaskHandler) GetWeekPlan(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	start := startOfDay(time.Now(), loc)
+	if val := c.Query("start"); val != "" {
+		start, err = time.ParseInLocation("2006-01-02", val, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start date. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	end := start.AddDate(0, 0, 7)
+
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	var tasks []models.Task
+	err = h.db.SelectContext(ctx, &tasks, `
+		SELECT * FROM tasks
+		WHERE user_id = $1
+			AND deleted_at IS NULL
+			AND status NOT IN ('completed', 'cancelled')
+			AND (due_date IS NULL OR due_date < $2)
+		ORDER BY due_date ASC NULLS LAST, created_at ASC
+		LIMIT $3
+	`, userID, end.UTC(), maxWeekPlanTasks)
+	if err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch weekly plan")
+		return
+	}
+
+	plan := models.WeekPlan{
+		Start:   start.Format("2006-01-02"),
+		End:     end.AddDate(0, 0, -1).Format("2006-01-02"),
+		Days:    make([]models.DayBucket, 7),
+		Overdue: []models.Task{},
+		Undated: []models.Task{},
+	}
+	for i := range plan.Days {
+		day := start.AddDate(0, 0, i)
+		plan.Days[i] = models.DayBucket{
+			Date:    day.Format("2006-01-02"),
+			Weekday: day.Weekday().String(),
+			Tasks:   []models.Task{},
+		}
+	}
+
+	for _, task := range tasks {
+		switch {
+		case task.DueDate == nil:
+			plan.Undated = append(plan.Undated, task)
+		case task.DueDate.Before(start):
+			plan.Overdue = append(plan.Overdue, task)
+		default:
+			// Count calendar days rather than 24h spans so DST changes don't shift buckets
+			due := startOfDay(*task.DueDate, loc)
+			for i := range plan.Days {
+				if due.Equal(start.AddDate(0, 0, i)) {
+					plan.Days[i].Tasks = append(plan.Days[i].Tasks, task)
+					break
+				}
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"week": plan})
+}
+
+// GetCalendar returns the caller's tasks grouped by due date for the days from
+// ?from= to ?to= (YYYY-MM-DD, inclusive; the current month by default), with
+// the upcoming occurrences of recurring tasks projected from their rules. Day
+// boundaries follow the caller's timezone and the task list filters apply.
+func (h *TaskHandler) GetCalendar(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	today := startOfDay(time.Now(), loc)
+	from := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 1, -1)
+	if val := c.Query("from"); val != "" {
+		if from, err = time.ParseInLocation("2006-01-02", val, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if val := c.Query("to"); val != "" {
+		if to, err = time.ParseInLocation("2006-01-02", val, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date. Use YYYY-MM-DD"})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+	end := to.AddDate(0, 0, 1)
+	days := 0
+	for day := from; day.Before(end); day = day.AddDate(0, 0, 1) {
+		days++
+	}
+	if days > maxCalendarDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("The range must be at most %d days", maxCalendarDays)})
+		return
+	}
+
+	filters, err := parseTaskFilters(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	// One range query loads the tasks due in the range along with the
+	// recurring tasks due before its end, whose occurrences may fall in it
+	w := buildTaskWhere(userID, filters)
+	w.add("due_date < " + w.arg(end.UTC()))
+	w.add("(due_date >= " + w.arg(from.UTC()) +
+		" OR (recurrence IS NOT NULL AND NOT recurrence_paused AND status <> 'cancelled'))")
+	query := "SELECT * FROM tasks" + w.sql() + " ORDER BY due_date ASC, created_at ASC LIMIT " + strconv.Itoa(maxCalendarTasks)
+
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	var tasks []models.Task
+	if err := h.db.SelectContext(ctx, &tasks, query, w.args...); err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch calendar")
+		return
+	}
+
+	calendar := models.Calendar{
+		From: from.Format("2006-01-02"),
+		To:   to.Format("2006-01-02"),
+		Days: make([]models.CalendarDay, days),
+	}
+	index := make(map[string]int, days)
+	for i := range calendar.Days {
+		day := from.AddDate(0, 0, i)
+		date := day.Format("2006-01-02")
+		calendar.Days[i] = models.CalendarDay{
+			Date:        date,
+			Weekday:     day.Weekday().String(),
+			Tasks:       []models.Task{},
+			Occurrences: []models.TaskOccurrence{},
+		}
+		index[date] = i
+	}
+
+	now := time.Now()
+	for _, task := range tasks {
+		if i, ok := index[task.DueDate.In(loc).Format("2006-01-02")]; ok {
+			calendar.Days[i].Tasks = append(calendar.Days[i].Tasks, task)
+		}
+		if task.Recurrence == nil || task.RecurrencePaused || task.Status == "cancelled" {
+			continue
+		}
+		for _, due := range projectOccurrences(task, now, end) {
+			if i, ok := index[due.In(loc).Format("2006-01-02")]; ok {
+				calendar.Days[i].Occurrences = append(calendar.Days[i].Occurrences, models.TaskOccurrence{
+					TaskID:   task.ID,
+					Title:    task.Title,
+					Priority: task.Priority,
+					DueDate:  due,
+				})
+			}
+		}
+	}
+	for _, day := range calendar.Days {
+		slices.SortStableFunc(day.Occurrences, func(a, b models.TaskOccurrence) int {
+			return a.DueDate.Compare(b.DueDate)
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"calendar": calendar})
+}
+
+// projectOccurrences returns the due dates of a recurring task's future
+// occurrences before end, at most maxCalendarDays of them. The first follows
+// the task as if it were completed now, like the recurrence scheduler does.
+func projectOccurrences(task models.Task, now, end time.Time) []time.Time {
+	rule, _, err := recurrence.Parse(*task.Recurrence)
+	if err != nil {
+		return nil
+	}
+
+	var dues []time.Time
+	due := rule.Next(*task.DueDate, now)
+	for due.Before(end) && len(dues) < maxCalendarDays {
+		dues = append(dues, due)
+		due = rule.Next(due, due)
+	}
+	return dues
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWeekPlanRejectsQuery(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodGet, "/tasks/week", (&TaskHandler{}).GetWeekPlan)
+
+	w := serve(t, router, http.MethodGet, "/tasks/week?start=next-monday", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid start date. Use YYYY-MM-DD", decode(t, w)["error"])
+
+	w = serve(t, router, http.MethodGet, "/tasks/week?tz=Mars/Olympus", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// titles lists the titles of the tasks in a decoded bucket
+func titles(bucket interface{}) []string {
+	names := []string{}
+	for _, task := range bucket.([]interface{}) {
+		names = append(names, task.(map[string]interface{})["title"].(string))
+	}
+	return names
+}
+
+// TestGetWeekPlan buckets tasks due around local midnights in a week with a
+// DST change, and checks the overdue and undated buckets
+func TestGetWeekPlan(t *testing.T) {
+	db := testdb.Open(t)
+	userID, other := seedUser(t, db, "planner"), seedUser(t, db, "other")
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	local := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, newYork).UTC()
+	}
+
+	// Clocks go forward on Sunday 8 March 2026 in New York
+	for title, due := range map[string]time.Time{
+		"Saturday":          local(7, 12, 0),
+		"Sunday midnight":   local(8, 0, 0),
+		"Sunday night":      local(8, 23, 30),
+		"Monday":            local(9, 0, 30),
+		"Saturday at 23:59": local(14, 23, 59),
+		"Next week":         local(15, 0, 0),
+	} {
+		mustExec(t, db, "INSERT INTO tasks (user_id, title, due_date) VALUES ($1, $2, $3)", userID, title, due)
+	}
+	mustExec(t, db, "INSERT INTO tasks (user_id, title) VALUES ($1, 'Someday')", userID)
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, status, due_date) VALUES ($1, 'Done', 'completed', $2)", userID, local(10, 9, 0))
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, status, due_date) VALUES ($1, 'Dropped', 'cancelled', $2)", userID, local(10, 9, 0))
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, due_date) VALUES ($1, 'Not mine', $2)", other, local(10, 9, 0))
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/week", h.GetWeekPlan)
+	w := serve(t, router, http.MethodGet, "/tasks/week?start=2026-03-08&tz=America/New_York", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	week := decode(t, w)["week"].(map[string]interface{})
+	assert.Equal(t, "2026-03-08", week["start"])
+	assert.Equal(t, "2026-03-14", week["end"])
+	assert.Equal(t, []string{"Saturday"}, titles(week["overdue"]))
+	assert.Equal(t, []string{"Someday"}, titles(week["undated"]))
+
+	days := week["days"].([]interface{})
+	require.Len(t, days, 7)
+	want := [][]string{
+		{"Sunday midnight", "Sunday night"},
+		{"Monday"},
+		{}, {}, {}, {},
+		{"Saturday at 23:59"},
+	}
+	for i, day := range days {
+		bucket := day.(map[string]interface{})
+		assert.Equal(t, time.Date(2026, time.March, 8+i, 0, 0, 0, 0, time.UTC).Format("2006-01-02"), bucket["date"])
+		assert.Equal(t, want[i], titles(bucket["tasks"]), bucket["date"])
+	}
+	assert.Equal(t, "Sunday", days[0].(map[string]interface{})["weekday"])
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressOutOfRangeIsRejected(t *testing.T) {
+	h := &TaskHandler{}
+	update := newRouter(uuid.New(), http.MethodPut, "/tasks/:id", h.UpdateTask)
+	list := newRouter(uuid.New(), http.MethodGet, "/tasks", h.GetTasks)
+
+	for _, progress := range []int{-1, 101} {
+		w := serve(t, update, http.MethodPut, "/tasks/"+uuid.NewString(), map[string]interface{}{"progress": progress})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "Invalid progress. Must be between 0 and 100", decode(t, w)["error"])
+	}
+
+	for _, query := range []string{"min_progress=101", "min_progress=-5", "min_progress=half"} {
+		w := serve(t, list, http.MethodGet, "/tasks?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestUpdateTaskSetsProgress(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "tracker")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Halfway')", taskID, userID)
+	mustExec(t, db, "INSERT INTO tasks (user_id, title) VALUES ($1, 'Not started')", userID)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+
+	update := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+	w := serve(t, update, http.MethodPut, "/tasks/"+taskID.String(), map[string]interface{}{"progress": 40})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(40), decode(t, w)["task"].(map[string]interface{})["progress"])
+
+	list := newRouter(userID, http.MethodGet, "/tasks", h.GetTasks)
+	w = serve(t, list, http.MethodGet, "/tasks?min_progress=40", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	tasks := decode(t, w)["tasks"].([]interface{})
+	require.Len(t, tasks, 1)
+	assert.Equal(t, taskID.String(), tasks[0].(map[string]interface{})["id"])
+}
+
+func TestProgressDerivedFromSubtasks(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "tracker")
+	parent, first, second := uuid.New(), uuid.New(), uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Parent')", parent, userID)
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, parent_task_id, title) VALUES ($1, $2, $3, 'First')", first, userID, parent)
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, parent_task_id, title) VALUES ($1, $2, $3, 'Second')", second, userID, parent)
+	mustExec(t, db, "INSERT INTO tasks (user_id, parent_task_id, title, status) VALUES ($1, $2, 'Dropped', 'cancelled')", userID, parent)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	progress := func() int {
+		var progress int
+		require.NoError(t, db.Get(&progress, "SELECT progress FROM tasks WHERE id = $1", parent))
+		return progress
+	}
+
+	w := serve(t, router, http.MethodPut, "/tasks/"+first.String(), map[string]interface{}{"status": "completed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 50, progress(), "cancelled subtasks don't count")
+
+	w = serve(t, router, http.MethodPut, "/tasks/"+second.String(), map[string]interface{}{"status": "completed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 100, progress())
+
+	w = serve(t, router, http.MethodPut, "/tasks/"+parent.String(), map[string]interface{}{"progress": 10})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 100, progress(), "a parent's progress can't be set directly")
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// maxProjectNameLength matches the projects.name column
+const maxProjectNameLength = 100
+
+// normalizeProjectName trims surrounding whitespace and rejects names that
+// are blank afterwards or too long for the column
+func normalizeProjectName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxProjectNameLength {
+		return "", errors.New("name must be at most 100 characters")
+	}
+	return name, nil
+}
+
+// CreateProject creates a new project
+func (h *TaskHandler) CreateProject(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.CreateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, err := normalizeProjectName(req.Name)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project := models.Project{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Name:        name,
+		Description: req.Description,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := h.projects.Create(c.Request.Context(), &project); err != nil {
+		respondResourceError(c, err, "Project", "Failed to create project")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Project created successfully",
+		"project": project,
+	})
+}
+
+// GetProjects lists the caller's projects by name with their task counts
+func (h *TaskHandler) GetProjects(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	projects, total, err := h.projects.List(c.Request.Context(), userID, limit, (page-1)*limit)
+	if err != nil {
+		respondResourceError(c, err, "Project", "Failed to fetch projects")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"projects": projects,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GetProject retrieves a single project with its task counts
+func (h *TaskHandler) GetProject(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	project, err := h.projects.GetByID(c.Request.Context(), projectID, userID)
+	if err != nil {
+		respondResourceError(c, err, "Project", "Failed to fetch project")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// GetProjectSummary returns a project's rollup: task counts, completion
+// percentage, overdue tasks and the earliest open due date
+func (h *TaskHandler) GetProjectSummary(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	summary, err := h.projects.Summary(c.Request.Context(), projectID, userID)
+	if err != nil {
+		respondResourceError(c, err, "Project", "Failed to fetch project summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"summary": summary})
+}
+
+// UpdateProject renames a project or changes its description
+func (h *TaskHandler) UpdateProject(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req models.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		name, err := normalizeProjectName(*req.Name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	if err := h.projects.Update(c.Request.Context(), projectID, userID, updates); err != nil {
+		respondResourceError(c, err, "Project", "Failed to update project")
+		return
+	}
+	project, err := h.projects.GetByID(c.Request.Context(), projectID, userID)
+	if err != nil {
+		respondResourceError(c, err, "Project", "Failed to fetch updated project")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Project updated successfully",
+		"project": project,
+	})
+}
+
+// DeleteProject deletes a project. Its tasks are kept without a project.
+func (h *TaskHandler) DeleteProject(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	if err := h.projects.Delete(c.Request.Context(), projectID, userID); err != nil {
+		respondResourceError(c, err, "Project", "Failed to delete project")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// checkProject verifies that a task's project, if any, belongs to userID,
+// writing the error response and returning false if it does not
+func (h *TaskHandler) checkProject(c *gin.Context, projectID *uuid.UUID, userID uuid.UUID) bool {
+	if projectID == nil {
+		return true
+	}
+	exists, err := h.projects.Exists(c.Request.Context(), *projectID, userID)
+	if err != nil {
+		respondResourceError(c, err, "Project", "Failed to look up project")
+		return false
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return false
+	}
+	return true
+}
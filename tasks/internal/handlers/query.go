@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+const (
+	defaultPageLimit = 10
+	defaultMaxLimit  = 100
+)
+
+// queryParamError describes a malformed query parameter
+type queryParamError struct {
+	field   string
+	message string
+}
+
+func (e *queryParamError) Error() string {
+	return e.message
+}
+
+// respondQueryError writes a 400 naming the offending query parameter
+func respondQueryError(c *gin.Context, err error) {
+	body := gin.H{"error": err.Error()}
+	if qErr, ok := err.(*queryParamError); ok {
+		body["field"] = qErr.field
+	}
+	c.JSON(http.StatusBadRequest, body)
+}
+
+// queryInt parses an optional integer query parameter within [min, max]
+func queryInt(c *gin.Context, name string, fallback, min, max int) (int, error) {
+	val := c.Query(name)
+	if val == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil || n < min || n > max {
+		return 0, &queryParamError{field: name, message: fmt.Sprintf("%s must be an integer between %d and %d", name, min, max)}
+	}
+	return n, nil
+}
+
+// queryBool parses an optional boolean query parameter
+func queryBool(c *gin.Context, name string) (bool, error) {
+	val := c.Query(name)
+	if val == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, &queryParamError{field: name, message: name + " must be true or false"}
+	}
+	return b, nil
+}
+
+// queryTime parses an optional date (YYYY-MM-DD, midnight in loc) or RFC 3339
+// timestamp query parameter
+func queryTime(c *gin.Context, name string, loc *time.Location) (*time.Time, error) {
+	val := c.Query(name)
+	if val == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", val, loc)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, val)
+	}
+	if err != nil {
+		return nil, &queryParamError{field: name, message: name + " must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"}
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// parsePagination reads ?page= and ?limit=, capping limit at PAGINATION_MAX_LIMIT
+func parsePagination(c *gin.Context) (page, limit int, err error) {
+	if val := c.Query("page"); val != "" {
+		// Bounded so the offset computed from it cannot overflow
+		page, err = strconv.Atoi(val)
+		if err != nil || page < 1 || page > math.MaxInt32 {
+			return 0, 0, &queryParamError{field: "page", message: "page must be a positive integer"}
+		}
+	} else {
+		page = 1
+	}
+	limit, err = queryInt(c, "limit", defaultPageLimit, 1, config.Int("PAGINATION_MAX_LIMIT", defaultMaxLimit))
+	if err != nil {
+		return 0, 0, err
+	}
+	return page, limit, nil
+}
+
+// parseTaskFilters reads and validates the task list query parameters.
+// Sort and order are validated when the ORDER BY clause is built.
+func parseTaskFilters(c *gin.Context) (models.TaskFilters, error) {
+	filters := models.TaskFilters{
+		Status:   c.Query("status"),
+		Priority: c.Query("priority"),
+		Origin:   c.Query("origin"),
+		Scope:    c.Query("scope"),
+		Sort:     c.Query("sort"),
+		Order:    c.Query("order"),
+	}
+
+	filters.Query = strings.TrimSpace(c.Query("q"))
+	if utf8.RuneCountInString(filters.Query) > maxSearchQueryLength {
+		return filters, &queryParamError{field: "q", message: fmt.Sprintf("q must be at most %d characters", maxSearchQueryLength)}
+	}
+
+	// Each takes a comma-separated list, negated with a leading "!"
+	if _, ok := parseValueFilter(filters.Status, isValidStatus); !ok && filters.Status != "" {
+		return filters, &queryParamError{field: "status", message: "status must be a comma-separated list of: pending, in_progress, completed, cancelled, optionally prefixed with ! to exclude them"}
+	}
+	if _, ok := parseValueFilter(filters.Priority, isValidPriority); !ok && filters.Priority != "" {
+		return filters, &queryParamError{field: "priority", message: "priority must be a comma-separated list of: low, medium, high, urgent, optionally prefixed with ! to exclude them"}
+	}
+	if _, ok := parseValueFilter(filters.Origin, isValidOrigin); !ok && filters.Origin != "" {
+		return filters, &queryParamError{field: "origin", message: "origin must be a comma-separated list of: api, import, recurring, template, optionally prefixed with ! to exclude them"}
+	}
+
+	if filters.Scope != "" && filters.Scope != ScopeOwn && filters.Scope != ScopeShared {
+		return filters, &queryParamError{field: "scope", message: "scope must be one of: own, shared"}
+	}
+
+	if val := c.Query("project_id"); val != "" {
+		projectID, err := uuid.Parse(val)
+		if err != nil {
+			return filters, &queryParamError{field: "project_id", message: "project_id must be a UUID"}
+		}
+		filters.ProjectID = &projectID
+	}
+
+	if val := c.Query("tags"); val != "" {
+		tags, err := normalizeTags(strings.Split(val, ","))
+		if err != nil {
+			return filters, &queryParamError{field: "tags", message: err.Error()}
+		}
+		filters.Tags = tags
+	}
+
+	metadata, err := parseMetadataFilters(c)
+	if err != nil {
+		return filters, err
+	}
+	filters.Metadata = metadata
+
+	if filters.Archived, err = queryBool(c, "archived"); err != nil {
+		return filters, err
+	}
+	if filters.Overdue, err = queryBool(c, "overdue"); err != nil {
+		return filters, err
+	}
+	if filters.Pinned, err = queryBool(c, "pinned"); err != nil {
+		return filters, err
+	}
+	if filters.Snoozed, err = queryBool(c, "snoozed"); err != nil {
+		return filters, err
+	}
+
+	if err := parseDateRanges(c, &filters); err != nil {
+		return filters, err
+	}
+
+	if c.Query("min_progress") != "" {
+		minProgress, err := queryInt(c, "min_progress", 0, 0, 100)
+		if err != nil {
+			return filters, err
+		}
+		filters.MinProgress = &minProgress
+	}
+	if c.Query("min_estimate") != "" {
+		minEstimate, err := queryInt(c, "min_estimate", 0, 0, maxEffortMinutes)
+		if err != nil {
+			return filters, err
+		}
+		filters.MinEstimate = &minEstimate
+	}
+	if c.Query("max_estimate") != "" {
+		maxEstimate, err := queryInt(c, "max_estimate", 0, 0, maxEffortMinutes)
+		if err != nil {
+			return filters, err
+		}
+		if filters.MinEstimate != nil && maxEstimate < *filters.MinEstimate {
+			return filters, &queryParamError{field: "max_estimate", message: "max_estimate must not be less than min_estimate"}
+		}
+		filters.MaxEstimate = &maxEstimate
+	}
+	if err := parseNear(c, &filters); err != nil {
+		return filters, err
+	}
+
+	filters.Page, filters.Limit, err = parsePagination(c)
+	if err != nil {
+		return filters, err
+	}
+	filters.Cursor, err = parseCursor(c)
+	return filters, err
+}
+
+// parseDateRanges reads the due_after/due_before and created_after/
+// created_before filters. Dates are midnight in the caller's timezone.
+func parseDateRanges(c *gin.Context, filters *models.TaskFilters) error {
+	loc, err := userLocation(c)
+	if err != nil {
+		return &queryParamError{field: "tz", message: err.Error()}
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{
+		{"due_after", &filters.DueAfter},
+		{"due_before", &filters.DueBefore},
+		{"created_after", &filters.CreatedAfter},
+		{"created_before", &filters.CreatedBefore},
+	} {
+		if *param.dest, err = queryTime(c, param.name, loc); err != nil {
+			return err
+		}
+	}
+
+	if filters.DueAfter != nil && filters.DueBefore != nil && !filters.DueAfter.Before(*filters.DueBefore) {
+		return &queryParamError{field: "due_after", message: "due_after must be earlier than due_before"}
+	}
+	if filters.CreatedAfter != nil && filters.CreatedBefore != nil && !filters.CreatedAfter.Before(*filters.CreatedBefore) {
+		return &queryParamError{field: "created_after", message: "created_after must be earlier than created_before"}
+	}
+	return nil
+}
+
+// parseDueOnFilters reads and validates the due-on-date query parameters
+func parseDueOnFilters(c *gin.Context) (models.DueOnFilters, error) {
+	var filters models.DueOnFilters
+	var err error
+	if filters.ExcludeCompleted, err = queryBool(c, "exclude_completed"); err != nil {
+		return filters, err
+	}
+	filters.Page, filters.Limit, err = parsePagination(c)
+	return filters, err
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filtersFor parses the task list filters of a request to target
+func filtersFor(target string) error {
+	_, err := parseTaskFilters(testContext(target))
+	return err
+}
+
+func TestParseTaskFiltersRejectsEachParameter(t *testing.T) {
+	for query, want := range map[string]struct{ field, message string }{
+		"page=abc":                      {"page", "page must be a positive integer"},
+		"page=0":                        {"page", "page must be a positive integer"},
+		"page=-2":                       {"page", "page must be a positive integer"},
+		"page=99999999999":              {"page", "page must be a positive integer"},
+		"limit=ten":                     {"limit", "limit must be an integer between 1 and 100"},
+		"limit=0":                       {"limit", "limit must be an integer between 1 and 100"},
+		"limit=101":                     {"limit", "limit must be an integer between 1 and 100"},
+		"q=" + strings.Repeat("a", 201): {"q", "q must be at most 200 characters"},
+		"status=sleeping":               {"status", "status must be a comma-separated list of: pending, in_progress, completed, cancelled, optionally prefixed with ! to exclude them"},
+		"priority=someday":              {"priority", "priority must be a comma-separated list of: low, medium, high, urgent, optionally prefixed with ! to exclude them"},
+		"origin=email":                  {"origin", "origin must be a comma-separated list of: api, import, recurring, template, optionally prefixed with ! to exclude them"},
+		"scope=everyone":                {"scope", "scope must be one of: own, shared"},
+		"project_id=42":                 {"project_id", "project_id must be a UUID"},
+		"archived=yes-please":           {"archived", "archived must be true or false"},
+		"overdue=2":                     {"overdue", "overdue must be true or false"},
+		"pinned=maybe":                  {"pinned", "pinned must be true or false"},
+		"snoozed=sometimes":             {"snoozed", "snoozed must be true or false"},
+		"tz=Mars/Olympus":               {"tz", `invalid timezone "Mars/Olympus"`},
+		"due_after=tomorrow":            {"due_after", "due_after must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"},
+		"due_before=03/01/2026":         {"due_before", "due_before must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"},
+		"created_after=yesterday":       {"created_after", "created_after must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"},
+		"created_before=now":            {"created_before", "created_before must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"},
+		"due_after=2026-03-02&due_before=2026-03-01":         {"due_after", "due_after must be earlier than due_before"},
+		"created_after=2026-03-01&created_before=2026-03-01": {"created_after", "created_after must be earlier than created_before"},
+		"min_progress=101":                {"min_progress", "min_progress must be an integer between 0 and 100"},
+		"min_estimate=-5":                 {"min_estimate", "min_estimate must be an integer between 0 and 525600"},
+		"max_estimate=lots":               {"max_estimate", "max_estimate must be an integer between 0 and 525600"},
+		"min_estimate=60&max_estimate=30": {"max_estimate", "max_estimate must not be less than min_estimate"},
+		"near=north":                      {"near", "near must be <latitude>,<longitude>"},
+		"radius_km=5":                     {"radius_km", "radius_km requires near"},
+		"meta.Bad-Key=x":                  {"meta.Bad-Key", "metadata filters must be meta.<key> with a key of lowercase letters, digits and underscores"},
+		"cursor=not-a-cursor":             {"cursor", "cursor is invalid; use a next_cursor or prev_cursor from a previous response"},
+	} {
+		t.Run(query, func(t *testing.T) {
+			err := filtersFor("/tasks?" + query)
+			require.Error(t, err)
+			qErr, ok := err.(*queryParamError)
+			require.True(t, ok, "%T is not a queryParamError", err)
+			assert.Equal(t, want.field, qErr.field)
+			assert.Equal(t, want.message, qErr.message)
+		})
+	}
+}
+
+func TestParseTaskFiltersAcceptsValidParameters(t *testing.T) {
+	assert.NoError(t, filtersFor("/tasks?page=2&limit=100&status=!completed,cancelled&priority=high&origin=api&scope=shared"+
+		"&project_id="+uuid.NewString()+"&archived=true&overdue=false&due_after=2026-03-01&due_before=2026-03-02T10:00:00Z"+
+		"&min_progress=0&min_estimate=30&max_estimate=30&tz=Europe/Berlin"))
+}
+
+func TestPaginationRespectsMaxLimit(t *testing.T) {
+	t.Setenv("PAGINATION_MAX_LIMIT", "500")
+	assert.NoError(t, filtersFor("/tasks?limit=500"))
+	err := filtersFor("/tasks?limit=501")
+	assert.EqualError(t, err, "limit must be an integer between 1 and 500")
+}
+
+func TestGetTasksReportsMalformedParameter(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodGet, "/tasks", (&TaskHandler{}).GetTasks)
+
+	w := serve(t, router, http.MethodGet, "/tasks?page=abc", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]interface{}{"error": "page must be a positive integer", "field": "page"}, decode(t, w))
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/recurrence"
+)
+
+// normalizeRecurrence validates a recurrence rule and returns its canonical form
+func normalizeRecurrence(rule string) (string, error) {
+	_, normalized, err := recurrence.Parse(rule)
+	return normalized, err
+}
+
+// PauseRecurrence stops a recurring task from creating new occurrences until
+// it is resumed
+func (h *TaskHandler) PauseRecurrence(c *gin.Context) {
+	h.setRecurrencePaused(c, true)
+}
+
+// ResumeRecurrence lets a paused recurring task create occurrences again. If
+// the task was completed while paused, its next occurrence follows shortly.
+func (h *TaskHandler) ResumeRecurrence(c *gin.Context) {
+	h.setRecurrencePaused(c, false)
+}
+
+func (h *TaskHandler) setRecurrencePaused(c *gin.Context, paused bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	current, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to update recurrence")
+		return
+	}
+	if current.Recurrence == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task does not recur"})
+		return
+	}
+
+	message := "Recurrence resumed"
+	if paused {
+		message = "Recurrence paused"
+	}
+	if current.RecurrencePaused == paused {
+		c.JSON(http.StatusOK, gin.H{"message": message, "task": current})
+		return
+	}
+
+	task, err := h.tasks.SetRecurrencePaused(c.Request.Context(), taskID, userID, paused)
+	if err != nil {
+		respondError(c, err, "Failed to update recurrence")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, userID, task.ID, task)
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "task": task})
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// CreateReminder schedules a reminder for a task, either at remind_at or a
+// duration before its due date
+func (h *TaskHandler) CreateReminder(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.CreateReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.RemindAt == nil) == (req.Before == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide exactly one of remind_at or before"})
+		return
+	}
+
+	channels, err := normalizeChannels(req.Channels)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "channels"})
+		return
+	}
+
+	task, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to create reminder")
+		return
+	}
+
+	var remindAt time.Time
+	if req.RemindAt != nil {
+		remindAt = *req.RemindAt
+	} else {
+		before, err := time.ParseDuration(*req.Before)
+		if err != nil || before < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before. Must be a duration such as 30m or 24h", "field": "before"})
+			return
+		}
+		if task.DueDate == nil {
+			c.JSON(http.StatusConflict, gin.H{"error": "Task has no due date to remind before"})
+			return
+		}
+		remindAt = task.DueDate.Add(-before)
+	}
+	if !remindAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Reminder time must be in the future", "remind_at": remindAt})
+		return
+	}
+
+	reminder := models.TaskReminder{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		RemindAt:  remindAt,
+		Channels:  channels,
+		CreatedAt: time.Now(),
+	}
+	if err := h.reminders.Create(c.Request.Context(), &reminder); err != nil {
+		respondResourceError(c, err, "Reminder", "Failed to create reminder")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Reminder scheduled successfully",
+		"reminder": reminder,
+	})
+}
+
+// GetReminders lists a task's reminders, including ones that already fired
+func (h *TaskHandler) GetReminders(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to fetch reminders")
+		return
+	}
+
+	reminders, err := h.reminders.List(c.Request.Context(), taskID)
+	if err != nil {
+		respondResourceError(c, err, "Reminder", "Failed to fetch reminders")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reminders": reminders})
+}
+
+// DeleteReminder cancels a reminder
+func (h *TaskHandler) DeleteReminder(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	reminderID, err := uuid.Parse(c.Param("reminderId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reminder ID"})
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to delete reminder")
+		return
+	}
+
+	if err := h.reminders.Delete(c.Request.Context(), reminderID, taskID); err != nil {
+		respondResourceError(c, err, "Reminder", "Failed to delete reminder")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder deleted successfully"})
+}
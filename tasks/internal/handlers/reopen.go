@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// ReopenTask moves a completed or cancelled task back to pending and
+// optionally reschedules it in the same call
+func (h *TaskHandler) ReopenTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// The body is optional
+	var req models.ReopenTaskRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if req.DueDate != nil && !req.DueDate.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "due_date must be in the future"})
+		return
+	}
+
+	ctx, err := deadlineContext(c.Request.Context(), userID, req.DueDate != nil, req.DueDateReason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.tasks.Reopen(ctx, taskID, userID, req.DueDate)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only completed or cancelled tasks can be reopened"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to reopen task")
+		return
+	}
+
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskReopened, userID, task.ID, task)
+	h.rollupParent(c.Request.Context(), userID, task)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task reopened successfully",
+		"task":    task,
+	})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReopenTaskRejectsRequest(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodPost, "/tasks/:id/reopen", (&TaskHandler{}).ReopenTask)
+
+	w := serve(t, router, http.MethodPost, "/tasks/not-a-uuid/reopen", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(t, router, http.MethodPost, "/tasks/"+uuid.NewString()+"/reopen", map[string]interface{}{"due_date": time.Now().Add(-time.Hour)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "due_date must be in the future", decode(t, w)["error"])
+
+	w = serve(t, router, http.MethodPost, "/tasks/"+uuid.NewString()+"/reopen", map[string]interface{}{"due_date_reason": "later"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "due_date_reason requires a new due date", decode(t, w)["error"])
+}
+
+// reopenFixture seeds a completed task due yesterday and routes reopen
+// requests for its owner
+func reopenFixture(t *testing.T) (db *database.DB, router *gin.Engine, events *recordedEvents, userID, taskID uuid.UUID) {
+	db = testdb.Open(t)
+	userID = seedUser(t, db, "reopener")
+	taskID = uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title, status, completed_at, due_date) VALUES ($1, $2, 'Done', 'completed', NOW(), NOW() - INTERVAL '1 day')", taskID, userID)
+	events = &recordedEvents{}
+	h := NewTaskHandler(db, search.NoopIndexer{}, events, nil)
+	router = newRouter(userID, http.MethodPost, "/tasks/:id/reopen", h.ReopenTask)
+	return db, router, events, userID, taskID
+}
+
+// statusChange is a status change in task history, as old>new, with the
+// acting user
+type statusChange struct {
+	Change string     `db:"change"`
+	Actor  *uuid.UUID `db:"user_id"`
+}
+
+// statusHistory lists the status changes recorded for a task
+func statusHistory(t *testing.T, db *database.DB, taskID uuid.UUID) []statusChange {
+	t.Helper()
+	var history []statusChange
+	require.NoError(t, db.Select(&history, "SELECT old_value || '>' || new_value AS change, user_id FROM task_history WHERE task_id = $1 AND field = 'status' ORDER BY created_at", taskID))
+	return history
+}
+
+func TestReopenTaskWithoutDate(t *testing.T) {
+	db, router, events, userID, taskID := reopenFixture(t)
+	var before models.Task
+	require.NoError(t, db.Get(&before, "SELECT * FROM tasks WHERE id = $1", taskID))
+
+	w := serve(t, router, http.MethodPost, "/tasks/"+taskID.String()+"/reopen", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	task := decode(t, w)["task"].(map[string]interface{})
+	assert.Equal(t, "pending", task["status"])
+	assert.Equal(t, before.DueDate.Format(time.RFC3339Nano), task["due_date"], "the due date is kept")
+
+	history := statusHistory(t, db, taskID)
+	require.Len(t, history, 1)
+	assert.Equal(t, "completed>pending", history[0].Change)
+	assert.Equal(t, &userID, history[0].Actor)
+
+	var deadlineChanges int
+	require.NoError(t, db.Get(&deadlineChanges, "SELECT COUNT(*) FROM deadline_changes WHERE task_id = $1", taskID))
+	assert.Zero(t, deadlineChanges)
+	assert.Equal(t, []string{models.EventTaskReopened}, events.types())
+
+	// A task that is already open can't be reopened
+	w = serve(t, router, http.MethodPost, "/tasks/"+taskID.String()+"/reopen", nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = serve(t, router, http.MethodPost, "/tasks/"+uuid.NewString()+"/reopen", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Len(t, events.types(), 1)
+}
+
+func TestReopenTaskWithNewDate(t *testing.T) {
+	db, router, events, userID, taskID := reopenFixture(t)
+	due := time.Now().Add(48 * time.Hour).UTC().Truncate(time.Second)
+
+	w := serve(t, router, http.MethodPost, "/tasks/"+taskID.String()+"/reopen", map[string]interface{}{
+		"due_date":        due,
+		"due_date_reason": "Picked back up",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	task := decode(t, w)["task"].(map[string]interface{})
+	assert.Equal(t, "pending", task["status"])
+	assert.Equal(t, due.Format(time.RFC3339), task["due_date"])
+
+	history := statusHistory(t, db, taskID)
+	require.Len(t, history, 1)
+	assert.Equal(t, "completed>pending", history[0].Change)
+	assert.Equal(t, &userID, history[0].Actor)
+
+	var change struct {
+		Actor  uuid.UUID `db:"user_id"`
+		Due    time.Time `db:"new_due_date"`
+		Reason string    `db:"reason"`
+	}
+	require.NoError(t, db.Get(&change, "SELECT user_id, new_due_date, reason FROM deadline_changes WHERE task_id = $1", taskID))
+	assert.Equal(t, userID, change.Actor)
+	assert.True(t, due.Equal(change.Due))
+	assert.Equal(t, "Picked back up", change.Reason)
+
+	assert.Equal(t, []string{models.EventTaskReopened}, events.types(), "one event for both changes")
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// maxSearchQueryLength bounds ?q= so tsquery parsing stays cheap
+const maxSearchQueryLength = 200
+
+// taskSearchVector is the weighted document searched by ?q=. It must match
+// the idx_tasks_search expression in init.sql exactly for the index to apply.
+const taskSearchVector = "(setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', coalesce(description, '')), 'B'))"
+
+// searchHeadlineOptions marks matched words and keeps description
+// highlights to a couple of short fragments
+const (
+	titleHeadlineOptions       = "StartSel=<mark>, StopSel=</mark>, HighlightAll=true"
+	descriptionHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+)
+
+// searchCondition matches tasks against the tsquery in placeholder
+func searchCondition(placeholder string) string {
+	return taskSearchVector + " @@ websearch_to_tsquery('english', " + placeholder + ")"
+}
+
+// searchColumns selects the rank and highlights for the tsquery in placeholder
+func searchColumns(placeholder string) string {
+	query := "websearch_to_tsquery('english', " + placeholder + ")"
+	return "ts_rank(" + taskSearchVector + ", " + query + ") AS search_rank, " +
+		"ts_headline('english', title, " + query + ", '" + titleHeadlineOptions + "') AS title_highlight, " +
+		"CASE WHEN description IS NULL THEN NULL ELSE ts_headline('english', description, " + query + ", '" + descriptionHeadlineOptions + "') END AS description_highlight"
+}
+
+// searchRow is a task scanned with its search rank and highlights
+type searchRow struct {
+	models.Task
+	SearchRank           float64 `db:"search_rank"`
+	TitleHighlight       string  `db:"title_highlight"`
+	DescriptionHighlight *string `db:"description_highlight"`
+}
+
+// searchResults attaches each row's match to its task
+func searchResults(rows []searchRow) []models.Task {
+	tasks := make([]models.Task, len(rows))
+	for i, row := range rows {
+		tasks[i] = row.Task
+		tasks[i].Match = &models.SearchMatch{
+			Rank:        row.SearchRank,
+			Title:       row.TitleHighlight,
+			Description: row.DescriptionHighlight,
+		}
+	}
+	return tasks
+}
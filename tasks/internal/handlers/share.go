@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// shareAudience keeps share tokens from being mistaken for other JWTs
+const shareAudience = "task-share"
+
+const (
+	defaultShareTTL    = 24 * time.Hour
+	defaultShareMaxTTL = 7 * 24 * time.Hour
+)
+
+// shareClaims identifies the shared task and the owner who shared it
+type shareClaims struct {
+	TaskID uuid.UUID `json:"taskId"`
+	jwt.RegisteredClaims
+}
+
+// shareSecret signs share tokens, falling back to the auth JWT secret
+func shareSecret() []byte {
+	return []byte(config.String("SHARE_TOKEN_SECRET", config.String("JWT_SECRET", "")))
+}
+
+// ShareTask issues a signed, time-limited token granting read-only access to
+// a task. ?ttl= (e.g. 2h) overrides SHARE_TOKEN_TTL up to SHARE_TOKEN_MAX_TTL.
+func (h *TaskHandler) ShareTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	ttl := config.Duration("SHARE_TOKEN_TTL", defaultShareTTL)
+	maxTTL := config.Duration("SHARE_TOKEN_MAX_TTL", defaultShareMaxTTL)
+	if val := c.Query("ttl"); val != "" {
+		ttl, err = time.ParseDuration(val)
+		if err != nil || ttl <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ttl. Use a positive duration such as 30m or 24h"})
+			return
+		}
+	}
+	if ttl > maxTTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("ttl must be at most %s", maxTTL)})
+		return
+	}
+
+	if _, err := h.tasks.GetByID(c.Request.Context(), taskID, userID); err != nil {
+		respondError(c, err, "Failed to share task")
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := shareClaims{
+		TaskID: taskID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{shareAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(shareSecret())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share link"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"path":       "/api/v1/tasks/shared/" + token,
+		"expires_at": expiresAt.UTC(),
+	})
+}
+
+// GetSharedTask returns a read-only view of a task from a share token. No
+// authentication is required. Tokens stop working once they expire or the
+// task changes owner.
+func (h *TaskHandler) GetSharedTask(c *gin.Context) {
+	var claims shareClaims
+	_, err := jwt.ParseWithClaims(c.Param("token"), &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return shareSecret(), nil
+	}, jwt.WithAudience(shareAudience), jwt.WithExpirationRequired())
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		c.JSON(http.StatusGone, gin.H{"error": "Share link has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid share link"})
+		return
+	}
+
+	ownerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid share link"})
+		return
+	}
+
+	task, err := h.tasks.GetByID(c.Request.Context(), claims.TaskID, ownerID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Shared task no longer exists"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to fetch shared task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"task":       models.NewSharedTask(*task),
+		"expires_at": claims.ExpiresAt.UTC(),
+	})
+}
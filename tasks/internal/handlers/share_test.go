@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signToken signs claims with HS256 and secret
+func signToken(t *testing.T, claims jwt.Claims, secret string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+// shareToken builds share claims for taskID owned by ownerID expiring at expiresAt
+func shareToken(taskID, ownerID uuid.UUID, audience string, expiresAt time.Time) shareClaims {
+	return shareClaims{
+		TaskID: taskID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   ownerID.String(),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+}
+
+// TestGetSharedTaskRejectsToken checks tokens that aren't valid share links
+// are refused before anything is loaded. Share and calendar tokens are
+// signed with JWT_SECRET here, as they are by default.
+func TestGetSharedTaskRejectsToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "shared-secret")
+	router := newRouter(uuid.New(), http.MethodGet, "/tasks/shared/:token", (&TaskHandler{}).GetSharedTask)
+	taskID, ownerID := uuid.New(), uuid.New()
+	valid := signToken(t, shareToken(taskID, ownerID, shareAudience, time.Now().Add(time.Hour)), "shared-secret")
+
+	for name, tc := range map[string]struct {
+		token  string
+		status int
+		error  string
+	}{
+		"calendar token": {signToken(t, jwt.RegisteredClaims{
+			ID: uuid.NewString(), Subject: ownerID.String(), Audience: jwt.ClaimStrings{calendarAudience},
+		}, "shared-secret"), http.StatusUnauthorized, "Invalid share link"},
+		"session token": {signToken(t, jwt.MapClaims{
+			"userId": ownerID.String(), "exp": time.Now().Add(time.Hour).Unix(),
+		}, "shared-secret"), http.StatusUnauthorized, "Invalid share link"},
+		"calendar audience with share claims": {signToken(t, shareToken(taskID, ownerID, calendarAudience, time.Now().Add(time.Hour)), "shared-secret"),
+			http.StatusUnauthorized, "Invalid share link"},
+		"without expiry": {signToken(t, shareClaims{TaskID: taskID, RegisteredClaims: jwt.RegisteredClaims{
+			Subject: ownerID.String(), Audience: jwt.ClaimStrings{shareAudience},
+		}}, "shared-secret"), http.StatusUnauthorized, "Invalid share link"},
+		"expired": {signToken(t, shareToken(taskID, ownerID, shareAudience, time.Now().Add(-time.Minute)), "shared-secret"),
+			http.StatusGone, "Share link has expired"},
+		"wrong secret": {signToken(t, shareToken(taskID, ownerID, shareAudience, time.Now().Add(time.Hour)), "other-secret"),
+			http.StatusUnauthorized, "Invalid share link"},
+		"tampered": {valid[:strings.LastIndex(valid, ".")] + ".c2lnbmF0dXJl", http.StatusUnauthorized, "Invalid share link"},
+		"bad subject": {signToken(t, shareClaims{TaskID: taskID, RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "someone", Audience: jwt.ClaimStrings{shareAudience}, ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		}}, "shared-secret"), http.StatusUnauthorized, "Invalid share link"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, "/tasks/shared/"+tc.token, nil)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, tc.error, decode(t, w)["error"])
+		})
+	}
+}
+
+// TestCalendarAuthRejectsShareToken checks a share link can't be used to
+// subscribe to its owner's calendar, though both are signed with JWT_SECRET
+func TestCalendarAuthRejectsShareToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "shared-secret")
+	ownerID := uuid.New()
+	h := &TaskHandler{}
+	router := newRouter(uuid.New(), http.MethodGet, "/tasks/export.ics", h.CalendarAuth(func(c *gin.Context) {
+		c.AbortWithStatus(http.StatusTeapot)
+	}), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for name, token := range map[string]string{
+		"share token": signToken(t, shareToken(uuid.New(), ownerID, shareAudience, time.Now().Add(time.Hour)), "shared-secret"),
+		"session token": signToken(t, jwt.MapClaims{
+			"userId": ownerID.String(), "sub": ownerID.String(), "exp": time.Now().Add(time.Hour).Unix(),
+		}, "shared-secret"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, router, http.MethodGet, "/tasks/export.ics?token="+token, nil)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, "Invalid calendar token", decode(t, w)["error"])
+		})
+	}
+
+	w := serve(t, router, http.MethodGet, "/tasks/export.ics", nil)
+	assert.Equal(t, http.StatusTeapot, w.Code, "requests without a token use the session auth")
+}
+
+// TestShareTask shares a task, fetches it through the link without signing
+// in, and checks the link stops working once the task changes owner
+func TestShareTask(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("JWT_SECRET", "shared-secret")
+	t.Setenv("SHARE_TOKEN_MAX_TTL", "48h")
+	ownerID, other := seedUser(t, db, "sharer"), seedUser(t, db, "other")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title, description) VALUES ($1, $2, 'Shared', 'Read only')", taskID, ownerID)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(ownerID, http.MethodGet, "/tasks/:id/share", h.ShareTask)
+	router.GET("/shared/:token", h.GetSharedTask)
+
+	for target, status := range map[string]int{
+		"/tasks/" + taskID.String() + "/share?ttl=soon": http.StatusBadRequest,
+		"/tasks/" + taskID.String() + "/share?ttl=72h":  http.StatusBadRequest,
+		"/tasks/" + uuid.NewString() + "/share":         http.StatusNotFound,
+	} {
+		assert.Equal(t, status, serve(t, router, http.MethodGet, target, nil).Code, target)
+	}
+
+	start := time.Now()
+	w := serve(t, router, http.MethodGet, "/tasks/"+taskID.String()+"/share?ttl=2h", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	link := decode(t, w)
+	token := link["token"].(string)
+	assert.Equal(t, "/api/v1/tasks/shared/"+token, link["path"])
+	expiresAt, err := time.Parse(time.RFC3339Nano, link["expires_at"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, start.Add(2*time.Hour), expiresAt, time.Minute)
+
+	w = serve(t, router, http.MethodGet, "/shared/"+token, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	task := decode(t, w)["task"].(map[string]interface{})
+	assert.Equal(t, taskID.String(), task["id"])
+	assert.Equal(t, "Shared", task["title"])
+	assert.Equal(t, "Read only", task["description"])
+	assert.NotContains(t, task, "user_id", "the view leaves out the owner")
+
+	mustExec(t, db, "UPDATE tasks SET user_id = $1 WHERE id = $2", other, taskID)
+	w = serve(t, router, http.MethodGet, "/shared/"+token, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
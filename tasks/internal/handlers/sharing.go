@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// Task list scopes
+const (
+	ScopeOwn    = "own"
+	ScopeShared = "shared"
+)
+
+// ShareTaskWithUser grants another user read or write access to a task.
+// Sharing again with the same user replaces their permission.
+func (h *TaskHandler) ShareTaskWithUser(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.ShareTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Permission == "" {
+		req.Permission = models.SharePermissionRead
+	}
+	if req.Permission != models.SharePermissionRead && req.Permission != models.SharePermissionWrite {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid permission. Must be one of: read, write"})
+		return
+	}
+	if req.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot share a task with yourself"})
+		return
+	}
+
+	exists, err := h.transfers.UserExists(c.Request.Context(), req.UserID)
+	if err != nil {
+		respondError(c, err, "Failed to look up user")
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	share, err := h.shares.Upsert(c.Request.Context(), taskID, userID, req.UserID, req.Permission)
+	if err != nil {
+		respondError(c, err, "Failed to share task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task shared successfully",
+		"share":   share,
+	})
+}
+
+// UnshareTask revokes a user's access to a task
+func (h *TaskHandler) UnshareTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+	shareUserID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.shares.Delete(c.Request.Context(), taskID, userID, shareUserID); err != nil {
+		respondResourceError(c, err, "Share", "Failed to unshare task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task unshared successfully"})
+}
+
+// accessibleTask loads a task the user owns or that was shared with them,
+// writing the error response and returning false if they may not access it.
+// write requires a write share.
+func (h *TaskHandler) accessibleTask(c *gin.Context, taskID, userID uuid.UUID, write bool, message string) (*models.Task, bool) {
+	task, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err == nil {
+		return task, true
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		respondError(c, err, message)
+		return nil, false
+	}
+
+	task, permission, err := h.shares.GetSharedTask(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, message)
+		return nil, false
+	}
+	if write && permission != models.SharePermissionWrite {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Task is shared with you read-only"})
+		return nil, false
+	}
+	return task, true
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// SnoozeTask hides a task from the task list until the requested time
+func (h *TaskHandler) SnoozeTask(c *gin.Context) {
+	var req models.SnoozeTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Until.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "until must be in the future"})
+		return
+	}
+
+	h.setSnoozed(c, req.Until)
+}
+
+// UnsnoozeTask returns a snoozed task to the task list right away
+func (h *TaskHandler) UnsnoozeTask(c *gin.Context) {
+	h.setSnoozed(c, nil)
+}
+
+func (h *TaskHandler) setSnoozed(c *gin.Context, until *time.Time) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, err := h.tasks.SetSnoozed(c.Request.Context(), taskID, userID, until)
+	if err != nil {
+		respondError(c, err, "Failed to update task")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), *task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, userID, task.ID, task)
+
+	message := "Task unsnoozed successfully"
+	if until != nil {
+		message = "Task snoozed successfully"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"task":    task,
+	})
+}
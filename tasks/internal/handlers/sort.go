@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultSortField = "created_at"
+
+// sortField describes a whitelisted sortable column and the direction used
+// when the client does not specify an order
+type sortField struct {
+	column       string
+	defaultOrder string
+	nullable     bool
+}
+
+// priorityRank orders priorities by severity rather than alphabetically
+const priorityRank = "CASE priority WHEN 'urgent' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END"
+
+// sortableFields is the whitelist of columns tasks can be ordered by.
+// Time-based fields default to newest first, text fields to alphabetical,
+// priority to most urgent first and position to kanban order.
+var sortableFields = map[string]sortField{
+	"created_at": {column: "created_at", defaultOrder: "desc"},
+	"updated_at": {column: "updated_at", defaultOrder: "desc"},
+	"due_date":   {column: "due_date", defaultOrder: "desc", nullable: true},
+	"priority":   {column: priorityRank, defaultOrder: "desc"},
+	"position":   {column: "position", defaultOrder: "asc"},
+	"title":      {column: "title", defaultOrder: "asc"},
+}
+
+// buildOrderBy validates the sort and order params and returns an ORDER BY clause
+func buildOrderBy(sort, order string) (string, error) {
+	if sort == "" {
+		sort = defaultSortField
+	}
+
+	field, ok := sortableFields[sort]
+	if !ok {
+		return "", fmt.Errorf("invalid sort field %q. Must be one of: %s", sort, strings.Join(sortFieldNames(), ", "))
+	}
+
+	order = strings.ToLower(order)
+	if order == "" {
+		order = field.defaultOrder
+	}
+	if order != "asc" && order != "desc" {
+		return "", fmt.Errorf("invalid order %q. Must be: asc or desc", order)
+	}
+
+	clause := " ORDER BY " + field.column + " " + strings.ToUpper(order)
+	if field.nullable {
+		clause += " NULLS LAST"
+	}
+	if field.column != "created_at" {
+		clause += ", created_at DESC"
+	}
+	return clause, nil
+}
+
+// sortFieldNames returns the whitelisted sort fields in a stable order
+func sortFieldNames() []string {
+	return []string{"created_at", "updated_at", "due_date", "priority", "position", "title"}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildOrderByDefaultDirection(t *testing.T) {
+	for sort, want := range map[string]string{
+		"":           " ORDER BY created_at DESC",
+		"created_at": " ORDER BY created_at DESC",
+		"updated_at": " ORDER BY updated_at DESC, created_at DESC",
+		"due_date":   " ORDER BY due_date DESC NULLS LAST, created_at DESC",
+		"priority":   " ORDER BY " + priorityRank + " DESC, created_at DESC",
+		"position":   " ORDER BY position ASC, created_at DESC",
+		"title":      " ORDER BY title ASC, created_at DESC",
+	} {
+		t.Run(sort, func(t *testing.T) {
+			clause, err := buildOrderBy(sort, "")
+			require.NoError(t, err)
+			assert.Equal(t, want, clause)
+		})
+	}
+}
+
+func TestBuildOrderByExplicitOrderOverridesDefault(t *testing.T) {
+	clause, err := buildOrderBy("title", "DESC")
+	require.NoError(t, err)
+	assert.Equal(t, " ORDER BY title DESC, created_at DESC", clause)
+
+	clause, err = buildOrderBy("due_date", "asc")
+	require.NoError(t, err)
+	assert.Equal(t, " ORDER BY due_date ASC NULLS LAST, created_at DESC", clause, "tasks without a due date still sort last")
+}
+
+func TestBuildOrderByRejectsUnknownInput(t *testing.T) {
+	_, err := buildOrderBy("user_id; DROP TABLE tasks", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Must be one of: created_at, updated_at, due_date, priority, position, title")
+
+	_, err = buildOrderBy("title", "sideways")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid order "sideways"`)
+}
+
+func TestSortFieldNamesMatchWhitelist(t *testing.T) {
+	names := sortFieldNames()
+	assert.Len(t, names, len(sortableFields))
+	for _, name := range names {
+		assert.Contains(t, sortableFields, name)
+	}
+}
@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+const defaultAdminStatsMaxUsers = 100
+
+// maxTrendBuckets bounds how many periods a trends query returns
+const maxTrendBuckets = 366
+
+// trendRangePattern matches trend ranges such as 30d, 12w, 6m or 1y
+var trendRangePattern = regexp.MustCompile(`^([1-9][0-9]{0,3})([dwmy])$`)
+
+// effortStatsWeeks is how many weeks, the current one included, the effort
+// summary covers
+const effortStatsWeeks = 8
+
+// overdueCondition matches tasks that are overdue now: past their due date
+// and still open. Cancelled tasks are never overdue.
+const overdueCondition = "due_date < NOW() AND status NOT IN ('completed', 'cancelled')"
+
+// wentOverdueCondition matches tasks that fell due without being completed
+// in time, whether they are still overdue or were completed late
+const wentOverdueCondition = "(" + overdueCondition + " OR completed_at > due_date)"
+
+// statsWeeks returns the starts of this week and last week, weeks starting
+// on Monday in loc
+func statsWeeks(loc *time.Location) (thisWeek, lastWeek time.Time) {
+	today := startOfDay(time.Now(), loc)
+	thisWeek = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	return thisWeek, thisWeek.AddDate(0, 0, -7)
+}
+
+// computeStats gathers the summary statistics for a user's tasks, counting
+// "today" in the given timezone
+func (h *TaskHandler) computeStats(ctx context.Context, userID uuid.UUID, zone string) (*models.TaskStats, error) {
+	stats, err := h.computeStatsBatch(ctx, []uuid.UUID{userID}, &zone)
+	if err != nil {
+		return nil, err
+	}
+	return stats[userID], nil
+}
+
+// computeStatsBatch gathers summary statistics for several users with grouped
+// queries. Every requested user gets an entry, even if they have no tasks.
+// "Today" is counted in zone, or when it is nil in each user's saved
+// timezone, defaulting to UTC.
+func (h *TaskHandler) computeStatsBatch(ctx context.Context, userIDs []uuid.UUID, zone *string) (map[uuid.UUID]*models.TaskStats, error) {
+	stats := make(map[uuid.UUID]*models.TaskStats, len(userIDs))
+	ids := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = id.String()
+		stats[id] = &models.TaskStats{
+			ByStatus:    make(map[string]int),
+			ByPriority:  make(map[string]int),
+			TimeTracked: models.TimeTracked{Tasks: []models.TaskTime{}},
+			Effort:      []models.EffortWeek{},
+		}
+	}
+
+	// Totals, overdue and completed today. Timestamps are stored in UTC.
+	// Overdue compares instants, so it is the same in every timezone. "Today"
+	// runs from STATS_DAY_START ($4 seconds past local midnight) to the same
+	// time the next day; completions after now ($3) are clamped to now or
+	// left out ($5) depending on STATS_CLOCK_SKEW_POLICY.
+	var totals []struct {
+		UserID         uuid.UUID `db:"user_id"`
+		TotalTasks     int       `db:"total_tasks"`
+		OverdueTasks   int       `db:"overdue_tasks"`
+		CompletedToday int       `db:"completed_today"`
+	}
+	err := h.db.SelectContext(ctx, &totals, `
+		SELECT
+			t.user_id,
+			COUNT(*) AS total_tasks,
+			COUNT(*) FILTER (WHERE `+overdueCondition+`) AS overdue_tasks,
+			COUNT(*) FILTER (WHERE t.completed_at IS NOT NULL
+				AND (NOT $5::boolean OR t.completed_at <= $3::timestamp)
+				AND ((LEAST(t.completed_at, $3::timestamp) AT TIME ZONE 'UTC' AT TIME ZONE z.name) - $4::int * INTERVAL '1 second')::date
+				= (($3::timestamp AT TIME ZONE 'UTC' AT TIME ZONE z.name) - $4::int * INTERVAL '1 second')::date) AS completed_today
+		FROM tasks t
+		LEFT JOIN user_settings s ON s.user_id = t.user_id
+		CROSS JOIN LATERAL (SELECT COALESCE($2, s.timezone, 'UTC') AS name) z
+		WHERE t.user_id = ANY($1::uuid[]) AND t.deleted_at IS NULL
+		GROUP BY t.user_id
+	`, pq.Array(ids), zone, time.Now().UTC(), int(statsDayStart().Seconds()), statsClockSkewPolicy() == ClockSkewExclude)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range totals {
+		stats[row.UserID].TotalTasks = row.TotalTasks
+		stats[row.UserID].OverdueTasks = row.OverdueTasks
+		stats[row.UserID].CompletedToday = row.CompletedToday
+	}
+
+	// Tasks whose due date was pushed back deadlineSlipThreshold or more times
+	var slipped []struct {
+		UserID uuid.UUID `db:"user_id"`
+		Tasks  int       `db:"tasks"`
+	}
+	err = h.db.SelectContext(ctx, &slipped, `
+		SELECT user_id, COUNT(*) AS tasks FROM (
+			SELECT t.user_id
+			FROM deadline_changes d
+			JOIN tasks t ON t.id = d.task_id
+			WHERE t.user_id = ANY($1::uuid[]) AND t.deleted_at IS NULL AND d.new_due_date > d.old_due_date
+			GROUP BY t.user_id, t.id
+			HAVING COUNT(*) >= $2
+		) slipped
+		GROUP BY user_id
+	`, pq.Array(ids), deadlineSlipThreshold)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range slipped {
+		stats[row.UserID].SlippedTasks = row.Tasks
+	}
+
+	// By status and priority
+	for _, column := range []string{"status", "priority"} {
+		var groups []struct {
+			UserID uuid.UUID `db:"user_id"`
+			Key    string    `db:"key"`
+			Count  int       `db:"count"`
+		}
+		err := h.db.SelectContext(ctx, &groups,
+			"SELECT user_id, "+column+" AS key, COUNT(*) AS count FROM tasks WHERE user_id = ANY($1::uuid[]) AND deleted_at IS NULL GROUP BY user_id, "+column,
+			pq.Array(ids))
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if column == "status" {
+				stats[group.UserID].ByStatus[group.Key] = group.Count
+			} else {
+				stats[group.UserID].ByPriority[group.Key] = group.Count
+			}
+		}
+	}
+
+	// Estimated vs actual effort of the tasks completed in each recent week
+	var weeks []struct {
+		UserID    uuid.UUID `db:"user_id"`
+		WeekStart time.Time `db:"week_start"`
+		models.EffortWeek
+	}
+	err = h.db.SelectContext(ctx, &weeks, `
+		SELECT user_id, date_trunc('week', completed_at) AS week_start, COUNT(*) AS tasks,
+			COALESCE(SUM(estimate_minutes), 0) AS estimated_minutes,
+			COALESCE(SUM(actual_minutes), 0) AS actual_minutes
+		FROM tasks
+		WHERE user_id = ANY($1::uuid[]) AND deleted_at IS NULL
+			AND completed_at >= date_trunc('week', NOW()) - $2 * INTERVAL '1 week'
+			AND (estimate_minutes IS NOT NULL OR actual_minutes IS NOT NULL)
+		GROUP BY user_id, week_start
+		ORDER BY user_id, week_start
+	`, pq.Array(ids), effortStatsWeeks-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range weeks {
+		row.EffortWeek.WeekStart = row.WeekStart.Format("2006-01-02")
+		stats[row.UserID].Effort = append(stats[row.UserID].Effort, row.EffortWeek)
+	}
+
+	// Tracked time per task, most tracked first, with running timers counted
+	// up to now
+	var times []struct {
+		UserID uuid.UUID `db:"user_id"`
+		models.TaskTime
+	}
+	err = h.db.SelectContext(ctx, &times, `
+		SELECT e.user_id, e.task_id, t.title,
+			SUM(EXTRACT(EPOCH FROM (COALESCE(e.stopped_at, $2) - e.started_at)))::bigint AS seconds
+		FROM time_entries e
+		JOIN tasks t ON t.id = e.task_id AND t.deleted_at IS NULL
+		WHERE e.user_id = ANY($1::uuid[])
+		GROUP BY e.user_id, e.task_id, t.title
+		ORDER BY e.user_id, seconds DESC, e.task_id
+	`, pq.Array(ids), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range times {
+		tracked := &stats[row.UserID].TimeTracked
+		tracked.TotalSeconds += row.Seconds
+		if len(tracked.Tasks) < timeStatsMaxTasks {
+			tracked.Tasks = append(tracked.Tasks, row.TaskTime)
+		}
+	}
+
+	return stats, nil
+}
+
+// GetUsersStats returns summary statistics for a list of users (admin only)
+func (h *TaskHandler) GetUsersStats(c *gin.Context) {
+	var req models.UsersStatsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxUsers := config.Int("ADMIN_STATS_MAX_USERS", defaultAdminStatsMaxUsers)
+	if len(req.UserIDs) > maxUsers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Too many users: at most %d per request", maxUsers)})
+		return
+	}
+
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	stats, err := h.computeStatsBatch(ctx, req.UserIDs, nil)
+	if err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// GetStatsOverview returns all-time totals together with this week vs last
+// week deltas, so dashboards can show trends with a single request. Weeks
+// start on Monday in the caller's timezone.
+func (h *TaskHandler) GetStatsOverview(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	thisWeek, lastWeek := statsWeeks(loc)
+
+	// $2 and $3 start this and last week
+	query := `
+		SELECT
+			COUNT(*) AS total_tasks,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed_tasks,
+			COUNT(*) FILTER (WHERE status IN ('pending', 'in_progress')) AS open_tasks,
+			COUNT(*) FILTER (WHERE ` + overdueCondition + `) AS overdue_tasks,
+			COUNT(*) FILTER (WHERE created_at >= $2) AS created_this_week,
+			COUNT(*) FILTER (WHERE created_at >= $3 AND created_at < $2) AS created_last_week,
+			COUNT(*) FILTER (WHERE completed_at >= $2) AS completed_this_week,
+			COUNT(*) FILTER (WHERE completed_at >= $3 AND completed_at < $2) AS completed_last_week
+		FROM tasks
+		WHERE user_id = $1 AND deleted_at IS NULL
+	`
+
+	var row struct {
+		TotalTasks        int `db:"total_tasks"`
+		CompletedTasks    int `db:"completed_tasks"`
+		OpenTasks         int `db:"open_tasks"`
+		OverdueTasks      int `db:"overdue_tasks"`
+		CreatedThisWeek   int `db:"created_this_week"`
+		CreatedLastWeek   int `db:"created_last_week"`
+		CompletedThisWeek int `db:"completed_this_week"`
+		CompletedLastWeek int `db:"completed_last_week"`
+	}
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	if err := h.db.GetContext(ctx, &row, query, userID, thisWeek.UTC(), lastWeek.UTC()); err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch stats overview")
+		return
+	}
+
+	overview := models.TaskStatsOverview{
+		TotalTasks:     row.TotalTasks,
+		CompletedTasks: row.CompletedTasks,
+		OpenTasks:      row.OpenTasks,
+		OverdueTasks:   row.OverdueTasks,
+		Created:        newTrendDelta(row.CreatedThisWeek, row.CreatedLastWeek),
+		Completed:      newTrendDelta(row.CompletedThisWeek, row.CompletedLastWeek),
+	}
+
+	c.JSON(http.StatusOK, gin.H{"overview": overview})
+}
+
+// GetStatsTrends returns how many tasks were created and completed in each
+// period of ?range= (e.g. 30d, 12w, 6m or 1y; 30d by default), with periods
+// of ?granularity= day, week or month (day by default) in the caller's
+// timezone. The first period starts at or before the beginning of the range
+// and the last one is the current, partial period.
+func (h *TaskHandler) GetStatsTrends(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	granularity := c.DefaultQuery("granularity", "day")
+	if granularity != "day" && granularity != "week" && granularity != "month" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid granularity. Must be one of: day, week, month"})
+		return
+	}
+	rangeText := c.DefaultQuery("range", "30d")
+	m := trendRangePattern.FindStringSubmatch(rangeText)
+	if m == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid range. Use a number of days, weeks, months or years such as 30d, 12w, 6m or 1y"})
+		return
+	}
+	n, _ := strconv.Atoi(m[1])
+
+	now := time.Now().In(loc)
+	from := startOfDay(now, loc)
+	switch m[2] {
+	case "d":
+		from = from.AddDate(0, 0, -n)
+	case "w":
+		from = from.AddDate(0, 0, -7*n)
+	case "m":
+		from = from.AddDate(0, -n, 0)
+	case "y":
+		from = from.AddDate(-n, 0, 0)
+	}
+
+	// Bucket bounds are stepped in the caller's timezone so DST changes don't
+	// shift them. Weeks start on Monday.
+	step := func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	switch granularity {
+	case "week":
+		from = from.AddDate(0, 0, -(int(from.Weekday())+6)%7)
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case "month":
+		from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, loc)
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	}
+	var starts []time.Time
+	for start := from; !start.After(now); start = step(start) {
+		if len(starts) == maxTrendBuckets {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("The range spans more than %d periods; use a coarser granularity", maxTrendBuckets)})
+			return
+		}
+		starts = append(starts, start)
+	}
+	bounds := make([]string, len(starts)+1)
+	for i, start := range starts {
+		bounds[i] = start.UTC().Format("2006-01-02 15:04:05")
+	}
+	bounds[len(starts)] = step(starts[len(starts)-1]).UTC().Format("2006-01-02 15:04:05")
+
+	// width_bucket numbers each timestamp's period from 1, given the bounds
+	var rows []struct {
+		Kind   string `db:"kind"`
+		Bucket int    `db:"bucket"`
+		Count  int    `db:"count"`
+	}
+	query := `
+		WITH bounds AS (SELECT $2::timestamp[] AS b)
+		SELECT 'created' AS kind, width_bucket(t.created_at, bounds.b) AS bucket, COUNT(*) AS count
+		FROM tasks t, bounds
+		WHERE t.user_id = $1 AND t.deleted_at IS NULL
+			AND t.created_at >= bounds.b[1] AND t.created_at < bounds.b[array_length(bounds.b, 1)]
+		GROUP BY 2
+		UNION ALL
+		SELECT 'completed', width_bucket(t.completed_at, bounds.b), COUNT(*)
+		FROM tasks t, bounds
+		WHERE t.user_id = $1 AND t.deleted_at IS NULL
+			AND t.completed_at >= bounds.b[1] AND t.completed_at < bounds.b[array_length(bounds.b, 1)]
+		GROUP BY 2
+	`
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	if err := h.db.SelectContext(ctx, &rows, query, userID, pq.Array(bounds)); err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch stats trends")
+		return
+	}
+
+	trends := models.TaskTrends{
+		Granularity: granularity,
+		Range:       rangeText,
+		Buckets:     make([]models.TrendBucket, len(starts)),
+	}
+	for i, start := range starts {
+		trends.Buckets[i].Start = start.Format("2006-01-02")
+	}
+	for _, row := range rows {
+		if row.Bucket < 1 || row.Bucket > len(starts) {
+			continue
+		}
+		bucket := &trends.Buckets[row.Bucket-1]
+		if row.Kind == "created" {
+			bucket.Created = row.Count
+		} else {
+			bucket.Completed = row.Count
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"trends": trends})
+}
+
+// GetStatsCompare compares the tasks completed, created and gone overdue this
+// week with last week. Weeks start on Monday in the caller's timezone. A task
+// went overdue in a week if it fell due then without being completed in time.
+func (h *TaskHandler) GetStatsCompare(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	thisWeek, lastWeek := statsWeeks(loc)
+
+	// $2 and $3 start this and last week; tasks due this week only count as
+	// overdue once their due date has passed
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE completed_at >= $2) AS completed_this_week,
+			COUNT(*) FILTER (WHERE completed_at >= $3 AND completed_at < $2) AS completed_last_week,
+			COUNT(*) FILTER (WHERE created_at >= $2) AS created_this_week,
+			COUNT(*) FILTER (WHERE created_at >= $3 AND created_at < $2) AS created_last_week,
+			COUNT(*) FILTER (WHERE due_date >= $2 AND ` + wentOverdueCondition + `) AS overdue_this_week,
+			COUNT(*) FILTER (WHERE due_date >= $3 AND due_date < $2 AND ` + wentOverdueCondition + `) AS overdue_last_week
+		FROM tasks
+		WHERE user_id = $1 AND deleted_at IS NULL
+			AND (completed_at >= $3 OR created_at >= $3 OR due_date >= $3)
+	`
+
+	var row struct {
+		CompletedThisWeek int `db:"completed_this_week"`
+		CompletedLastWeek int `db:"completed_last_week"`
+		CreatedThisWeek   int `db:"created_this_week"`
+		CreatedLastWeek   int `db:"created_last_week"`
+		OverdueThisWeek   int `db:"overdue_this_week"`
+		OverdueLastWeek   int `db:"overdue_last_week"`
+	}
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
+
+	if err := h.db.GetContext(ctx, &row, query, userID, thisWeek.UTC(), lastWeek.UTC()); err != nil {
+		respondError(c, repository.Translate(err), "Failed to compare stats")
+		return
+	}
+
+	comparison := models.TaskStatsComparison{
+		ThisWeek:  thisWeek.Format("2006-01-02"),
+		LastWeek:  lastWeek.Format("2006-01-02"),
+		Completed: newTrendDelta(row.CompletedThisWeek, row.CompletedLastWeek),
+		Created:   newTrendDelta(row.CreatedThisWeek, row.CreatedLastWeek),
+		Overdue:   newTrendDelta(row.OverdueThisWeek, row.OverdueLastWeek),
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comparison": comparison})
+}
+
+// newTrendDelta builds a period comparison. The percentage change is omitted
+// when the previous period is zero since it would be undefined.
+func newTrendDelta(current, previous int) models.TrendDelta {
+	delta := models.TrendDelta{
+		Current:  current,
+		Previous: previous,
+		Delta:    current - previous,
+	}
+	if previous != 0 {
+		pct := float64(current-previous) / float64(previous) * 100
+		delta.PercentChange = &pct
+	}
+	return delta
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrendDelta(t *testing.T) {
+	pct := func(v float64) *float64 { return &v }
+	for name, tc := range map[string]struct {
+		current, previous int
+		want              models.TrendDelta
+	}{
+		"growth":        {6, 4, models.TrendDelta{Current: 6, Previous: 4, Delta: 2, PercentChange: pct(50)}},
+		"decline":       {1, 4, models.TrendDelta{Current: 1, Previous: 4, Delta: -3, PercentChange: pct(-75)}},
+		"unchanged":     {3, 3, models.TrendDelta{Current: 3, Previous: 3, Delta: 0, PercentChange: pct(0)}},
+		"down to zero":  {0, 2, models.TrendDelta{Current: 0, Previous: 2, Delta: -2, PercentChange: pct(-100)}},
+		"from zero":     {5, 0, models.TrendDelta{Current: 5, Previous: 0, Delta: 5}},
+		"zero and zero": {0, 0, models.TrendDelta{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, newTrendDelta(tc.current, tc.previous))
+		})
+	}
+}
+
+// TestGetStatsOverviewDeltas seeds two weeks of tasks around the start of
+// the current week and checks the totals and week-over-week deltas
+func TestGetStatsOverviewDeltas(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "dashboard")
+	other := seedUser(t, db, "other")
+
+	const (
+		thisWeek    = "date_trunc('week', NOW() AT TIME ZONE 'UTC')"
+		lastWeek    = "date_trunc('week', NOW() AT TIME ZONE 'UTC') - INTERVAL '3 days'"
+		twoWeeksAgo = "date_trunc('week', NOW() AT TIME ZONE 'UTC') - INTERVAL '10 days'"
+	)
+	for _, q := range []string{
+		// Created this week: 3, one of them completed and one overdue
+		"INSERT INTO tasks (user_id, title, created_at) VALUES ($1, 'New', " + thisWeek + ")",
+		"INSERT INTO tasks (user_id, title, status, created_at, completed_at) VALUES ($1, 'New and done', 'completed', " + thisWeek + ", " + thisWeek + ")",
+		"INSERT INTO tasks (user_id, title, created_at, due_date) VALUES ($1, 'New and late', " + thisWeek + ", NOW() - INTERVAL '1 day')",
+		// Created last week: 2
+		"INSERT INTO tasks (user_id, title, created_at) VALUES ($1, 'Old', " + lastWeek + ")",
+		"INSERT INTO tasks (user_id, title, status, created_at, due_date) VALUES ($1, 'Dropped', 'cancelled', " + lastWeek + ", NOW() - INTERVAL '1 day')",
+		// Completed last week: 2
+		"INSERT INTO tasks (user_id, title, status, created_at, completed_at) VALUES ($1, 'Older', 'completed', " + twoWeeksAgo + ", " + lastWeek + ")",
+		"INSERT INTO tasks (user_id, title, status, created_at, completed_at) VALUES ($1, 'Oldest', 'completed', " + twoWeeksAgo + ", " + lastWeek + ")",
+		// Neither trashed tasks nor other users' tasks count
+		"INSERT INTO tasks (user_id, title, created_at, deleted_at) VALUES ($1, 'Trashed', " + thisWeek + ", NOW())",
+	} {
+		mustExec(t, db, q, userID)
+	}
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, created_at) VALUES ($1, 'Not mine', "+thisWeek+")", other)
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/stats/overview", h.GetStatsOverview)
+	w := serve(t, router, http.MethodGet, "/tasks/stats/overview", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	overview := decode(t, w)["overview"].(map[string]interface{})
+	assert.Equal(t, float64(7), overview["total_tasks"])
+	assert.Equal(t, float64(3), overview["completed_tasks"])
+	assert.Equal(t, float64(3), overview["open_tasks"])
+	assert.Equal(t, float64(1), overview["overdue_tasks"], "cancelled tasks are not overdue")
+	assert.Equal(t, map[string]interface{}{
+		"current": float64(3), "previous": float64(2), "delta": float64(1), "percent_change": float64(50),
+	}, overview["created"])
+	assert.Equal(t, map[string]interface{}{
+		"current": float64(1), "previous": float64(2), "delta": float64(-1), "percent_change": float64(-50),
+	}, overview["completed"])
+}
+
+// TestGetStatsOverviewWeeksInTimezone seeds a task created just after this
+// week began in Tokyo and checks which week it counts in for callers in
+// different timezones
+func TestGetStatsOverviewWeeksInTimezone(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "traveller")
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	tokyoWeek, _ := statsWeeks(tokyo)
+	createdAt := tokyoWeek.Add(time.Hour)
+	if createdAt.After(time.Now()) {
+		t.Skip("this week began less than an hour ago in Tokyo")
+	}
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, created_at) VALUES ($1, 'Early start', $2)", userID, createdAt.UTC())
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/stats/overview", h.GetStatsOverview)
+	for _, zone := range []string{"Asia/Tokyo", "UTC", "America/Los_Angeles"} {
+		t.Run(zone, func(t *testing.T) {
+			loc, err := time.LoadLocation(zone)
+			require.NoError(t, err)
+			thisWeek, lastWeek := statsWeeks(loc)
+			var current, previous float64
+			switch {
+			case !createdAt.Before(thisWeek):
+				current = 1
+			case !createdAt.Before(lastWeek):
+				previous = 1
+			}
+
+			w := serve(t, router, http.MethodGet, "/tasks/stats/overview?tz="+zone, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			created := decode(t, w)["overview"].(map[string]interface{})["created"].(map[string]interface{})
+			assert.Equal(t, current, created["current"])
+			assert.Equal(t, previous, created["previous"])
+		})
+	}
+
+	w := serve(t, router, http.MethodGet, "/tasks/stats/overview?tz=Mars/Olympus", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestGetStatsCompareOverdue checks which tasks count as gone overdue in
+// each week: late completions do, cancelled tasks and tasks completed in
+// time don't
+func TestGetStatsCompareOverdue(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "comparer")
+	thisWeek, lastWeek := statsWeeks(time.UTC)
+	dueLastWeek := lastWeek.Add(24 * time.Hour)
+	at := func(t time.Time) *time.Time {
+		t = t.UTC()
+		return &t
+	}
+
+	for _, task := range []struct {
+		title, status string
+		due           time.Time
+		completedAt   *time.Time
+	}{
+		{"Missed", "pending", dueLastWeek, nil},
+		{"Late", "completed", dueLastWeek, at(dueLastWeek.Add(time.Hour))},
+		{"In time", "completed", dueLastWeek, at(dueLastWeek.Add(-time.Hour))},
+		{"Dropped", "cancelled", dueLastWeek, nil},
+		{"Missed today", "in_progress", time.Now().Add(-time.Minute), nil},
+		{"Not due yet", "pending", time.Now().Add(time.Hour), nil},
+	} {
+		if task.due.Before(thisWeek) != (task.due == dueLastWeek) {
+			t.Skip("too close to the start of the week")
+		}
+		mustExec(t, db, "INSERT INTO tasks (user_id, title, status, due_date, completed_at) VALUES ($1, $2, $3, $4, $5)",
+			userID, task.title, task.status, task.due.UTC(), task.completedAt)
+	}
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/stats/compare", h.GetStatsCompare)
+	w := serve(t, router, http.MethodGet, "/tasks/stats/compare", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	overdue := decode(t, w)["comparison"].(map[string]interface{})["overdue"].(map[string]interface{})
+	assert.Equal(t, float64(1), overdue["current"])
+	assert.Equal(t, float64(2), overdue["previous"])
+}
+
+func TestStatsWeeksStartOnMonday(t *testing.T) {
+	for _, zone := range []string{"UTC", "Asia/Tokyo", "America/New_York"} {
+		loc, err := time.LoadLocation(zone)
+		require.NoError(t, err)
+		thisWeek, lastWeek := statsWeeks(loc)
+		assert.Equal(t, time.Monday, thisWeek.Weekday(), zone)
+		assert.Equal(t, time.Monday, lastWeek.Weekday(), zone)
+		assert.Equal(t, loc, thisWeek.Location(), zone)
+		assert.False(t, thisWeek.After(time.Now()), zone)
+		assert.True(t, time.Now().Before(thisWeek.AddDate(0, 0, 7)), zone)
+		assert.Equal(t, thisWeek, lastWeek.AddDate(0, 0, 7), zone)
+	}
+}
+
+func TestGetUsersStatsRejectsRequest(t *testing.T) {
+	t.Setenv("ADMIN_STATS_MAX_USERS", "2")
+	router := newRouter(uuid.New(), http.MethodPost, "/admin/stats", (&TaskHandler{}).GetUsersStats)
+
+	w := serve(t, router, http.MethodPost, "/admin/stats", map[string]interface{}{"user_ids": []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Too many users: at most 2 per request", decode(t, w)["error"])
+
+	w = serve(t, router, http.MethodPost, "/admin/stats", map[string]interface{}{"user_ids": []uuid.UUID{}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestGetUsersStats seeds tasks for several users and checks each user's
+// stats only count their own tasks, including users with none
+func TestGetUsersStats(t *testing.T) {
+	db := testdb.Open(t)
+	alice, bob, idle := seedUser(t, db, "alice"), seedUser(t, db, "bob"), seedUser(t, db, "idle")
+	outsider := seedUser(t, db, "outsider")
+
+	for _, q := range []string{
+		"INSERT INTO tasks (user_id, title, priority) VALUES ($1, 'Plan', 'high')",
+		"INSERT INTO tasks (user_id, title, status, completed_at) VALUES ($1, 'Ship', 'completed', NOW())",
+		"INSERT INTO tasks (user_id, title, due_date) VALUES ($1, 'Late', NOW() - INTERVAL '1 day')",
+		"INSERT INTO tasks (user_id, title, deleted_at) VALUES ($1, 'Trashed', NOW())",
+	} {
+		mustExec(t, db, q, alice)
+	}
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, status) VALUES ($1, 'Review', 'in_progress')", bob)
+	mustExec(t, db, "INSERT INTO tasks (user_id, title, status, due_date) VALUES ($1, 'Dropped', 'cancelled', NOW() - INTERVAL '1 day')", bob)
+	mustExec(t, db, "INSERT INTO tasks (user_id, title) VALUES ($1, 'Not asked for')", outsider)
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(uuid.New(), http.MethodPost, "/admin/stats", h.GetUsersStats)
+	w := serve(t, router, http.MethodPost, "/admin/stats", map[string]interface{}{"user_ids": []uuid.UUID{alice, bob, idle}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	stats := decode(t, w)["stats"].(map[string]interface{})
+	require.Len(t, stats, 3)
+
+	aliceStats := stats[alice.String()].(map[string]interface{})
+	assert.Equal(t, float64(3), aliceStats["total_tasks"])
+	assert.Equal(t, float64(1), aliceStats["overdue_tasks"])
+	assert.Equal(t, float64(1), aliceStats["completed_today"])
+	assert.Equal(t, map[string]interface{}{"pending": float64(2), "completed": float64(1)}, aliceStats["by_status"])
+	assert.Equal(t, map[string]interface{}{"high": float64(1), "medium": float64(2)}, aliceStats["by_priority"])
+
+	bobStats := stats[bob.String()].(map[string]interface{})
+	assert.Equal(t, float64(2), bobStats["total_tasks"])
+	assert.Equal(t, float64(0), bobStats["overdue_tasks"], "cancelled tasks are not overdue")
+	assert.Equal(t, map[string]interface{}{"in_progress": float64(1), "cancelled": float64(1)}, bobStats["by_status"])
+	assert.Equal(t, float64(0), bobStats["completed_today"])
+
+	idleStats := stats[idle.String()].(map[string]interface{})
+	assert.Equal(t, float64(0), idleStats["total_tasks"])
+	assert.Empty(t, idleStats["by_status"])
+}
+
+// TestGetStatsSharesConcurrentQueries holds a lock on the tasks table while
+// identical stats requests arrive, and checks only one query waits on it
+func TestGetStatsSharesConcurrentQueries(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "poller")
+	mustExec(t, db, "INSERT INTO tasks (user_id, title) VALUES ($1, 'Polled')", userID)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks/stats/summary", h.GetStats)
+
+	lock, err := db.Beginx()
+	require.NoError(t, err)
+	defer lock.Rollback()
+	_, err = lock.Exec("LOCK TABLE tasks IN ACCESS EXCLUSIVE MODE")
+	require.NoError(t, err)
+
+	const requests = 20
+	var started, done sync.WaitGroup
+	codes := make([]int, requests)
+	bodies := make([]string, requests)
+	for i := 0; i < requests; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			started.Done()
+			w := serve(t, router, http.MethodGet, "/tasks/stats/summary?tz=UTC", nil)
+			codes[i], bodies[i] = w.Code, w.Body.String()
+		}(i)
+	}
+	started.Wait()
+
+	waiting := func() int {
+		var n int
+		require.NoError(t, db.Get(&n, "SELECT COUNT(*) FROM pg_locks WHERE relation = 'tasks'::regclass AND NOT granted"))
+		return n
+	}
+	require.Eventually(t, func() bool { return waiting() > 0 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 1, waiting(), "identical requests share one query")
+
+	require.NoError(t, lock.Rollback())
+	done.Wait()
+	for i := range codes {
+		assert.Equal(t, http.StatusOK, codes[i])
+		assert.Equal(t, bodies[0], bodies[i])
+	}
+	assert.Contains(t, bodies[0], `"total_tasks":1`)
+}
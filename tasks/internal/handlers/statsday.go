@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+)
+
+// Policies for completions stamped later than the current time, which happens
+// when completed_at comes from a clock running ahead of the database's
+const (
+	// ClockSkewClamp counts a future completion as completed now
+	ClockSkewClamp = "clamp"
+	// ClockSkewExclude leaves future completions out of completed_today
+	ClockSkewExclude = "exclude"
+)
+
+// statsDayStart reads STATS_DAY_START, the local time of day ("HH:MM") at
+// which "today" begins for completed_today. It defaults to midnight.
+func statsDayStart() time.Duration {
+	value := config.String("STATS_DAY_START", "00:00")
+	start, err := time.Parse("15:04", value)
+	if err != nil {
+		log.Printf("⚠️  Invalid STATS_DAY_START %q, using 00:00", value)
+		return 0
+	}
+	return time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+}
+
+// statsClockSkewPolicy reads STATS_CLOCK_SKEW_POLICY
+func statsClockSkewPolicy() string {
+	policy := config.String("STATS_CLOCK_SKEW_POLICY", ClockSkewClamp)
+	switch policy {
+	case ClockSkewClamp, ClockSkewExclude:
+		return policy
+	}
+	log.Printf("⚠️  Unknown STATS_CLOCK_SKEW_POLICY %q, using %s", policy, ClockSkewClamp)
+	return ClockSkewClamp
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsDayStart(t *testing.T) {
+	for value, want := range map[string]time.Duration{
+		"":      0,
+		"00:00": 0,
+		"04:30": 4*time.Hour + 30*time.Minute,
+		"23:59": 23*time.Hour + 59*time.Minute,
+		"25:00": 0,
+		"4am":   0,
+	} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("STATS_DAY_START", value)
+			assert.Equal(t, want, statsDayStart())
+		})
+	}
+}
+
+func TestStatsClockSkewPolicy(t *testing.T) {
+	for value, want := range map[string]string{
+		"":        ClockSkewClamp,
+		"clamp":   ClockSkewClamp,
+		"exclude": ClockSkewExclude,
+		"ignore":  ClockSkewClamp,
+	} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("STATS_CLOCK_SKEW_POLICY", value)
+			assert.Equal(t, want, statsClockSkewPolicy())
+		})
+	}
+}
+
+// TestCompletedTodayAtDayBoundaries seeds completions either side of the
+// start of the current day in several timezones and day starts, plus one
+// stamped in the future, and checks which count as completed today
+func TestCompletedTodayAtDayBoundaries(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+
+	for _, zone := range []string{"UTC", "Asia/Tokyo", "America/Los_Angeles", "Asia/Kathmandu"} {
+		for _, dayStart := range []string{"00:00", "04:00"} {
+			for policy, want := range map[string]int{ClockSkewClamp: 2, ClockSkewExclude: 1} {
+				t.Run(zone+" from "+dayStart+" "+policy, func(t *testing.T) {
+					t.Setenv("STATS_DAY_START", dayStart)
+					t.Setenv("STATS_CLOCK_SKEW_POLICY", policy)
+					loc, err := time.LoadLocation(zone)
+					require.NoError(t, err)
+
+					// The start of the current day, which is yesterday's
+					// calendar date while it's still before the day start
+					offset := statsDayStart()
+					today := startOfDay(time.Now().In(loc).Add(-offset), loc).Add(offset)
+
+					userID := seedUser(t, db, uuid.NewString())
+					for _, completedAt := range []time.Time{
+						today.Add(-time.Second),    // yesterday
+						today,                      // the first moment of today
+						time.Now().Add(time.Hour),  // from a clock running ahead
+						today.Add(-24 * time.Hour), // the start of yesterday
+						today.AddDate(0, 0, -1).Add(time.Hour),
+					} {
+						mustExec(t, db, "INSERT INTO tasks (user_id, title, status, completed_at) VALUES ($1, 'Done', 'completed', $2)", userID, completedAt.UTC())
+					}
+					mustExec(t, db, "INSERT INTO tasks (user_id, title) VALUES ($1, 'Open')", userID)
+
+					stats, err := h.computeStats(context.Background(), userID, zone)
+					require.NoError(t, err)
+					assert.Equal(t, want, stats.CompletedToday)
+				})
+			}
+		}
+	}
+}
+
+// TestCompletedTodayUsesSavedTimezone checks the batch stats count "today" in
+// each user's saved timezone when no zone is given
+func TestCompletedTodayUsesSavedTimezone(t *testing.T) {
+	db := testdb.Open(t)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	require.NoError(t, err)
+
+	// Each user completed a task a minute after their own local midnight,
+	// and another a minute before it
+	users := map[uuid.UUID]*time.Location{}
+	for name, loc := range map[string]*time.Location{"tokyo": tokyo, "los-angeles": losAngeles} {
+		userID := seedUser(t, db, name)
+		users[userID] = loc
+		mustExec(t, db, "INSERT INTO user_settings (user_id, timezone) VALUES ($1, $2)", userID, loc.String())
+		midnight := startOfDay(time.Now(), loc)
+		for _, completedAt := range []time.Time{midnight.Add(time.Minute), midnight.Add(-time.Minute)} {
+			if completedAt.After(time.Now()) {
+				t.Skip("too close to midnight in " + loc.String())
+			}
+			mustExec(t, db, "INSERT INTO tasks (user_id, title, status, completed_at) VALUES ($1, 'Done', 'completed', $2)", userID, completedAt.UTC())
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(users))
+	for userID := range users {
+		ids = append(ids, userID)
+	}
+	stats, err := h.computeStatsBatch(context.Background(), ids, nil)
+	require.NoError(t, err)
+	for userID, loc := range users {
+		assert.Equal(t, 1, stats[userID].CompletedToday, loc.String())
+	}
+}
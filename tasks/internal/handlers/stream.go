@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+const (
+	defaultStreamPollInterval = time.Second
+	defaultStreamHeartbeat    = 15 * time.Second
+	defaultStreamSafetyLag    = 2 * time.Second
+
+	// streamBatchSize bounds how many events are read per poll
+	streamBatchSize = 100
+	// streamRetryMillis is how long EventSource clients wait to reconnect
+	streamRetryMillis = 3000
+)
+
+// CloseStreams ends every open event stream. The server calls it on
+// shutdown, since streams would otherwise hold the shutdown open.
+func (h *TaskHandler) CloseStreams() {
+	h.streams.closing.Do(func() { close(h.streams.done) })
+}
+
+// StreamTasks streams the caller's task events as Server-Sent Events. A
+// client that reconnects with Last-Event-ID gets the events it missed that
+// are still retained; a new stream starts with the next event.
+func (h *TaskHandler) StreamTasks(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	after, resume, err := lastEventID(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	if !h.streams.acquire(userID) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": fmt.Sprintf("Too many open event streams; at most %d per user", h.streams.max),
+		})
+		return
+	}
+	defer h.streams.release(userID)
+
+	if !resume {
+		if after, err = h.streamEvents.Latest(c.Request.Context()); err != nil {
+			respondError(c, err, "Failed to open event stream")
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keep nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetryMillis)
+	c.Writer.Flush()
+
+	poll := time.NewTicker(config.Duration("STREAM_POLL_INTERVAL", defaultStreamPollInterval))
+	defer poll.Stop()
+	heartbeat := time.NewTicker(config.Duration("STREAM_HEARTBEAT_INTERVAL", defaultStreamHeartbeat))
+	defer heartbeat.Stop()
+
+	lag := config.Duration("STREAM_SAFETY_LAG", defaultStreamSafetyLag)
+
+	ctx := c.Request.Context()
+	for {
+		events, err := h.streamEvents.Since(ctx, userID, after, lag, streamBatchSize)
+		if err != nil {
+			// The client reconnects with the last ID it saw
+			if ctx.Err() == nil {
+				log.Printf("❌ Failed to read event stream for user %s: %v\n", userID, err)
+			}
+			return
+		}
+		for _, event := range events {
+			writeStreamEvent(c, event)
+			after = event.ID
+		}
+		if len(events) > 0 {
+			c.Writer.Flush()
+			if len(events) == streamBatchSize {
+				// More are waiting
+				continue
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.streams.done:
+			return
+		case <-heartbeat.C:
+			// A comment keeps proxies from timing out an idle stream
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case <-poll.C:
+		}
+	}
+}
+
+// lastEventID reads the ID of the last event a client saw from the
+// Last-Event-ID header, or from ?last_event_id= for clients that cannot set
+// headers. resume is false when neither is given.
+func lastEventID(c *gin.Context) (id int64, resume bool, err error) {
+	val := c.GetHeader("Last-Event-ID")
+	if val == "" {
+		val = c.Query("last_event_id")
+	}
+	if val == "" {
+		return 0, false, nil
+	}
+	id, err = strconv.ParseInt(val, 10, 64)
+	if err != nil || id < 0 {
+		return 0, false, &queryParamError{field: "last_event_id", message: "Last-Event-ID must be an event id from this stream"}
+	}
+	return id, true, nil
+}
+
+// writeStreamEvent writes one event in the text/event-stream format. The
+// payload is single-line JSON, so it fits one data field.
+func writeStreamEvent(c *gin.Context, event models.StreamEvent) {
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.EventType, event.Payload)
+}
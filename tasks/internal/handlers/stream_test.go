@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamSetCapsPerUser(t *testing.T) {
+	streams := newStreamSet(2)
+	alice, bob := uuid.New(), uuid.New()
+
+	assert.True(t, streams.acquire(alice))
+	assert.True(t, streams.acquire(alice))
+	assert.False(t, streams.acquire(alice), "a third stream is over the cap")
+	assert.True(t, streams.acquire(bob), "the cap is per user")
+
+	streams.release(alice)
+	assert.True(t, streams.acquire(alice), "a closed stream frees its slot")
+
+	streams.release(alice)
+	streams.release(alice)
+	streams.release(bob)
+	assert.Empty(t, streams.open, "users with no streams are forgotten")
+}
+
+func TestStreamSetZeroMaxIsUnlimited(t *testing.T) {
+	streams := newStreamSet(0)
+	userID := uuid.New()
+	for i := 0; i < 100; i++ {
+		require.True(t, streams.acquire(userID))
+	}
+}
+
+func TestStreamSetConcurrentAcquire(t *testing.T) {
+	streams := newStreamSet(3)
+	userID := uuid.New()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	acquired := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if streams.acquire(userID) {
+				mu.Lock()
+				acquired++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 3, acquired)
+}
+
+func TestStreamTasksOverCapIs429(t *testing.T) {
+	userID := uuid.New()
+	h := &TaskHandler{streams: newStreamSet(1)}
+	require.True(t, h.streams.acquire(userID))
+
+	router := newRouter(userID, http.MethodGet, "/tasks/stream", h.StreamTasks)
+	w := serve(t, router, http.MethodGet, "/tasks/stream", nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Contains(t, decode(t, w)["error"], "at most 1 per user")
+}
+
+// TestStreamTasksUpToCap opens real streams up to STREAM_MAX_PER_USER, checks
+// the next one is refused and that closing one lets another open
+func TestStreamTasksUpToCap(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("STREAM_MAX_PER_USER", "2")
+	userID := uuid.New()
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	server := httptest.NewServer(newRouter(userID, http.MethodGet, "/tasks/stream", h.StreamTasks))
+	t.Cleanup(func() {
+		h.CloseStreams()
+		server.Close()
+	})
+
+	open := func() *http.Response {
+		resp, err := http.Get(server.URL + "/tasks/stream")
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	first, second := open(), open()
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Equal(t, http.StatusOK, second.StatusCode)
+	assert.Equal(t, "text/event-stream", first.Header.Get("Content-Type"))
+
+	assert.Equal(t, http.StatusTooManyRequests, open().StatusCode)
+
+	first.Body.Close()
+	assert.Eventually(t, func() bool {
+		resp := open()
+		defer resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 5*time.Second, 50*time.Millisecond, "closing a stream frees its slot")
+}
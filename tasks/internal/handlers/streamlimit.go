@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// defaultStreamMaxPerUser is how many event streams a user may have open
+// at once unless STREAM_MAX_PER_USER says otherwise
+const defaultStreamMaxPerUser = 5
+
+// streamSet tracks the open event streams, capping them per user and
+// ending them all when the server shuts down
+type streamSet struct {
+	mu      sync.Mutex
+	open    map[uuid.UUID]int
+	max     int
+	done    chan struct{}
+	closing sync.Once
+}
+
+func newStreamSet(max int) *streamSet {
+	return &streamSet{open: make(map[uuid.UUID]int), max: max, done: make(chan struct{})}
+}
+
+// acquire counts a new stream for the user, or returns false if they
+// already have the most allowed
+func (s *streamSet) acquire(userID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.max > 0 && s.open[userID] >= s.max {
+		return false
+	}
+	s.open[userID]++
+	return true
+}
+
+func (s *streamSet) release(userID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.open[userID]--; s.open[userID] <= 0 {
+		delete(s.open, userID)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+)
+
+// Policies for completing a parent task while some of its subtasks are open
+const (
+	// SubtaskPolicyNone completes the parent and leaves subtasks as they are
+	SubtaskPolicyNone = "none"
+	// SubtaskPolicyCascade completes the open subtasks along with the parent
+	SubtaskPolicyCascade = "cascade"
+	// SubtaskPolicyBlock refuses to complete the parent until its subtasks are done
+	SubtaskPolicyBlock = "block"
+)
+
+// subtaskCompletionPolicy reads SUBTASK_COMPLETION_POLICY
+func subtaskCompletionPolicy() string {
+	policy := config.String("SUBTASK_COMPLETION_POLICY", SubtaskPolicyNone)
+	switch policy {
+	case SubtaskPolicyNone, SubtaskPolicyCascade, SubtaskPolicyBlock:
+		return policy
+	}
+	log.Printf("⚠️  Unknown SUBTASK_COMPLETION_POLICY %q, using %s", policy, SubtaskPolicyNone)
+	return SubtaskPolicyNone
+}
+
+// applySubtaskPolicy decides what completing a parent with openSubtasks does.
+// It reports whether the subtasks should be completed too, and responds 409
+// and returns false when the block policy refuses the completion.
+func applySubtaskPolicy(c *gin.Context, openSubtasks int) (cascade bool, ok bool) {
+	if openSubtasks == 0 {
+		return false, true
+	}
+	switch subtaskCompletionPolicy() {
+	case SubtaskPolicyBlock:
+		c.JSON(http.StatusConflict, gin.H{
+			"error":         "Complete or cancel the open subtasks first",
+			"open_subtasks": openSubtasks,
+		})
+		return false, false
+	case SubtaskPolicyCascade:
+		return true, true
+	}
+	return false, true
+}
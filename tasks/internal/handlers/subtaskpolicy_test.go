@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubtaskCompletionPolicy(t *testing.T) {
+	for value, want := range map[string]string{
+		"":        SubtaskPolicyNone,
+		"none":    SubtaskPolicyNone,
+		"cascade": SubtaskPolicyCascade,
+		"block":   SubtaskPolicyBlock,
+		"Block":   SubtaskPolicyNone,
+		"refuse":  SubtaskPolicyNone,
+	} {
+		t.Run(value, func(t *testing.T) {
+			t.Setenv("SUBTASK_COMPLETION_POLICY", value)
+			assert.Equal(t, want, subtaskCompletionPolicy())
+		})
+	}
+}
+
+// parentWithSubtasks seeds a parent task with one open and one completed
+// subtask and returns the owner, the parent and the open subtask
+func parentWithSubtasks(t *testing.T, db *database.DB) (userID, parent, open uuid.UUID) {
+	t.Helper()
+	userID = seedUser(t, db, "planner")
+	parent, open = uuid.New(), uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Parent')", parent, userID)
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, parent_task_id, title) VALUES ($1, $2, $3, 'Open')", open, userID, parent)
+	mustExec(t, db, "INSERT INTO tasks (user_id, parent_task_id, title, status, completed_at) VALUES ($1, $2, 'Done', 'completed', NOW())", userID, parent)
+	return userID, parent, open
+}
+
+func TestUpdateTaskCascadesCompletionToSubtasks(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("SUBTASK_COMPLETION_POLICY", SubtaskPolicyCascade)
+	userID, parent, open := parentWithSubtasks(t, db)
+	events := &recordedEvents{}
+	h := NewTaskHandler(db, search.NoopIndexer{}, events, nil)
+	router := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, router, http.MethodPut, "/tasks/"+parent.String(), map[string]interface{}{"status": "completed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var statuses []string
+	require.NoError(t, db.Select(&statuses, "SELECT status FROM tasks WHERE id IN ($1, $2) ORDER BY title DESC", parent, open))
+	assert.Equal(t, []string{"completed", "completed"}, statuses, "the open subtask is completed with its parent")
+
+	var completedAt []bool
+	require.NoError(t, db.Select(&completedAt, "SELECT completed_at IS NOT NULL FROM tasks WHERE user_id = $1", userID))
+	assert.Equal(t, []bool{true, true, true}, completedAt)
+	assert.Contains(t, events.types(), models.EventTaskCompleted)
+}
+
+func TestUpdateTaskBlockedByOpenSubtasks(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("SUBTASK_COMPLETION_POLICY", SubtaskPolicyBlock)
+	userID, parent, open := parentWithSubtasks(t, db)
+	events := &recordedEvents{}
+	h := NewTaskHandler(db, search.NoopIndexer{}, events, nil)
+	router := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, router, http.MethodPut, "/tasks/"+parent.String(), map[string]interface{}{"status": "completed"})
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	body := decode(t, w)
+	assert.Equal(t, "Complete or cancel the open subtasks first", body["error"])
+	assert.Equal(t, float64(1), body["open_subtasks"])
+
+	var statuses []string
+	require.NoError(t, db.Select(&statuses, "SELECT status FROM tasks WHERE id IN ($1, $2) ORDER BY title DESC", parent, open))
+	assert.Equal(t, []string{"pending", "pending"}, statuses, "nothing changes")
+	assert.Empty(t, events.types())
+
+	// Once the subtask is done the parent completes
+	mustExec(t, db, "UPDATE tasks SET status = 'cancelled' WHERE id = $1", open)
+	w = serve(t, router, http.MethodPut, "/tasks/"+parent.String(), map[string]interface{}{"status": "completed"})
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestUpdateTaskLeavesSubtasksWithoutPolicy(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("SUBTASK_COMPLETION_POLICY", SubtaskPolicyNone)
+	userID, parent, open := parentWithSubtasks(t, db)
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, router, http.MethodPut, "/tasks/"+parent.String(), map[string]interface{}{"status": "completed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var status string
+	require.NoError(t, db.Get(&status, "SELECT status FROM tasks WHERE id = $1", open))
+	assert.Equal(t, "pending", status)
+}
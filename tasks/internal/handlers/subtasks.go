@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// CreateSubtask creates a task under the parent in /:id/subtasks and
+// recomputes the parent's progress. Subtasks are one level deep.
+func (h *TaskHandler) CreateSubtask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	parentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	parent, err := h.tasks.GetByID(c.Request.Context(), parentID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to create subtask")
+		return
+	}
+	if parent.ParentTaskID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtasks cannot have subtasks of their own"})
+		return
+	}
+
+	task, ok := bindNewTask(c, userID)
+	if !ok {
+		return
+	}
+	if task.Recurrence != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtasks cannot recur"})
+		return
+	}
+	task.ParentTaskID = &parentID
+	// Subtasks join their parent's project unless given one
+	if task.ProjectID == nil {
+		task.ProjectID = parent.ProjectID
+	}
+	if !h.checkProject(c, task.ProjectID, userID) {
+		return
+	}
+
+	parent, err = h.tasks.CreateSubtask(c.Request.Context(), &task)
+	if err != nil {
+		respondError(c, err, "Failed to create subtask")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskCreated, userID, task.ID, &task)
+	h.indexer.Index(c.Request.Context(), *parent)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskUpdated, userID, parent.ID, parent)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Subtask created successfully",
+		"task":    task,
+		"parent":  parent,
+	})
+}
+
+// GetSubtasks lists the subtasks of a task
+func (h *TaskHandler) GetSubtasks(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	parentID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// Resolve the parent first so a missing task is a 404 rather than an empty list
+	if _, err := h.tasks.GetByID(c.Request.Context(), parentID, userID); err != nil {
+		respondError(c, err, "Failed to fetch subtasks")
+		return
+	}
+
+	subtasks, err := h.tasks.ListSubtasks(c.Request.Context(), parentID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch subtasks")
+		return
+	}
+	if err := h.tasks.AttachTags(c.Request.Context(), subtasks); err != nil {
+		respondError(c, err, "Failed to fetch subtasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subtasks": subtasks})
+}
+
+// rollupParent recomputes the progress of task's parent after the subtask
+// changed. Failures are logged; the next subtask change corrects them.
+func (h *TaskHandler) rollupParent(ctx context.Context, userID uuid.UUID, task *models.Task) {
+	if task == nil || task.ParentTaskID == nil {
+		return
+	}
+	parent, err := h.tasks.RollupProgress(ctx, *task.ParentTaskID, userID)
+	if err != nil {
+		log.Printf("⚠️  Failed to roll up progress for task %s: %v\n", *task.ParentTaskID, err)
+		return
+	}
+	h.indexer.Index(ctx, *parent)
+	h.publishTaskUpdate(ctx, userID, parent, false)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxTagLength   = 50
+	maxTagsPerTask = 20
+)
+
+// tagPattern allows letters, digits, dashes and underscores; commas are
+// reserved as the ?tags= separator
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+// normalizeTag trims and lowercases a tag name and validates it
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", fmt.Errorf("tags cannot be blank")
+	}
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		return "", fmt.Errorf("tag %q must be at most %d characters", tag, maxTagLength)
+	}
+	if !tagPattern.MatchString(tag) {
+		return "", fmt.Errorf("tag %q may only contain letters, digits, dashes and underscores", tag)
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes each tag and drops duplicates, keeping order
+func normalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > maxTagsPerTask {
+		return nil, fmt.Errorf("a task can have at most %d tags", maxTagsPerTask)
+	}
+	return normalized, nil
+}
+
+// GetTags lists the tags on the caller's tasks with task counts
+func (h *TaskHandler) GetTags(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	tags, err := h.tasks.TagCounts(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetTasksByTag lists the caller's tasks carrying /tags/:tag/tasks, with the
+// same filters, sorting and pagination as the task list. Only tasks the
+// caller owns are listed; tasks shared with them keep their owner's tags.
+func (h *TaskHandler) GetTasksByTag(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	tag, err := normalizeTag(c.Param("tag"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filters, err := parseTaskFilters(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	if filters.Scope == ScopeShared {
+		respondQueryError(c, &queryParamError{field: "scope", message: "scope=shared is not supported; tag listings only include your own tasks"})
+		return
+	}
+	filters.Scope = ScopeOwn
+	filters.Tags = []string{tag}
+
+	h.listTasks(c, userID, filters)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTasksByTagRejectsInput(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodGet, "/tags/:tag/tasks", (&TaskHandler{}).GetTasksByTag)
+
+	w := serve(t, router, http.MethodGet, "/tags/no%20spaces/tasks", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = serve(t, router, http.MethodGet, "/tags/work/tasks?scope=shared", nil)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "scope", decode(t, w)["field"])
+}
+
+// TestGetTasksByTagListsOnlyOwnTasks tags tasks of two users the same way,
+// shares one of the other user's with the caller, and checks the caller only
+// ever sees their own
+func TestGetTasksByTagListsOnlyOwnTasks(t *testing.T) {
+	db := testdb.Open(t)
+	owner, other := seedUser(t, db, "owner"), seedUser(t, db, "other")
+	first, second, untagged, othersTask, sharedTask := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	work := uuid.New()
+	mustExec(t, db, "INSERT INTO tags (id, name) VALUES ($1, 'work')", work)
+	for _, task := range []struct {
+		id     uuid.UUID
+		userID uuid.UUID
+		title  string
+		tagged bool
+	}{
+		{first, owner, "First", true},
+		{second, owner, "Second", true},
+		{untagged, owner, "Untagged", false},
+		{othersTask, other, "Theirs", true},
+		{sharedTask, other, "Shared with owner", true},
+	} {
+		mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, $3)", task.id, task.userID, task.title)
+		if task.tagged {
+			mustExec(t, db, "INSERT INTO task_tags (task_id, tag_id) VALUES ($1, $2)", task.id, work)
+		}
+	}
+	mustExec(t, db, "INSERT INTO task_shares (task_id, user_id, permission) VALUES ($1, $2, 'write')", sharedTask, owner)
+
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(owner, http.MethodGet, "/tags/:tag/tasks", h.GetTasksByTag)
+	list := func(target string) ([]string, map[string]interface{}) {
+		t.Helper()
+		w := serve(t, router, http.MethodGet, target, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		body := decode(t, w)
+		var ids []string
+		for _, task := range body["tasks"].([]interface{}) {
+			ids = append(ids, task.(map[string]interface{})["id"].(string))
+		}
+		return ids, body["pagination"].(map[string]interface{})
+	}
+
+	ids, pagination := list("/tags/work/tasks")
+	want := []string{first.String(), second.String()}
+	sort.Strings(ids)
+	sort.Strings(want)
+	assert.Equal(t, want, ids)
+	assert.Equal(t, float64(2), pagination["total"])
+
+	// Tags are matched case-insensitively, and pages never reach other users' tasks
+	ids, pagination = list("/tags/WORK/tasks?sort=title&order=asc&limit=1&page=2")
+	assert.Equal(t, []string{second.String()}, ids)
+	assert.Equal(t, float64(2), pagination["total"])
+	ids, _ = list("/tags/work/tasks?limit=1&page=3")
+	assert.Empty(t, ids)
+
+	ids, _ = list("/tags/home/tasks")
+	assert.Empty(t, ids)
+}
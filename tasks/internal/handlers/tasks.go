@@ -1,34 +1,158 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/buildinfo"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
 	"github.com/moabdelazem/microservices/tasks/internal/database"
 	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/storage"
+	"github.com/moabdelazem/microservices/tasks/internal/webhooks"
+	"golang.org/x/sync/singleflight"
 )
 
+// defaultMaxOffset is the deepest offset served by offset pagination
+const defaultMaxOffset = 10000
+
 type TaskHandler struct {
-	db *database.DB
+	db          *database.DB
+	tasks       *repository.TaskRepository
+	transfers   *repository.TransferRepository
+	delegations *repository.DelegationRepository
+	shares      *repository.ShareRepository
+	projects    *repository.ProjectRepository
+	timeEntries *repository.TimeEntryRepository
+	calendar    *repository.CalendarTokenRepository
+	checklist   *repository.ChecklistRepository
+	history     *repository.HistoryRepository
+	deadlines   *repository.DeadlineRepository
+	comments    *repository.CommentRepository
+	reminders   *repository.ReminderRepository
+	templates   *repository.TemplateRepository
+	views       *repository.ViewRepository
+	watchers    *repository.WatcherRepository
+	links       *repository.LinkRepository
+	idempotency IdempotencyStore
+	webhooks    *repository.WebhookRepository
+	indexer     search.Indexer
+	events      EventPublisher
+
+	attachments *repository.AttachmentRepository
+	storage     storage.Store
+
+	streamEvents *repository.StreamRepository
+	streams      *streamSet
+
+	webhookPolicy *webhooks.Policy
+
+	statsFlight singleflight.Group
 }
 
-func NewTaskHandler(db *database.DB) *TaskHandler {
-	return &TaskHandler{db: db}
+func NewTaskHandler(db *database.DB, indexer search.Indexer, events EventPublisher, store storage.Store) *TaskHandler {
+	return &TaskHandler{
+		db:          db,
+		indexer:     indexer,
+		events:      events,
+		tasks:       repository.NewTaskRepository(db),
+		transfers:   repository.NewTransferRepository(db),
+		delegations: repository.NewDelegationRepository(db),
+		shares:      repository.NewShareRepository(db),
+		projects:    repository.NewProjectRepository(db),
+		timeEntries: repository.NewTimeEntryRepository(db),
+		calendar:    repository.NewCalendarTokenRepository(db),
+		checklist:   repository.NewChecklistRepository(db),
+		history:     repository.NewHistoryRepository(db),
+		deadlines:   repository.NewDeadlineRepository(db),
+		comments:    repository.NewCommentRepository(db),
+		reminders:   repository.NewReminderRepository(db),
+		templates:   repository.NewTemplateRepository(db),
+		views:       repository.NewViewRepository(db),
+		watchers:    repository.NewWatcherRepository(db),
+		links:       repository.NewLinkRepository(db),
+		idempotency: repository.NewIdempotencyRepository(db),
+		webhooks:    repository.NewWebhookRepository(db),
+		attachments: repository.NewAttachmentRepository(db),
+		storage:     store,
+
+		streamEvents: repository.NewStreamRepository(db),
+		streams:      newStreamSet(config.Int("STREAM_MAX_PER_USER", defaultStreamMaxPerUser)),
+
+		webhookPolicy: webhooks.NewPolicy(),
+	}
 }
 
 // CreateTask creates a new task
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
+	expand, ok := parseMutationExpand(c)
+	if !ok {
+		return
+	}
+
+	task, ok := bindNewTask(c, userID)
+	if !ok || !h.checkProject(c, task.ProjectID, userID) {
+		return
+	}
+
+	if err := h.tasks.Create(c.Request.Context(), &task); err != nil {
+		respondError(c, err, "Failed to create task")
+		return
+	}
+	if !h.expandMutated(c, &task, expand, "created") {
+		return
+	}
+	h.indexer.Index(c.Request.Context(), task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskCreated, userID, task.ID, &task)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Task created successfully",
+		"task":    task,
+	})
+}
+
+// bindNewTask binds and validates a CreateTaskRequest into a new task owned by
+// userID, writing a 4xx response and returning false if it is invalid
+func bindNewTask(c *gin.Context, userID uuid.UUID) (models.Task, bool) {
 	var req models.CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return models.Task{}, false
+	}
+
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.Task{}, false
 	}
 
+	task, err := newTask(req, userID, loc)
+	var colorErr invalidColorError
+	if errors.As(err, &colorErr) {
+		respondInvalidColor(c, colorErr.color)
+		return models.Task{}, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.Task{}, false
+	}
+	return task, true
+}
+
+// newTask validates a bound CreateTaskRequest and builds a new task owned by
+// userID from it. A due_date_text is read in loc.
+func newTask(req models.CreateTaskRequest, userID uuid.UUID, loc *time.Location) (models.Task, error) {
 	// Set defaults
 	status := "pending"
 	if req.Status != nil {
@@ -40,129 +164,300 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 		priority = *req.Priority
 	}
 
+	title, err := normalizeTitle(req.Title)
+	if err != nil {
+		return models.Task{}, err
+	}
+
 	// Validate status and priority
 	if !isValidStatus(status) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Must be: pending, in_progress, completed, or cancelled"})
-		return
+		return models.Task{}, errors.New("Invalid status. Must be: pending, in_progress, completed, or cancelled")
 	}
 
 	if !isValidPriority(priority) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priority. Must be: low, medium, high, or urgent"})
-		return
+		return models.Task{}, errors.New("Invalid priority. Must be: low, medium, high, or urgent")
+	}
+
+	var color *string
+	if req.Color != nil {
+		normalized, ok := normalizeColor(*req.Color)
+		if !ok {
+			return models.Task{}, invalidColorError{color: *req.Color}
+		}
+		color = &normalized
+	}
+
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return models.Task{}, err
+	}
+
+	dueDate := req.DueDate
+	if req.DueDateText != nil {
+		if req.DueDate != nil {
+			return models.Task{}, errors.New("Use either due_date or due_date_text, not both")
+		}
+		due, err := parseDueDateText(*req.DueDateText, time.Now().In(loc))
+		if err != nil {
+			return models.Task{}, err
+		}
+		dueDate = &due
+	}
+
+	if req.EstimateMinutes != nil && !isValidEffort(*req.EstimateMinutes) {
+		return models.Task{}, errors.New("Invalid estimate_minutes. Must be between 0 and 525600")
+	}
+	if req.ActualMinutes != nil && !isValidEffort(*req.ActualMinutes) {
+		return models.Task{}, errors.New("Invalid actual_minutes. Must be between 0 and 525600")
+	}
+
+	if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+		return models.Task{}, err
+	}
+	var placeName *string
+	if req.PlaceName != nil {
+		normalized, err := normalizePlaceName(*req.PlaceName)
+		if err != nil {
+			return models.Task{}, err
+		}
+		if normalized != "" {
+			placeName = &normalized
+		}
+	}
+
+	if err := validateMetadata(req.Metadata); err != nil {
+		return models.Task{}, err
+	}
+	metadata := req.Metadata
+	if metadata == nil {
+		metadata = models.Metadata{}
+	}
+
+	var rule *string
+	if req.Recurrence != nil {
+		normalized, err := normalizeRecurrence(*req.Recurrence)
+		if err != nil {
+			return models.Task{}, err
+		}
+		rule = &normalized
 	}
 
 	task := models.Task{
 		ID:          uuid.New(),
 		UserID:      userID,
-		Title:       req.Title,
-		Description: req.Description,
+		Title:       title,
+		Description: sanitizeDescription(req.Description),
 		Status:      status,
 		Priority:    priority,
-		DueDate:     req.DueDate,
+		DueDate:     dueDate,
+		Origin:      models.OriginAPI,
+		Color:       color,
+		Tags:        tags,
+		Recurrence:  rule,
+		ProjectID:   req.ProjectID,
+		Metadata:    metadata,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
-	}
 
-	query := `
-		INSERT INTO tasks (id, user_id, title, description, status, priority, due_date, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-	`
+		EstimateMinutes: req.EstimateMinutes,
+		ActualMinutes:   req.ActualMinutes,
 
-	_, err := h.db.Exec(query, task.ID, task.UserID, task.Title, task.Description, task.Status, task.Priority, task.DueDate, task.CreatedAt, task.UpdatedAt)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
-		return
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		PlaceName: placeName,
 	}
-
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "Task created successfully",
-		"task":    task,
-	})
+	return task, nil
 }
 
 // GetTasks retrieves tasks with optional filters
 func (h *TaskHandler) GetTasks(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
-	var filters models.TaskFilters
-	if err := c.ShouldBindQuery(&filters); err != nil {
+	filters, err := parseTaskFilters(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	h.listTasks(c, userID, filters)
+}
+
+// listTasks writes a sorted, paginated page of the user's tasks matching filters
+func (h *TaskHandler) listTasks(c *gin.Context, userID uuid.UUID, filters models.TaskFilters) {
+	orderBy, err := buildOrderBy(filters.Sort, filters.Order)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Build query
-	query := "SELECT * FROM tasks WHERE user_id = $1"
-	args := []interface{}{userID}
-	argCount := 1
+	render, err := parseRender(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	fields, err := parseFields(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
 
-	if filters.Status != "" {
-		argCount++
-		query += " AND status = $" + string(rune(argCount+'0'))
-		args = append(args, filters.Status)
+	offset := (filters.Page - 1) * filters.Limit
+	var cursorDirection string
+	if filters.Cursor != nil {
+		if cursorDirection, err = cursorOrder(filters); err != nil {
+			respondQueryError(c, err)
+			return
+		}
 	}
 
-	if filters.Priority != "" {
-		argCount++
-		query += " AND priority = $" + string(rune(argCount+'0'))
-		args = append(args, filters.Priority)
+	// Deep OFFSET scans get slower with every skipped row, so refuse them
+	maxOffset := config.Int("PAGINATION_MAX_OFFSET", defaultMaxOffset)
+	if offset > maxOffset {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Page too deep: offset %d exceeds the maximum of %d", offset, maxOffset),
+			"hint":  "Use cursor pagination (cursor= instead of page), narrow the results with filters, or reverse the sort order to reach the end of the list",
+		})
+		return
 	}
 
-	query += " ORDER BY created_at DESC"
+	// Pinned tasks come first in the default order
+	if filters.Sort == "" {
+		orderBy = " ORDER BY pinned DESC," + strings.TrimPrefix(orderBy, " ORDER BY")
+	}
 
-	// Pagination
-	if filters.Limit <= 0 {
-		filters.Limit = 10
+	// Build query
+	where := buildTaskWhere(userID, filters)
+	if filters.Cursor != nil {
+		// Cursor pages are keyed on (created_at, id), so pinning doesn't apply
+		orderBy = cursorPage(where, *filters.Cursor, cursorDirection)
+	}
+	args := append([]interface{}{}, where.args...)
+	columns := fields.columns()
+	if filters.Query != "" {
+		args = append(args, filters.Query)
+		columns += ", " + searchColumns("$"+strconv.Itoa(len(args)))
+		// Searches rank the best matches first unless a sort is requested
+		if filters.Sort == "" {
+			orderBy = " ORDER BY search_rank DESC, created_at DESC"
+		}
 	}
-	if filters.Page <= 0 {
-		filters.Page = 1
+	query := "SELECT " + columns + " FROM tasks" + where.sql() + orderBy
+	if filters.Cursor != nil {
+		// One extra row tells whether there is another page
+		query += " LIMIT $" + strconv.Itoa(len(args)+1)
+		args = append(args, filters.Limit+1)
+	} else {
+		query += " LIMIT $" + strconv.Itoa(len(args)+1) + " OFFSET $" + strconv.Itoa(len(args)+2)
+		args = append(args, filters.Limit, offset)
 	}
 
-	offset := (filters.Page - 1) * filters.Limit
-	argCount++
-	query += " LIMIT $" + string(rune(argCount+'0'))
-	args = append(args, filters.Limit)
+	ctx, cancel := repository.ReadContext(c.Request.Context())
+	defer cancel()
 
-	argCount++
-	query += " OFFSET $" + string(rune(argCount+'0'))
-	args = append(args, offset)
+	// Per-status counts ignore the status filter so every tab gets a badge;
+	// the total is read off them. They cover every task the page could
+	// hold, so they also validate the ETag before the page is read.
+	counts, latest, err := h.statusCounts(ctx, userID, filters)
+	if err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch tasks")
+		return
+	}
+	if notModified(c, listETag(c, userID, filters, counts, latest)) {
+		return
+	}
 
 	var tasks []models.Task
-	err := h.db.Select(&tasks, query, args...)
+	if filters.Query != "" {
+		var rows []searchRow
+		err = h.db.SelectContext(ctx, &rows, query, args...)
+		tasks = searchResults(rows)
+	} else {
+		err = h.db.SelectContext(ctx, &tasks, query, args...)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		respondError(c, repository.Translate(err), "Failed to fetch tasks")
 		return
 	}
-
-	// Get total count
-	countQuery := "SELECT COUNT(*) FROM tasks WHERE user_id = $1"
-	countArgs := []interface{}{userID}
-	if filters.Status != "" {
-		countQuery += " AND status = $2"
-		countArgs = append(countArgs, filters.Status)
+	var nextCursor, prevCursor *string
+	if filters.Cursor != nil {
+		hasMore := len(tasks) > filters.Limit
+		if hasMore {
+			tasks = tasks[:filters.Limit]
+		}
+		if filters.Cursor.Before {
+			reverseTasks(tasks)
+		}
+		nextCursor, prevCursor = pageCursors(tasks, *filters.Cursor, hasMore)
+	}
+	if fields.has("tags") {
+		if err := h.tasks.AttachTags(ctx, tasks); err != nil {
+			respondError(c, err, "Failed to fetch tasks")
+			return
+		}
 	}
-	if filters.Priority != "" {
-		idx := len(countArgs) + 1
-		countQuery += " AND priority = $" + string(rune(idx+'0'))
-		countArgs = append(countArgs, filters.Priority)
+	if render {
+		renderDescriptions(tasks)
 	}
 
-	var total int
-	err = h.db.Get(&total, countQuery, countArgs...)
-	if err != nil {
-		total = 0
+	statusFilter, _ := parseValueFilter(filters.Status, nil)
+	total := 0
+	for status, count := range counts {
+		if filters.Status == "" || statusFilter.matches(status) {
+			total += count
+		}
+	}
+
+	pagination := gin.H{
+		"page":  filters.Page,
+		"limit": filters.Limit,
+		"total": total,
+	}
+	if filters.Cursor != nil {
+		pagination = gin.H{
+			"limit":       filters.Limit,
+			"total":       total,
+			"next_cursor": nextCursor,
+			"prev_cursor": prevCursor,
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"tasks": tasks,
-		"pagination": gin.H{
-			"page":  filters.Page,
-			"limit": filters.Limit,
-			"total": total,
-		},
+		"tasks":      fields.shapeAll(tasks),
+		"counts":     counts,
+		"pagination": pagination,
 	})
 }
 
-// GetTask retrieves a single task by ID
+// statusCounts counts the user's tasks per status matching filters other
+// than status, with every status present, and returns when the most
+// recently updated of them changed
+func (h *TaskHandler) statusCounts(ctx context.Context, userID uuid.UUID, filters models.TaskFilters) (map[string]int, *time.Time, error) {
+	filters.Status = ""
+	where := buildTaskWhere(userID, filters)
+
+	var rows []struct {
+		Status string     `db:"status"`
+		Count  int        `db:"count"`
+		Latest *time.Time `db:"latest"`
+	}
+	err := h.db.SelectContext(ctx, &rows, "SELECT status, COUNT(*) AS count, MAX(updated_at) AS latest FROM tasks"+where.sql()+" GROUP BY status", where.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counts := map[string]int{"pending": 0, "in_progress": 0, "completed": 0, "cancelled": 0}
+	var latest *time.Time
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+		if row.Latest != nil && (latest == nil || row.Latest.After(*latest)) {
+			latest = row.Latest
+		}
+	}
+	return counts, latest, nil
+}
+
+// GetTask retrieves a single task by ID, including tasks shared with the
+// caller
 func (h *TaskHandler) GetTask(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	taskID, err := uuid.Parse(c.Param("id"))
@@ -171,23 +466,62 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		return
 	}
 
-	var task models.Task
-	query := "SELECT * FROM tasks WHERE id = $1 AND user_id = $2"
-	err = h.db.Get(&task, query, taskID, userID)
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	expand, err := parseExpand(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+	render, err := parseRender(c)
+	if err != nil {
+		respondQueryError(c, err)
 		return
 	}
+	fields, err := parseFields(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+		respondQueryError(c, err)
+		return
+	}
+
+	task, ok := h.accessibleTask(c, taskID, userID, false, "Failed to fetch task")
+	if !ok {
 		return
 	}
+	if err := h.expandTask(c.Request.Context(), task, expand); err != nil {
+		respondError(c, err, "Failed to fetch task")
+		return
+	}
+	if render {
+		tasks := []models.Task{*task}
+		renderDescriptions(tasks)
+		renderDescriptions(task.Subtasks)
+		*task = tasks[0]
+	}
 
-	c.JSON(http.StatusOK, gin.H{"task": task})
+	response := gin.H{"task": fields.shape(task)}
+	if notModified(c, bodyETag(response)) {
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
 
-// UpdateTask updates a task
+// UpdateTask updates a task the caller owns or that was shared with them
+// with write access
 func (h *TaskHandler) UpdateTask(c *gin.Context) {
+	h.updateTask(c, bindUpdateRequest)
+}
+
+// updateBinder reads the body of an update request, along with the merge
+// patch it came from if any
+type updateBinder func(c *gin.Context) (models.UpdateTaskRequest, *mergePatch, error)
+
+// bindUpdateRequest reads a PUT body; fields left out are unchanged
+func bindUpdateRequest(c *gin.Context) (models.UpdateTaskRequest, *mergePatch, error) {
+	var req models.UpdateTaskRequest
+	err := c.ShouldBindJSON(&req)
+	return req, nil, err
+}
+
+func (h *TaskHandler) updateTask(c *gin.Context, bind updateBinder) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	taskID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -195,27 +529,36 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	var req models.UpdateTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	expand, ok := parseMutationExpand(c)
+	if !ok {
 		return
 	}
 
-	// Check task exists and belongs to user
-	var exists bool
-	err = h.db.Get(&exists, "SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2)", taskID, userID)
-	if err != nil || !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+	// force completes the task even while its blockers are open
+	force, err := queryBool(c, "force")
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	req, patch, err := bind(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Build update query dynamically
 	updates := make(map[string]interface{})
 	if req.Title != nil {
-		updates["title"] = *req.Title
+		title, err := normalizeTitle(*req.Title)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["title"] = title
 	}
 	if req.Description != nil {
-		updates["description"] = *req.Description
+		updates["description"] = *sanitizeDescription(req.Description)
 	}
 	if req.Status != nil {
 		if !isValidStatus(*req.Status) {
@@ -234,50 +577,262 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	if req.DueDate != nil {
 		updates["due_date"] = *req.DueDate
 	}
+	if req.DueDateText != nil {
+		if req.DueDate != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Use either due_date or due_date_text, not both"})
+			return
+		}
+		loc, err := userLocation(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		due, err := parseDueDateText(*req.DueDateText, time.Now().In(loc))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["due_date"] = due
+	}
+	if req.Progress != nil {
+		if !isValidProgress(*req.Progress) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid progress. Must be between 0 and 100"})
+			return
+		}
+		updates["progress"] = *req.Progress
+	}
+	if req.EstimateMinutes != nil {
+		if !isValidEffort(*req.EstimateMinutes) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid estimate_minutes. Must be between 0 and 525600"})
+			return
+		}
+		updates["estimate_minutes"] = *req.EstimateMinutes
+	}
+	if req.ActualMinutes != nil {
+		if !isValidEffort(*req.ActualMinutes) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actual_minutes. Must be between 0 and 525600"})
+			return
+		}
+		updates["actual_minutes"] = *req.ActualMinutes
+	}
+	if req.Color != nil {
+		// An empty string clears the color
+		if *req.Color == "" {
+			updates["color"] = nil
+		} else {
+			normalized, ok := normalizeColor(*req.Color)
+			if !ok {
+				respondInvalidColor(c, *req.Color)
+				return
+			}
+			updates["color"] = normalized
+		}
+	}
 
-	if len(updates) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+	if req.Recurrence != nil {
+		// An empty string stops the task recurring
+		if *req.Recurrence == "" {
+			updates["recurrence"] = nil
+			updates["recurrence_paused"] = false
+		} else {
+			normalized, err := normalizeRecurrence(*req.Recurrence)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			updates["recurrence"] = normalized
+		}
+	}
+
+	var projectID *uuid.UUID
+	if req.ProjectID != nil {
+		// An empty string removes the task from its project
+		if *req.ProjectID == "" {
+			updates["project_id"] = nil
+		} else {
+			id, err := uuid.Parse(*req.ProjectID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+				return
+			}
+			projectID = &id
+			updates["project_id"] = id
+		}
+	}
+
+	if req.ClearLocation {
+		if req.Latitude != nil || req.Longitude != nil || req.PlaceName != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Use either clear_location or a new location, not both"})
+			return
+		}
+		updates["latitude"] = nil
+		updates["longitude"] = nil
+		updates["place_name"] = nil
+	}
+	if err := validateCoordinates(req.Latitude, req.Longitude); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if req.Latitude != nil {
+		updates["latitude"] = *req.Latitude
+		updates["longitude"] = *req.Longitude
+	}
+	if req.PlaceName != nil {
+		// An empty string removes the place name
+		normalized, err := normalizePlaceName(*req.PlaceName)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if normalized == "" {
+			updates["place_name"] = nil
+		} else {
+			updates["place_name"] = normalized
+		}
+	}
 
-	updates["updated_at"] = time.Now()
+	if req.Metadata != nil {
+		if err := validateMetadata(*req.Metadata); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["metadata"] = *req.Metadata
+	}
+
+	var tags *[]string
+	if req.Tags != nil {
+		normalized, err := normalizeTags(*req.Tags)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		tags = &normalized
+	}
 
-	// Build SQL
-	query := "UPDATE tasks SET "
-	args := []interface{}{}
-	i := 1
-	for key, val := range updates {
-		if i > 1 {
-			query += ", "
+	if patch != nil {
+		for _, column := range patch.nulls {
+			updates[column] = nil
 		}
-		query += key + " = $" + string(rune(i+'0'))
-		args = append(args, val)
-		i++
 	}
-	query += " WHERE id = $" + string(rune(i+'0')) + " AND user_id = $" + string(rune(i+1+'0'))
-	args = append(args, taskID, userID)
 
-	_, err = h.db.Exec(query, args...)
+	if len(updates) == 0 && tags == nil && (patch == nil || patch.metadata == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	// Due date changes are recorded as made by the caller, not the owner
+	_, dueDateSet := updates["due_date"]
+	ctx, err := deadlineContext(c.Request.Context(), userID, dueDateSet, req.DueDateReason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, ok := h.accessibleTask(c, taskID, userID, true, "Failed to update task")
+	if !ok {
 		return
 	}
+	// Shared editors update the task on the owner's behalf
+	userID = current.UserID
+	if !h.checkProject(c, projectID, userID) {
+		return
+	}
+
+	if patch != nil && patch.metadata != nil {
+		metadata := mergeMetadata(current.Metadata, patch.metadata)
+		if err := validateMetadata(metadata); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["metadata"] = metadata
+	}
 
-	// Fetch updated task
-	var task models.Task
-	err = h.db.Get(&task, "SELECT * FROM tasks WHERE id = $1", taskID)
+	if req.Recurrence != nil && *req.Recurrence != "" && current.ParentTaskID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtasks cannot recur"})
+		return
+	}
+
+	// Parent tasks derive their progress from their subtasks
+	var totalSubtasks, openSubtasks int
+	if current.ParentTaskID == nil {
+		totalSubtasks, openSubtasks, err = h.tasks.SubtaskCounts(c.Request.Context(), taskID)
+		if err != nil {
+			respondError(c, err, "Failed to update task")
+			return
+		}
+	}
+	if req.Progress != nil && totalSubtasks > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Progress is derived from subtasks and cannot be set directly"})
+		return
+	}
+
+	// Only a transition to completed counts as a completion
+	completing := req.Status != nil && *req.Status == "completed" && current.Status != "completed"
+	if completing && !force && !h.checkBlockers(c, taskID) {
+		return
+	}
+	cascade := false
+	if completing {
+		var ok bool
+		if cascade, ok = applySubtaskPolicy(c, openSubtasks); !ok {
+			return
+		}
+	}
+
+	// Same-value updates skip the write unless no-op detection is disabled.
+	// New tags are set in the same transaction as the other fields.
+	task := current
+	var completedSubtasks []models.Task
+	changed := false
+	skipNoop := config.Bool("UPDATE_SKIP_NOOP", true)
+	switch {
+	case cascade:
+		task, completedSubtasks, err = h.tasks.UpdateCompletingSubtasks(ctx, taskID, userID, updates, tags)
+		changed = true
+	case tags != nil:
+		task, changed, err = h.tasks.UpdateWithTags(ctx, taskID, userID, updates, *tags, skipNoop)
+	case len(updates) == 0:
+	case skipNoop:
+		task, changed, err = h.tasks.UpdateIfChanged(ctx, taskID, userID, updates)
+	default:
+		task, err = h.tasks.Update(ctx, taskID, userID, updates)
+		changed = true
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch updated task"})
+		respondError(c, err, "Failed to update task")
 		return
 	}
 
+	if !h.expandMutated(c, task, expand, "updated") {
+		return
+	}
+
+	if completing {
+		observeCompletion(task)
+	}
+	for i := range completedSubtasks {
+		subtask := &completedSubtasks[i]
+		observeCompletion(subtask)
+		h.indexer.Index(c.Request.Context(), *subtask)
+		h.publishTaskUpdate(c.Request.Context(), userID, subtask, true)
+	}
+
+	message := "Task updated successfully"
+	if changed {
+		h.indexer.Index(c.Request.Context(), *task)
+		h.publishTaskUpdate(c.Request.Context(), userID, task, completing)
+		h.rollupParent(c.Request.Context(), userID, task)
+	} else {
+		message = "Task unchanged"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Task updated successfully",
+		"message": message,
 		"task":    task,
 	})
 }
 
-// DeleteTask deletes a task
+// DeleteTask moves a task and its subtasks to the trash
 func (h *TaskHandler) DeleteTask(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 	taskID, err := uuid.Parse(c.Param("id"))
@@ -286,71 +841,61 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	result, err := h.db.Exec("DELETE FROM tasks WHERE id = $1 AND user_id = $2", taskID, userID)
+	trashed, err := h.tasks.Trash(c.Request.Context(), taskID, userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+		respondError(c, err, "Failed to delete task")
 		return
 	}
-
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
-		return
+	for i := range trashed {
+		task := &trashed[i]
+		h.indexer.Delete(c.Request.Context(), task.ID)
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskDeleted, userID, task.ID, nil)
+		if task.ID == taskID {
+			h.rollupParent(c.Request.Context(), userID, task)
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Task moved to trash"})
 }
 
-// GetStats retrieves task statistics
+// GetStats retrieves task statistics, counting "today" in the caller's
+// timezone
 func (h *TaskHandler) GetStats(c *gin.Context) {
 	userID := c.MustGet("userID").(uuid.UUID)
 
-	stats := models.TaskStats{
-		ByStatus:   make(map[string]int),
-		ByPriority: make(map[string]int),
-	}
-
-	// Total tasks
-	h.db.Get(&stats.TotalTasks, "SELECT COUNT(*) FROM tasks WHERE user_id = $1", userID)
-
-	// By status
-	rows, _ := h.db.Query("SELECT status, COUNT(*) FROM tasks WHERE user_id = $1 GROUP BY status", userID)
-	for rows.Next() {
-		var status string
-		var count int
-		rows.Scan(&status, &count)
-		stats.ByStatus[status] = count
+	loc, err := userLocation(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	rows.Close()
+	zone := loc.String()
 
-	// By priority
-	rows, _ = h.db.Query("SELECT priority, COUNT(*) FROM tasks WHERE user_id = $1 GROUP BY priority", userID)
-	for rows.Next() {
-		var priority string
-		var count int
-		rows.Scan(&priority, &count)
-		stats.ByPriority[priority] = count
+	// Concurrent identical requests (e.g. dashboards polling) share one query
+	result, err, _ := h.statsFlight.Do("stats:"+userID.String()+":"+zone, func() (interface{}, error) {
+		// The result is shared, so don't let one caller's disconnect cancel it
+		ctx, cancel := repository.ReadContext(context.WithoutCancel(c.Request.Context()))
+		defer cancel()
+		return h.computeStats(ctx, userID, zone)
+	})
+	if err != nil {
+		respondError(c, repository.Translate(err), "Failed to fetch stats")
+		return
 	}
-	rows.Close()
-
-	// Overdue tasks
-	h.db.Get(&stats.OverdueTasks,
-		"SELECT COUNT(*) FROM tasks WHERE user_id = $1 AND due_date < NOW() AND status != 'completed'",
-		userID)
-
-	// Completed today
-	h.db.Get(&stats.CompletedToday,
-		"SELECT COUNT(*) FROM tasks WHERE user_id = $1 AND status = 'completed' AND DATE(updated_at) = CURRENT_DATE",
-		userID)
+	stats := result.(*models.TaskStats)
 
 	c.JSON(http.StatusOK, gin.H{"stats": stats})
 }
 
-// Health check
+// Health check with build and uptime information
 func (h *TaskHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "tasks-service",
+		"status":         "healthy",
+		"service":        "tasks-service",
+		"version":        buildinfo.Version,
+		"commit":         buildinfo.Commit,
+		"go_version":     buildinfo.GoVersion(),
+		"started_at":     buildinfo.StartedAt(),
+		"uptime_seconds": int64(buildinfo.Uptime().Seconds()),
 	})
 }
 
@@ -365,6 +910,25 @@ func isValidStatus(status string) bool {
 	return false
 }
 
+func isValidOrigin(origin string) bool {
+	switch origin {
+	case models.OriginAPI, models.OriginImport, models.OriginRecurring, models.OriginTemplate:
+		return true
+	}
+	return false
+}
+
+func isValidProgress(progress int) bool {
+	return progress >= 0 && progress <= 100
+}
+
+// maxEffortMinutes bounds effort estimates and actuals to a year
+const maxEffortMinutes = 365 * 24 * 60
+
+func isValidEffort(minutes int) bool {
+	return minutes >= 0 && minutes <= maxEffortMinutes
+}
+
 func isValidPriority(priority string) bool {
 	validPriorities := []string{"low", "medium", "high", "urgent"}
 	for _, p := range validPriorities {
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTasksRejectsOffsetPastCeiling(t *testing.T) {
+	router := newRouter(uuid.New(), http.MethodGet, "/tasks", (&TaskHandler{}).GetTasks)
+
+	t.Run("configured", func(t *testing.T) {
+		t.Setenv("PAGINATION_MAX_OFFSET", "99")
+		w := serve(t, router, http.MethodGet, "/tasks?page=11&limit=10", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		body := decode(t, w)
+		assert.Equal(t, "Page too deep: offset 100 exceeds the maximum of 99", body["error"])
+		assert.Equal(t, "Use cursor pagination (cursor= instead of page), narrow the results with filters, or reverse the sort order to reach the end of the list", body["hint"])
+	})
+
+	t.Run("default", func(t *testing.T) {
+		w := serve(t, router, http.MethodGet, "/tasks?page=202&limit=50", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, decode(t, w)["error"], "offset 10050 exceeds the maximum of 10000")
+	})
+}
+
+func TestGetTasksServesOffsetAtCeiling(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("PAGINATION_MAX_OFFSET", "100")
+	userID := seedUser(t, db, "reader")
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodGet, "/tasks", h.GetTasks)
+
+	w := serve(t, router, http.MethodGet, "/tasks?page=11&limit=10", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, decode(t, w)["tasks"])
+
+	w = serve(t, router, http.MethodGet, "/tasks?page=12&limit=10", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "one page past the ceiling is refused")
+}
+
+func TestSameValueUpdateSkipsWrite(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "idler")
+	taskID := uuid.New()
+	updatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title, priority, updated_at) VALUES ($1, $2, 'Same', 'high', $3)", taskID, userID, updatedAt)
+	events := &recordedEvents{}
+	h := NewTaskHandler(db, search.NoopIndexer{}, events, nil)
+	router := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	lastUpdate := func() time.Time {
+		var at time.Time
+		require.NoError(t, db.Get(&at, "SELECT updated_at FROM tasks WHERE id = $1", taskID))
+		return at.UTC()
+	}
+
+	w := serve(t, router, http.MethodPut, "/tasks/"+taskID.String(), map[string]interface{}{"title": "Same", "priority": "high"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	body := decode(t, w)
+	assert.Equal(t, "Task unchanged", body["message"])
+	assert.Equal(t, "Same", body["task"].(map[string]interface{})["title"])
+	assert.Equal(t, updatedAt, lastUpdate(), "nothing was written")
+	assert.Empty(t, events.types(), "no event for a no-op")
+
+	w = serve(t, router, http.MethodPut, "/tasks/"+taskID.String(), map[string]interface{}{"title": "Same", "priority": "low"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "Task updated successfully", decode(t, w)["message"])
+	assert.True(t, lastUpdate().After(updatedAt))
+	assert.Equal(t, []string{models.EventTaskUpdated}, events.types())
+}
+
+func TestSameValueUpdateWritesWhenDetectionDisabled(t *testing.T) {
+	db := testdb.Open(t)
+	t.Setenv("UPDATE_SKIP_NOOP", "false")
+	userID := seedUser(t, db, "idler")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Same')", taskID, userID)
+	events := &recordedEvents{}
+	h := NewTaskHandler(db, search.NoopIndexer{}, events, nil)
+	router := newRouter(userID, http.MethodPut, "/tasks/:id", h.UpdateTask)
+
+	w := serve(t, router, http.MethodPut, "/tasks/"+taskID.String(), map[string]interface{}{"title": "Same"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "Task updated successfully", decode(t, w)["message"])
+	assert.Equal(t, []string{models.EventTaskUpdated}, events.types())
+}
+
+// recordedIndex is a search.Indexer that records "index <id>" and
+// "delete <id>" operations
+type recordedIndex struct {
+	mu  sync.Mutex
+	ops []string
+}
+
+func (r *recordedIndex) Index(ctx context.Context, task models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, "index "+task.ID.String())
+	return nil
+}
+
+func (r *recordedIndex) Delete(ctx context.Context, taskID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, "delete "+taskID.String())
+	return nil
+}
+
+func TestTaskMutationsAreIndexed(t *testing.T) {
+	db := testdb.Open(t)
+	userID := seedUser(t, db, "searcher")
+	index := &recordedIndex{}
+	h := NewTaskHandler(db, index, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodPost, "/tasks", h.CreateTask)
+	router.PUT("/tasks/:id", h.UpdateTask)
+	router.DELETE("/tasks/:id", h.DeleteTask)
+
+	w := serve(t, router, http.MethodPost, "/tasks", map[string]interface{}{"title": "Indexed"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	taskID := decode(t, w)["task"].(map[string]interface{})["id"].(string)
+
+	w = serve(t, router, http.MethodPut, "/tasks/"+taskID, map[string]interface{}{"title": "Reindexed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	// A no-op update leaves the index alone
+	w = serve(t, router, http.MethodPut, "/tasks/"+taskID, map[string]interface{}{"title": "Reindexed"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = serve(t, router, http.MethodDelete, "/tasks/"+taskID, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	assert.Equal(t, []string{"index " + taskID, "index " + taskID, "delete " + taskID}, index.ops)
+}
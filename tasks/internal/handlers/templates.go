@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// maxChecklistItems bounds how many subtasks one instantiation creates
+const maxChecklistItems = 50
+
+// normalizeChecklist trims checklist items and drops blank ones
+func normalizeChecklist(items []string) ([]string, error) {
+	checklist := make([]string, 0, len(items))
+	for _, item := range items {
+		title, err := normalizeTitle(item)
+		if err == errBlankTitle {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("checklist item: %w", err)
+		}
+		checklist = append(checklist, title)
+	}
+	if len(checklist) > maxChecklistItems {
+		return nil, fmt.Errorf("checklist must have at most %d items", maxChecklistItems)
+	}
+	return checklist, nil
+}
+
+// CreateTemplate saves a template from the request fields or, with task_id,
+// from an existing task whose subtask titles become the checklist
+func (h *TaskHandler) CreateTemplate(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template := models.TaskTemplate{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Priority:  "medium",
+		Checklist: []string{},
+		CreatedAt: time.Now(),
+	}
+
+	if req.TaskID != nil {
+		task, err := h.tasks.GetByID(c.Request.Context(), *req.TaskID, userID)
+		if err != nil {
+			respondError(c, err, "Failed to create template")
+			return
+		}
+		subtasks, err := h.tasks.ListSubtasks(c.Request.Context(), task.ID, userID)
+		if err != nil {
+			respondError(c, err, "Failed to create template")
+			return
+		}
+		template.Title = task.Title
+		template.Description = sanitizeDescription(task.Description)
+		template.Priority = task.Priority
+		for _, subtask := range subtasks {
+			template.Checklist = append(template.Checklist, subtask.Title)
+		}
+	}
+
+	if req.Title != nil {
+		template.Title = *req.Title
+	}
+	title, err := normalizeTitle(template.Title)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	template.Title = title
+
+	if req.Description != nil {
+		template.Description = sanitizeDescription(req.Description)
+	}
+	if req.Priority != nil {
+		if !isValidPriority(*req.Priority) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priority. Must be: low, medium, high, or urgent"})
+			return
+		}
+		template.Priority = *req.Priority
+	}
+	if req.Checklist != nil {
+		template.Checklist = *req.Checklist
+	}
+	checklist, err := normalizeChecklist(template.Checklist)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	template.Checklist = checklist
+
+	if err := h.templates.Create(c.Request.Context(), &template); err != nil {
+		respondResourceError(c, err, "Template", "Failed to create template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+// GetTemplates lists the caller's templates
+func (h *TaskHandler) GetTemplates(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	templates, err := h.templates.List(c.Request.Context(), userID)
+	if err != nil {
+		respondResourceError(c, err, "Template", "Failed to fetch templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+// InstantiateTemplate creates a task from a template, with one pending
+// subtask per checklist item
+func (h *TaskHandler) InstantiateTemplate(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	// The body is optional
+	var req models.InstantiateTemplateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	template, err := h.templates.GetByID(c.Request.Context(), templateID, userID)
+	if err != nil {
+		respondResourceError(c, err, "Template", "Failed to instantiate template")
+		return
+	}
+
+	title := template.Title
+	if req.Title != nil {
+		title, err = normalizeTitle(*req.Title)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	now := time.Now()
+	task := models.Task{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Title:       title,
+		Description: template.Description,
+		Status:      "pending",
+		Priority:    template.Priority,
+		DueDate:     req.DueDate,
+		Origin:      models.OriginTemplate,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	subtasks := make([]models.Task, len(template.Checklist))
+	for i, item := range template.Checklist {
+		subtasks[i] = models.Task{
+			ID:           uuid.New(),
+			UserID:       userID,
+			ParentTaskID: &task.ID,
+			Title:        item,
+			Status:       "pending",
+			Priority:     template.Priority,
+			DueDate:      req.DueDate,
+			Origin:       models.OriginTemplate,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+	}
+
+	if err := h.tasks.CreateWithSubtasks(c.Request.Context(), &task, subtasks); err != nil {
+		respondError(c, err, "Failed to instantiate template")
+		return
+	}
+	h.indexer.Index(c.Request.Context(), task)
+	h.publishTaskEvent(c.Request.Context(), models.EventTaskCreated, userID, task.ID, &task)
+	for i := range subtasks {
+		subtask := &subtasks[i]
+		h.indexer.Index(c.Request.Context(), *subtask)
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskCreated, userID, subtask.ID, subtask)
+	}
+
+	task.Subtasks = subtasks
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Task created from template",
+		"task":    task,
+	})
+}
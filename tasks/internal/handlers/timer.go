@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// timeStatsMaxTasks bounds how many tasks the tracked time summary lists
+const timeStatsMaxTasks = 10
+
+// StartTimer starts tracking the caller's time on a task. Each user has at
+// most one running timer.
+func (h *TaskHandler) StartTimer(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, true, "Failed to start timer"); !ok {
+		return
+	}
+
+	entry := models.TimeEntry{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		UserID:    userID,
+		StartedAt: time.Now(),
+	}
+	err = h.timeEntries.Start(c.Request.Context(), &entry)
+	if errors.Is(err, repository.ErrConflict) {
+		running, err := h.timeEntries.Running(c.Request.Context(), userID)
+		if err != nil {
+			respondResourceError(c, err, "Timer", "Failed to start timer")
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "A timer is already running; stop it first",
+			"running": running,
+		})
+		return
+	}
+	if err != nil {
+		respondResourceError(c, err, "Timer", "Failed to start timer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Timer started",
+		"entry":   entry,
+	})
+}
+
+// StopTimer stops the caller's running timer on a task and reports the time
+// tracked on the task so far
+func (h *TaskHandler) StopTimer(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	entry, err := h.timeEntries.Stop(c.Request.Context(), taskID, userID, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No timer is running on this task"})
+		return
+	}
+	if err != nil {
+		respondResourceError(c, err, "Timer", "Failed to stop timer")
+		return
+	}
+
+	tracked, err := h.timeEntries.TaskTotal(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondResourceError(c, err, "Timer", "Failed to fetch tracked time")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Timer stopped",
+		"entry":            entry,
+		"duration_seconds": int64(entry.StoppedAt.Sub(entry.StartedAt).Seconds()),
+		"tracked_seconds":  tracked,
+	})
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// userLocation resolves the caller's timezone from the ?tz= query parameter
+// or the X-Timezone header (IANA names such as "Europe/Berlin"), falling back
+// to the timezone saved for the user and then UTC
+func userLocation(c *gin.Context) (*time.Location, error) {
+	name := c.Query("tz")
+	if name == "" {
+		name = c.GetHeader("X-Timezone")
+	}
+	if name == "" {
+		name = c.GetString("timezone")
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", name)
+	}
+	return loc, nil
+}
+
+// startOfDay returns midnight of t's calendar day in loc
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
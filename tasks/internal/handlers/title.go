@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxTitleLength matches the tasks.title column
+const maxTitleLength = 255
+
+var (
+	errBlankTitle   = errors.New("title is required")
+	errTitleTooLong = errors.New("title must be at most 255 characters")
+)
+
+// normalizeTitle trims surrounding whitespace and rejects titles that are
+// blank afterwards or too long for the column
+func normalizeTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", errBlankTitle
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		return "", errTitleTooLong
+	}
+	return title, nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// TransferTask starts a transfer of task ownership to another user.
+// Ownership only changes once the recipient accepts.
+func (h *TaskHandler) TransferTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req models.CreateTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.ToUserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot transfer a task to yourself"})
+		return
+	}
+
+	task, err := h.tasks.GetByID(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to create transfer")
+		return
+	}
+	if task.ParentTaskID != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subtasks move with their parent; transfer the parent task instead"})
+		return
+	}
+
+	exists, err := h.transfers.UserExists(c.Request.Context(), req.ToUserID)
+	if err != nil {
+		respondError(c, err, "Failed to look up recipient")
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recipient not found"})
+		return
+	}
+
+	transfer, err := h.transfers.Create(c.Request.Context(), taskID, userID, req.ToUserID)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task already has a pending transfer"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to create transfer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Transfer requested, waiting for recipient to accept",
+		"transfer": transfer,
+	})
+}
+
+// GetPendingTransfers lists transfers waiting for the current user's response
+func (h *TaskHandler) GetPendingTransfers(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	transfers, err := h.transfers.ListPending(c.Request.Context(), userID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch transfers")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": transfers})
+}
+
+// AcceptTransfer accepts a pending transfer, making the current user the owner
+func (h *TaskHandler) AcceptTransfer(c *gin.Context) {
+	h.respondToTransfer(c, true)
+}
+
+// RejectTransfer rejects a pending transfer, leaving ownership unchanged
+func (h *TaskHandler) RejectTransfer(c *gin.Context) {
+	h.respondToTransfer(c, false)
+}
+
+func (h *TaskHandler) respondToTransfer(c *gin.Context, accept bool) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	transferID, err := uuid.Parse(c.Param("transferId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transfer ID"})
+		return
+	}
+
+	transfer, err := h.transfers.Respond(c.Request.Context(), transferID, userID, accept)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Task ownership changed since the transfer was requested"})
+		return
+	}
+	if err != nil {
+		respondResourceError(c, err, "Transfer", "Failed to update transfer")
+		return
+	}
+
+	message := "Transfer rejected"
+	if accept {
+		message = "Transfer accepted, you now own the task"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"transfer": transfer,
+	})
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTaskToSelfIsRejected(t *testing.T) {
+	userID := uuid.New()
+	router := newRouter(userID, http.MethodPost, "/tasks/:id/transfer", (&TaskHandler{}).TransferTask)
+
+	w := serve(t, router, http.MethodPost, "/tasks/"+uuid.NewString()+"/transfer", map[string]interface{}{"to_user_id": userID})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Cannot transfer a task to yourself", decode(t, w)["error"])
+}
+
+// transferRouter routes the transfer endpoints as userID
+func transferRouter(db *database.DB, userID uuid.UUID) *gin.Engine {
+	h := NewTaskHandler(db, search.NoopIndexer{}, &recordedEvents{}, nil)
+	router := newRouter(userID, http.MethodPost, "/tasks/:id/transfer", h.TransferTask)
+	router.GET("/tasks/transfers/pending", h.GetPendingTransfers)
+	router.POST("/tasks/transfers/:transferId/accept", h.AcceptTransfer)
+	router.POST("/tasks/transfers/:transferId/reject", h.RejectTransfer)
+	return router
+}
+
+// requestTransfer has owner offer taskID to recipient and returns the
+// transfer ID
+func requestTransfer(t *testing.T, db *database.DB, owner, recipient, taskID uuid.UUID) string {
+	t.Helper()
+	w := serve(t, transferRouter(db, owner), http.MethodPost, "/tasks/"+taskID.String()+"/transfer", map[string]interface{}{"to_user_id": recipient})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	transfer := decode(t, w)["transfer"].(map[string]interface{})
+	assert.Equal(t, "pending", transfer["status"])
+	return transfer["id"].(string)
+}
+
+// ownerOf returns the user who owns taskID
+func ownerOf(t *testing.T, db *database.DB, taskID uuid.UUID) uuid.UUID {
+	t.Helper()
+	var owner uuid.UUID
+	require.NoError(t, db.Get(&owner, "SELECT user_id FROM tasks WHERE id = $1", taskID))
+	return owner
+}
+
+func TestTransferTaskAccepted(t *testing.T) {
+	db := testdb.Open(t)
+	owner, recipient, stranger := seedUser(t, db, "owner"), seedUser(t, db, "recipient"), seedUser(t, db, "stranger")
+	taskID, subtaskID := uuid.New(), uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Hand over')", taskID, owner)
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, parent_task_id, title) VALUES ($1, $2, $3, 'Part')", subtaskID, owner, taskID)
+
+	transferID := requestTransfer(t, db, owner, recipient, taskID)
+	assert.Equal(t, owner, ownerOf(t, db, taskID), "ownership waits for the recipient")
+
+	w := serve(t, transferRouter(db, owner), http.MethodPost, "/tasks/"+taskID.String()+"/transfer", map[string]interface{}{"to_user_id": stranger})
+	assert.Equal(t, http.StatusConflict, w.Code, "one pending transfer per task")
+
+	w = serve(t, transferRouter(db, recipient), http.MethodGet, "/tasks/transfers/pending", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Len(t, decode(t, w)["transfers"], 1)
+
+	w = serve(t, transferRouter(db, stranger), http.MethodPost, "/tasks/transfers/"+transferID+"/accept", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "only the recipient can accept")
+
+	w = serve(t, transferRouter(db, recipient), http.MethodPost, "/tasks/transfers/"+transferID+"/accept", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "accepted", decode(t, w)["transfer"].(map[string]interface{})["status"])
+	assert.Equal(t, recipient, ownerOf(t, db, taskID))
+	assert.Equal(t, recipient, ownerOf(t, db, subtaskID), "subtasks move with their parent")
+
+	w = serve(t, transferRouter(db, recipient), http.MethodPost, "/tasks/transfers/"+transferID+"/reject", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code, "a transfer is answered once")
+}
+
+func TestTransferTaskRejected(t *testing.T) {
+	db := testdb.Open(t)
+	owner, recipient := seedUser(t, db, "owner"), seedUser(t, db, "recipient")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Keep')", taskID, owner)
+
+	transferID := requestTransfer(t, db, owner, recipient, taskID)
+	w := serve(t, transferRouter(db, recipient), http.MethodPost, "/tasks/transfers/"+transferID+"/reject", nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "rejected", decode(t, w)["transfer"].(map[string]interface{})["status"])
+	assert.Equal(t, owner, ownerOf(t, db, taskID))
+
+	// Once rejected the task can be offered again
+	requestTransfer(t, db, owner, recipient, taskID)
+}
+
+func TestTransferTaskToUnknownUser(t *testing.T) {
+	db := testdb.Open(t)
+	owner := seedUser(t, db, "owner")
+	taskID := uuid.New()
+	mustExec(t, db, "INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Keep')", taskID, owner)
+
+	w := serve(t, transferRouter(db, owner), http.MethodPost, "/tasks/"+taskID.String()+"/transfer", map[string]interface{}{"to_user_id": uuid.New()})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "Recipient not found", decode(t, w)["error"])
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// GetTrash lists the caller's trashed tasks, most recently deleted first.
+// Subtasks trashed with their parent are listed through the parent.
+func (h *TaskHandler) GetTrash(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	tasks, total, err := h.tasks.ListTrash(c.Request.Context(), userID, limit, (page-1)*limit)
+	if err != nil {
+		respondError(c, err, "Failed to fetch trash")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tasks":     tasks,
+		"retention": config.Duration("TRASH_RETENTION", 30*24*time.Hour).String(),
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// RestoreTask takes a task and the subtasks trashed along with it out of the
+// trash
+func (h *TaskHandler) RestoreTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	restored, err := h.tasks.Restore(c.Request.Context(), taskID, userID)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Restore the parent task first"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to restore task")
+		return
+	}
+	for i := range restored {
+		h.indexer.Index(c.Request.Context(), restored[i])
+		h.publishTaskEvent(c.Request.Context(), models.EventTaskRestored, userID, restored[i].ID, &restored[i])
+	}
+	task := &restored[0]
+	h.rollupParent(c.Request.Context(), userID, task)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task restored successfully",
+		"task":    task,
+	})
+}
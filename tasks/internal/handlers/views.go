@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// maxViewNameLength bounds a saved view's name in characters
+const maxViewNameLength = 100
+
+// CreateView saves a named combination of task list filters
+func (h *TaskHandler) CreateView(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.CreateSavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "View name is required"})
+		return
+	}
+	if utf8.RuneCountInString(name) > maxViewNameLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "View name must be at most 100 characters"})
+		return
+	}
+	if req.Status != nil && !isValidStatus(*req.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Must be: pending, in_progress, completed, or cancelled"})
+		return
+	}
+	if req.Priority != nil && !isValidPriority(*req.Priority) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid priority. Must be: low, medium, high, or urgent"})
+		return
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	view := models.SavedView{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Status:    req.Status,
+		Priority:  req.Priority,
+		Tags:      tags,
+		Sort:      req.Sort,
+		Order:     req.Order,
+		CreatedAt: time.Now(),
+	}
+	applied := viewFilters(&view, models.TaskFilters{})
+	if _, err := buildOrderBy(applied.Sort, applied.Order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.views.Create(c.Request.Context(), &view)
+	if errors.Is(err, repository.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A view with this name already exists"})
+		return
+	}
+	if err != nil {
+		respondResourceError(c, err, "View", "Failed to create view")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "View created successfully",
+		"view":    view,
+	})
+}
+
+// GetViews lists the caller's saved views by name
+func (h *TaskHandler) GetViews(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	views, err := h.views.List(c.Request.Context(), userID)
+	if err != nil {
+		respondResourceError(c, err, "View", "Failed to fetch views")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"views": views})
+}
+
+// DeleteView removes one of the caller's saved views
+func (h *TaskHandler) DeleteView(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	viewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	if err := h.views.Delete(c.Request.Context(), viewID, userID); err != nil {
+		respondResourceError(c, err, "View", "Failed to delete view")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "View deleted successfully"})
+}
+
+// GetViewTasks lists tasks through a saved view. The view's fields replace
+// the matching list parameters; the other parameters, such as pagination,
+// apply as for the task list.
+func (h *TaskHandler) GetViewTasks(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	viewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid view ID"})
+		return
+	}
+
+	filters, err := parseTaskFilters(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	view, err := h.views.GetByID(c.Request.Context(), viewID, userID)
+	if err != nil {
+		respondResourceError(c, err, "View", "Failed to fetch view")
+		return
+	}
+
+	h.listTasks(c, userID, viewFilters(view, filters))
+}
+
+// viewFilters applies a saved view's fields on top of filters
+func viewFilters(view *models.SavedView, filters models.TaskFilters) models.TaskFilters {
+	if view.Status != nil {
+		filters.Status = *view.Status
+	}
+	if view.Priority != nil {
+		filters.Priority = *view.Priority
+	}
+	if len(view.Tags) > 0 {
+		filters.Tags = view.Tags
+	}
+	if view.Sort != nil {
+		filters.Sort = *view.Sort
+	}
+	if view.Order != nil {
+		filters.Order = *view.Order
+	}
+	return filters
+}
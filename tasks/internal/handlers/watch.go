@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// WatchTask subscribes the caller to events of a task shared with them, so
+// the notification service alerts them when it changes
+func (h *TaskHandler) WatchTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, ok := h.accessibleTask(c, taskID, userID, false, "Failed to watch task")
+	if !ok {
+		return
+	}
+	if task.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You already receive events for your own tasks"})
+		return
+	}
+
+	watcher, err := h.watchers.Watch(c.Request.Context(), taskID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to watch task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task watched successfully",
+		"watcher": watcher,
+	})
+}
+
+// UnwatchTask stops the caller's subscription to a task's events
+func (h *TaskHandler) UnwatchTask(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	// Unwatching needs no access, so users can stop watching tasks that are
+	// no longer shared with them
+	err = h.watchers.Unwatch(c.Request.Context(), taskID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You are not watching this task"})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to unwatch task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Task unwatched successfully"})
+}
+
+// GetWatchers lists the users watching a task
+func (h *TaskHandler) GetWatchers(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	taskID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	if _, ok := h.accessibleTask(c, taskID, userID, false, "Failed to fetch watchers"); !ok {
+		return
+	}
+
+	watchers, err := h.watchers.List(c.Request.Context(), taskID)
+	if err != nil {
+		respondError(c, err, "Failed to fetch watchers")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"watchers": watchers})
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+const defaultWebhookMaxPerUser = 10
+
+// webhookEventTypes are the event types a webhook can subscribe to
+var webhookEventTypes = map[string]bool{
+	models.EventTaskCreated:            true,
+	models.EventTaskUpdated:            true,
+	models.EventTaskDeleted:            true,
+	models.EventTaskReopened:           true,
+	models.EventTaskRestored:           true,
+	models.EventTaskCompleted:          true,
+	models.EventTaskEscalated:          true,
+	models.EventTaskOverdue:            true,
+	models.EventTaskMentioned:          true,
+	models.EventTaskDelegated:          true,
+	models.EventTaskDelegationAccepted: true,
+	models.EventTaskDelegationDeclined: true,
+	models.EventTaskReminderDue:        true,
+}
+
+// CreateWebhook registers a URL to receive the caller's task events of the
+// given types. The response includes the secret deliveries are signed with;
+// it is not shown again.
+func (h *TaskHandler) CreateWebhook(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhookURL, err := normalizeLinkURL(req.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Webhook URL must be an absolute http or https URL of at most %d characters", maxLinkURLLength),
+		})
+		return
+	}
+	eventTypes, err := normalizeEventTypes(req.EventTypes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.webhookPolicy.CheckURL(c.Request.Context(), webhookURL); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	count, err := h.webhooks.Count(c.Request.Context(), userID)
+	if err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to create webhook")
+		return
+	}
+	if maxWebhooks := config.Int("WEBHOOK_MAX_PER_USER", defaultWebhookMaxPerUser); count >= maxWebhooks {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A user can have at most %d webhooks", maxWebhooks)})
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	webhook := models.Webhook{
+		ID:         uuid.New(),
+		UserID:     userID,
+		URL:        webhookURL,
+		EventTypes: eventTypes,
+		Secret:     hex.EncodeToString(secret),
+		CreatedAt:  time.Now(),
+	}
+	if err := h.webhooks.Create(c.Request.Context(), &webhook); err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to create webhook")
+		return
+	}
+
+	log.Printf("🪝 Webhook %s registered for user %s\n", webhook.ID, userID)
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully",
+		"webhook": webhook,
+	})
+}
+
+// GetWebhooks lists the caller's webhooks, oldest first, without their secrets
+func (h *TaskHandler) GetWebhooks(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+
+	webhooks, err := h.webhooks.List(c.Request.Context(), userID)
+	if err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to fetch webhooks")
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// UpdateWebhook changes a webhook's URL and/or event types. A new URL is
+// checked against the webhook policy as on registration.
+func (h *TaskHandler) UpdateWebhook(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updates := make(map[string]interface{})
+	if req.EventTypes != nil {
+		if len(req.EventTypes) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A webhook needs at least one event type"})
+			return
+		}
+		eventTypes, err := normalizeEventTypes(req.EventTypes)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updates["event_types"] = pq.StringArray(eventTypes)
+	}
+	if req.URL != nil {
+		webhookURL, err := normalizeLinkURL(*req.URL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("Webhook URL must be an absolute http or https URL of at most %d characters", maxLinkURLLength),
+			})
+			return
+		}
+		if err := h.webhookPolicy.CheckURL(c.Request.Context(), webhookURL); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		updates["url"] = webhookURL
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	if err := h.webhooks.Update(c.Request.Context(), webhookID, userID, updates); err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to update webhook")
+		return
+	}
+	webhook, err := h.webhooks.GetByID(c.Request.Context(), webhookID, userID)
+	if err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to fetch updated webhook")
+		return
+	}
+	webhook.Secret = ""
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook updated successfully",
+		"webhook": webhook,
+	})
+}
+
+// DeleteWebhook removes one of the caller's webhooks and its delivery log.
+// Deliveries still pending are dropped.
+func (h *TaskHandler) DeleteWebhook(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	if err := h.webhooks.Delete(c.Request.Context(), webhookID, userID); err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// GetWebhookDeliveries lists a webhook's deliveries, newest first, with
+// pagination. ?status= keeps only pending, succeeded or failed deliveries.
+func (h *TaskHandler) GetWebhookDeliveries(c *gin.Context) {
+	userID := c.MustGet("userID").(uuid.UUID)
+	webhookID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		respondQueryError(c, &queryParamError{field: "status", message: "status must be pending, succeeded or failed"})
+		return
+	}
+	page, limit, err := parsePagination(c)
+	if err != nil {
+		respondQueryError(c, err)
+		return
+	}
+
+	if _, err := h.webhooks.GetByID(c.Request.Context(), webhookID, userID); err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to fetch deliveries")
+		return
+	}
+
+	deliveries, total, err := h.webhooks.ListDeliveries(c.Request.Context(), webhookID, status, page, limit)
+	if err != nil {
+		respondResourceError(c, err, "Webhook", "Failed to fetch deliveries")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// normalizeEventTypes checks that each event type can be subscribed to and
+// drops duplicates
+func normalizeEventTypes(eventTypes []string) ([]string, error) {
+	seen := make(map[string]bool, len(eventTypes))
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !webhookEventTypes[eventType] {
+			known := make([]string, 0, len(webhookEventTypes))
+			for t := range webhookEventTypes {
+				known = append(known, t)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("Unknown event type %q. Must be one of: %s", eventType, strings.Join(known, ", "))
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			normalized = append(normalized, eventType)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/webhooks"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCreateWebhookRefusesPrivateURL covers the policy check, which runs
+// before anything is read from or written to the database
+func TestCreateWebhookRefusesPrivateURL(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "")
+	t.Setenv("WEBHOOK_DENIED_CIDRS", "")
+	h := &TaskHandler{webhookPolicy: webhooks.NewPolicy()}
+
+	for _, url := range []string{
+		"http://127.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.1.1/hook",
+		"http://[::ffff:169.254.169.254]/hook",
+	} {
+		t.Run(url, func(t *testing.T) {
+			router := newRouter(uuid.New(), http.MethodPost, "/webhooks", h.CreateWebhook)
+			w := serve(t, router, http.MethodPost, "/webhooks",
+				map[string]interface{}{"url": url, "event_types": []string{"task.created"}})
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+			assert.Contains(t, decode(t, w)["error"], "not a public address")
+		})
+	}
+}
+
+func TestUpdateWebhookRefusesPrivateURL(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "")
+	t.Setenv("WEBHOOK_DENIED_CIDRS", "")
+	h := &TaskHandler{webhookPolicy: webhooks.NewPolicy()}
+
+	router := newRouter(uuid.New(), http.MethodPut, "/webhooks/:id", h.UpdateWebhook)
+	w := serve(t, router, http.MethodPut, "/webhooks/"+uuid.NewString(),
+		map[string]interface{}{"url": "http://10.1.2.3/hook"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+}
+
+func TestCreateWebhookRefusesUnlistedHost(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "hooks.example.com")
+	h := &TaskHandler{webhookPolicy: webhooks.NewPolicy()}
+
+	router := newRouter(uuid.New(), http.MethodPost, "/webhooks", h.CreateWebhook)
+	w := serve(t, router, http.MethodPost, "/webhooks",
+		map[string]interface{}{"url": "https://elsewhere.example.com/hook", "event_types": []string{"task.created"}})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	assert.Contains(t, decode(t, w)["error"], "WEBHOOK_ALLOWED_HOSTS")
+}
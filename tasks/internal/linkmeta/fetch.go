@@ -0,0 +1,265 @@
+package linkmeta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// maxBodyBytes bounds how much of a page is read looking for its head
+	maxBodyBytes = 1 << 20
+	// maxRedirects bounds the redirects followed to reach a page
+	maxRedirects = 3
+	// maxTitleLength matches the length of stored link titles
+	maxTitleLength = 255
+)
+
+// ErrPrivateAddress means a URL points at a loopback, private or other
+// non-public address, which is never connected to
+var ErrPrivateAddress = errors.New("URL resolves to a non-public address")
+
+// Metadata is what a page says about itself. Fields are empty when the page
+// doesn't provide them.
+type Metadata struct {
+	Title      string
+	FaviconURL string
+}
+
+var client = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: RefusePrivate,
+		}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 5 * time.Second,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+// RefusePrivate rejects connections to non-public addresses. Used as a
+// net.Dialer's Control it runs after name resolution, so hostnames that
+// resolve to internal addresses are caught too.
+func RefusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !IsPublic(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
+
+// nonPublicNets are the special-purpose ranges net.IP's own checks miss:
+// "this network", shared address space (CGNAT), IETF protocol assignments,
+// documentation, benchmarking, reserved space and local-use NAT64
+var nonPublicNets = mustParseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"240.0.0.0/4",
+	"2001:db8::/32",
+	"64:ff9b:1::/48",
+)
+
+// embeddedIPv4Nets are IPv6 ranges whose last or leading bytes carry an
+// IPv4 address that traffic ends up at: NAT64, IPv4-compatible and 6to4
+var embeddedIPv4Nets = mustParseCIDRs("64:ff9b::/96", "::/96", "2002::/16")
+
+// IsPublic reports whether ip is a globally routable unicast address.
+// IPv4-mapped, NAT64, IPv4-compatible and 6to4 IPv6 addresses are judged by
+// the IPv4 address they carry, so "::ffff:169.254.169.254" is not public.
+func IsPublic(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	} else if ip4 := embeddedIPv4(ip); ip4 != nil {
+		// "::" itself falls in ::/96 and is unspecified either way
+		if ip.IsUnspecified() || !IsPublic(ip4) {
+			return false
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range nonPublicNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// embeddedIPv4 returns the IPv4 address inside an IPv6 address from
+// embeddedIPv4Nets, or nil
+func embeddedIPv4(ip net.IP) net.IP {
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return nil
+	}
+	for _, n := range embeddedIPv4Nets {
+		if !n.Contains(ip16) {
+			continue
+		}
+		if ip16[0] == 0x20 && ip16[1] == 0x02 {
+			// 6to4: 2002:AABB:CCDD::/48 carries A.B.C.D
+			return net.IPv4(ip16[2], ip16[3], ip16[4], ip16[5]).To4()
+		}
+		return net.IPv4(ip16[12], ip16[13], ip16[14], ip16[15]).To4()
+	}
+	return nil
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// Fetch downloads the page at rawURL and reads its title and favicon from the
+// head. Pages without an icon link get the site's /favicon.ico.
+func Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "tasks-service-link-preview/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return Metadata{}, fmt.Errorf("page returned %s", resp.Status)
+	}
+
+	// The final URL after redirects is the base for relative icon links
+	base := resp.Request.URL
+	meta := Metadata{FaviconURL: (&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}).String()}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return meta, nil
+	}
+
+	title, icon := parseHead(io.LimitReader(resp.Body, maxBodyBytes))
+	meta.Title = title
+	if icon != "" {
+		if ref, err := base.Parse(icon); err == nil && (ref.Scheme == "http" || ref.Scheme == "https") {
+			meta.FaviconURL = ref.String()
+		}
+	}
+	return meta, nil
+}
+
+// parseHead reads a page's title and icon link from its head. An og:title
+// is used when the page has no <title>.
+func parseHead(r io.Reader) (title, icon string) {
+	var ogTitle string
+	inTitle := false
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return cleanTitle(title, ogTitle), icon
+
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			switch token.Data {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "meta":
+				if attr(token, "property") == "og:title" {
+					ogTitle = attr(token, "content")
+				}
+			case "link":
+				if icon == "" && isIconRel(attr(token, "rel")) {
+					icon = attr(token, "href")
+				}
+			case "body":
+				return cleanTitle(title, ogTitle), icon
+			}
+
+		case html.EndTagToken:
+			switch token := z.Token(); token.Data {
+			case "title":
+				inTitle = false
+			case "head":
+				return cleanTitle(title, ogTitle), icon
+			}
+		}
+	}
+}
+
+// isIconRel reports whether a link's rel names a favicon
+func isIconRel(rel string) bool {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		if value == "icon" {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(token html.Token, key string) string {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// cleanTitle collapses whitespace in the page title, or the og:title without
+// one, and shortens it to maxTitleLength characters
+func cleanTitle(title, ogTitle string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		title = strings.Join(strings.Fields(ogTitle), " ")
+	}
+	if utf8.RuneCountInString(title) > maxTitleLength {
+		title = string([]rune(title)[:maxTitleLength])
+	}
+	return title
+}
@@ -0,0 +1,58 @@
+package linkmeta
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"198.18.0.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"fd00:ec2::254", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.216.34", true},
+		{"::169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b::5db8:d822", true},
+		{"2002:a9fe:a9fe::1", false},
+		{"2002:0a00:0001::1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("bad test IP %q", tt.ip)
+			}
+			assert.Equal(t, tt.public, IsPublic(ip))
+		})
+	}
+}
+
+func TestRefusePrivate(t *testing.T) {
+	assert.ErrorIs(t, RefusePrivate("tcp", "[::ffff:169.254.169.254]:80", nil), ErrPrivateAddress)
+	assert.ErrorIs(t, RefusePrivate("tcp", "100.64.0.1:443", nil), ErrPrivateAddress)
+	assert.NoError(t, RefusePrivate("tcp", "93.184.216.34:443", nil))
+}
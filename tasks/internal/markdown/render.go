@@ -0,0 +1,313 @@
+package markdown
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingPattern     = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	rulePattern        = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	fencePattern       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^ \t`]*)")
+	bulletPattern      = regexp.MustCompile(`^( {0,3})[-*+][ \t]+`)
+	orderedPattern     = regexp.MustCompile(`^( {0,3})(\d{1,9})[.)][ \t]+`)
+	quotePattern       = regexp.MustCompile(`^ {0,3}> ?`)
+	entityPattern      = regexp.MustCompile(`^&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+	linkPattern        = regexp.MustCompile(`^\[([^\]]*)\]\(\s*<?((?:[^\s()<>]|\([^\s()<>]*\))*)>?(?:\s+"([^"]*)")?\s*\)`)
+	emphasisDelimiters = []string{"**", "__", "~~", "*", "_"}
+)
+
+// emphasisTags maps emphasis delimiters to the elements they render as
+var emphasisTags = map[string]string{"**": "strong", "__": "strong", "~~": "del", "*": "em", "_": "em"}
+
+// Render converts markdown to HTML. It covers the common subset used in task
+// descriptions: headings, paragraphs, emphasis, strikethrough, code spans and
+// fenced code, links, images, lists, block quotes and rules. Raw HTML is
+// escaped and shown as text, and links and images other than http, https or
+// relative URLs are rendered as their text alone, so the output is safe to
+// embed.
+func Render(text string) string {
+	text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+	var b strings.Builder
+	renderBlocks(&b, strings.Split(text, "\n"))
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// renderBlocks renders lines as a sequence of block elements
+func renderBlocks(b *strings.Builder, lines []string) {
+	var paragraph []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			text := strings.TrimRight(strings.Join(paragraph, "\n"), " \t")
+			b.WriteString("<p>" + renderInline(text) + "</p>\n")
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+		case fencePattern.MatchString(line):
+			flush()
+			m := fencePattern.FindStringSubmatch(line)
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code")
+			if m[2] != "" {
+				b.WriteString(` class="language-` + escape(m[2]) + `"`)
+			}
+			body := escape(strings.Join(code, "\n"))
+			if len(code) > 0 {
+				body += "\n"
+			}
+			b.WriteString(">" + body + "</code></pre>\n")
+
+		case headingPattern.MatchString(line):
+			flush()
+			m := headingPattern.FindStringSubmatch(line)
+			level := strconv.Itoa(len(m[1]))
+			b.WriteString("<h" + level + ">" + renderInline(m[2]) + "</h" + level + ">\n")
+
+		case rulePattern.MatchString(line):
+			flush()
+			b.WriteString("<hr>\n")
+
+		case quotePattern.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quotePattern.ReplaceAllString(lines[i], ""))
+			}
+			i--
+			b.WriteString("<blockquote>\n")
+			renderBlocks(b, quoted)
+			b.WriteString("</blockquote>\n")
+
+		case bulletPattern.MatchString(line), orderedPattern.MatchString(line):
+			flush()
+			i = renderList(b, lines, i) - 1
+
+		default:
+			// Trailing spaces are kept for hard line breaks
+			paragraph = append(paragraph, strings.TrimLeft(line, " \t"))
+		}
+	}
+	flush()
+}
+
+// renderList renders the list starting at lines[start] and returns the index
+// of the first line after it. Lines indented past an item's marker belong to
+// the item, so lists nest.
+func renderList(b *strings.Builder, lines []string, start int) int {
+	pattern, tag := bulletPattern, "ul"
+	if m := orderedPattern.FindStringSubmatch(lines[start]); m != nil {
+		pattern, tag = orderedPattern, "ol"
+		if n, _ := strconv.Atoi(m[2]); n != 1 {
+			b.WriteString(`<ol start="` + strconv.Itoa(n) + `">` + "\n")
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+
+	i := start
+	for i < len(lines) {
+		m := pattern.FindStringSubmatchIndex(lines[i])
+		if m == nil {
+			break
+		}
+		indent := m[1]
+		item := []string{lines[i][indent:]}
+		for i++; i < len(lines); i++ {
+			line := lines[i]
+			if strings.TrimSpace(line) == "" {
+				// A blank line ends the list unless the item continues after it
+				if i+1 < len(lines) && leadingSpaces(lines[i+1]) >= indent {
+					item = append(item, "")
+					continue
+				}
+				break
+			}
+			if leadingSpaces(line) < indent {
+				break
+			}
+			item = append(item, line[indent:])
+		}
+
+		var content strings.Builder
+		renderBlocks(&content, item)
+		html := strings.TrimSuffix(content.String(), "\n")
+		// Tight items hold their text directly rather than in a paragraph
+		if strings.HasPrefix(html, "<p>") && strings.Count(html, "<p>") == 1 {
+			html = strings.Replace(strings.Replace(html, "<p>", "", 1), "</p>", "", 1)
+		}
+		b.WriteString("<li>" + html + "</li>\n")
+
+		if i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+			break
+		}
+	}
+
+	b.WriteString("</" + tag + ">\n")
+	return i
+}
+
+// leadingSpaces counts a line's indentation, with tabs as four spaces
+func leadingSpaces(line string) int {
+	n := 0
+	for _, r := range line {
+		switch r {
+		case ' ':
+			n++
+		case '\t':
+			n += 4
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// renderInline renders the inline markdown of a block's text
+func renderInline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!~<>&\"'|", rune(rest[1])):
+			b.WriteString(escape(rest[1:2]))
+			i += 2
+			continue
+
+		case rest[0] == '\\' && len(rest) > 1 && rest[1] == '\n':
+			b.WriteString("<br>\n")
+			i += 2
+			continue
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			if end := strings.Index(rest[ticks:], rest[:ticks]); end >= 0 {
+				code := strings.TrimSpace(strings.ReplaceAll(rest[ticks:ticks+end], "\n", " "))
+				b.WriteString("<code>" + escape(code) + "</code>")
+				i += 2*ticks + end
+				continue
+			}
+			b.WriteString(rest[:ticks])
+			i += ticks
+			continue
+
+		case strings.HasPrefix(rest, "!["):
+			if m := linkPattern.FindStringSubmatch(rest[1:]); m != nil {
+				if renderableURL(m[2]) {
+					b.WriteString(`<img src="` + escape(m[2]) + `" alt="` + escape(m[1]) + `"`)
+					if m[3] != "" {
+						b.WriteString(` title="` + escape(m[3]) + `"`)
+					}
+					b.WriteString(">")
+				} else {
+					b.WriteString(escape(m[1]))
+				}
+				i += 1 + len(m[0])
+				continue
+			}
+
+		case rest[0] == '[':
+			if m := linkPattern.FindStringSubmatch(rest); m != nil {
+				if renderableURL(m[2]) {
+					b.WriteString(`<a href="` + escape(m[2]) + `"`)
+					if m[3] != "" {
+						b.WriteString(` title="` + escape(m[3]) + `"`)
+					}
+					b.WriteString(">" + renderInline(m[1]) + "</a>")
+				} else {
+					b.WriteString(renderInline(m[1]))
+				}
+				i += len(m[0])
+				continue
+			}
+
+		case rest[0] == '\n':
+			// Two trailing spaces make a hard line break
+			if strings.HasSuffix(b.String(), "  ") {
+				trimmed := strings.TrimRight(b.String(), " ")
+				b.Reset()
+				b.WriteString(trimmed + "<br>")
+			}
+			b.WriteString("\n")
+			i++
+			continue
+		}
+
+		if rest[0] != '_' || i == 0 || !isWordByte(text[i-1]) {
+			if n, html := renderEmphasis(rest); n > 0 {
+				b.WriteString(html)
+				i += n
+				continue
+			}
+		}
+		if m := entityPattern.FindString(rest); m != "" {
+			b.WriteString(m)
+			i += len(m)
+			continue
+		}
+		b.WriteString(escape(rest[:1]))
+		i++
+	}
+	return b.String()
+}
+
+// renderEmphasis renders emphasis, strong emphasis or strikethrough starting
+// at text[0], returning how many bytes it consumed, or 0 if there is none.
+// The closing delimiter must follow non-space text, and underscores inside
+// words don't count, so snake_case names stay as they are.
+func renderEmphasis(text string) (int, string) {
+	for _, delim := range emphasisDelimiters {
+		if !strings.HasPrefix(text, delim) || len(text) <= len(delim) || text[len(delim)] == ' ' {
+			continue
+		}
+		for from := len(delim) + 1; from <= len(text)-len(delim); {
+			end := strings.Index(text[from:], delim)
+			if end < 0 {
+				break
+			}
+			end += from
+			after := end + len(delim)
+			if text[end-1] != ' ' && (delim[0] != '_' || after == len(text) || !isWordByte(text[after])) {
+				tag := emphasisTags[delim]
+				return after, "<" + tag + ">" + renderInline(text[len(delim):end]) + "</" + tag + ">"
+			}
+			from = end + 1
+		}
+	}
+	return 0, ""
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// renderableURL reports whether a link or image URL is relative or uses http
+// or https. Render is stricter than Sanitize, which also keeps mailto links.
+func renderableURL(url string) bool {
+	switch urlScheme(url) {
+	case "", "http", "https":
+		return true
+	}
+	return false
+}
+
+// escape escapes text for HTML
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;").Replace(text)
+}
@@ -0,0 +1,55 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"paragraph", "Hello *world*", "<p>Hello <em>world</em></p>"},
+		{"heading", "## Title", "<h2>Title</h2>"},
+		{"raw html escaped", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"code span escaped", "`<b>`", "<p><code>&lt;b&gt;</code></p>"},
+		{"fenced code escaped", "```go\n<x>\n```", "<pre><code class=\"language-go\">&lt;x&gt;\n</code></pre>"},
+		{"http link", "[x](https://example.com)", `<p><a href="https://example.com">x</a></p>`},
+		{"relative link", "[x](/tasks/1)", `<p><a href="/tasks/1">x</a></p>`},
+		{"link with title", `[x](https://example.com "T")`, `<p><a href="https://example.com" title="T">x</a></p>`},
+		{"link with parentheses", "[x](https://en.wikipedia.org/wiki/Go_(language))", `<p><a href="https://en.wikipedia.org/wiki/Go_(language)">x</a></p>`},
+		{"javascript link", "[x](javascript:alert(1))", "<p>x</p>"},
+		{"javascript link uppercase", "[x](JAVASCRIPT:alert(1))", "<p>x</p>"},
+		{"angle bracket javascript link", "[x](<javascript:alert(1)>)", "<p>x</p>"},
+		{"data link", "[x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>"},
+		{"mailto link", "[x](mailto:a@example.com)", "<p>x</p>"},
+		{"vbscript image", "![alt](vbscript:msgbox)", "<p>alt</p>"},
+		{"http image", "![alt](https://example.com/a.png)", `<p><img src="https://example.com/a.png" alt="alt"></p>`},
+		{"attribute escaped", `[x](https://example.com/"onmouseover=alert(1))`, `<p><a href="https://example.com/&quot;onmouseover=alert(1)">x</a></p>`},
+		{"block quote", "> quoted", "<blockquote>\n<p>quoted</p>\n</blockquote>"},
+		{"list", "- a\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(tt.in))
+		})
+	}
+}
+
+// TestRenderSanitized checks that stored, sanitized descriptions render safely
+func TestRenderSanitized(t *testing.T) {
+	for _, in := range []string{
+		"<<x>img src=x onerror=alert(1)>",
+		"<xmp><img src=x onerror=alert(1)></xmp>",
+		"`<script>alert(1)</script>`",
+		"[x](javascript:alert(1))",
+	} {
+		out := Render(Sanitize(in))
+		assert.NotContains(t, out, "<img", in)
+		assert.NotContains(t, out, "<script", in)
+		assert.NotContains(t, out, "javascript:", in)
+	}
+}
@@ -0,0 +1,238 @@
+package markdown
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the HTML elements kept in sanitized text. Other tags are
+// removed but their content is kept.
+var allowedTags = map[string]bool{
+	"a": true, "abbr": true, "b": true, "blockquote": true, "br": true, "code": true,
+	"dd": true, "del": true, "details": true, "div": true, "dl": true, "dt": true,
+	"em": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"hr": true, "i": true, "img": true, "ins": true, "kbd": true, "li": true,
+	"mark": true, "ol": true, "p": true, "pre": true, "q": true, "s": true,
+	"small": true, "span": true, "strong": true, "sub": true, "summary": true, "sup": true,
+	"table": true, "tbody": true, "td": true, "tfoot": true, "th": true, "thead": true,
+	"tr": true, "u": true, "ul": true,
+}
+
+// droppedTags are removed together with everything inside them
+var droppedTags = map[string]bool{
+	"applet": true, "embed": true, "frame": true, "frameset": true, "iframe": true,
+	"math": true, "noembed": true, "noframes": true, "noscript": true, "object": true,
+	"script": true, "style": true, "svg": true, "template": true, "textarea": true,
+	"plaintext": true, "title": true, "xmp": true,
+}
+
+// globalAttrs are allowed on every kept element
+var globalAttrs = map[string]bool{"title": true, "lang": true, "dir": true}
+
+// allowedAttrs are the attributes kept per element, besides globalAttrs. Event
+// handlers (on*) and style are never kept.
+var allowedAttrs = map[string]map[string]bool{
+	"a":       {"href": true},
+	"img":     {"src": true, "alt": true, "width": true, "height": true},
+	"code":    {"class": true},
+	"ol":      {"start": true},
+	"td":      {"colspan": true, "rowspan": true, "align": true},
+	"th":      {"colspan": true, "rowspan": true, "align": true},
+	"details": {"open": true},
+}
+
+// urlAttrs hold URLs, which must use a safe scheme
+var urlAttrs = map[string]bool{"href": true, "src": true}
+
+// textEscaper escapes the text between tags. '>' and quotes can't start
+// markup outside a tag, and escaping them would break markdown block quotes
+// and link titles.
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;")
+
+// safeSchemes are the URL schemes kept in links and images. URLs without a
+// scheme are relative and kept too.
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Sanitize removes unsafe HTML from markdown text: script and other active
+// elements with their content, event handler and style attributes, and links
+// to schemes such as javascript:. Text between tags is escaped so it can't
+// be joined into a new tag once others are removed. Code spans and fenced
+// code are left as written, as are the markdown and safe HTML.
+func Sanitize(text string) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+
+	var b strings.Builder
+	for _, seg := range splitCode(text) {
+		if seg.code {
+			b.WriteString(seg.text)
+		} else {
+			sanitizeHTML(&b, seg.text)
+		}
+	}
+	return b.String()
+}
+
+// segment is a run of markdown text that is either code or not
+type segment struct {
+	text string
+	code bool
+}
+
+// splitCode splits markdown text into fenced code blocks, code spans and the
+// text between them, found the same way Render finds them
+func splitCode(text string) []segment {
+	var segments []segment
+	var prose strings.Builder
+	flush := func() {
+		segments = append(segments, splitCodeSpans(prose.String())...)
+		prose.Reset()
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		m := fencePattern.FindStringSubmatch(strings.TrimRight(lines[i], "\r\n"))
+		if m == nil {
+			prose.WriteString(lines[i])
+			continue
+		}
+		flush()
+		code := lines[i]
+		for i++; i < len(lines); i++ {
+			code += lines[i]
+			if strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]) {
+				break
+			}
+		}
+		segments = append(segments, segment{text: code, code: true})
+	}
+	flush()
+	return segments
+}
+
+// splitCodeSpans splits text into code spans and the text around them
+func splitCodeSpans(text string) []segment {
+	var segments []segment
+	start := 0
+	for i := 0; i < len(text); {
+		switch {
+		case text[i] == '\\' && i+1 < len(text):
+			i += 2
+		case text[i] == '`':
+			rest := text[i:]
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			end := strings.Index(rest[ticks:], rest[:ticks])
+			if end < 0 {
+				i += ticks
+				continue
+			}
+			if i > start {
+				segments = append(segments, segment{text: text[start:i]})
+			}
+			start = i + 2*ticks + end
+			segments = append(segments, segment{text: text[i:start], code: true})
+			i = start
+		default:
+			i++
+		}
+	}
+	if start < len(text) {
+		segments = append(segments, segment{text: text[start:]})
+	}
+	return segments
+}
+
+// sanitizeHTML writes text with its unsafe HTML removed
+func sanitizeHTML(b *strings.Builder, text string) {
+	z := html.NewTokenizer(strings.NewReader(text))
+	skipping, depth := "", 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				// An unterminated tag at the end is kept as text
+				b.WriteString(strings.ReplaceAll(string(z.Raw()), "<", "&lt;"))
+			}
+			return
+		}
+
+		token := z.Token()
+		if skipping != "" {
+			switch {
+			case tt == html.StartTagToken && token.Data == skipping:
+				depth++
+			case tt == html.EndTagToken && token.Data == skipping:
+				if depth--; depth == 0 {
+					skipping = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.WriteString(textEscaper.Replace(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[token.Data] {
+				if tt == html.StartTagToken {
+					skipping, depth = token.Data, 1
+				}
+				continue
+			}
+			if allowedTags[token.Data] {
+				writeTag(b, token)
+			}
+		case html.EndTagToken:
+			if allowedTags[token.Data] {
+				b.WriteString("</" + token.Data + ">")
+			}
+		}
+		// Comments and doctypes are dropped
+	}
+}
+
+// writeTag writes a start or self-closing tag with only its allowed attributes
+func writeTag(b *strings.Builder, token html.Token) {
+	b.WriteString("<" + token.Data)
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !(globalAttrs[attr.Key] || allowedAttrs[token.Data][attr.Key]) {
+			continue
+		}
+		if urlAttrs[attr.Key] && !SafeURL(attr.Val) {
+			continue
+		}
+		b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	if token.Type == html.SelfClosingTagToken {
+		b.WriteString(" /")
+	}
+	b.WriteString(">")
+}
+
+// SafeURL reports whether a link or image URL is relative or uses a safe
+// scheme
+func SafeURL(url string) bool {
+	scheme := urlScheme(url)
+	return scheme == "" || safeSchemes[scheme]
+}
+
+// urlScheme returns a URL's scheme in lower case, or "" if it is relative.
+// Control characters and whitespace are ignored like browsers do, so
+// "java\tscript:" is caught.
+func urlScheme(url string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, url)
+
+	end := strings.IndexAny(cleaned, ":/?#")
+	if end <= 0 || cleaned[end] != ':' {
+		return ""
+	}
+	return strings.ToLower(cleaned[:end])
+}
@@ -0,0 +1,75 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain text", "Fix the **login** bug", "Fix the **login** bug"},
+		{"safe html kept", `<b title="x">bold</b>`, `<b title="x">bold</b>`},
+		{"script dropped", "a<script>alert(1)</script>b", "ab"},
+		{"event handler dropped", `<img src="a.png" onerror="alert(1)">`, `<img src="a.png">`},
+		{"style dropped", `<p style="color:red">x</p>`, "<p>x</p>"},
+		{"unknown tag unwrapped", "<custom>text</custom>", "text"},
+		{"javascript href dropped", `<a href="javascript:alert(1)">x</a>`, "<a>x</a>"},
+		{"obfuscated scheme dropped", "<a href=\"java\tscript:alert(1)\">x</a>", "<a>x</a>"},
+		{"mailto kept", `<a href="mailto:a@example.com">x</a>`, `<a href="mailto:a@example.com">x</a>`},
+		{"comment dropped", "a<!-- <script>alert(1)</script> -->b", "ab"},
+		{"split tag not rejoined", "<<x>img src=x onerror=alert(1)>", "&lt;img src=x onerror=alert(1)>"},
+		{"nested split tag", "<scr<x>ipt>alert(1)</scr<x>ipt>", "ipt>alert(1)ipt>"},
+		{"entity stays escaped", "&lt;script&gt; <b>x</b>", "&lt;script> <b>x</b>"},
+		{"bare ampersand", "AT&T <b>x</b>", "AT&amp;T <b>x</b>"},
+		{"plaintext dropped", "a<plaintext><img src=x onerror=alert(1)>", "a"},
+		{"xmp dropped", "<xmp><img src=x onerror=alert(1)></xmp>b", "b"},
+		{"noembed dropped", "<noembed><img src=x onerror=alert(1)></noembed>b", "b"},
+		{"noframes dropped", "<noframes><img src=x onerror=alert(1)></noframes>b", "b"},
+		{"iframe dropped", `<iframe src="https://example.com"></iframe>b`, "b"},
+		{"template dropped", "<template><img src=x onerror=alert(1)></template>b", "b"},
+		{"textarea dropped", "<textarea></textarea><img src=x onerror=alert(1)></textarea>b", "<img src=\"x\">b"},
+		{"unterminated tag", "a <b", "a &lt;b"},
+		{"code span untouched", "Use `<script>` here <b>x</b>", "Use `<script>` here <b>x</b>"},
+		{"double tick code span", "``a ` <img onerror=x>`` <i>y</i>", "``a ` <img onerror=x>`` <i>y</i>"},
+		{"escaped backtick is not code", "\\`<script>x</script>\\`", "\\`\\`"},
+		{"fenced code untouched", "<b>x</b>\n```html\n<script>alert(1)</script>\n```\n<i>y</i>", "<b>x</b>\n```html\n<script>alert(1)</script>\n```\n<i>y</i>"},
+		{"tilde fence untouched", "~~~\n<iframe>\n~~~\n<script>x</script>", "~~~\n<iframe>\n~~~\n"},
+		{"unclosed fence runs to end", "<i>a</i>\n```\n<script>", "<i>a</i>\n```\n<script>"},
+		{"block quote kept", "> quote <b>x</b>", "> quote <b>x</b>"},
+		{"link title kept", `[a](https://example.com "t") <b>x</b>`, `[a](https://example.com "t") <b>x</b>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Sanitize(tt.in))
+		})
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		safe bool
+	}{
+		{"https://example.com", true},
+		{"HTTP://example.com", true},
+		{"mailto:a@example.com", true},
+		{"/relative/path", true},
+		{"page?a=b:c", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript:alert(1)", false},
+		{" javascript:alert(1)", false},
+		{"java\nscript:alert(1)", false},
+		{"data:text/html,<script>", false},
+		{"vbscript:msgbox", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			assert.Equal(t, tt.safe, SafeURL(tt.url))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	h := &Histogram{name: "test_seconds", help: "Test.", buckets: []float64{1, 10}, counts: make([]uint64, 2)}
+	for _, value := range []float64{0.5, 1, 5, 60} {
+		h.Observe(value)
+	}
+
+	var out bytes.Buffer
+	h.Write(&out)
+	assert.Equal(t, "# HELP test_seconds Test.\n"+
+		"# TYPE test_seconds histogram\n"+
+		"test_seconds_bucket{le=\"1\"} 2\n"+
+		"test_seconds_bucket{le=\"10\"} 3\n"+
+		"test_seconds_bucket{le=\"+Inf\"} 4\n"+
+		"test_seconds_sum 66.5\n"+
+		"test_seconds_count 4\n", out.String())
+}
+
+func TestNewHistogramSortsBuckets(t *testing.T) {
+	h := NewHistogram("test_sorted_seconds", "Test.", []float64{30, 1, 5})
+	assert.Equal(t, []float64{1, 5, 30}, h.buckets)
+}
+
+func TestHandlerServesRegisteredMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewHistogram("test_served_seconds", "Test.", []float64{1})
+	h.Observe(2)
+
+	router := gin.New()
+	router.GET("/metrics", Handler())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, w.Body.String(), "test_served_seconds_bucket{le=\"1\"} 0\n")
+	assert.Contains(t, w.Body.String(), "test_served_seconds_count 1\n")
+	assert.Contains(t, w.Body.String(), "# TYPE tasks_completion_age_seconds histogram\n")
+}
@@ -0,0 +1,13 @@
+package metrics
+
+const (
+	hour = 3600.0
+	day  = 24 * hour
+)
+
+// TaskCompletionAge observes how long tasks take from creation to completion
+var TaskCompletionAge = NewHistogram(
+	"tasks_completion_age_seconds",
+	"Time from task creation to completion in seconds.",
+	[]float64{hour, 6 * hour, day, 3 * day, 7 * day, 14 * day, 30 * day, 90 * day},
+)
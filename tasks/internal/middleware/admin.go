@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+)
+
+// RequireAdmin restricts a route to the users listed in ADMIN_USER_IDS.
+// It must run after AuthMiddleware, which sets the userID.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userID")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		for _, id := range config.List("ADMIN_USER_IDS") {
+			if adminID, err := uuid.Parse(id); err == nil && adminID == userID.(uuid.UUID) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		c.Abort()
+	}
+}
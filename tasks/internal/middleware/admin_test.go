@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	admin, user := uuid.New(), uuid.New()
+	t.Setenv("ADMIN_USER_IDS", "not-a-uuid, "+admin.String())
+
+	for name, tc := range map[string]struct {
+		userID *uuid.UUID
+		status int
+	}{
+		"admin":           {&admin, http.StatusOK},
+		"other user":      {&user, http.StatusForbidden},
+		"unauthenticated": {nil, http.StatusUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tc.userID != nil {
+					c.Set("userID", *tc.userID)
+				}
+			})
+			router.Use(RequireAdmin())
+			router.POST("/admin/stats", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/stats", nil))
+			assert.Equal(t, tc.status, w.Code)
+		})
+	}
+}
+
+func TestRequireAdminWithoutAdmins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("ADMIN_USER_IDS", "")
+	userID := uuid.New()
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("userID", userID) }, RequireAdmin())
+	router.POST("/admin/stats", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/stats", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -1,15 +1,21 @@
 package middleware
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
 	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
 )
 
 // Claims represents JWT claims
@@ -61,20 +67,84 @@ func AuthMiddleware(db *database.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Check if user exists in cache
-		var exists bool
-		err = db.Get(&exists, "SELECT EXISTS(SELECT 1 FROM tasks_users WHERE user_id = $1)", claims.UserID)
+		// Check if user exists in cache, loading their timezone with it. A
+		// freshly registered user can log in before the user.created event is
+		// consumed, so optionally self-heal the cache from the verified token
+		// claims.
+		var timezone string
+		err = db.Get(&timezone, `
+			SELECT COALESCE(s.timezone, '') FROM tasks_users u
+			LEFT JOIN user_settings s ON s.user_id = u.user_id
+			WHERE u.user_id = $1
+		`, claims.UserID)
+		exists := err == nil
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			if config.Bool("USER_CACHE_SELF_HEAL", true) {
+				exists = cacheUserFromClaims(db, claims)
+			}
+		}
 		if err != nil || !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in cache. Please wait for sync."})
 			c.Abort()
 			return
 		}
 
-		// Set user info in context
+		// Set user info in context. Task changes made with the request's
+		// context are recorded as made by the user.
+		c.Request = c.Request.WithContext(repository.WithActor(c.Request.Context(), claims.UserID))
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
 
+		// Remember the timezone the client reports, so requests and stats
+		// that don't name one use it too
+		if header := c.GetHeader("X-Timezone"); header != "" && header != timezone {
+			if _, err := time.LoadLocation(header); err == nil {
+				if err := saveTimezone(db, claims.UserID, header); err != nil {
+					log.Printf("⚠️  Failed to save timezone for user %s: %v\n", claims.UserID, err)
+				} else {
+					timezone = header
+				}
+			}
+		}
+		if timezone != "" {
+			c.Set("timezone", timezone)
+		}
+
 		c.Next()
 	}
 }
+
+// saveTimezone stores a user's timezone, which must be a valid IANA name
+func saveTimezone(db *database.DB, userID uuid.UUID, timezone string) error {
+	_, err := db.Exec(`
+		INSERT INTO user_settings (user_id, timezone, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone, updated_at = EXCLUDED.updated_at
+	`, userID, timezone, time.Now())
+	return err
+}
+
+// cacheUserFromClaims inserts the token's user into the cache if it is still
+// missing. The user.created event later overwrites these values. It reports
+// whether the user is now cached.
+func cacheUserFromClaims(db *database.DB, claims *Claims) bool {
+	if claims.UserID == uuid.Nil || claims.Username == "" || claims.Email == "" {
+		return false
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO tasks_users (user_id, username, email)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+	`, claims.UserID, claims.Username, claims.Email)
+	if err != nil {
+		// Most likely the email is cached under another ID; leave it to the consumer
+		log.Printf("⚠️  Failed to self-heal user cache for %s: %v\n", claims.UserID, err)
+		return false
+	}
+
+	log.Printf("🩹 User %s (%s) cached from token before sync event\n", claims.Username, claims.UserID)
+	return true
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+)
+
+// bufferedWriter captures a JSON response body so it can be rewritten. Any
+// other response, such as an event stream or a CSV export that flushes as it
+// goes, is passed straight through.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	passing   bool
+	buffering bool
+}
+
+// passThrough reports whether the response is anything but JSON, deciding on
+// the first write once the handler has set the Content-Type
+func (w *bufferedWriter) passThrough() bool {
+	if !w.passing && !w.buffering {
+		if strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			w.buffering = true
+		} else {
+			w.passing = true
+		}
+	}
+	return w.passing
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.passThrough() {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	if w.passThrough() {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+// PrettyJSON indents JSON responses when the request has ?pretty=true.
+// It is enabled outside production by default; JSON_PRETTY_ENABLED overrides that.
+func PrettyJSON() gin.HandlerFunc {
+	enabled := config.Bool("JSON_PRETTY_ENABLED", os.Getenv("ENV") != "production")
+
+	return func(c *gin.Context) {
+		if !enabled || c.Query("pretty") != "true" {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		if !writer.buffering {
+			return
+		}
+
+		body := writer.body.Bytes()
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err == nil {
+			body = indented.Bytes()
+		}
+		writer.ResponseWriter.Write(body)
+	}
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettyJSONIndents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JSON_PRETTY_ENABLED", "true")
+	router := gin.New()
+	router.Use(PrettyJSON())
+	router.GET("/", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"a": 1}) })
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?pretty=true", nil))
+	assert.Equal(t, "{\n  \"a\": 1\n}", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, `{"a":1}`, w.Body.String())
+}
+
+// TestPrettyJSONPassesEventStreams checks each event reaches the client as
+// it is flushed rather than when the stream ends
+func TestPrettyJSONPassesEventStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JSON_PRETTY_ENABLED", "true")
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.Use(PrettyJSON())
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("data: {\"a\":1}\n\n")
+		c.Writer.Flush()
+		assert.Equal(t, "data: {\"a\":1}\n\n", w.Body.String(), "the event was held back")
+		assert.True(t, w.Flushed)
+		c.Writer.WriteString("data: {\"b\":2}\n\n")
+	})
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream?pretty=true", nil))
+	assert.Equal(t, "data: {\"a\":1}\n\ndata: {\"b\":2}\n\n", w.Body.String())
+}
+
+// TestPrettyJSONPassesOtherContent checks a CSV export that flushes as it
+// goes reaches the client unchanged and as it is written
+func TestPrettyJSONPassesOtherContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JSON_PRETTY_ENABLED", "true")
+	w := httptest.NewRecorder()
+	router := gin.New()
+	router.Use(PrettyJSON())
+	router.GET("/export", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="tasks.csv"`)
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("id,title\n")
+		c.Writer.Flush()
+		assert.Equal(t, "id,title\n", w.Body.String(), "the rows were held back")
+		c.Writer.WriteString("1,\"{\"\"a\"\":1}\"\n")
+	})
+
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/export?pretty=true", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,title\n1,\"{\"\"a\"\":1}\"\n", w.Body.String())
+}
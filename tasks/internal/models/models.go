@@ -1,9 +1,13 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // User represents a cached user from auth service
@@ -15,17 +19,191 @@ type User struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Task origins record how a task was created
+const (
+	OriginAPI       = "api"
+	OriginImport    = "import"
+	OriginRecurring = "recurring"
+	OriginTemplate  = "template"
+)
+
 // Task represents a task in the system
 type Task struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
-	Title       string     `json:"title" db:"title"`
-	Description *string    `json:"description,omitempty" db:"description"`
-	Status      string     `json:"status" db:"status"`
-	Priority    string     `json:"priority" db:"priority"`
-	DueDate     *time.Time `json:"due_date,omitempty" db:"due_date"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	ParentTaskID *uuid.UUID `json:"parent_task_id,omitempty" db:"parent_task_id"`
+	ProjectID    *uuid.UUID `json:"project_id,omitempty" db:"project_id"`
+	Title        string     `json:"title" db:"title"`
+	Description  *string    `json:"description,omitempty" db:"description"`
+	Status       string     `json:"status" db:"status"`
+	Priority     string     `json:"priority" db:"priority"`
+	DueDate      *time.Time `json:"due_date,omitempty" db:"due_date"`
+	Origin       string     `json:"origin" db:"origin"`
+	Progress     int        `json:"progress" db:"progress"`
+	Position     int        `json:"position" db:"position"`
+	Color        *string    `json:"color,omitempty" db:"color"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	// Set by the database whenever the task moves to completed
+	CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+
+	// Percentage of checklist items done, null while there is no checklist
+	ChecklistProgress *int `json:"checklist_progress" db:"checklist_progress"`
+	// Custom fields; see Metadata
+	Metadata Metadata `json:"metadata" db:"metadata"`
+
+	// Recurrence moves to each new occurrence, so only the latest carries it
+	Recurrence       *string `json:"recurrence,omitempty" db:"recurrence"`
+	RecurrencePaused bool    `json:"recurrence_paused,omitempty" db:"recurrence_paused"`
+
+	// Planned and actual effort in minutes
+	EstimateMinutes *int `json:"estimate_minutes,omitempty" db:"estimate_minutes"`
+	ActualMinutes   *int `json:"actual_minutes,omitempty" db:"actual_minutes"`
+	// Snoozed tasks are hidden from the task list until this time passes
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty" db:"snoozed_until"`
+	// Pinned tasks list first unless another sort is asked for
+	Pinned bool `json:"pinned" db:"pinned"`
+	// Archived tasks are hidden from the task list unless asked for
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+	// Set by the overdue worker once an open task passes its due date
+	Overdue bool `json:"overdue" db:"overdue"`
+	// Set when an overdue task's priority was last raised automatically
+	EscalatedAt *time.Time `json:"escalated_at,omitempty" db:"escalated_at"`
+	// Trashed tasks are hidden everywhere but the trash until purged
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// Optional location for field work; latitude and longitude are set together
+	Latitude  *float64 `json:"latitude,omitempty" db:"latitude"`
+	Longitude *float64 `json:"longitude,omitempty" db:"longitude"`
+	PlaceName *string  `json:"place_name,omitempty" db:"place_name"`
+
+	// Loaded separately from the tasks row
+	Tags     []string     `json:"tags,omitempty" db:"-"`
+	Subtasks []Task       `json:"subtasks,omitempty" db:"-"`
+	Match    *SearchMatch `json:"match,omitempty" db:"-"`
+	// DescriptionHTML is the description rendered from markdown, on ?render=html
+	DescriptionHTML *string `json:"description_html,omitempty" db:"-"`
+}
+
+// Metadata holds custom fields attached to a task, stored as a JSONB object
+// whose values are strings, numbers or booleans
+type Metadata map[string]interface{}
+
+// Value stores the fields as JSON text; nil is stored as an empty object
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan reads the fields from a JSONB column, replacing any already held
+func (m *Metadata) Scan(src interface{}) error {
+	*m = nil
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(src, m)
+	case string:
+		return json.Unmarshal([]byte(src), m)
+	}
+	return fmt.Errorf("cannot scan %T into Metadata", src)
+}
+
+// SearchMatch describes how a task matched a full-text search. Highlights
+// wrap matched words in <mark> tags.
+type SearchMatch struct {
+	Rank        float64 `json:"rank"`
+	Title       string  `json:"title"`
+	Description *string `json:"description,omitempty"`
+}
+
+// Project groups related tasks. Task counts cover live, unarchived tasks.
+type Project struct {
+	ID            uuid.UUID `json:"id" db:"id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	Name          string    `json:"name" db:"name"`
+	Description   *string   `json:"description,omitempty" db:"description"`
+	TaskCount     int       `json:"task_count" db:"task_count"`
+	OpenTaskCount int       `json:"open_task_count" db:"open_task_count"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProjectSummary rolls up the live, unarchived tasks of a project
+type ProjectSummary struct {
+	ProjectID      uuid.UUID `json:"project_id" db:"project_id"`
+	Name           string    `json:"name" db:"name"`
+	TotalTasks     int       `json:"total_tasks" db:"total_tasks"`
+	OpenTasks      int       `json:"open_tasks" db:"open_tasks"`
+	CompletedTasks int       `json:"completed_tasks" db:"completed_tasks"`
+	OverdueTasks   int       `json:"overdue_tasks" db:"overdue_tasks"`
+	// Share of the non-cancelled tasks that are completed, null while there
+	// are none
+	PercentComplete *int `json:"percent_complete" db:"percent_complete"`
+	// Earliest due date of an open task, which may be past
+	NextDueDate *time.Time `json:"next_due_date" db:"next_due_date"`
+}
+
+// CreateProjectRequest represents the request body for creating a project
+type CreateProjectRequest struct {
+	Name        string  `json:"name" binding:"required"`
+	Description *string `json:"description,omitempty"`
+}
+
+// UpdateProjectRequest represents the request body for updating a project
+type UpdateProjectRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// Tag is a label that can be attached to any number of tasks
+type Tag struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// TagCount is a tag with the number of the caller's tasks carrying it
+type TagCount struct {
+	Name  string `json:"name" db:"name"`
+	Count int    `json:"count" db:"count"`
+}
+
+// SharedTask is the read-only view of a task served through a share link.
+// It leaves out the owner and other internal fields.
+type SharedTask struct {
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Description *string    `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Progress    int        `json:"progress"`
+	Color       *string    `json:"color,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NewSharedTask builds the shared view of a task
+func NewSharedTask(task Task) SharedTask {
+	return SharedTask{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		DueDate:     task.DueDate,
+		Progress:    task.Progress,
+		Color:       task.Color,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+	}
 }
 
 // CreateTaskRequest represents the request body for creating a task
@@ -35,6 +213,20 @@ type CreateTaskRequest struct {
 	Status      *string    `json:"status,omitempty"`
 	Priority    *string    `json:"priority,omitempty"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	DueDateText *string    `json:"due_date_text,omitempty"` // e.g. "tomorrow 5pm", instead of due_date
+	Color       *string    `json:"color,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Recurrence  *string    `json:"recurrence,omitempty"`
+	ProjectID   *uuid.UUID `json:"project_id,omitempty"`
+	Metadata    Metadata   `json:"metadata,omitempty"`
+
+	EstimateMinutes *int `json:"estimate_minutes,omitempty"`
+	ActualMinutes   *int `json:"actual_minutes,omitempty"`
+
+	// Optional location; latitude and longitude are given together
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	PlaceName *string  `json:"place_name,omitempty"`
 }
 
 // UpdateTaskRequest represents the request body for updating a task
@@ -44,14 +236,379 @@ type UpdateTaskRequest struct {
 	Status      *string    `json:"status,omitempty"`
 	Priority    *string    `json:"priority,omitempty"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
+	DueDateText *string    `json:"due_date_text,omitempty"` // e.g. "next friday", instead of due_date
+	Progress    *int       `json:"progress,omitempty"`
+	Color       *string    `json:"color,omitempty"`
+	Tags        *[]string  `json:"tags,omitempty"`       // replaces all tags; [] clears them
+	Recurrence  *string    `json:"recurrence,omitempty"` // "" stops the task recurring
+	ProjectID   *string    `json:"project_id,omitempty"` // "" removes the task from its project
+	Metadata    *Metadata  `json:"metadata,omitempty"`   // replaces all custom fields; {} clears them
+
+	EstimateMinutes *int `json:"estimate_minutes,omitempty"`
+	ActualMinutes   *int `json:"actual_minutes,omitempty"`
+
+	DueDateReason *string `json:"due_date_reason,omitempty"` // why the due date changed, kept in its history
+
+	// Latitude and longitude are given together
+	Latitude      *float64 `json:"latitude,omitempty"`
+	Longitude     *float64 `json:"longitude,omitempty"`
+	PlaceName     *string  `json:"place_name,omitempty"`     // "" removes the place name
+	ClearLocation bool     `json:"clear_location,omitempty"` // removes the coordinates and place name
+}
+
+// MoveTaskRequest represents the request body for moving a task on a kanban
+// board: to the zero-based position in its status column, or in the status
+// column given
+type MoveTaskRequest struct {
+	Status   *string `json:"status,omitempty"`
+	Position *int    `json:"position" binding:"required,min=0"`
+}
+
+// ReopenTaskRequest represents the optional request body for reopening a task
+type ReopenTaskRequest struct {
+	DueDate       *time.Time `json:"due_date,omitempty"`
+	DueDateReason *string    `json:"due_date_reason,omitempty"`
+}
+
+// DuplicateTaskRequest represents the optional request body for duplicating a task
+type DuplicateTaskRequest struct {
+	Title            *string `json:"title,omitempty"`
+	IncludeSubtasks  bool    `json:"include_subtasks"`
+	IncludeTags      bool    `json:"include_tags"`
+	IncludeChecklist bool    `json:"include_checklist"`
 }
 
 // TaskFilters represents query parameters for filtering tasks
 type TaskFilters struct {
-	Status   string `form:"status"`
-	Priority string `form:"priority"`
-	Page     int    `form:"page,default=1"`
-	Limit    int    `form:"limit,default=10"`
+	Query       string     `form:"q"`
+	Status      string     `form:"status"`
+	Priority    string     `form:"priority"`
+	Origin      string     `form:"origin"`
+	Scope       string     `form:"scope"`
+	ProjectID   *uuid.UUID `form:"project_id"`
+	Archived    bool       `form:"archived"`
+	Overdue     bool       `form:"overdue"`
+	Pinned      bool       `form:"pinned"`
+	Snoozed     bool       `form:"snoozed"`
+	MinProgress *int       `form:"min_progress"`
+	MinEstimate *int       `form:"min_estimate"`
+	MaxEstimate *int       `form:"max_estimate"`
+	Tags        []string   `form:"tags"`
+	Near        *GeoPoint  `form:"-"` // from near=<lat>,<lng>, matched within RadiusKm
+	RadiusKm    float64    `form:"radius_km"`
+	// Custom field filters from meta.<key>=<value> parameters
+	Metadata      map[string]string `form:"-"`
+	DueAfter      *time.Time        `form:"due_after"`
+	DueBefore     *time.Time        `form:"due_before"`
+	CreatedAfter  *time.Time        `form:"created_after"`
+	CreatedBefore *time.Time        `form:"created_before"`
+	Sort          string            `form:"sort"`
+	Order         string            `form:"order"`
+	Page          int               `form:"page,default=1"`
+	Limit         int               `form:"limit,default=10"`
+	// Cursor pagination from ?cursor=, used instead of Page when set
+	Cursor *TaskCursor `form:"-"`
+}
+
+// TaskCursor is a position in the task list, keyed on (created_at, id).
+// The zero value is the start of the list.
+type TaskCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	// Before pages back from the position rather than forward
+	Before bool `json:"b,omitempty"`
+}
+
+// GeoPoint is a latitude and longitude in degrees
+type GeoPoint struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// DueOnFilters represents query parameters for listing tasks due on a date
+type DueOnFilters struct {
+	ExcludeCompleted bool `form:"exclude_completed"`
+	Page             int  `form:"page,default=1"`
+	Limit            int  `form:"limit,default=10"`
+}
+
+// ChecklistItem is one step of a task's checklist, kept in position order
+type ChecklistItem struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	TaskID    uuid.UUID `json:"task_id" db:"task_id"`
+	Text      string    `json:"text" db:"text"`
+	Done      bool      `json:"done" db:"done"`
+	Position  int       `json:"position" db:"position"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateChecklistItemRequest represents the request body for adding a checklist item
+type CreateChecklistItemRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// MoveChecklistItemRequest represents the request body for reordering a checklist item
+type MoveChecklistItemRequest struct {
+	Position *int `json:"position" binding:"required,min=0"`
+}
+
+// Task history actions
+const (
+	HistoryActionUpdated     = "updated"
+	HistoryActionTransferred = "transferred"
+	HistoryActionDeleted     = "deleted"
+	HistoryActionRestored    = "restored"
+	HistoryActionEscalated   = "escalated"
+)
+
+// TaskHistoryEntry records a change to one field of a task. UserID is nil
+// for changes the service made on its own.
+type TaskHistoryEntry struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	TaskID    uuid.UUID  `json:"task_id" db:"task_id"`
+	UserID    *uuid.UUID `json:"user_id" db:"user_id"`
+	Action    string     `json:"action" db:"action"`
+	Field     string     `json:"field" db:"field"`
+	OldValue  *string    `json:"old_value" db:"old_value"`
+	NewValue  *string    `json:"new_value" db:"new_value"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// DeadlineChange records a change to a task's due date. UserID is nil for
+// changes the service made on its own.
+type DeadlineChange struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	TaskID     uuid.UUID  `json:"task_id" db:"task_id"`
+	UserID     *uuid.UUID `json:"user_id" db:"user_id"`
+	OldDueDate *time.Time `json:"old_due_date" db:"old_due_date"`
+	NewDueDate *time.Time `json:"new_due_date" db:"new_due_date"`
+	Reason     *string    `json:"reason" db:"reason"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// TaskComment is a comment left on a task
+type TaskComment struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	TaskID    uuid.UUID `json:"task_id" db:"task_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Body      string    `json:"body" db:"body"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	// Mentions are the users @mentioned in the body, set when it is created
+	Mentions []uuid.UUID `json:"mentions,omitempty" db:"-"`
+}
+
+// CreateCommentRequest represents the request body for commenting on a task
+type CreateCommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// TaskAttachment describes a file attached to a task. The file is kept in
+// object storage under StorageKey and downloaded through presigned URLs.
+type TaskAttachment struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	TaskID      uuid.UUID `json:"task_id" db:"task_id"`
+	UserID      uuid.UUID `json:"user_id" db:"user_id"`
+	Filename    string    `json:"filename" db:"filename"`
+	ContentType string    `json:"content_type" db:"content_type"`
+	Size        int64     `json:"size" db:"size"`
+	StorageKey  string    `json:"-" db:"storage_key"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// TaskLink is an external URL referenced from a task. PageTitle and
+// FaviconURL are fetched from the page when the link is added and are nil
+// if it could not be read.
+type TaskLink struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	TaskID     uuid.UUID `json:"task_id" db:"task_id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	URL        string    `json:"url" db:"url"`
+	Title      *string   `json:"title" db:"title"`
+	PageTitle  *string   `json:"page_title" db:"page_title"`
+	FaviconURL *string   `json:"favicon_url" db:"favicon_url"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// IdempotencyKey is the stored outcome of a request sent with an
+// Idempotency-Key header
+type IdempotencyKey struct {
+	UserID      uuid.UUID `db:"user_id"`
+	Key         string    `db:"key"`
+	RequestHash string    `db:"request_hash"`
+	// Unset while the first request is still being handled
+	StatusCode *int      `db:"status_code"`
+	Response   *string   `db:"response"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// CreateLinkRequest represents the request body for adding a link to a task
+type CreateLinkRequest struct {
+	URL   string  `json:"url" binding:"required"`
+	Title *string `json:"title,omitempty"`
+}
+
+// SnoozeTaskRequest represents the request body for snoozing a task
+type SnoozeTaskRequest struct {
+	Until *time.Time `json:"until" binding:"required"`
+}
+
+// SavedView is a named combination of task list filters. Unset fields leave
+// the matching list parameter to the request.
+type SavedView struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	UserID    uuid.UUID      `json:"user_id" db:"user_id"`
+	Name      string         `json:"name" db:"name"`
+	Status    *string        `json:"status,omitempty" db:"status"`
+	Priority  *string        `json:"priority,omitempty" db:"priority"`
+	Tags      pq.StringArray `json:"tags" db:"tags"`
+	Sort      *string        `json:"sort,omitempty" db:"sort"`
+	Order     *string        `json:"order,omitempty" db:"sort_order"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// CreateSavedViewRequest represents the request body for saving a view
+type CreateSavedViewRequest struct {
+	Name     string   `json:"name" binding:"required"`
+	Status   *string  `json:"status,omitempty"`
+	Priority *string  `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Sort     *string  `json:"sort,omitempty"`
+	Order    *string  `json:"order,omitempty"`
+}
+
+// TaskTemplate is a reusable blueprint for a task. Each checklist item
+// becomes a subtask when the template is instantiated.
+type TaskTemplate struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	UserID      uuid.UUID      `json:"user_id" db:"user_id"`
+	Title       string         `json:"title" db:"title"`
+	Description *string        `json:"description,omitempty" db:"description"`
+	Priority    string         `json:"priority" db:"priority"`
+	Checklist   pq.StringArray `json:"checklist" db:"checklist"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+}
+
+// CreateTemplateRequest represents the request body for saving a template.
+// With task_id the template is copied from that task and its subtasks, and
+// any other fields given override the copied values.
+type CreateTemplateRequest struct {
+	TaskID      *uuid.UUID `json:"task_id,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Priority    *string    `json:"priority,omitempty"`
+	Checklist   *[]string  `json:"checklist,omitempty"`
+}
+
+// InstantiateTemplateRequest represents the optional request body for
+// creating a task from a template
+type InstantiateTemplateRequest struct {
+	Title   *string    `json:"title,omitempty"`
+	DueDate *time.Time `json:"due_date,omitempty"`
+}
+
+// Reminder delivery channels
+const (
+	ChannelEmail   = "email"
+	ChannelPush    = "push"
+	ChannelWebhook = "webhook"
+)
+
+// TaskReminder schedules a task.reminder.due event for a task. The
+// notification service delivers it through each of Channels.
+type TaskReminder struct {
+	ID        uuid.UUID      `json:"id" db:"id"`
+	TaskID    uuid.UUID      `json:"task_id" db:"task_id"`
+	RemindAt  time.Time      `json:"remind_at" db:"remind_at"`
+	Channels  pq.StringArray `json:"channels" db:"channels"`
+	FiredAt   *time.Time     `json:"fired_at,omitempty" db:"fired_at"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
+
+// CreateReminderRequest represents the request body for scheduling a
+// reminder, either at remind_at or a duration before the task's due date
+type CreateReminderRequest struct {
+	RemindAt *time.Time `json:"remind_at,omitempty"`
+	Before   *string    `json:"before,omitempty"`
+	Channels []string   `json:"channels,omitempty"`
+}
+
+// Task share permissions
+const (
+	SharePermissionRead  = "read"
+	SharePermissionWrite = "write"
+)
+
+// TaskShare grants a user other than the owner access to a task
+type TaskShare struct {
+	TaskID     uuid.UUID `json:"task_id" db:"task_id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	Permission string    `json:"permission" db:"permission"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// TaskWatcher subscribes a user other than the owner to a task's events
+type TaskWatcher struct {
+	TaskID    uuid.UUID `json:"task_id" db:"task_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ShareTaskRequest represents the request body for sharing a task with a
+// user. Permission defaults to read.
+type ShareTaskRequest struct {
+	UserID     uuid.UUID `json:"user_id" binding:"required"`
+	Permission string    `json:"permission"`
+}
+
+// Task transfer statuses
+const (
+	TransferPending  = "pending"
+	TransferAccepted = "accepted"
+	TransferRejected = "rejected"
+)
+
+// TaskTransfer represents a request to hand task ownership to another user
+type TaskTransfer struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	TaskID      uuid.UUID  `json:"task_id" db:"task_id"`
+	FromUserID  uuid.UUID  `json:"from_user_id" db:"from_user_id"`
+	ToUserID    uuid.UUID  `json:"to_user_id" db:"to_user_id"`
+	Status      string     `json:"status" db:"status"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty" db:"responded_at"`
+}
+
+// CreateTransferRequest represents the request body for transferring a task
+type CreateTransferRequest struct {
+	ToUserID uuid.UUID `json:"to_user_id" binding:"required"`
+}
+
+// Task delegation statuses
+const (
+	DelegationPending  = "pending"
+	DelegationAccepted = "accepted"
+	DelegationDeclined = "declined"
+)
+
+// TaskDelegation asks another user to take on a task. The owner keeps the
+// task; accepting gives the delegatee write access to it.
+type TaskDelegation struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	TaskID      uuid.UUID  `json:"task_id" db:"task_id"`
+	FromUserID  uuid.UUID  `json:"from_user_id" db:"from_user_id"`
+	ToUserID    uuid.UUID  `json:"to_user_id" db:"to_user_id"`
+	Status      string     `json:"status" db:"status"`
+	Note        *string    `json:"note,omitempty" db:"note"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty" db:"responded_at"`
+
+	Task *Task `json:"task,omitempty" db:"-"`
+}
+
+// DelegateTaskRequest represents the request body for delegating a task
+type DelegateTaskRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+	Note   *string   `json:"note,omitempty"`
 }
 
 // TaskStats represents task statistics
@@ -61,6 +618,315 @@ type TaskStats struct {
 	ByPriority     map[string]int `json:"by_priority"`
 	OverdueTasks   int            `json:"overdue_tasks"`
 	CompletedToday int            `json:"completed_today"`
+	SlippedTasks   int            `json:"slipped_tasks"` // tasks whose due date was pushed back 3 or more times
+	TimeTracked    TimeTracked    `json:"time_tracked"`
+	Effort         []EffortWeek   `json:"effort"`
+}
+
+// EffortWeek totals the estimated and actual minutes of the tasks completed
+// in a week starting on Monday
+type EffortWeek struct {
+	WeekStart        string `json:"week_start" db:"-"`
+	Tasks            int    `json:"tasks" db:"tasks"`
+	EstimatedMinutes int    `json:"estimated_minutes" db:"estimated_minutes"`
+	ActualMinutes    int    `json:"actual_minutes" db:"actual_minutes"`
+}
+
+// TimeTracked summarizes the time a user tracked: the total and the tasks
+// with the most time, running timers included
+type TimeTracked struct {
+	TotalSeconds int64      `json:"total_seconds"`
+	Tasks        []TaskTime `json:"tasks"`
+}
+
+// TaskTime is the time tracked on one task
+type TaskTime struct {
+	TaskID  uuid.UUID `json:"task_id" db:"task_id"`
+	Title   string    `json:"title" db:"title"`
+	Seconds int64     `json:"seconds" db:"seconds"`
+}
+
+// TaskDependency records that the blocker task must be done before the
+// blocked task can be completed
+type TaskDependency struct {
+	BlockerID uuid.UUID `json:"blocker_id" db:"blocker_id"`
+	BlockedID uuid.UUID `json:"blocked_id" db:"blocked_id"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// AddDependencyRequest represents the request body for declaring that a
+// task is blocked by another
+type AddDependencyRequest struct {
+	BlockedBy uuid.UUID `json:"blocked_by" binding:"required"`
+}
+
+// TimeEntry is a span of time a user tracked on a task. StoppedAt is nil
+// while the timer runs.
+type TimeEntry struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	TaskID    uuid.UUID  `json:"task_id" db:"task_id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	StartedAt time.Time  `json:"started_at" db:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty" db:"stopped_at"`
+}
+
+// UsersStatsRequest represents the request body for batch user statistics
+type UsersStatsRequest struct {
+	UserIDs []uuid.UUID `json:"user_ids" binding:"required,min=1"`
+}
+
+// TrendDelta compares a metric between the current and previous period
+type TrendDelta struct {
+	Current       int      `json:"current"`
+	Previous      int      `json:"previous"`
+	Delta         int      `json:"delta"`
+	PercentChange *float64 `json:"percent_change"`
+}
+
+// TaskStatsOverview represents all-time totals with week-over-week deltas
+type TaskStatsOverview struct {
+	TotalTasks     int        `json:"total_tasks"`
+	CompletedTasks int        `json:"completed_tasks"`
+	OpenTasks      int        `json:"open_tasks"`
+	OverdueTasks   int        `json:"overdue_tasks"`
+	Created        TrendDelta `json:"created"`
+	Completed      TrendDelta `json:"completed"`
+}
+
+// TaskStatsComparison compares this week's activity with last week's
+type TaskStatsComparison struct {
+	ThisWeek  string     `json:"this_week"`
+	LastWeek  string     `json:"last_week"`
+	Completed TrendDelta `json:"completed"`
+	Created   TrendDelta `json:"created"`
+	Overdue   TrendDelta `json:"overdue"`
+}
+
+// TrendBucket counts the tasks created and completed in one period
+type TrendBucket struct {
+	Start     string `json:"start"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// TaskTrends represents created and completed counts over consecutive periods
+type TaskTrends struct {
+	Granularity string        `json:"granularity"`
+	Range       string        `json:"range"`
+	Buckets     []TrendBucket `json:"buckets"`
+}
+
+// DayBucket groups tasks due on a single calendar day
+type DayBucket struct {
+	Date    string `json:"date"`
+	Weekday string `json:"weekday"`
+	Tasks   []Task `json:"tasks"`
+}
+
+// WeekPlan represents open tasks grouped by day for a week
+type WeekPlan struct {
+	Start   string      `json:"start"`
+	End     string      `json:"end"`
+	Days    []DayBucket `json:"days"`
+	Overdue []Task      `json:"overdue"`
+	Undated []Task      `json:"undated"`
+}
+
+// TaskOccurrence is a projected future occurrence of a recurring task, which
+// the recurrence scheduler creates once the current one is completed
+type TaskOccurrence struct {
+	TaskID   uuid.UUID `json:"task_id"`
+	Title    string    `json:"title"`
+	Priority string    `json:"priority"`
+	DueDate  time.Time `json:"due_date"`
+}
+
+// CalendarDay groups the tasks and recurring occurrences due on one day
+type CalendarDay struct {
+	Date        string           `json:"date"`
+	Weekday     string           `json:"weekday"`
+	Tasks       []Task           `json:"tasks"`
+	Occurrences []TaskOccurrence `json:"occurrences"`
+}
+
+// Calendar represents tasks grouped by due date over a range of days
+type Calendar struct {
+	From string        `json:"from"`
+	To   string        `json:"to"`
+	Days []CalendarDay `json:"days"`
+}
+
+// ImportRowError describes why a single imported row was rejected
+type ImportRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// BulkItemError describes why one item of a bulk request was rejected
+type BulkItemError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// BulkUpdateRequest represents the request body for updating many tasks at
+// once. Exactly one of IDs or Filter selects the tasks.
+type BulkUpdateRequest struct {
+	IDs    []uuid.UUID     `json:"ids,omitempty"`
+	Filter *BulkTaskFilter `json:"filter,omitempty"`
+	Update BulkTaskUpdate  `json:"update"`
+}
+
+// BulkTaskFilter selects tasks for a bulk update like the task list filters
+type BulkTaskFilter struct {
+	Status   string   `json:"status,omitempty"`
+	Priority string   `json:"priority,omitempty"`
+	Origin   string   `json:"origin,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// BulkTaskUpdate lists the fields a bulk update may set
+type BulkTaskUpdate struct {
+	Status   *string    `json:"status,omitempty"`
+	Priority *string    `json:"priority,omitempty"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+	Color    *string    `json:"color,omitempty"` // "" clears the color
+
+	DueDateReason *string `json:"due_date_reason,omitempty"`
+}
+
+// BulkUpdateResult reports the rows one task ID affected in a bulk update
+type BulkUpdateResult struct {
+	ID           uuid.UUID `json:"id"`
+	RowsAffected int64     `json:"rows_affected"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// BulkDeleteResult summarizes a bulk delete
+type BulkDeleteResult struct {
+	Deleted         int  `json:"deleted"`
+	SubtasksDeleted int  `json:"subtasks_deleted"`
+	HasMore         bool `json:"has_more"`
+}
+
+// ImportDuplicate describes an imported row skipped because a task with the
+// same title and due date already exists or appeared on an earlier line
+type ImportDuplicate struct {
+	Line          int        `json:"line"`
+	Title         string     `json:"title"`
+	DueDate       *time.Time `json:"due_date"`
+	TaskID        *uuid.UUID `json:"task_id,omitempty"`
+	DuplicateLine int        `json:"duplicate_line,omitempty"`
+}
+
+// ImportResult summarizes the outcome of a task import. On a dry run,
+// Imported is how many tasks would have been created.
+type ImportResult struct {
+	Imported   int               `json:"imported"`
+	Failed     int               `json:"failed"`
+	Errors     []ImportRowError  `json:"errors"`
+	Duplicates []ImportDuplicate `json:"duplicates,omitempty"`
+	DryRun     bool              `json:"dry_run,omitempty"`
+}
+
+// Task event types, also used as RabbitMQ routing keys
+const (
+	EventTaskCreated  = "task.created"
+	EventTaskUpdated  = "task.updated"
+	EventTaskDeleted  = "task.deleted"
+	EventTaskReopened = "task.reopened"
+	EventTaskRestored = "task.restored"
+	// EventTaskCompleted is published by the complete endpoint
+	EventTaskCompleted = "task.completed"
+	// EventTaskEscalated is published when an overdue task's priority is raised
+	EventTaskEscalated = "task.escalated"
+	// EventTaskOverdue is published once when an open task passes its due date
+	EventTaskOverdue = "task.overdue"
+	// EventTaskMentioned is published for each user @mentioned in a comment
+	EventTaskMentioned = "task.mentioned"
+	// Delegation events are published when a task is delegated and when the
+	// delegatee accepts or declines
+	EventTaskDelegated          = "task.delegated"
+	EventTaskDelegationAccepted = "task.delegation.accepted"
+	EventTaskDelegationDeclined = "task.delegation.declined"
+
+	EventTaskReminderDue = "task.reminder.due"
+)
+
+// TaskEvent represents an event published when a task changes
+type TaskEvent struct {
+	EventType string        `json:"eventType"`
+	TaskID    uuid.UUID     `json:"taskId"`
+	UserID    uuid.UUID     `json:"userId"`
+	Task      *Task         `json:"task,omitempty"`
+	Reminder  *TaskReminder `json:"reminder,omitempty"`
+	// Watchers are the other users watching the task, to be notified too
+	Watchers []uuid.UUID `json:"watchers,omitempty"`
+	// MentionedUserID and Comment say who was @mentioned where
+	MentionedUserID *uuid.UUID   `json:"mentionedUserId,omitempty"`
+	Comment         *TaskComment `json:"comment,omitempty"`
+	// Delegation is the delegation a delegation event is about
+	Delegation *TaskDelegation `json:"delegation,omitempty"`
+	// Change is the history entry behind the event, when there is one
+	Change    *TaskHistoryEntry `json:"change,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// StreamEvent is a task event as recorded for one of the users it concerns.
+// IDs increase, so a stream resumes after the last ID a client saw.
+type StreamEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	UserID    uuid.UUID       `json:"user_id" db:"user_id"`
+	TaskID    uuid.UUID       `json:"task_id" db:"task_id"`
+	EventType string          `json:"event_type" db:"event_type"`
+	Payload   json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Webhook delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a URL a user registered to receive task events of the listed
+// types
+type Webhook struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	UserID     uuid.UUID      `json:"user_id" db:"user_id"`
+	URL        string         `json:"url" db:"url"`
+	EventTypes pq.StringArray `json:"event_types" db:"event_types"`
+	// Secret signs deliveries; it is only shown when the webhook is created
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreateWebhookRequest represents the request body for registering a webhook
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+}
+
+// UpdateWebhookRequest represents the request body for updating a webhook
+type UpdateWebhookRequest struct {
+	URL        *string  `json:"url,omitempty"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	ID        uuid.UUID       `json:"id" db:"id"`
+	WebhookID uuid.UUID       `json:"webhook_id" db:"webhook_id"`
+	EventType string          `json:"event_type" db:"event_type"`
+	Payload   json.RawMessage `json:"payload" db:"payload"`
+	Status    string          `json:"status" db:"status"`
+	Attempts  int             `json:"attempts" db:"attempts"`
+	// The outcome of the last attempt: the response status, or why there was none
+	ResponseStatus *int       `json:"response_status,omitempty" db:"response_status"`
+	Error          *string    `json:"error,omitempty" db:"error"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty" db:"last_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
 }
 
 // UserEvent represents an event received from auth service
@@ -69,5 +935,6 @@ type UserEvent struct {
 	UserID    uuid.UUID `json:"userId"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
+	Timezone  string    `json:"timezone,omitempty"` // IANA name, e.g. "Europe/Berlin"
 	Timestamp time.Time `json:"timestamp"`
 }
@@ -0,0 +1,266 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation documents one route. Request, Response and Query are example
+// values whose types are reflected into schemas, so the spec follows the
+// models as they change.
+type Operation struct {
+	Summary     string
+	Description string
+	// Request is the JSON body, or nil for none
+	Request interface{}
+	// RequestType overrides the body's content type, e.g. for uploads
+	RequestType string
+	// Query is a struct whose form tags are the query parameters
+	Query interface{}
+	// Params are query parameters read outside of Query
+	Params []Param
+	// Status is the success status, 200 by default
+	Status int
+	// Response is the JSON success body, or nil for none
+	Response interface{}
+	// ResponseType overrides the success body's content type
+	ResponseType string
+	// Public operations don't require a JWT
+	Public bool
+}
+
+// Param documents a query parameter
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type components struct {
+	Schemas         map[string]*Schema     `json:"schemas"`
+	SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+}
+
+type operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []parameter           `json:"parameters,omitempty"`
+	RequestBody *body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*body      `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type body struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// errorSchema is the body of every error response
+var errorSchema = &Schema{
+	Type:       "object",
+	Properties: map[string]*Schema{"error": {Type: "string"}},
+	Required:   []string{"error"},
+}
+
+// Build documents the routes registered on an engine. Routes under the
+// prefix, such as /api/v1, and routes outside /api are included; other
+// /api routes are aliases and left out. operations are keyed by method and
+// path relative to the prefix ("GET /tasks/:id"); routes without one are
+// still listed, summarized from their handler's name.
+func Build(info Info, routes gin.RoutesInfo, prefix string, operations map[string]Operation) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*operation{},
+		Components: components{
+			Schemas: map[string]*Schema{"Error": errorSchema},
+			SecuritySchemes: map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	s := &schemas{components: doc.Components.Schemas}
+
+	for _, route := range routes {
+		key := route.Path
+		if rest, ok := strings.CutPrefix(route.Path, prefix); ok {
+			key = rest
+		} else if strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		op, ok := operations[route.Method+" "+key]
+		if !ok {
+			op = Operation{Summary: summarize(handlerName(route.Handler))}
+		}
+
+		path, params := pathParams(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = s.operation(op, route, key, params)
+	}
+	return doc
+}
+
+// operation renders one documented route
+func (s *schemas) operation(op Operation, route gin.RouteInfo, key string, params []parameter) *operation {
+	out := &operation{
+		OperationID: handlerName(route.Handler),
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        []string{tag(key)},
+		Parameters:  append(params, s.queryParams(op)...),
+		Responses:   map[string]*body{},
+	}
+	if !op.Public {
+		out.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	if op.Request != nil || op.RequestType != "" {
+		contentType := op.RequestType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		out.RequestBody = &body{Required: true, Content: map[string]mediaType{contentType: {Schema: s.of(op.Request)}}}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &body{Description: http.StatusText(status)}
+	if op.Response != nil || op.ResponseType != "" {
+		contentType := op.ResponseType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		success.Content = map[string]mediaType{contentType: {Schema: s.of(op.Response)}}
+	}
+	out.Responses[strconv.Itoa(status)] = success
+	out.Responses["default"] = &body{
+		Description: "Error",
+		Content:     map[string]mediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/Error"}}},
+	}
+	return out
+}
+
+// queryParams lists an operation's query parameters: the form fields of
+// Query, then Params
+func (s *schemas) queryParams(op Operation) []parameter {
+	var params []parameter
+	if op.Query != nil {
+		t := indirect(reflect.TypeOf(op.Query))
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			schema := s.typeOf(field.Type)
+			schema.Nullable = false
+			params = append(params, parameter{Name: name, In: "query", Schema: schema})
+		}
+	}
+	for _, param := range op.Params {
+		paramType := param.Type
+		if paramType == "" {
+			paramType = "string"
+		}
+		params = append(params, parameter{Name: param.Name, In: "query", Description: param.Description, Schema: &Schema{Type: paramType}})
+	}
+	return params
+}
+
+// pathParams converts a gin path to OpenAPI's form, /tasks/:id becoming
+// /tasks/{id}, and returns its parameters
+func pathParams(path string) (string, []parameter) {
+	var params []parameter
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "Id") {
+			schema.Format = "uuid"
+		}
+		params = append(params, parameter{Name: name, In: "path", Required: true, Schema: schema})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// tag groups a route by its first path segment
+func tag(path string) string {
+	if strings.Contains(path, "/admin/") {
+		return "admin"
+	}
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return segment
+}
+
+// handlerName trims a handler's qualified name, such as
+// "...handlers.(*TaskHandler).GetTasks-fm", to the method name
+func handlerName(handler string) string {
+	handler = strings.TrimSuffix(handler, "-fm")
+	if i := strings.LastIndex(handler, "."); i >= 0 {
+		handler = handler[i+1:]
+	}
+	return handler
+}
+
+// summarize turns a handler name into a summary: GetTasksDueOn becomes
+// "Get tasks due on"
+func summarize(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) {
+			b.WriteByte(' ')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Handler serves the document as JSON
+func (d *Document) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, d)
+	}
+}
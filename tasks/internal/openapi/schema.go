@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Object describes an ad hoc JSON object, such as a response envelope,
+// by example: each value stands for the schema of its field
+type Object map[string]interface{}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	uuidType    = reflect.TypeOf(uuid.UUID{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+	objectType  = reflect.TypeOf(Object{})
+)
+
+// schemas reflects Go types into schemas. Named structs are registered as
+// components and referenced, so each model is described once.
+type schemas struct {
+	components map[string]*Schema
+}
+
+// of returns the schema of v's type, or of the fields of an Object
+func (s *schemas) of(v interface{}) *Schema {
+	if obj, ok := v.(Object); ok {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema, len(obj))}
+		for name, field := range obj {
+			schema.Properties[name] = s.of(field)
+		}
+		return schema
+	}
+	if v == nil {
+		return &Schema{}
+	}
+	return s.typeOf(reflect.TypeOf(v))
+}
+
+func (s *schemas) typeOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawJSONType, objectType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.typeOf(t.Elem())
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.typeOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.typeOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structOf(t)
+		}
+		name := t.Name()
+		if _, ok := s.components[name]; !ok {
+			// Registered before the fields are walked so recursive types,
+			// such as a task's subtasks, end in a reference
+			s.components[name] = &Schema{}
+			*s.components[name] = *s.structOf(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	// Interfaces and anything else may hold any JSON value
+	return &Schema{}
+}
+
+// structOf describes a struct by its JSON fields. The fields listed as
+// required are those a request must send, by their binding tags.
+func (s *schemas) structOf(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := s.structOf(indirect(field.Type))
+			for prop, fieldSchema := range embedded.Properties {
+				schema.Properties[prop] = fieldSchema
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := s.typeOf(field.Type)
+		rules := strings.Split(field.Tag.Get("binding"), ",")
+		applyBinding(fieldSchema, rules)
+		schema.Properties[name] = fieldSchema
+		if hasRule(rules, "required") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// jsonName reads the name from a field's json tag
+func jsonName(field reflect.StructField) (name string, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	return name, false
+}
+
+// applyBinding carries the min and max rules of a binding tag over to a
+// schema: lengths for strings, bounds for numbers
+func applyBinding(schema *Schema, rules []string) {
+	for _, rule := range rules {
+		key, val, ok := strings.Cut(rule, "=")
+		if !ok || (key != "min" && key != "max") {
+			continue
+		}
+		n, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			continue
+		}
+		switch schema.Type {
+		case "string":
+			length := int(n)
+			if key == "min" {
+				schema.MinLength = &length
+			} else {
+				schema.MaxLength = &length
+			}
+		case "integer", "number":
+			if key == "min" {
+				schema.Minimum = &n
+			} else {
+				schema.Maximum = &n
+			}
+		}
+	}
+}
+
+func hasRule(rules []string, name string) bool {
+	for _, rule := range rules {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
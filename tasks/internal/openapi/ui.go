@@ -0,0 +1,41 @@
+package openapi
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIVersion pins the Swagger UI release the docs page loads
+const swaggerUIVersion = "5"
+
+var uiPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: {{.SpecURL}}, dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+
+// UI serves a Swagger UI page for the document at specURL
+func UI(title, specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		uiPage.Execute(c.Writer, map[string]string{
+			"Title":   title,
+			"Version": swaggerUIVersion,
+			"SpecURL": specURL,
+		})
+	}
+}
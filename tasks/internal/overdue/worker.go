@@ -0,0 +1,106 @@
+package overdue
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+)
+
+// batchSize bounds how many tasks are flagged per query
+const batchSize = 100
+
+// Publisher publishes task change events
+type Publisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// Worker flags open tasks as overdue once their due date passes and
+// publishes a task.overdue event for each. Running it on several instances
+// is safe: each task is flagged by whichever instance locks it first.
+type Worker struct {
+	tasks    *repository.TaskRepository
+	indexer  search.Indexer
+	events   Publisher
+	interval time.Duration
+}
+
+// NewWorker creates a worker that runs every OVERDUE_CHECK_INTERVAL
+func NewWorker(db *database.DB, indexer search.Indexer, events Publisher) *Worker {
+	return &Worker{
+		tasks:    repository.NewTaskRepository(db),
+		indexer:  indexer,
+		events:   events,
+		interval: config.Duration("OVERDUE_CHECK_INTERVAL", time.Minute),
+	}
+}
+
+// Start runs the worker in the background until ctx is done. A zero interval
+// disables it.
+func (w *Worker) Start(ctx context.Context) {
+	if w.interval <= 0 {
+		log.Println("⚠️  Overdue worker disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.run(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping overdue worker...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Overdue worker checking every %s", w.interval)
+}
+
+// run flags newly overdue tasks until none are left
+func (w *Worker) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		tasks, err := w.tasks.MarkOverdue(ctx, time.Now(), batchSize)
+		if err != nil {
+			log.Printf("❌ Failed to flag overdue tasks: %v\n", err)
+			return
+		}
+
+		if !w.notify(ctx, tasks) || len(tasks) < batchSize {
+			return
+		}
+	}
+}
+
+// notify publishes events for flagged tasks, unflagging any that fail so the
+// next run retries them. It reports whether every task was handled.
+func (w *Worker) notify(ctx context.Context, tasks []models.Task) bool {
+	ok := true
+	for i := range tasks {
+		task := &tasks[i]
+		event := models.TaskEvent{
+			EventType: models.EventTaskOverdue,
+			TaskID:    task.ID,
+			UserID:    task.UserID,
+			Task:      task,
+		}
+		if err := w.events.Publish(ctx, event); err != nil {
+			log.Printf("❌ Failed to publish overdue event for task %s: %v\n", task.ID, err)
+			if err := w.tasks.UnmarkOverdue(ctx, task.ID); err != nil {
+				log.Printf("❌ Failed to unflag overdue task %s: %v\n", task.ID, err)
+			}
+			ok = false
+			continue
+		}
+		log.Printf("⏰ Task %s is overdue\n", task.ID)
+		w.indexer.Index(ctx, *task)
+	}
+	return ok
+}
@@ -0,0 +1,103 @@
+package rabbitmq
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// Breaker stops message processing after consecutive database failures so the
+// consumer doesn't spin nack-requeueing messages while the database is down
+type Breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     string
+	openedAt  time.Time
+}
+
+// NewBreaker creates a breaker that opens after threshold consecutive failures
+// and allows a trial message once cooldown has elapsed
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     BreakerClosed,
+	}
+}
+
+// State returns the current breaker state
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// RecordSuccess closes the breaker and resets the failure count
+func (b *Breaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != BreakerClosed {
+		log.Println("✅ Database circuit breaker closed, resuming normal consumption")
+	}
+	b.failures = 0
+	b.state = BreakerClosed
+}
+
+// RecordFailure counts a failure and opens the breaker once the threshold is
+// reached. A failed trial in the half-open state reopens it immediately.
+func (b *Breaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		if b.state != BreakerOpen {
+			log.Printf("⚠️  Database circuit breaker opened after %d consecutive failures, pausing for %v\n", b.failures, b.cooldown)
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Wait blocks while the breaker is open. Once the cooldown has elapsed the
+// breaker moves to half-open and lets the next message through as a trial.
+// It returns false if the context is cancelled while waiting.
+func (b *Breaker) Wait(ctx context.Context) bool {
+	b.mu.Lock()
+	if b.state != BreakerOpen {
+		b.mu.Unlock()
+		return true
+	}
+	remaining := b.cooldown - time.Since(b.openedAt)
+	b.mu.Unlock()
+
+	if remaining > 0 {
+		timer := time.NewTimer(remaining)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+	}
+
+	b.mu.Lock()
+	if b.state == BreakerOpen {
+		b.state = BreakerHalfOpen
+		log.Println("🔄 Database circuit breaker half-open, trying next message")
+	}
+	b.mu.Unlock()
+	return true
+}
@@ -0,0 +1,77 @@
+package rabbitmq
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewBreaker(3, time.Minute)
+
+	b.RecordFailure()
+	b.RecordFailure()
+	assert.Equal(t, BreakerClosed, b.State())
+
+	// A success in between resets the count
+	b.RecordSuccess()
+	b.RecordFailure()
+	b.RecordFailure()
+	assert.Equal(t, BreakerClosed, b.State())
+
+	b.RecordFailure()
+	assert.Equal(t, BreakerOpen, b.State())
+}
+
+func TestBreakerThresholdIsAtLeastOne(t *testing.T) {
+	b := NewBreaker(0, time.Minute)
+	b.RecordFailure()
+	assert.Equal(t, BreakerOpen, b.State())
+}
+
+func TestBreakerWaitsForCooldown(t *testing.T) {
+	const cooldown = 50 * time.Millisecond
+	b := NewBreaker(1, cooldown)
+
+	start := time.Now()
+	assert.True(t, b.Wait(context.Background()), "a closed breaker doesn't wait")
+	assert.Less(t, time.Since(start), cooldown)
+
+	b.RecordFailure()
+	start = time.Now()
+	assert.True(t, b.Wait(context.Background()))
+	assert.GreaterOrEqual(t, time.Since(start), cooldown)
+	assert.Equal(t, BreakerHalfOpen, b.State(), "the next message is a trial")
+}
+
+func TestBreakerTrialOutcome(t *testing.T) {
+	b := NewBreaker(3, time.Millisecond)
+	for i := 0; i < 3; i++ {
+		b.RecordFailure()
+	}
+	assert.True(t, b.Wait(context.Background()))
+	assert.Equal(t, BreakerHalfOpen, b.State())
+
+	// One failed trial reopens it without waiting for the threshold
+	b.RecordFailure()
+	assert.Equal(t, BreakerOpen, b.State())
+
+	assert.True(t, b.Wait(context.Background()))
+	b.RecordSuccess()
+	assert.Equal(t, BreakerClosed, b.State())
+
+	b.RecordFailure()
+	assert.Equal(t, BreakerClosed, b.State(), "closing resets the failure count")
+}
+
+func TestBreakerWaitStopsOnCancel(t *testing.T) {
+	b := NewBreaker(1, time.Hour)
+	b.RecordFailure()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, b.Wait(ctx))
+	assert.Equal(t, BreakerOpen, b.State())
+}
@@ -2,21 +2,64 @@ package rabbitmq
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
 	"github.com/moabdelazem/microservices/tasks/internal/database"
 	"github.com/moabdelazem/microservices/tasks/internal/models"
 	"github.com/streadway/amqp"
 )
 
+// Email conflict policies applied when an incoming user event carries an
+// email that is already cached under a different user ID
+const (
+	// EmailConflictKeepNewest merges the older cached user into the newer one
+	EmailConflictKeepNewest = "keep-newest"
+	// EmailConflictLogAndSkip leaves the cache untouched and drops the event
+	EmailConflictLogAndSkip = "log-and-skip"
+)
+
+// consumerTag identifies this service's consumer so it can be cancelled
+const consumerTag = "tasks-service"
+
+var (
+	// ErrConsumerPaused is returned when pausing a consumer that is already paused
+	ErrConsumerPaused = errors.New("consumer is already paused")
+	// ErrConsumerRunning is returned when resuming a consumer that is not paused
+	ErrConsumerRunning = errors.New("consumer is not paused")
+)
+
+// consumeChannel is the part of an AMQP channel the consumer uses once its
+// topology is declared
+type consumeChannel interface {
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	Cancel(consumer string, noWait bool) error
+	Close() error
+}
+
 type Consumer struct {
-	conn    *amqp.Connection
-	channel *amqp.Channel
-	db      *database.DB
+	conn                *amqp.Connection
+	channel             consumeChannel
+	db                  *database.DB
+	exchange            string
+	queueName           string
+	deadLetterQueue     string
+	emailConflictPolicy string
+	breaker             *Breaker
+
+	mu     sync.Mutex
+	ctx    context.Context
+	paused bool
 }
 
 // NewConsumer creates a new RabbitMQ consumer with retry logic
@@ -65,20 +108,25 @@ func NewConsumer(db *database.DB) (*Consumer, error) {
 		exchange = "auth_events" // Default exchange name
 	}
 
-	// Declare exchange
-	err = channel.ExchangeDeclare(
-		exchange, // name
-		"topic",  // type
-		true,     // durable
-		false,    // auto-deleted
-		false,    // internal
-		false,    // no-wait
-		nil,      // arguments
-	)
+	// Declare exchange, or only assert it exists in passive mode
+	passive := config.Bool("RABBITMQ_EXCHANGE_PASSIVE", false)
+	if passive {
+		err = channel.ExchangeDeclarePassive(exchange, "topic", true, false, false, false, nil)
+	} else {
+		err = channel.ExchangeDeclare(
+			exchange, // name
+			"topic",  // type
+			true,     // durable
+			false,    // auto-deleted
+			false,    // internal
+			false,    // no-wait
+			nil,      // arguments
+		)
+	}
 	if err != nil {
 		channel.Close()
 		conn.Close()
-		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+		return nil, exchangeDeclareError(exchange, passive, err)
 	}
 
 	log.Printf("✅ Declared exchange: %s", exchange)
@@ -89,78 +137,103 @@ func NewConsumer(db *database.DB) (*Consumer, error) {
 		queueName = "tasks-service-queue" // Default queue name
 	}
 
-	// Declare queue
-	queue, err := channel.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
-	)
-	if err != nil {
+	// Messages the consumer rejects are dead-lettered instead of dropped,
+	// so they can be inspected and requeued once the cause is fixed
+	deadLetterExchange := config.String("RABBITMQ_DEAD_LETTER_EXCHANGE", queueName+".dlx")
+	deadLetterQueue := config.String("RABBITMQ_DEAD_LETTER_QUEUE", queueName+".dlq")
+	if err := declareDeadLetterQueue(channel, deadLetterExchange, deadLetterQueue); err != nil {
 		channel.Close()
 		conn.Close()
-		return nil, fmt.Errorf("failed to declare queue: %w", err)
+		return nil, err
 	}
 
-	log.Printf("✅ Declared queue: %s", queue.Name)
+	log.Printf("✅ Declared dead-letter queue: %s", deadLetterQueue)
 
-	// Bind queue to exchange for user.created events
-	err = channel.QueueBind(
-		queue.Name,     // queue name
-		"user.created", // routing key
-		exchange,       // exchange
-		false,
-		nil,
-	)
-	if err != nil {
+	// Declare queue. Dead-lettering is set by a policy rather than queue
+	// arguments, so queues declared by earlier releases are reused as-is.
+	if err := declareQueue(channel, queueName, exchange); err != nil {
 		channel.Close()
 		conn.Close()
-		return nil, fmt.Errorf("failed to bind queue: %w", err)
+		return nil, err
 	}
 
-	log.Printf("✅ Bound queue to exchange with routing key: user.created")
-
-	// Also bind to user.updated
-	err = channel.QueueBind(
-		queue.Name,     // queue name
-		"user.updated", // routing key
-		exchange,       // exchange
-		false,
-		nil,
-	)
-	if err != nil {
-		channel.Close()
-		conn.Close()
-		return nil, fmt.Errorf("failed to bind queue to user.updated: %w", err)
-	}
+	log.Printf("✅ Declared queue: %s", queueName)
+	log.Printf("✅ Bound queue to exchange with routing keys: user.created, user.updated")
+	log.Printf("ℹ️  Rejected events are dead-lettered to %s only while a policy is set on %s: %s",
+		deadLetterQueue, queueName, deadLetterPolicyCommand(queueName, deadLetterExchange))
 
 	log.Printf("✅ Connected to RabbitMQ, listening on queue: %s\n", queueName)
 
+	policy := config.String("USER_EMAIL_CONFLICT_POLICY", EmailConflictLogAndSkip)
+	if policy != EmailConflictKeepNewest && policy != EmailConflictLogAndSkip {
+		log.Printf("⚠️  Unknown USER_EMAIL_CONFLICT_POLICY %q, using %s", policy, EmailConflictLogAndSkip)
+		policy = EmailConflictLogAndSkip
+	}
+
 	return &Consumer{
-		conn:    conn,
-		channel: channel,
-		db:      db,
+		conn:                conn,
+		channel:             channel,
+		db:                  db,
+		exchange:            exchange,
+		queueName:           queueName,
+		deadLetterQueue:     deadLetterQueue,
+		emailConflictPolicy: policy,
+		breaker: NewBreaker(
+			config.Int("RABBITMQ_BREAKER_THRESHOLD", 5),
+			config.Duration("RABBITMQ_BREAKER_COOLDOWN", 30*time.Second),
+		),
 	}, nil
 }
 
+// exchangeDeclareError turns AMQP declaration failures into actionable errors.
+// A PRECONDITION_FAILED reply means the exchange already exists with a
+// different type, durability or auto-delete setting.
+func exchangeDeclareError(exchange string, passive bool, err error) error {
+	amqpErr, ok := err.(*amqp.Error)
+	if !ok {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	switch {
+	case amqpErr.Code == amqp.PreconditionFailed:
+		return fmt.Errorf(
+			"exchange %q already exists with different settings than this service declares (topic, durable, not auto-deleted). "+
+				"Align the other declaration, delete the exchange so it can be recreated, "+
+				"or set RABBITMQ_EXCHANGE_PASSIVE=true to use the existing exchange as-is: %w", exchange, err)
+	case passive && amqpErr.Code == amqp.NotFound:
+		return fmt.Errorf(
+			"exchange %q does not exist and RABBITMQ_EXCHANGE_PASSIVE is enabled. "+
+				"Create the exchange first or disable passive mode: %w", exchange, err)
+	default:
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+}
+
 // Start begins consuming messages
 func (c *Consumer) Start(ctx context.Context) error {
-	queueName := os.Getenv("RABBITMQ_QUEUE")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ctx = ctx
+	return c.consume()
+}
+
+// consume registers the consumer and dispatches deliveries until the
+// consumer is cancelled or ctx is done. Callers must hold c.mu.
+func (c *Consumer) consume() error {
 	msgs, err := c.channel.Consume(
-		queueName, // queue
-		"",        // consumer
-		false,     // auto-ack
-		false,     // exclusive
-		false,     // no-local
-		false,     // no-wait
-		nil,       // args
+		c.queueName, // queue
+		consumerTag, // consumer
+		false,       // auto-ack
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
 	)
 	if err != nil {
 		return fmt.Errorf("failed to register consumer: %w", err)
 	}
 
+	ctx := c.ctx
 	go func() {
 		for {
 			select {
@@ -169,7 +242,16 @@ func (c *Consumer) Start(ctx context.Context) error {
 				return
 			case msg, ok := <-msgs:
 				if !ok {
-					log.Println("RabbitMQ channel closed")
+					if c.Paused() {
+						log.Println("⏸️  RabbitMQ consumer paused")
+					} else {
+						log.Println("RabbitMQ channel closed")
+					}
+					return
+				}
+				if !c.breaker.Wait(ctx) {
+					msg.Nack(false, true)
+					log.Println("Stopping RabbitMQ consumer...")
 					return
 				}
 				c.handleMessage(msg)
@@ -180,11 +262,52 @@ func (c *Consumer) Start(ctx context.Context) error {
 	return nil
 }
 
+// Pause cancels the consumer so the broker stops delivering messages, while
+// keeping the connection and channel open. Deliveries already received are
+// still processed.
+func (c *Consumer) Pause() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.paused {
+		return ErrConsumerPaused
+	}
+	if err := c.channel.Cancel(consumerTag, false); err != nil {
+		return fmt.Errorf("failed to cancel consumer: %w", err)
+	}
+	c.paused = true
+	log.Println("⏸️  RabbitMQ consumer pause requested")
+	return nil
+}
+
+// Resume re-registers a paused consumer
+func (c *Consumer) Resume() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.paused {
+		return ErrConsumerRunning
+	}
+	if err := c.consume(); err != nil {
+		return err
+	}
+	c.paused = false
+	log.Println("▶️  RabbitMQ consumer resumed")
+	return nil
+}
+
+// Paused reports whether event processing has been paused by an operator
+func (c *Consumer) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
 // handleMessage processes incoming messages
 func (c *Consumer) handleMessage(msg amqp.Delivery) {
 	var event models.UserEvent
 	if err := json.Unmarshal(msg.Body, &event); err != nil {
-		log.Printf("❌ Failed to unmarshal message: %v\n", err)
+		log.Printf("❌ Failed to unmarshal message, dead-lettering it: %v\n", err)
 		msg.Nack(false, false)
 		return
 	}
@@ -195,9 +318,11 @@ func (c *Consumer) handleMessage(msg amqp.Delivery) {
 	case "user.created", "user.updated":
 		if err := c.cacheUser(event); err != nil {
 			log.Printf("❌ Failed to cache user: %v\n", err)
+			c.breaker.RecordFailure()
 			msg.Nack(false, true) // Requeue
 			return
 		}
+		c.breaker.RecordSuccess()
 	}
 
 	msg.Ack(false)
@@ -205,6 +330,28 @@ func (c *Consumer) handleMessage(msg amqp.Delivery) {
 
 // cacheUser inserts or updates user in local cache
 func (c *Consumer) cacheUser(event models.UserEvent) error {
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Detect another cached user already holding this email
+	var existing models.User
+	err = tx.Get(&existing, "SELECT * FROM tasks_users WHERE email = $1 AND user_id <> $2 FOR UPDATE", event.Email, event.UserID)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to check email conflict: %w", err)
+	}
+	if err == nil {
+		apply, err := c.resolveEmailConflict(tx, existing, event)
+		if err != nil {
+			return err
+		}
+		if !apply {
+			return nil
+		}
+	}
+
 	query := `
 		INSERT INTO tasks_users (user_id, username, email, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5)
@@ -215,15 +362,244 @@ func (c *Consumer) cacheUser(event models.UserEvent) error {
 			updated_at = EXCLUDED.updated_at
 	`
 
-	_, err := c.db.Exec(query, event.UserID, event.Username, event.Email, time.Now(), time.Now())
+	_, err = tx.Exec(query, event.UserID, event.Username, event.Email, time.Now(), time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to cache user: %w", err)
 	}
 
+	if event.Timezone != "" {
+		if _, err := time.LoadLocation(event.Timezone); err != nil {
+			log.Printf("⚠️  Ignoring invalid timezone %q for user %s\n", event.Timezone, event.UserID)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO user_settings (user_id, timezone, updated_at)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone, updated_at = EXCLUDED.updated_at
+			`, event.UserID, event.Timezone, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to cache user timezone: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user cache: %w", err)
+	}
+
 	log.Printf("✅ User %s (%s) cached successfully\n", event.Username, event.UserID)
 	return nil
 }
 
+// resolveEmailConflict applies the configured policy when the event's email is
+// already cached under another user ID. It reports whether the event should
+// still be written to the cache.
+func (c *Consumer) resolveEmailConflict(tx *sqlx.Tx, existing models.User, event models.UserEvent) (bool, error) {
+	log.Printf("⚠️  Email %s for user %s is already cached for user %s (policy: %s)\n",
+		event.Email, event.UserID, existing.UserID, c.emailConflictPolicy)
+
+	if c.emailConflictPolicy != EmailConflictKeepNewest {
+		log.Printf("⏭️  Skipping event for user %s due to email conflict\n", event.UserID)
+		return false, nil
+	}
+
+	eventTime := event.Timestamp
+	if eventTime.IsZero() {
+		eventTime = time.Now()
+	}
+	if eventTime.Before(existing.UpdatedAt) {
+		log.Printf("⏭️  Skipping stale event for user %s, cached user %s is newer\n", event.UserID, existing.UserID)
+		return false, nil
+	}
+
+	// Make sure the surviving user exists before moving tasks onto it
+	_, err := tx.Exec(`
+		INSERT INTO tasks_users (user_id, username, email, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id) DO NOTHING
+	`, event.UserID, event.Username, "merging:"+event.UserID.String(), time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare merged user: %w", err)
+	}
+
+	// Each user has one running timer at most, so the duplicate's stops
+	_, err = tx.Exec("UPDATE time_entries SET stopped_at = $1 WHERE user_id = $2 AND stopped_at IS NULL", time.Now(), existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to stop timers of duplicate user: %w", err)
+	}
+
+	// Everything else the duplicate owns would be deleted along with it
+	moved, err := moveUserRows(tx, existing.UserID, event.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	// Saved views move unless the merged user has a view of the same name
+	_, err = tx.Exec(`
+		UPDATE saved_views v SET user_id = $1
+		WHERE v.user_id = $2 AND NOT EXISTS (SELECT 1 FROM saved_views o WHERE o.user_id = $1 AND o.name = v.name)
+	`, event.UserID, existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move saved views to merged user: %w", err)
+	}
+
+	// Mentions move unless the merged user is mentioned in the comment already
+	_, err = tx.Exec(`
+		UPDATE comment_mentions m SET user_id = $1
+		WHERE m.user_id = $2 AND NOT EXISTS (SELECT 1 FROM comment_mentions o WHERE o.comment_id = m.comment_id AND o.user_id = $1)
+	`, event.UserID, existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move mentions to merged user: %w", err)
+	}
+
+	// Watches move unless the merged user watches the task already
+	_, err = tx.Exec(`
+		UPDATE task_watchers w SET user_id = $1
+		WHERE w.user_id = $2 AND NOT EXISTS (SELECT 1 FROM task_watchers o WHERE o.task_id = w.task_id AND o.user_id = $1)
+	`, event.UserID, existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move watches to merged user: %w", err)
+	}
+
+	// Settings and the calendar feed token move unless the merged user has
+	// their own
+	_, err = tx.Exec(`
+		UPDATE user_settings SET user_id = $1
+		WHERE user_id = $2 AND NOT EXISTS (SELECT 1 FROM user_settings WHERE user_id = $1)
+	`, event.UserID, existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move settings to merged user: %w", err)
+	}
+	_, err = tx.Exec(`
+		UPDATE calendar_tokens SET user_id = $1
+		WHERE user_id = $2 AND NOT EXISTS (SELECT 1 FROM calendar_tokens WHERE user_id = $1)
+	`, event.UserID, existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move calendar token to merged user: %w", err)
+	}
+
+	// Idempotency keys move unless the merged user used the same key
+	_, err = tx.Exec(`
+		UPDATE idempotency_keys k SET user_id = $1
+		WHERE k.user_id = $2 AND NOT EXISTS (SELECT 1 FROM idempotency_keys o WHERE o.user_id = $1 AND o.key = k.key)
+	`, event.UserID, existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move idempotency keys to merged user: %w", err)
+	}
+
+	// Shares move too, unless the merged user already has access to the task
+	_, err = tx.Exec(`
+		UPDATE task_shares s SET user_id = $1
+		WHERE s.user_id = $2
+			AND NOT EXISTS (SELECT 1 FROM task_shares o WHERE o.task_id = s.task_id AND o.user_id = $1)
+			AND NOT EXISTS (SELECT 1 FROM tasks t WHERE t.id = s.task_id AND t.user_id = $1)
+	`, event.UserID, existing.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to move shares to merged user: %w", err)
+	}
+	_, err = tx.Exec(`
+		DELETE FROM task_shares s USING tasks t
+		WHERE s.task_id = t.id AND s.user_id = t.user_id AND s.user_id = $1
+	`, event.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to drop shares of merged user's own tasks: %w", err)
+	}
+
+	// Transfers and delegations between the two users now point from the
+	// merged user to itself; pending ones are withdrawn
+	_, err = tx.Exec(`
+		UPDATE task_transfers SET status = 'rejected', responded_at = $2
+		WHERE status = 'pending' AND from_user_id = $1 AND to_user_id = $1
+	`, event.UserID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to withdraw transfers to merged user: %w", err)
+	}
+	_, err = tx.Exec(`
+		UPDATE task_delegations SET status = 'declined', responded_at = $2
+		WHERE status = 'pending' AND from_user_id = $1 AND to_user_id = $1
+	`, event.UserID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to withdraw delegations to merged user: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM tasks_users WHERE user_id = $1", existing.UserID); err != nil {
+		return false, fmt.Errorf("failed to remove duplicate user: %w", err)
+	}
+
+	log.Printf("🔀 Merged cached user %s into %s (%d tasks moved)\n", existing.UserID, event.UserID, moved["tasks.user_id"])
+	return true, nil
+}
+
+// userForeignKeysQuery lists the table and column of each foreign key to
+// tasks_users in the current schema
+const userForeignKeysQuery = `
+	SELECT kcu.table_name, kcu.column_name
+	FROM information_schema.referential_constraints rc
+	JOIN information_schema.key_column_usage kcu
+		ON kcu.constraint_schema = rc.constraint_schema AND kcu.constraint_name = rc.constraint_name
+	JOIN information_schema.key_column_usage ref
+		ON ref.constraint_schema = rc.unique_constraint_schema AND ref.constraint_name = rc.unique_constraint_name
+		AND ref.ordinal_position = kcu.position_in_unique_constraint
+	WHERE ref.table_name = 'tasks_users' AND ref.column_name = 'user_id'
+		AND kcu.table_schema = current_schema()
+	ORDER BY kcu.table_name, kcu.column_name
+`
+
+// userColumn is a column with a foreign key to tasks_users
+type userColumn struct {
+	Table  string `db:"table_name"`
+	Column string `db:"column_name"`
+}
+
+// mergeConflictTables hold at most one row per user, or per user and key,
+// so resolveEmailConflict moves their rows itself; rows the merged user
+// already has an equivalent of are dropped with the duplicate
+var mergeConflictTables = map[string]bool{
+	"user_settings":    true,
+	"saved_views":      true,
+	"comment_mentions": true,
+	"task_watchers":    true,
+	"task_shares":      true,
+	"calendar_tokens":  true,
+	"idempotency_keys": true,
+}
+
+// moveUserRows re-points every column with a foreign key to tasks_users from
+// one user to another, except in mergeConflictTables. The columns are read
+// from the schema so tables added later are not deleted by a merge. It
+// returns how many rows moved per "table.column".
+func moveUserRows(tx *sqlx.Tx, from, to uuid.UUID) (map[string]int64, error) {
+	var columns []userColumn
+	err := tx.Select(&columns, userForeignKeysQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user foreign keys: %w", err)
+	}
+
+	moved := make(map[string]int64, len(columns))
+	for _, col := range columns {
+		if mergeConflictTables[col.Table] {
+			continue
+		}
+		query := fmt.Sprintf("UPDATE %s SET %s = $1 WHERE %s = $2",
+			pq.QuoteIdentifier(col.Table), pq.QuoteIdentifier(col.Column), pq.QuoteIdentifier(col.Column))
+		result, err := tx.Exec(query, to, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s.%s to merged user: %w", col.Table, col.Column, err)
+		}
+		moved[col.Table+"."+col.Column], _ = result.RowsAffected()
+	}
+	return moved, nil
+}
+
+// Connection returns the underlying AMQP connection so publishers can share it
+func (c *Consumer) Connection() *amqp.Connection {
+	return c.conn
+}
+
+// BreakerState reports the database circuit breaker state
+func (c *Consumer) BreakerState() string {
+	return c.breaker.State()
+}
+
 // Close closes the RabbitMQ connection
 func (c *Consumer) Close() error {
 	if c.channel != nil {
@@ -0,0 +1,263 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEmailConflictMergeKeepsUserRows seeds a row in every table that
+// references tasks_users for a duplicate user, merges it into the user an
+// event names, and checks nothing was left behind or deleted. A table added
+// to the schema without a seed row here fails the test.
+func TestEmailConflictMergeKeepsUserRows(t *testing.T) {
+	db := testdb.Open(t)
+
+	dup, other, merged := uuid.New(), uuid.New(), uuid.New()
+	task, otherTask, project, comment := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	seed := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO tasks_users (user_id, username, email, updated_at) VALUES ($1, 'dup', 'shared@example.com', $2)", []interface{}{dup, time.Now().Add(-time.Hour)}},
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'other', 'other@example.com')", []interface{}{other}},
+		{"INSERT INTO user_settings (user_id, timezone) VALUES ($1, 'UTC')", []interface{}{dup}},
+		{"INSERT INTO projects (id, user_id, name) VALUES ($1, $2, 'Project')", []interface{}{project, dup}},
+		{"INSERT INTO tasks (id, user_id, project_id, title) VALUES ($1, $2, $3, 'Task')", []interface{}{task, dup, project}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Other task')", []interface{}{otherTask, other}},
+		{"INSERT INTO task_history (task_id, user_id, action, field) VALUES ($1, $2, 'updated', 'title')", []interface{}{task, dup}},
+		{"INSERT INTO deadline_changes (task_id, user_id, new_due_date) VALUES ($1, $2, NOW())", []interface{}{task, dup}},
+		{"INSERT INTO task_comments (id, task_id, user_id, body) VALUES ($1, $2, $3, 'Hi')", []interface{}{comment, otherTask, dup}},
+		{"INSERT INTO comment_mentions (comment_id, user_id) VALUES ($1, $2)", []interface{}{comment, dup}},
+		{"INSERT INTO task_attachments (task_id, user_id, filename, content_type, size, storage_key) VALUES ($1, $2, 'a.txt', 'text/plain', 1, $3)", []interface{}{task, dup, uuid.NewString()}},
+		{"INSERT INTO task_links (task_id, user_id, url) VALUES ($1, $2, 'https://example.com')", []interface{}{task, dup}},
+		{"INSERT INTO task_templates (user_id, title) VALUES ($1, 'Template')", []interface{}{dup}},
+		{"INSERT INTO saved_views (user_id, name) VALUES ($1, 'View')", []interface{}{dup}},
+		{"INSERT INTO task_shares (task_id, user_id, permission) VALUES ($1, $2, 'read')", []interface{}{otherTask, dup}},
+		{"INSERT INTO task_watchers (task_id, user_id) VALUES ($1, $2)", []interface{}{otherTask, dup}},
+		{"INSERT INTO time_entries (task_id, user_id, started_at) VALUES ($1, $2, NOW())", []interface{}{task, dup}},
+		{"INSERT INTO calendar_tokens (user_id, token_id) VALUES ($1, $2)", []interface{}{dup, uuid.New()}},
+		{"INSERT INTO task_transfers (task_id, from_user_id, to_user_id) VALUES ($1, $2, $3)", []interface{}{task, dup, other}},
+		{"INSERT INTO task_transfers (task_id, from_user_id, to_user_id, status) VALUES ($1, $2, $3, 'accepted')", []interface{}{otherTask, other, dup}},
+		{"INSERT INTO task_delegations (task_id, from_user_id, to_user_id) VALUES ($1, $2, $3)", []interface{}{task, dup, other}},
+		{"INSERT INTO task_delegations (task_id, from_user_id, to_user_id, status) VALUES ($1, $2, $3, 'accepted')", []interface{}{otherTask, other, dup}},
+		{"INSERT INTO idempotency_keys (user_id, key, request_hash) VALUES ($1, 'key', REPEAT('0', 64))", []interface{}{dup}},
+		{"INSERT INTO stream_events (user_id, task_id, event_type, payload) VALUES ($1, $2, 'task.created', '{}')", []interface{}{dup, task}},
+		{"INSERT INTO webhooks (user_id, url, event_types, secret) VALUES ($1, 'https://example.com/hook', $2, 'secret')", []interface{}{dup, pq.StringArray{models.EventTaskCreated}}},
+	}
+	for _, s := range seed {
+		_, err := db.Exec(s.query, s.args...)
+		require.NoError(t, err, s.query)
+	}
+
+	var columns []userColumn
+	require.NoError(t, db.Select(&columns, userForeignKeysQuery))
+	require.NotEmpty(t, columns)
+	for _, col := range columns {
+		var count int
+		require.NoError(t, db.Get(&count, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", col.Table, col.Column), dup))
+		require.NotZero(t, count, "no seed row for %s.%s", col.Table, col.Column)
+	}
+
+	c := &Consumer{db: db, emailConflictPolicy: EmailConflictKeepNewest}
+	err := c.cacheUser(models.UserEvent{
+		UserID:    merged,
+		Username:  "merged",
+		Email:     "shared@example.com",
+		Timestamp: time.Now(),
+	})
+	require.NoError(t, err)
+
+	var email string
+	require.NoError(t, db.Get(&email, "SELECT email FROM tasks_users WHERE user_id = $1", merged))
+	assert.Equal(t, "shared@example.com", email)
+	var dupUsers int
+	require.NoError(t, db.Get(&dupUsers, "SELECT COUNT(*) FROM tasks_users WHERE user_id = $1", dup))
+	assert.Zero(t, dupUsers, "duplicate user should be removed")
+
+	for _, col := range columns {
+		var count int
+		require.NoError(t, db.Get(&count, fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", col.Table, col.Column), merged))
+		assert.NotZero(t, count, "%s.%s lost its row in the merge", col.Table, col.Column)
+	}
+
+	var running int
+	require.NoError(t, db.Get(&running, "SELECT COUNT(*) FROM time_entries WHERE user_id = $1 AND stopped_at IS NULL", merged))
+	assert.Zero(t, running, "the duplicate's timer should be stopped")
+}
+
+// TestEmailConflictLogAndSkip leaves the cache as it was
+func TestEmailConflictLogAndSkip(t *testing.T) {
+	db := testdb.Open(t)
+
+	dup := uuid.New()
+	_, err := db.Exec("INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'dup', 'shared@example.com')", dup)
+	require.NoError(t, err)
+
+	c := &Consumer{db: db, emailConflictPolicy: EmailConflictLogAndSkip}
+	incoming := uuid.New()
+	require.NoError(t, c.cacheUser(models.UserEvent{UserID: incoming, Username: "new", Email: "shared@example.com"}))
+
+	var owner uuid.UUID
+	require.NoError(t, db.Get(&owner, "SELECT user_id FROM tasks_users WHERE email = 'shared@example.com'"))
+	assert.Equal(t, dup, owner)
+	var count int
+	require.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM tasks_users WHERE user_id = $1", incoming))
+	assert.Zero(t, count)
+}
+
+func TestExchangeDeclareError(t *testing.T) {
+	inequivalent := &amqp.Error{Code: amqp.PreconditionFailed, Reason: "PRECONDITION_FAILED - inequivalent arg 'durable' for exchange 'auth_events' in vhost '/': received 'true' but current is 'false'"}
+	missing := &amqp.Error{Code: amqp.NotFound, Reason: "NOT_FOUND - no exchange 'auth_events' in vhost '/'"}
+
+	for name, tc := range map[string]struct {
+		err     error
+		passive bool
+		want    []string
+	}{
+		"different settings": {inequivalent, false, []string{
+			`exchange "auth_events" already exists with different settings`,
+			"RABBITMQ_EXCHANGE_PASSIVE=true",
+		}},
+		"missing in passive mode": {missing, true, []string{
+			`exchange "auth_events" does not exist and RABBITMQ_EXCHANGE_PASSIVE is enabled`,
+		}},
+		"missing":  {missing, false, []string{"failed to declare exchange"}},
+		"not amqp": {errors.New("connection reset"), false, []string{"failed to declare exchange: connection reset"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := exchangeDeclareError("auth_events", tc.passive, tc.err)
+			for _, want := range tc.want {
+				assert.Contains(t, err.Error(), want)
+			}
+			assert.ErrorIs(t, err, tc.err, "the broker's reply stays wrapped")
+		})
+	}
+}
+
+// fakeBroker stands in for a channel with one queue. Messages sent while no
+// consumer is registered wait in the queue, and cancelling the consumer
+// closes its deliveries as the broker does.
+type fakeBroker struct {
+	mu         sync.Mutex
+	deliveries chan amqp.Delivery
+	queued     []amqp.Delivery
+	cancelErr  error
+}
+
+func (b *fakeBroker) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deliveries = make(chan amqp.Delivery, 16)
+	for _, d := range b.queued {
+		b.deliveries <- d
+	}
+	b.queued = nil
+	return b.deliveries, nil
+}
+
+func (b *fakeBroker) Cancel(consumer string, noWait bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancelErr != nil {
+		return b.cancelErr
+	}
+	close(b.deliveries)
+	b.deliveries = nil
+	return nil
+}
+
+func (b *fakeBroker) Close() error { return nil }
+
+// send delivers a message to the consumer, or queues it while there is none
+func (b *fakeBroker) send(d amqp.Delivery) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.deliveries == nil {
+		b.queued = append(b.queued, d)
+		return
+	}
+	b.deliveries <- d
+}
+
+// handledDeliveries is an Acknowledger counting the deliveries the consumer
+// settled
+type handledDeliveries struct {
+	mu   sync.Mutex
+	tags []uint64
+}
+
+func (h *handledDeliveries) settle(tag uint64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tags = append(h.tags, tag)
+	return nil
+}
+
+func (h *handledDeliveries) Ack(tag uint64, multiple bool) error           { return h.settle(tag) }
+func (h *handledDeliveries) Nack(tag uint64, multiple, requeue bool) error { return h.settle(tag) }
+func (h *handledDeliveries) Reject(tag uint64, requeue bool) error         { return h.settle(tag) }
+
+func (h *handledDeliveries) handled() []uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]uint64(nil), h.tags...)
+}
+
+// TestPauseStopsDispatchUntilResume sends messages while the consumer runs,
+// while it is paused and after it resumes. The messages are malformed, so the
+// consumer settles them without touching the database.
+func TestPauseStopsDispatchUntilResume(t *testing.T) {
+	broker := &fakeBroker{}
+	acks := &handledDeliveries{}
+	c := &Consumer{channel: broker, queueName: "tasks-service-queue", breaker: NewBreaker(5, time.Minute)}
+	message := func(tag uint64) amqp.Delivery {
+		return amqp.Delivery{Acknowledger: acks, DeliveryTag: tag, RoutingKey: "user.created", Body: []byte("not json")}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, c.Start(ctx))
+
+	broker.send(message(1))
+	assert.Eventually(t, func() bool { return len(acks.handled()) == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, c.Pause())
+	assert.True(t, c.Paused())
+	assert.ErrorIs(t, c.Pause(), ErrConsumerPaused)
+
+	broker.send(message(2))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, []uint64{1}, acks.handled(), "nothing is dispatched while paused")
+
+	require.NoError(t, c.Resume())
+	assert.False(t, c.Paused())
+	assert.ErrorIs(t, c.Resume(), ErrConsumerRunning)
+	assert.Eventually(t, func() bool { return len(acks.handled()) == 2 }, time.Second, 5*time.Millisecond, "the queued message arrives on resume")
+
+	broker.send(message(3))
+	assert.Eventually(t, func() bool { return len(acks.handled()) == 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []uint64{1, 2, 3}, acks.handled())
+}
+
+func TestPauseFailureKeepsConsuming(t *testing.T) {
+	broker := &fakeBroker{cancelErr: errors.New("channel closed")}
+	c := &Consumer{channel: broker, breaker: NewBreaker(5, time.Minute)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, c.Start(ctx))
+
+	assert.ErrorContains(t, c.Pause(), "failed to cancel consumer")
+	assert.False(t, c.Paused())
+}
@@ -0,0 +1,197 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// maxDeadLetterBody bounds how much of a dead-lettered body is inspected
+	maxDeadLetterBody = 4 << 10
+	// deadLetterConfirmTimeout bounds the wait for a requeued message's confirm
+	deadLetterConfirmTimeout = 5 * time.Second
+)
+
+// DeadLetter describes a message in the dead-letter queue
+type DeadLetter struct {
+	RoutingKey string    `json:"routing_key"`
+	MessageID  string    `json:"message_id,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	// Reason and Deaths come from the broker's x-death header
+	Reason    string `json:"reason,omitempty"`
+	Deaths    int64  `json:"deaths"`
+	Body      string `json:"body"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// deadLetterBroker is the part of an amqp.Channel used to drain the
+// dead-letter queue, so tests can stand in for the broker
+type deadLetterBroker interface {
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// deadLetters drains a dead-letter queue into an exchange
+type deadLetters struct {
+	broker   deadLetterBroker
+	confirms <-chan amqp.Confirmation
+	timeout  time.Duration
+	queue    string
+	exchange string
+}
+
+// inspect fetches up to max messages without removing them. They stay
+// unacknowledged until all are fetched, so each is seen once, and are then
+// returned to the queue.
+func (d *deadLetters) inspect(max int) ([]DeadLetter, error) {
+	letters := []DeadLetter{}
+	var fetched []amqp.Delivery
+	defer func() {
+		for _, msg := range fetched {
+			msg.Nack(false, true)
+		}
+	}()
+
+	for len(letters) < max {
+		msg, ok, err := d.broker.Get(d.queue, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			break
+		}
+		fetched = append(fetched, msg)
+		letters = append(letters, describeDeadLetter(msg))
+	}
+	return letters, nil
+}
+
+// requeue republishes up to max messages to the exchange with their original
+// routing keys, removing each from the queue once the broker has confirmed
+// its copy. It returns how many were requeued and how many are left.
+func (d *deadLetters) requeue(max int) (requeued, remaining int, err error) {
+	for requeued < max {
+		msg, ok, err := d.broker.Get(d.queue, false)
+		if err != nil {
+			return requeued, remaining, fmt.Errorf("failed to get dead letter: %w", err)
+		}
+		if !ok {
+			return requeued, 0, nil
+		}
+		remaining = int(msg.MessageCount)
+
+		if err := d.publish(msg); err != nil {
+			msg.Nack(false, true)
+			return requeued, remaining + 1, err
+		}
+		if err := msg.Ack(false); err != nil {
+			return requeued, remaining, fmt.Errorf("failed to remove requeued dead letter: %w", err)
+		}
+		requeued++
+	}
+	return requeued, remaining, nil
+}
+
+// publish republishes a dead letter and waits for the broker's confirm
+func (d *deadLetters) publish(msg amqp.Delivery) error {
+	err := d.broker.Publish(
+		d.exchange,     // exchange
+		msg.RoutingKey, // routing key
+		false,          // mandatory
+		false,          // immediate
+		amqp.Publishing{
+			Headers:      msg.Headers,
+			ContentType:  msg.ContentType,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    msg.MessageId,
+			Timestamp:    msg.Timestamp,
+			Body:         msg.Body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to republish dead letter: %w", err)
+	}
+
+	timer := time.NewTimer(d.timeout)
+	defer timer.Stop()
+	select {
+	case confirm, ok := <-d.confirms:
+		if !ok {
+			return errors.New("failed to confirm republished dead letter: channel closed")
+		}
+		if !confirm.Ack {
+			return fmt.Errorf("failed to republish dead letter: %w", errNacked)
+		}
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("no confirm for republished dead letter after %s", d.timeout)
+	}
+}
+
+// describeDeadLetter summarises a dead letter, reading why it was
+// dead-lettered from the most recent x-death entry
+func describeDeadLetter(msg amqp.Delivery) DeadLetter {
+	letter := DeadLetter{
+		RoutingKey: msg.RoutingKey,
+		MessageID:  msg.MessageId,
+		Timestamp:  msg.Timestamp,
+		Body:       string(msg.Body),
+	}
+	if len(msg.Body) > maxDeadLetterBody {
+		letter.Body = string(msg.Body[:maxDeadLetterBody])
+		letter.Truncated = true
+	}
+	if deaths, ok := msg.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			letter.Reason, _ = death["reason"].(string)
+			letter.Deaths, _ = death["count"].(int64)
+		}
+	}
+	return letter
+}
+
+// deadLetterChannel opens a channel in confirm mode for draining the
+// dead-letter queue, leaving the consumer's channel alone
+func (c *Consumer) deadLetterChannel() (*amqp.Channel, *deadLetters, error) {
+	channel, err := c.conn.Channel()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open dead-letter channel: %w", err)
+	}
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		return nil, nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	return channel, &deadLetters{
+		broker:   channel,
+		confirms: channel.NotifyPublish(make(chan amqp.Confirmation, 1)),
+		timeout:  deadLetterConfirmTimeout,
+		queue:    c.deadLetterQueue,
+		exchange: c.exchange,
+	}, nil
+}
+
+// InspectDeadLetters returns up to max messages from the dead-letter queue,
+// leaving them in it
+func (c *Consumer) InspectDeadLetters(max int) ([]DeadLetter, error) {
+	channel, dl, err := c.deadLetterChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer channel.Close()
+	return dl.inspect(max)
+}
+
+// RequeueDeadLetters republishes up to max dead-lettered messages to the
+// user events exchange so they are consumed again. A message that fails
+// again is dead-lettered again, behind any that are still queued.
+func (c *Consumer) RequeueDeadLetters(max int) (requeued, remaining int, err error) {
+	channel, dl, err := c.deadLetterChannel()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer channel.Close()
+	return dl.requeue(max)
+}
@@ -0,0 +1,151 @@
+package rabbitmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockBroker is an in-memory queue and exchange. Fetched messages stay
+// unacknowledged until acked or nacked, as on a real channel.
+type mockBroker struct {
+	queue     []amqp.Delivery
+	unacked   map[uint64]amqp.Delivery
+	published []amqp.Publishing
+	keys      []string
+	confirms  chan amqp.Confirmation
+	nextTag   uint64
+	// nack makes the broker refuse publishes
+	nack bool
+}
+
+func newMockBroker(bodies ...string) *mockBroker {
+	b := &mockBroker{unacked: map[uint64]amqp.Delivery{}, confirms: make(chan amqp.Confirmation, 1)}
+	for _, body := range bodies {
+		b.queue = append(b.queue, amqp.Delivery{
+			RoutingKey: "user.created",
+			Body:       []byte(body),
+			Headers: amqp.Table{"x-death": []interface{}{
+				amqp.Table{"reason": "rejected", "count": int64(1), "queue": "tasks-service-queue"},
+			}},
+		})
+	}
+	return b
+}
+
+func (b *mockBroker) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	if len(b.queue) == 0 {
+		return amqp.Delivery{}, false, nil
+	}
+	msg := b.queue[0]
+	b.queue = b.queue[1:]
+	b.nextTag++
+	msg.Acknowledger = b
+	msg.DeliveryTag = b.nextTag
+	msg.MessageCount = uint32(len(b.queue))
+	b.unacked[msg.DeliveryTag] = msg
+	return msg, true, nil
+}
+
+func (b *mockBroker) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if !b.nack {
+		b.published = append(b.published, msg)
+		b.keys = append(b.keys, exchange+"/"+key)
+	}
+	b.confirms <- amqp.Confirmation{DeliveryTag: uint64(len(b.published)), Ack: !b.nack}
+	return nil
+}
+
+func (b *mockBroker) Ack(tag uint64, multiple bool) error {
+	delete(b.unacked, tag)
+	return nil
+}
+
+func (b *mockBroker) Nack(tag uint64, multiple, requeue bool) error {
+	if msg, ok := b.unacked[tag]; ok && requeue {
+		msg.Redelivered = true
+		b.queue = append([]amqp.Delivery{msg}, b.queue...)
+	}
+	delete(b.unacked, tag)
+	return nil
+}
+
+func (b *mockBroker) Reject(tag uint64, requeue bool) error {
+	return b.Nack(tag, false, requeue)
+}
+
+func (b *mockBroker) deadLetters() *deadLetters {
+	return &deadLetters{
+		broker:   b,
+		confirms: b.confirms,
+		timeout:  time.Second,
+		queue:    "tasks-service-queue.dlq",
+		exchange: "auth_events",
+	}
+}
+
+func TestRequeueDeadLettersDrainsQueue(t *testing.T) {
+	broker := newMockBroker(`{"user_id":"1"}`, `{"user_id":"2"}`, `{"user_id":"3"}`)
+
+	requeued, remaining, err := broker.deadLetters().requeue(10)
+	require.NoError(t, err)
+	assert.Equal(t, 3, requeued)
+	assert.Zero(t, remaining)
+	assert.Empty(t, broker.queue, "the dead-letter queue should be drained")
+	assert.Empty(t, broker.unacked)
+
+	require.Len(t, broker.published, 3)
+	assert.Equal(t, []string{"auth_events/user.created", "auth_events/user.created", "auth_events/user.created"}, broker.keys)
+	assert.Equal(t, `{"user_id":"1"}`, string(broker.published[0].Body))
+	assert.Equal(t, amqp.Persistent, broker.published[0].DeliveryMode)
+}
+
+func TestRequeueDeadLettersStopsAtMax(t *testing.T) {
+	broker := newMockBroker("a", "b", "c")
+
+	requeued, remaining, err := broker.deadLetters().requeue(2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requeued)
+	assert.Equal(t, 1, remaining)
+	require.Len(t, broker.queue, 1)
+	assert.Equal(t, "c", string(broker.queue[0].Body))
+}
+
+func TestRequeueDeadLettersKeepsRefusedMessage(t *testing.T) {
+	broker := newMockBroker("a", "b")
+	broker.nack = true
+
+	requeued, remaining, err := broker.deadLetters().requeue(10)
+	assert.True(t, errors.Is(err, errNacked))
+	assert.Zero(t, requeued)
+	assert.Equal(t, 2, remaining)
+	require.Len(t, broker.queue, 2, "a message the broker refused stays dead-lettered")
+	assert.Equal(t, "a", string(broker.queue[0].Body))
+}
+
+func TestInspectDeadLettersLeavesQueue(t *testing.T) {
+	broker := newMockBroker(`{"user_id":"1"}`, "not json")
+
+	letters, err := broker.deadLetters().inspect(10)
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	assert.Equal(t, "user.created", letters[0].RoutingKey)
+	assert.Equal(t, "rejected", letters[0].Reason)
+	assert.Equal(t, int64(1), letters[0].Deaths)
+	assert.Equal(t, "not json", letters[1].Body)
+
+	assert.Empty(t, broker.published)
+	assert.Len(t, broker.queue, 2)
+	assert.Empty(t, broker.unacked)
+}
+
+func TestDescribeDeadLetterTruncatesBody(t *testing.T) {
+	letter := describeDeadLetter(amqp.Delivery{Body: make([]byte, maxDeadLetterBody+1)})
+	assert.True(t, letter.Truncated)
+	assert.Len(t, letter.Body, maxDeadLetterBody)
+	assert.Zero(t, letter.Deaths)
+}
@@ -0,0 +1,244 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/streadway/amqp"
+)
+
+// Publish modes
+const (
+	// PublishSync publishes inline, so the caller waits for the broker
+	PublishSync = "sync"
+	// PublishAsync hands events to a background worker for lower latency
+	PublishAsync = "async"
+)
+
+// errNacked reports a publish the broker refused to take responsibility for
+var errNacked = errors.New("broker nacked publish")
+
+// ErrPublisherClosed is returned for events published after Close
+var ErrPublisherClosed = errors.New("publisher closed")
+
+// publishChannel is the part of an AMQP channel the publisher uses once
+// the exchange is declared and confirms are enabled
+type publishChannel interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// Publisher publishes task events to the task events exchange
+type Publisher struct {
+	channel  publishChannel
+	exchange string
+	mode     string
+
+	// Publisher confirms; confirms is nil when they are disabled
+	confirms       chan amqp.Confirmation
+	confirmTimeout time.Duration
+	retries        int
+	nextTag        uint64
+
+	mu    sync.Mutex // amqp.Channel is not safe for concurrent publishing
+	queue chan models.TaskEvent
+	done  chan struct{}
+
+	// closeMu guards closed: Publish holds it for reading while it sends to
+	// queue, so Close cannot close the queue under a send
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// NewPublisher opens a channel on conn and declares the task events exchange
+func NewPublisher(conn *amqp.Connection) (*Publisher, error) {
+	channel, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open publisher channel: %w", err)
+	}
+
+	exchange := config.String("TASK_EVENTS_EXCHANGE", "task_events")
+	err = channel.ExchangeDeclare(
+		exchange, // name
+		"topic",  // type
+		true,     // durable
+		false,    // auto-deleted
+		false,    // internal
+		false,    // no-wait
+		nil,      // arguments
+	)
+	if err != nil {
+		channel.Close()
+		return nil, exchangeDeclareError(exchange, false, err)
+	}
+
+	mode := config.String("TASK_EVENTS_MODE", PublishAsync)
+	if mode != PublishSync && mode != PublishAsync {
+		log.Printf("⚠️  Unknown TASK_EVENTS_MODE %q, using %s", mode, PublishAsync)
+		mode = PublishAsync
+	}
+
+	p := &Publisher{
+		channel:        channel,
+		exchange:       exchange,
+		mode:           mode,
+		confirmTimeout: config.Duration("TASK_EVENTS_CONFIRM_TIMEOUT", 5*time.Second),
+		retries:        config.Int("TASK_EVENTS_PUBLISH_RETRIES", 2),
+	}
+
+	// A zero timeout disables confirms and publishes fire-and-forget
+	if p.confirmTimeout > 0 {
+		if err := channel.Confirm(false); err != nil {
+			channel.Close()
+			return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+		}
+		p.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, 16))
+	}
+
+	if mode == PublishAsync {
+		p.queue = make(chan models.TaskEvent, config.Int("TASK_EVENTS_QUEUE_SIZE", 1000))
+		p.done = make(chan struct{})
+		go p.run()
+	}
+
+	log.Printf("✅ Publishing task events to exchange: %s (%s)", exchange, mode)
+	return p, nil
+}
+
+// Publish sends a task event using its event type as the routing key.
+// In async mode the event is queued and the call returns immediately; if the
+// queue is full the event is dropped and an error is returned. Events
+// published after Close are dropped with ErrPublisherClosed.
+func (p *Publisher) Publish(ctx context.Context, event models.TaskEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	p.closeMu.RLock()
+	defer p.closeMu.RUnlock()
+	if p.closed {
+		return fmt.Errorf("dropped %s for task %s: %w", event.EventType, event.TaskID, ErrPublisherClosed)
+	}
+
+	if p.mode == PublishSync {
+		return p.publish(event)
+	}
+
+	select {
+	case p.queue <- event:
+		return nil
+	default:
+		return fmt.Errorf("event queue full, dropped %s for task %s", event.EventType, event.TaskID)
+	}
+}
+
+// publish sends the event, republishing up to the configured number of
+// retries when the broker nacks it or doesn't confirm it in time
+func (p *Publisher) publish(event models.TaskEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		err = p.publishOnce(event, body)
+		if err == nil || attempt >= p.retries || !(errors.Is(err, errNacked) || errors.Is(err, context.DeadlineExceeded)) {
+			return err
+		}
+		log.Printf("⚠️  %v, republishing (attempt %d of %d)\n", err, attempt+1, p.retries)
+	}
+}
+
+// publishOnce publishes a single time and waits for its confirm if enabled.
+// Callers must hold p.mu.
+func (p *Publisher) publishOnce(event models.TaskEvent, body []byte) error {
+	err := p.channel.Publish(
+		p.exchange,      // exchange
+		event.EventType, // routing key
+		false,           // mandatory
+		false,           // immediate
+		amqp.Publishing{
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    event.Timestamp,
+			Body:         body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to publish %s: %w", event.EventType, err)
+	}
+	if p.confirms == nil {
+		return nil
+	}
+
+	// Delivery tags count every publish on the channel, including ones whose
+	// confirm timed out earlier, so skip confirms for those
+	p.nextTag++
+	tag := p.nextTag
+
+	timer := time.NewTimer(p.confirmTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case confirm, ok := <-p.confirms:
+			if !ok {
+				return fmt.Errorf("failed to confirm %s: channel closed", event.EventType)
+			}
+			if confirm.DeliveryTag < tag {
+				continue
+			}
+			if !confirm.Ack {
+				return fmt.Errorf("failed to publish %s for task %s: %w", event.EventType, event.TaskID, errNacked)
+			}
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("no confirm for %s for task %s after %s: %w", event.EventType, event.TaskID, p.confirmTimeout, context.DeadlineExceeded)
+		}
+	}
+}
+
+// run publishes queued events until the queue is closed
+func (p *Publisher) run() {
+	defer close(p.done)
+	for event := range p.queue {
+		if err := p.publish(event); err != nil {
+			log.Printf("❌ %v\n", err)
+		}
+	}
+}
+
+// Close flushes queued events and closes the publisher channel
+func (p *Publisher) Close() error {
+	p.stop()
+	return p.channel.Close()
+}
+
+// stop refuses further events and waits for the queued ones to be
+// published. Workers and handlers may still be publishing while the service
+// shuts down; their events are refused rather than sent to a closed queue.
+func (p *Publisher) stop() {
+	p.closeMu.Lock()
+	if p.closed {
+		p.closeMu.Unlock()
+		return
+	}
+	p.closed = true
+	if p.queue != nil {
+		close(p.queue)
+	}
+	p.closeMu.Unlock()
+
+	if p.done != nil {
+		<-p.done
+	}
+}
@@ -0,0 +1,192 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// asyncPublisher returns a publisher in async mode whose queue is drained
+// into received instead of a broker
+func asyncPublisher(size int) (*Publisher, *[]models.TaskEvent) {
+	p := &Publisher{
+		mode:  PublishAsync,
+		queue: make(chan models.TaskEvent, size),
+		done:  make(chan struct{}),
+	}
+	received := &[]models.TaskEvent{}
+	go func() {
+		defer close(p.done)
+		for event := range p.queue {
+			*received = append(*received, event)
+		}
+	}()
+	return p, received
+}
+
+func TestPublishAsyncQueuesEvent(t *testing.T) {
+	p, received := asyncPublisher(10)
+
+	event := models.TaskEvent{EventType: models.EventTaskCreated, TaskID: uuid.New()}
+	require.NoError(t, p.Publish(context.Background(), event))
+	p.stop()
+
+	require.Len(t, *received, 1)
+	assert.Equal(t, event.TaskID, (*received)[0].TaskID)
+	assert.False(t, (*received)[0].Timestamp.IsZero(), "Publish should stamp the event")
+}
+
+func TestPublishAsyncDoesNotBlockWhenFull(t *testing.T) {
+	p := &Publisher{mode: PublishAsync, queue: make(chan models.TaskEvent, 1)}
+
+	require.NoError(t, p.Publish(context.Background(), models.TaskEvent{EventType: models.EventTaskCreated}))
+	err := p.Publish(context.Background(), models.TaskEvent{EventType: models.EventTaskCreated})
+	assert.ErrorContains(t, err, "event queue full")
+}
+
+func TestPublishAfterStopIsRefused(t *testing.T) {
+	p, _ := asyncPublisher(10)
+	p.stop()
+
+	err := p.Publish(context.Background(), models.TaskEvent{EventType: models.EventTaskUpdated})
+	assert.True(t, errors.Is(err, ErrPublisherClosed), "got %v", err)
+
+	// Stopping twice is harmless
+	p.stop()
+}
+
+func TestStopWhilePublishingDoesNotPanic(t *testing.T) {
+	p, _ := asyncPublisher(16)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				err := p.Publish(context.Background(), models.TaskEvent{EventType: models.EventTaskUpdated})
+				if errors.Is(err, ErrPublisherClosed) {
+					return
+				}
+			}
+		}()
+	}
+	p.stop()
+	wg.Wait()
+}
+
+// Broker replies to a publish in confirmingChannel
+const (
+	confirmAck  = "ack"
+	confirmNack = "nack"
+	// confirmLate holds the confirm back until the next publish, as if it
+	// arrived after the publisher stopped waiting
+	confirmLate = "late"
+)
+
+// confirmingChannel stands in for a channel in confirm mode, replying to
+// each publish in turn with the next of replies
+type confirmingChannel struct {
+	replies   []string
+	confirms  chan amqp.Confirmation
+	published []amqp.Publishing
+	late      []amqp.Confirmation
+}
+
+func (c *confirmingChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	c.published = append(c.published, msg)
+	tag := uint64(len(c.published))
+	for _, confirm := range c.late {
+		c.confirms <- confirm
+	}
+	c.late = nil
+
+	reply := confirmAck
+	if len(c.replies) > 0 {
+		reply, c.replies = c.replies[0], c.replies[1:]
+	}
+	switch reply {
+	case confirmAck, confirmNack:
+		c.confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: reply == confirmAck}
+	case confirmLate:
+		c.late = append(c.late, amqp.Confirmation{DeliveryTag: tag, Ack: true})
+	}
+	return nil
+}
+
+func (c *confirmingChannel) Close() error { return nil }
+
+// confirmingPublisher returns a sync publisher with confirms enabled whose
+// broker gives replies
+func confirmingPublisher(retries int, replies ...string) (*Publisher, *confirmingChannel) {
+	channel := &confirmingChannel{replies: replies, confirms: make(chan amqp.Confirmation, 16)}
+	return &Publisher{
+		channel:        channel,
+		exchange:       "task_events",
+		mode:           PublishSync,
+		confirms:       channel.confirms,
+		confirmTimeout: 50 * time.Millisecond,
+		retries:        retries,
+	}, channel
+}
+
+func TestPublishWaitsForConfirm(t *testing.T) {
+	for name, tc := range map[string]struct {
+		retries   int
+		replies   []string
+		published int
+		err       error
+	}{
+		"acked":                   {2, []string{confirmAck}, 1, nil},
+		"nacked then acked":       {2, []string{confirmNack, confirmAck}, 2, nil},
+		"nacked every time":       {2, []string{confirmNack, confirmNack, confirmNack}, 3, errNacked},
+		"nacked without retries":  {0, []string{confirmNack}, 1, errNacked},
+		"timed out then acked":    {2, []string{confirmLate, confirmAck}, 2, nil},
+		"timed out every time":    {1, []string{confirmLate, confirmLate}, 2, context.DeadlineExceeded},
+		"late ack before a nack":  {1, []string{confirmLate, confirmNack}, 2, errNacked},
+		"late ack, nack then ack": {2, []string{confirmLate, confirmNack, confirmAck}, 3, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p, channel := confirmingPublisher(tc.retries, tc.replies...)
+			event := models.TaskEvent{EventType: models.EventTaskCreated, TaskID: uuid.New()}
+
+			err := p.Publish(context.Background(), event)
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Contains(t, err.Error(), event.TaskID.String())
+			}
+			require.Len(t, channel.published, tc.published)
+			for _, msg := range channel.published {
+				assert.Equal(t, channel.published[0].Body, msg.Body, "republishes send the same event")
+				assert.Equal(t, uint8(amqp.Persistent), msg.DeliveryMode)
+			}
+		})
+	}
+}
+
+func TestPublishWithoutConfirms(t *testing.T) {
+	p, channel := confirmingPublisher(2, confirmNack)
+	p.confirms = nil
+
+	require.NoError(t, p.Publish(context.Background(), models.TaskEvent{EventType: models.EventTaskCreated}))
+	assert.Len(t, channel.published, 1, "nothing waits for the broker's reply")
+}
+
+func TestPublishConfirmsClosed(t *testing.T) {
+	p, channel := confirmingPublisher(2, confirmLate)
+	close(channel.confirms)
+
+	err := p.Publish(context.Background(), models.TaskEvent{EventType: models.EventTaskCreated})
+	assert.ErrorContains(t, err, "channel closed")
+	assert.Len(t, channel.published, 1, "a closed channel isn't retried")
+}
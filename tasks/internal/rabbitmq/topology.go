@@ -0,0 +1,80 @@
+package rabbitmq
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/streadway/amqp"
+)
+
+// userEventKeys are the routing keys the consumer's queue is bound to
+var userEventKeys = []string{"user.created", "user.updated"}
+
+// declarer is the part of an AMQP channel that declares the topology
+type declarer interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+}
+
+// declareQueue declares the consumer's durable queue and binds it to the
+// user event keys on exchange. The queue takes no arguments: RabbitMQ refuses
+// to redeclare a queue with different ones, so settings that may change, such
+// as dead-lettering, come from a policy instead.
+func declareQueue(channel declarer, queue, exchange string) error {
+	if _, err := channel.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return queueDeclareError(queue, err)
+	}
+	for _, key := range userEventKeys {
+		if err := channel.QueueBind(queue, key, exchange, false, nil); err != nil {
+			return fmt.Errorf("failed to bind queue to %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// declareDeadLetterQueue declares a fanout dead-letter exchange and a durable
+// queue bound to it. Dead-lettered messages keep their routing keys.
+func declareDeadLetterQueue(channel declarer, exchange, queue string) error {
+	err := channel.ExchangeDeclare(
+		exchange, // name
+		"fanout", // type
+		true,     // durable
+		false,    // auto-deleted
+		false,    // internal
+		false,    // no-wait
+		nil,      // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+	if _, err := channel.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+	if err := channel.QueueBind(queue, "", exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
+	return nil
+}
+
+// deadLetterPolicyCommand returns the rabbitmqctl command that points queue's
+// dead letters at exchange. Policies apply to existing queues and can be
+// changed without redeclaring them.
+func deadLetterPolicyCommand(queue, exchange string) string {
+	definition, _ := json.Marshal(map[string]string{"dead-letter-exchange": exchange})
+	return fmt.Sprintf("rabbitmqctl set_policy --apply-to queues %s-dead-letter '^%s$' '%s'",
+		queue, regexp.QuoteMeta(queue), definition)
+}
+
+// queueDeclareError explains a PRECONDITION_FAILED reply, which means the
+// queue exists with arguments this service doesn't declare
+func queueDeclareError(queue string, err error) error {
+	if amqpErr, ok := err.(*amqp.Error); ok && amqpErr.Code == amqp.PreconditionFailed {
+		return fmt.Errorf(
+			"queue %q already exists with arguments this service doesn't declare. "+
+				"Dead-lettering is configured by policy rather than queue arguments; "+
+				"delete the queue so it can be recreated without them: %w", queue, err)
+	}
+	return fmt.Errorf("failed to declare queue: %w", err)
+}
@@ -0,0 +1,114 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTopology is a declarer that, like RabbitMQ, refuses to redeclare an
+// exchange or queue with different settings than it already has
+type fakeTopology struct {
+	exchanges map[string]string
+	queues    map[string]amqp.Table
+	bindings  map[string][]string
+}
+
+func newFakeTopology() *fakeTopology {
+	return &fakeTopology{
+		exchanges: map[string]string{},
+		queues:    map[string]amqp.Table{},
+		bindings:  map[string][]string{},
+	}
+}
+
+func (f *fakeTopology) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	if existing, ok := f.exchanges[name]; ok && existing != kind {
+		return &amqp.Error{Code: amqp.PreconditionFailed, Reason: fmt.Sprintf("PRECONDITION_FAILED - inequivalent arg 'type' for exchange '%s'", name)}
+	}
+	f.exchanges[name] = kind
+	return nil
+}
+
+func (f *fakeTopology) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if existing, ok := f.queues[name]; ok && !sameArgs(existing, args) {
+		return amqp.Queue{}, &amqp.Error{Code: amqp.PreconditionFailed, Reason: fmt.Sprintf("PRECONDITION_FAILED - inequivalent arg for queue '%s'", name)}
+	}
+	if _, ok := f.queues[name]; !ok {
+		f.queues[name] = args
+	}
+	return amqp.Queue{Name: name}, nil
+}
+
+func (f *fakeTopology) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	binding := exchange + " -> " + key
+	for _, existing := range f.bindings[name] {
+		if existing == binding {
+			return nil
+		}
+	}
+	f.bindings[name] = append(f.bindings[name], binding)
+	return nil
+}
+
+func sameArgs(a, b amqp.Table) bool {
+	return len(a) == 0 && len(b) == 0 || reflect.DeepEqual(a, b)
+}
+
+// declareTopology declares what NewConsumer does
+func declareTopology(t *testing.T, channel declarer) error {
+	t.Helper()
+	if err := declareDeadLetterQueue(channel, "tasks-service-queue.dlx", "tasks-service-queue.dlq"); err != nil {
+		return err
+	}
+	return declareQueue(channel, "tasks-service-queue", "auth_events")
+}
+
+// TestDeclareOverExistingQueue starts against a broker where an earlier
+// release already declared the queue, without any arguments
+func TestDeclareOverExistingQueue(t *testing.T) {
+	broker := newFakeTopology()
+	broker.queues["tasks-service-queue"] = nil
+	broker.bindings["tasks-service-queue"] = []string{"auth_events -> user.created", "auth_events -> user.updated"}
+
+	require.NoError(t, declareTopology(t, broker))
+
+	assert.Nil(t, broker.queues["tasks-service-queue"], "the queue keeps its arguments")
+	assert.Equal(t, []string{"auth_events -> user.created", "auth_events -> user.updated"}, broker.bindings["tasks-service-queue"])
+	assert.Equal(t, "fanout", broker.exchanges["tasks-service-queue.dlx"])
+	assert.Equal(t, []string{"tasks-service-queue.dlx -> "}, broker.bindings["tasks-service-queue.dlq"])
+
+	// Restarts declare the same topology again
+	require.NoError(t, declareTopology(t, broker))
+}
+
+func TestDeclareOnEmptyBroker(t *testing.T) {
+	broker := newFakeTopology()
+	require.NoError(t, declareTopology(t, broker))
+
+	assert.Contains(t, broker.queues, "tasks-service-queue")
+	assert.Empty(t, broker.queues["tasks-service-queue"])
+	assert.Contains(t, broker.queues, "tasks-service-queue.dlq")
+}
+
+func TestDeclareOverQueueWithArguments(t *testing.T) {
+	broker := newFakeTopology()
+	broker.queues["tasks-service-queue"] = amqp.Table{"x-dead-letter-exchange": "tasks-service-queue.dlx"}
+
+	err := declareTopology(t, broker)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Dead-lettering is configured by policy")
+	var amqpErr *amqp.Error
+	assert.ErrorAs(t, err, &amqpErr, "the broker's reply stays wrapped")
+}
+
+func TestDeadLetterPolicyCommand(t *testing.T) {
+	assert.Equal(t,
+		`rabbitmqctl set_policy --apply-to queues tasks-service-queue-dead-letter '^tasks-service-queue$' '{"dead-letter-exchange":"tasks-service-queue.dlx"}'`,
+		deadLetterPolicyCommand("tasks-service-queue", "tasks-service-queue.dlx"))
+	assert.Contains(t, deadLetterPolicyCommand("tasks.v2", "tasks.v2.dlx"), `'^tasks\.v2$'`)
+}
@@ -0,0 +1,101 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Interval rules repeat relative to the previous occurrence's due date
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// maxRuleLength matches the tasks.recurrence column
+const maxRuleLength = 100
+
+// ErrInvalidRule is returned for recurrence rules that cannot be parsed
+var ErrInvalidRule = errors.New("invalid recurrence: must be daily, weekly, monthly or a cron expression such as \"0 9 * * 1\"")
+
+// Rule computes when the next occurrence of a recurring task is due
+type Rule interface {
+	// Next returns the first due date after both the previous due date and now
+	Next(previous, now time.Time) time.Time
+}
+
+// Parse parses and normalizes a recurrence rule. Rules are daily, weekly,
+// monthly, or a standard five-field cron expression (descriptors like
+// @weekly and a CRON_TZ= prefix are accepted; the default zone is UTC).
+func Parse(rule string) (Rule, string, error) {
+	rule = strings.Join(strings.Fields(rule), " ")
+	if rule == "" || len(rule) > maxRuleLength {
+		return nil, "", ErrInvalidRule
+	}
+
+	switch lower := strings.ToLower(rule); lower {
+	case Daily:
+		return interval{days: 1}, lower, nil
+	case Weekly:
+		return interval{days: 7}, lower, nil
+	case Monthly:
+		return interval{months: 1}, lower, nil
+	}
+
+	spec := rule
+	if !strings.HasPrefix(spec, "CRON_TZ=") && !strings.HasPrefix(spec, "TZ=") {
+		spec = "CRON_TZ=UTC " + spec
+	}
+	schedule, err := cronParser.Parse(spec)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+	return cronRule{schedule: schedule}, rule, nil
+}
+
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// interval repeats every few days or months, keeping the time of day
+type interval struct {
+	days   int
+	months int
+}
+
+// Next steps forward from the previous due date, skipping occurrences that
+// were missed while the task was open
+func (r interval) Next(previous, now time.Time) time.Time {
+	next := r.step(previous)
+	for !next.After(now) {
+		next = r.step(next)
+	}
+	return next
+}
+
+func (r interval) step(t time.Time) time.Time {
+	if r.months == 0 {
+		return t.AddDate(0, 0, r.days)
+	}
+	// Clamp to the end of shorter months instead of overflowing (Jan 31 -> Feb 28)
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(r.months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// cronRule fires on a cron schedule
+type cronRule struct {
+	schedule cron.Schedule
+}
+
+func (r cronRule) Next(previous, now time.Time) time.Time {
+	if previous.After(now) {
+		return r.schedule.Next(previous)
+	}
+	return r.schedule.Next(now)
+}
@@ -0,0 +1,156 @@
+package recurrence
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+)
+
+// batchSize bounds how many completed occurrences are handled per query
+const batchSize = 100
+
+// Publisher publishes task change events
+type Publisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// Scheduler periodically creates the next occurrence of completed recurring
+// tasks. Running it on several instances is safe: each occurrence is created
+// by whichever instance claims the completed task first.
+type Scheduler struct {
+	tasks    *repository.TaskRepository
+	indexer  search.Indexer
+	events   Publisher
+	interval time.Duration
+}
+
+// NewScheduler creates a scheduler that runs every RECURRENCE_SCHEDULER_INTERVAL
+func NewScheduler(db *database.DB, indexer search.Indexer, events Publisher) *Scheduler {
+	return &Scheduler{
+		tasks:    repository.NewTaskRepository(db),
+		indexer:  indexer,
+		events:   events,
+		interval: config.Duration("RECURRENCE_SCHEDULER_INTERVAL", time.Minute),
+	}
+}
+
+// Start runs the scheduler in the background until ctx is done. A zero
+// interval disables it.
+func (s *Scheduler) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		log.Println("⚠️  Recurrence scheduler disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.run(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping recurrence scheduler...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Recurrence scheduler running every %s", s.interval)
+}
+
+// run creates occurrences until no completed recurring tasks are left
+func (s *Scheduler) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		completed, err := s.tasks.CompletedRecurring(ctx, batchSize)
+		if err != nil {
+			log.Printf("❌ Failed to fetch completed recurring tasks: %v\n", err)
+			return
+		}
+
+		created := 0
+		for _, task := range completed {
+			if s.createNext(ctx, task) {
+				created++
+			}
+		}
+		// Stop when the batch wasn't full, or every row failed and would be
+		// fetched again
+		if len(completed) < batchSize || created == 0 {
+			return
+		}
+	}
+}
+
+// createNext creates the occurrence following a completed task and reports
+// whether it did
+func (s *Scheduler) createNext(ctx context.Context, completed models.Task) bool {
+	rule, normalized, err := Parse(*completed.Recurrence)
+	if err != nil {
+		// Rules are validated on write, so this only happens if they're edited by hand
+		log.Printf("❌ Task %s has an invalid recurrence %q: %v\n", completed.ID, *completed.Recurrence, err)
+		return false
+	}
+
+	now := time.Now()
+	previous := completed.UpdatedAt
+	if completed.CompletedAt != nil {
+		previous = *completed.CompletedAt
+	}
+	if completed.DueDate != nil {
+		previous = *completed.DueDate
+	}
+	due := rule.Next(previous, now)
+
+	next := models.Task{
+		ID:          uuid.New(),
+		UserID:      completed.UserID,
+		ProjectID:   completed.ProjectID,
+		Title:       completed.Title,
+		Description: completed.Description,
+		Status:      "pending",
+		Priority:    completed.Priority,
+		DueDate:     &due,
+		Origin:      models.OriginRecurring,
+		Color:       completed.Color,
+		Tags:        completed.Tags,
+		Metadata:    completed.Metadata,
+		Latitude:    completed.Latitude,
+		Longitude:   completed.Longitude,
+		PlaceName:   completed.PlaceName,
+		// Each occurrence is estimated alike but tracks its own actual effort
+		EstimateMinutes: completed.EstimateMinutes,
+		Recurrence:      &normalized,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	err = s.tasks.CreateOccurrence(ctx, completed.ID, &next)
+	if errors.Is(err, repository.ErrConflict) {
+		return false
+	}
+	if err != nil {
+		log.Printf("❌ Failed to create next occurrence of task %s: %v\n", completed.ID, err)
+		return false
+	}
+
+	s.indexer.Index(ctx, next)
+	event := models.TaskEvent{
+		EventType: models.EventTaskCreated,
+		TaskID:    next.ID,
+		UserID:    next.UserID,
+		Task:      &next,
+	}
+	if err := s.events.Publish(ctx, event); err != nil {
+		log.Printf("❌ Failed to publish %s for task %s: %v\n", event.EventType, next.ID, err)
+	}
+	log.Printf("🔁 Created occurrence %s of recurring task %s due %s\n", next.ID, completed.ID, due.Format(time.RFC3339))
+	return true
+}
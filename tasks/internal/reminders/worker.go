@@ -0,0 +1,134 @@
+package reminders
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// batchSize bounds how many reminders are claimed per query
+const batchSize = 100
+
+// Publisher publishes task events
+type Publisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// Worker publishes a task.reminder.due event for each reminder once its time
+// comes. Running it on several instances is safe: each reminder is claimed
+// by exactly one of them.
+type Worker struct {
+	reminders *repository.ReminderRepository
+	tasks     *repository.TaskRepository
+	events    Publisher
+	interval  time.Duration
+}
+
+// NewWorker creates a worker that polls every REMINDER_POLL_INTERVAL
+func NewWorker(db *database.DB, events Publisher) *Worker {
+	return &Worker{
+		reminders: repository.NewReminderRepository(db),
+		tasks:     repository.NewTaskRepository(db),
+		events:    events,
+		interval:  config.Duration("REMINDER_POLL_INTERVAL", 30*time.Second),
+	}
+}
+
+// Start runs the worker in the background until ctx is done. A zero interval
+// disables it.
+func (w *Worker) Start(ctx context.Context) {
+	if w.interval <= 0 {
+		log.Println("⚠️  Reminder worker disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			w.run(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping reminder worker...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Reminder worker polling every %s", w.interval)
+}
+
+// run fires due reminders until none are left
+func (w *Worker) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		due, err := w.reminders.ClaimDue(ctx, time.Now(), batchSize)
+		if err != nil {
+			log.Printf("❌ Failed to claim due reminders: %v\n", err)
+			return
+		}
+		if len(due) == 0 {
+			return
+		}
+
+		if !w.fire(ctx, due) || len(due) < batchSize {
+			return
+		}
+	}
+}
+
+// fire publishes events for claimed reminders, releasing any that fail so the
+// next run retries them. It reports whether every reminder was handled.
+func (w *Worker) fire(ctx context.Context, due []models.TaskReminder) bool {
+	taskIDs := make([]uuid.UUID, len(due))
+	for i, reminder := range due {
+		taskIDs[i] = reminder.TaskID
+	}
+	tasks, err := w.tasks.ListByIDs(ctx, taskIDs)
+	if err != nil {
+		log.Printf("❌ Failed to load tasks for due reminders: %v\n", err)
+		w.release(ctx, due)
+		return false
+	}
+	byID := make(map[uuid.UUID]*models.Task, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	ok := true
+	for i := range due {
+		reminder := &due[i]
+		task := byID[reminder.TaskID]
+		// The task was deleted after the claim, or no longer needs a nudge
+		if task == nil || task.Status == "completed" || task.Status == "cancelled" {
+			continue
+		}
+
+		event := models.TaskEvent{
+			EventType: models.EventTaskReminderDue,
+			TaskID:    task.ID,
+			UserID:    task.UserID,
+			Task:      task,
+			Reminder:  reminder,
+		}
+		if err := w.events.Publish(ctx, event); err != nil {
+			log.Printf("❌ Failed to publish reminder %s for task %s: %v\n", reminder.ID, task.ID, err)
+			w.release(ctx, []models.TaskReminder{*reminder})
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (w *Worker) release(ctx context.Context, reminders []models.TaskReminder) {
+	for _, reminder := range reminders {
+		if err := w.reminders.Release(ctx, reminder.ID); err != nil {
+			log.Printf("❌ Failed to release reminder %s: %v\n", reminder.ID, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPublisher keeps the events it is given, or fails with err
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []models.TaskEvent
+	err    error
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event models.TaskEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// seedReminder adds a task with a reminder that is already due
+func seedReminder(t *testing.T, db *database.DB, status string, channels string) (taskID, reminderID uuid.UUID) {
+	t.Helper()
+	userID := uuid.New()
+	taskID, reminderID = uuid.New(), uuid.New()
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, $2, $3)", []interface{}{userID, userID.String(), userID.String() + "@example.com"}},
+		{"INSERT INTO tasks (id, user_id, title, status) VALUES ($1, $2, 'Call back', $3)", []interface{}{taskID, userID, status}},
+		{"INSERT INTO reminders (id, task_id, remind_at, channels) VALUES ($1, $2, NOW() - INTERVAL '1 minute', $3)", []interface{}{reminderID, taskID, channels}},
+	} {
+		_, err := db.Exec(q.query, q.args...)
+		require.NoError(t, err, q.query)
+	}
+	return taskID, reminderID
+}
+
+func TestWorkerEventCarriesReminderChannels(t *testing.T) {
+	db := testdb.Open(t)
+	taskID, reminderID := seedReminder(t, db, "pending", "{push,webhook}")
+	seedReminder(t, db, "completed", "{email}")
+	events := &recordingPublisher{}
+
+	NewWorker(db, events).run(context.Background())
+
+	require.Len(t, events.events, 1, "completed tasks get no reminder")
+	event := events.events[0]
+	assert.Equal(t, models.EventTaskReminderDue, event.EventType)
+	assert.Equal(t, taskID, event.TaskID)
+	require.NotNil(t, event.Reminder)
+	assert.Equal(t, reminderID, event.Reminder.ID)
+	assert.Equal(t, []string{"push", "webhook"}, []string(event.Reminder.Channels))
+
+	// The notification service routes on the channels in the message body
+	var payload struct {
+		Reminder struct {
+			Channels []string `json:"channels"`
+		} `json:"reminder"`
+	}
+	body, err := json.Marshal(event)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, []string{"push", "webhook"}, payload.Reminder.Channels)
+}
+
+func TestWorkerReleasesReminderWhenPublishFails(t *testing.T) {
+	db := testdb.Open(t)
+	_, reminderID := seedReminder(t, db, "pending", "{email}")
+
+	NewWorker(db, &recordingPublisher{err: errors.New("broker down")}).run(context.Background())
+
+	var fired bool
+	require.NoError(t, db.Get(&fired, "SELECT fired_at IS NOT NULL FROM reminders WHERE id = $1", reminderID))
+	assert.False(t, fired, "the reminder is retried on the next run")
+}
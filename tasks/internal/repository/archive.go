@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// SetArchived archives or unarchives a task together with its subtasks and
+// returns every task that changed. Tasks already in the requested state are
+// left untouched.
+func (r *TaskRepository) SetArchived(ctx context.Context, taskID, userID uuid.UUID, archived bool) ([]models.Task, error) {
+	defer observe("tasks.set_archived", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	var archivedAt *time.Time
+	if archived {
+		archivedAt = &now
+	}
+
+	changed := []models.Task{}
+	err := r.db.SelectContext(ctx, &changed, `
+		UPDATE tasks SET archived_at = $1, updated_at = $2
+		WHERE user_id = $3 AND (id = $4 OR parent_task_id = $4) AND (archived_at IS NULL) = $5 AND deleted_at IS NULL
+		RETURNING *
+	`, archivedAt, now, userID, taskID, archived)
+	return changed, Translate(err)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// AttachmentRepository provides persistence for task attachment metadata.
+// The files themselves live in object storage under StorageKey.
+type AttachmentRepository struct {
+	db *database.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *database.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// Create records an uploaded attachment
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *models.TaskAttachment) error {
+	defer observe("attachments.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO task_attachments (id, task_id, user_id, filename, content_type, size, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, attachment.ID, attachment.TaskID, attachment.UserID, attachment.Filename, attachment.ContentType,
+		attachment.Size, attachment.StorageKey, attachment.CreatedAt)
+	return Translate(err)
+}
+
+// List returns a task's attachments, newest first
+func (r *AttachmentRepository) List(ctx context.Context, taskID uuid.UUID) ([]models.TaskAttachment, error) {
+	defer observe("attachments.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	attachments := []models.TaskAttachment{}
+	err := r.db.SelectContext(ctx, &attachments,
+		"SELECT * FROM task_attachments WHERE task_id = $1 ORDER BY created_at DESC", taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return attachments, nil
+}
+
+// Get returns one of a task's attachments
+func (r *AttachmentRepository) Get(ctx context.Context, attachmentID, taskID uuid.UUID) (*models.TaskAttachment, error) {
+	defer observe("attachments.get", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var attachment models.TaskAttachment
+	err := r.db.GetContext(ctx, &attachment,
+		"SELECT * FROM task_attachments WHERE id = $1 AND task_id = $2", attachmentID, taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &attachment, nil
+}
+
+// Delete removes an attachment record and returns it so the caller can
+// remove the stored file
+func (r *AttachmentRepository) Delete(ctx context.Context, attachmentID, taskID uuid.UUID) (*models.TaskAttachment, error) {
+	defer observe("attachments.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var attachment models.TaskAttachment
+	err := r.db.GetContext(ctx, &attachment,
+		"DELETE FROM task_attachments WHERE id = $1 AND task_id = $2 RETURNING *", attachmentID, taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &attachment, nil
+}
+
+// StorageKeys returns the storage keys of every attachment on the given tasks
+func (r *AttachmentRepository) StorageKeys(ctx context.Context, taskIDs []uuid.UUID) ([]string, error) {
+	defer observe("attachments.storage_keys", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var keys []string
+	err := r.db.SelectContext(ctx, &keys,
+		"SELECT storage_key FROM task_attachments WHERE task_id = ANY($1::uuid[])", pq.Array(taskIDs))
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return keys, nil
+}
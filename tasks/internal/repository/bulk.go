@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// BulkUpdateOptions decides what happens to tasks completed with open
+// subtasks or blockers
+type BulkUpdateOptions struct {
+	// CascadeSubtasks completes the open subtasks along with the parent
+	CascadeSubtasks bool
+	// BlockOpenSubtasks skips parents that still have open subtasks
+	BlockOpenSubtasks bool
+	// BlockOpenBlockers skips tasks blocked by open tasks
+	BlockOpenBlockers bool
+}
+
+// BulkUpdateOutcome is the result of UpdateMany
+type BulkUpdateOutcome struct {
+	// Results has one entry per requested ID, in request order
+	Results []models.BulkUpdateResult
+	// Updated holds every task changed, including cascaded subtasks and
+	// parents whose progress was rolled up
+	Updated []models.Task
+	// Completed holds the tasks in Updated that moved to completed
+	Completed []models.Task
+}
+
+// bulkTarget is the locked state of a task targeted by a bulk update
+type bulkTarget struct {
+	ID           uuid.UUID  `db:"id"`
+	Status       string     `db:"status"`
+	ParentTaskID *uuid.UUID `db:"parent_task_id"`
+}
+
+// UpdateMany applies the same column updates to each of the user's tasks in
+// taskIDs within one transaction, reporting the rows affected per ID. IDs that
+// don't exist are reported with zero rows rather than failing the batch.
+func (r *TaskRepository) UpdateMany(ctx context.Context, userID uuid.UUID, taskIDs []uuid.UUID, updates map[string]interface{}, opts BulkUpdateOptions) (*BulkUpdateOutcome, error) {
+	defer observe("tasks.update_many", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return nil, Translate(err)
+	}
+
+	var locked []bulkTarget
+	err = tx.SelectContext(ctx, &locked,
+		"SELECT id, status, parent_task_id FROM tasks WHERE id = ANY($1::uuid[]) AND user_id = $2 AND deleted_at IS NULL ORDER BY id FOR UPDATE",
+		pq.Array(taskIDs), userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	targets := make(map[uuid.UUID]bulkTarget, len(locked))
+	for _, target := range locked {
+		targets[target.ID] = target
+	}
+
+	outcome := &BulkUpdateOutcome{Results: make([]models.BulkUpdateResult, 0, len(taskIDs))}
+	updated := make(map[uuid.UUID]int) // task ID -> index in outcome.Updated
+	record := func(task models.Task, completed bool) {
+		if i, ok := updated[task.ID]; ok {
+			outcome.Updated[i] = task
+		} else {
+			updated[task.ID] = len(outcome.Updated)
+			outcome.Updated = append(outcome.Updated, task)
+		}
+		if completed {
+			outcome.Completed = append(outcome.Completed, task)
+		}
+	}
+
+	completing := updates["status"] == "completed"
+	parents := make(map[uuid.UUID]bool)
+	for _, taskID := range taskIDs {
+		target, ok := targets[taskID]
+		if !ok {
+			outcome.Results = append(outcome.Results, models.BulkUpdateResult{ID: taskID, Error: "task not found"})
+			continue
+		}
+		transition := completing && target.Status != "completed"
+
+		if transition && opts.BlockOpenBlockers {
+			open, err := openBlockers(ctx, tx, taskID)
+			if err != nil {
+				return nil, err
+			}
+			if open > 0 {
+				outcome.Results = append(outcome.Results, models.BulkUpdateResult{ID: taskID, Error: "task is blocked by open tasks"})
+				continue
+			}
+		}
+
+		cascaded := false
+		if transition && (opts.CascadeSubtasks || opts.BlockOpenSubtasks) {
+			var open int
+			err := tx.GetContext(ctx, &open,
+				"SELECT COUNT(*) FROM tasks WHERE parent_task_id = $1 AND status NOT IN ('completed', 'cancelled') AND deleted_at IS NULL", taskID)
+			if err != nil {
+				return nil, Translate(err)
+			}
+			if open > 0 && opts.BlockOpenSubtasks {
+				outcome.Results = append(outcome.Results, models.BulkUpdateResult{ID: taskID, Error: "task has open subtasks"})
+				continue
+			}
+			if open > 0 {
+				subtasks, err := completeSubtasks(ctx, tx, taskID, userID)
+				if err != nil {
+					return nil, err
+				}
+				for _, subtask := range subtasks {
+					record(subtask, true)
+				}
+				cascaded = true
+			}
+		}
+
+		query, args := buildTaskUpdate(taskID, userID, updates, false)
+		var task models.Task
+		if err := tx.GetContext(ctx, &task, query, args...); err != nil {
+			return nil, Translate(err)
+		}
+		if cascaded {
+			if err := tx.GetContext(ctx, &task, rollupProgressQuery, taskID, userID); err != nil {
+				return nil, Translate(err)
+			}
+		}
+		record(task, transition)
+		outcome.Results = append(outcome.Results, models.BulkUpdateResult{ID: taskID, RowsAffected: 1})
+
+		if target.ParentTaskID != nil {
+			parents[*target.ParentTaskID] = true
+		}
+	}
+
+	// Parents of updated subtasks derive their progress from them
+	for parentID := range parents {
+		var parent models.Task
+		if err := tx.GetContext(ctx, &parent, rollupProgressQuery, parentID, userID); err != nil {
+			return nil, Translate(err)
+		}
+		record(parent, false)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, Translate(err)
+	}
+	return outcome, nil
+}
+
+// BulkDeleteOutcome is the result of DeleteMany
+type BulkDeleteOutcome struct {
+	// Deleted holds the requested tasks that were removed
+	Deleted []models.Task
+	// Subtasks holds subtasks removed along with their deleted parents
+	Subtasks []models.Task
+	// Parents holds surviving parents whose progress was rolled up
+	Parents []models.Task
+}
+
+// DeleteMany moves the user's tasks in taskIDs, and their subtasks, to the
+// trash in one transaction
+func (r *TaskRepository) DeleteMany(ctx context.Context, userID uuid.UUID, taskIDs []uuid.UUID) (*BulkDeleteOutcome, error) {
+	defer observe("tasks.delete_many", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return nil, Translate(err)
+	}
+
+	var removed []models.Task
+	err = tx.SelectContext(ctx, &removed, `
+		SELECT * FROM tasks
+		WHERE user_id = $1 AND (id = ANY($2::uuid[]) OR parent_task_id = ANY($2::uuid[])) AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE
+	`, userID, pq.Array(taskIDs))
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	requested := make(map[uuid.UUID]bool, len(taskIDs))
+	for _, taskID := range taskIDs {
+		requested[taskID] = true
+	}
+	outcome := &BulkDeleteOutcome{}
+	removedIDs := make([]uuid.UUID, len(removed))
+	for i, task := range removed {
+		removedIDs[i] = task.ID
+		if requested[task.ID] {
+			outcome.Deleted = append(outcome.Deleted, task)
+		} else {
+			outcome.Subtasks = append(outcome.Subtasks, task)
+		}
+	}
+
+	// Subtasks share their parent's deleted_at so they are restored with it
+	_, err = tx.ExecContext(ctx, "UPDATE tasks SET deleted_at = $1 WHERE id = ANY($2::uuid[])", time.Now(), pq.Array(removedIDs))
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	// Parents that survive derive their progress from the remaining subtasks
+	parents := make(map[uuid.UUID]bool)
+	for _, task := range outcome.Deleted {
+		if task.ParentTaskID != nil && !requested[*task.ParentTaskID] {
+			parents[*task.ParentTaskID] = true
+		}
+	}
+	for parentID := range parents {
+		var parent models.Task
+		if err := tx.GetContext(ctx, &parent, rollupProgressQuery, parentID, userID); err != nil {
+			return nil, Translate(err)
+		}
+		outcome.Parents = append(outcome.Parents, parent)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, Translate(err)
+	}
+	return outcome, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+)
+
+// CalendarTokenRepository tracks each user's current calendar feed token so
+// issuing a new one revokes the old
+type CalendarTokenRepository struct {
+	db *database.DB
+}
+
+// NewCalendarTokenRepository creates a new calendar token repository
+func NewCalendarTokenRepository(db *database.DB) *CalendarTokenRepository {
+	return &CalendarTokenRepository{db: db}
+}
+
+// Rotate records a new token ID for the user, replacing any previous one
+func (r *CalendarTokenRepository) Rotate(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	defer observe("calendar_tokens.rotate", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tokenID := uuid.New()
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO calendar_tokens (user_id, token_id, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE SET token_id = EXCLUDED.token_id, created_at = EXCLUDED.created_at
+	`, userID, tokenID, time.Now())
+	if err != nil {
+		return uuid.Nil, Translate(err)
+	}
+	return tokenID, nil
+}
+
+// Current returns the user's current token ID. It returns ErrNotFound if the
+// user has none.
+func (r *CalendarTokenRepository) Current(ctx context.Context, userID uuid.UUID) (uuid.UUID, error) {
+	defer observe("calendar_tokens.current", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var tokenID uuid.UUID
+	if err := r.db.GetContext(ctx, &tokenID, "SELECT token_id FROM calendar_tokens WHERE user_id = $1", userID); err != nil {
+		return uuid.Nil, Translate(err)
+	}
+	return tokenID, nil
+}
+
+// Revoke deletes the user's token. It returns ErrNotFound if the user had none.
+func (r *CalendarTokenRepository) Revoke(ctx context.Context, userID uuid.UUID) error {
+	defer observe("calendar_tokens.revoke", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM calendar_tokens WHERE user_id = $1", userID)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
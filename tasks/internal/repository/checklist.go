@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// checklistProgressQuery sets a task's checklist_progress to the share of its
+// checklist items that are done, or NULL when it has none
+const checklistProgressQuery = `
+	UPDATE tasks SET checklist_progress = (
+		SELECT ROUND(100.0 * COUNT(*) FILTER (WHERE done) / NULLIF(COUNT(*), 0))
+		FROM checklist_items WHERE task_id = $1
+	), updated_at = $2
+	WHERE id = $1
+	RETURNING *
+`
+
+// ChecklistRepository provides persistence for task checklist items. Callers
+// must check that the user may access the task.
+type ChecklistRepository struct {
+	db *database.DB
+}
+
+// NewChecklistRepository creates a new checklist repository
+func NewChecklistRepository(db *database.DB) *ChecklistRepository {
+	return &ChecklistRepository{db: db}
+}
+
+// Add appends an item to a task's checklist and returns the task with its
+// recomputed checklist progress
+func (r *ChecklistRepository) Add(ctx context.Context, item *models.ChecklistItem) (*models.Task, error) {
+	defer observe("checklist.add", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &item.Position, `
+		INSERT INTO checklist_items (id, task_id, text, done, position, created_at)
+		VALUES ($1, $2, $3, FALSE, (SELECT COALESCE(MAX(position) + 1, 0) FROM checklist_items WHERE task_id = $2), $4)
+		RETURNING position
+	`, item.ID, item.TaskID, item.Text, item.CreatedAt)
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	var task models.Task
+	if err := tx.GetContext(ctx, &task, checklistProgressQuery, item.TaskID, time.Now()); err != nil {
+		return nil, Translate(err)
+	}
+	return &task, Translate(tx.Commit())
+}
+
+// List returns a task's checklist in position order
+func (r *ChecklistRepository) List(ctx context.Context, taskID uuid.UUID) ([]models.ChecklistItem, error) {
+	defer observe("checklist.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	items := []models.ChecklistItem{}
+	err := r.db.SelectContext(ctx, &items,
+		"SELECT * FROM checklist_items WHERE task_id = $1 ORDER BY position, created_at, id", taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return items, nil
+}
+
+// Toggle flips an item between done and not done and returns it with the
+// task's recomputed checklist progress. It returns ErrNotFound if the item is
+// not on the task.
+func (r *ChecklistRepository) Toggle(ctx context.Context, taskID, itemID uuid.UUID) (*models.ChecklistItem, *models.Task, error) {
+	defer observe("checklist.toggle", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	var item models.ChecklistItem
+	err = tx.GetContext(ctx, &item,
+		"UPDATE checklist_items SET done = NOT done WHERE id = $1 AND task_id = $2 RETURNING *", itemID, taskID)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	var task models.Task
+	if err := tx.GetContext(ctx, &task, checklistProgressQuery, taskID, time.Now()); err != nil {
+		return nil, nil, Translate(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, Translate(err)
+	}
+	return &item, &task, nil
+}
+
+// Move places an item at the zero-based index of its checklist and renumbers
+// the checklist so positions stay dense. An index past the end appends. It
+// returns the reordered checklist, or ErrNotFound if the item is not on the
+// task.
+func (r *ChecklistRepository) Move(ctx context.Context, taskID, itemID uuid.UUID, index int) ([]models.ChecklistItem, error) {
+	defer observe("checklist.move", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	// Locking the checklist serializes concurrent moves within it
+	var ids []uuid.UUID
+	err = tx.SelectContext(ctx, &ids, `
+		SELECT id FROM checklist_items WHERE task_id = $1
+		ORDER BY position, created_at, id
+		FOR UPDATE
+	`, taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	others := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if id != itemID {
+			others = append(others, id)
+		}
+	}
+	if len(others) == len(ids) {
+		return nil, ErrNotFound
+	}
+	if index > len(others) {
+		index = len(others)
+	}
+	ordered := make([]uuid.UUID, 0, len(ids))
+	ordered = append(ordered, others[:index]...)
+	ordered = append(ordered, itemID)
+	ordered = append(ordered, others[index:]...)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE checklist_items i SET position = c.ord - 1
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS c(id, ord)
+		WHERE i.id = c.id AND i.position <> c.ord - 1
+	`, pq.Array(ordered))
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	items := []models.ChecklistItem{}
+	err = tx.SelectContext(ctx, &items,
+		"SELECT * FROM checklist_items WHERE task_id = $1 ORDER BY position", taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return items, Translate(tx.Commit())
+}
+
+// Delete removes an item from a task's checklist and returns the task with
+// its recomputed checklist progress. It returns ErrNotFound if the item is
+// not on the task.
+func (r *ChecklistRepository) Delete(ctx context.Context, taskID, itemID uuid.UUID) (*models.Task, error) {
+	defer observe("checklist.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM checklist_items WHERE id = $1 AND task_id = $2", itemID, taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrNotFound
+	}
+
+	var task models.Task
+	if err := tx.GetContext(ctx, &task, checklistProgressQuery, taskID, time.Now()); err != nil {
+		return nil, Translate(err)
+	}
+	return &task, Translate(tx.Commit())
+}
+
+// copyChecklist copies a task's checklist onto another task within tx, with
+// every item not done, and stores the copy's checklist progress in task
+func copyChecklist(ctx context.Context, tx *sqlx.Tx, fromID uuid.UUID, task *models.Task) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO checklist_items (task_id, text, done, position, created_at)
+		SELECT $2, text, FALSE, position, $3 FROM checklist_items WHERE task_id = $1
+	`, fromID, task.ID, task.CreatedAt)
+	if err != nil {
+		return Translate(err)
+	}
+	return Translate(tx.GetContext(ctx, task, checklistProgressQuery, task.ID, task.UpdatedAt))
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// CommentRepository provides persistence for task comments
+type CommentRepository struct {
+	db *database.DB
+}
+
+// NewCommentRepository creates a new comment repository
+func NewCommentRepository(db *database.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// Create adds a comment to a task and records its mentions of usernames,
+// matched case-insensitively. Only the task's owner and the users it is shared
+// with can be mentioned, and never the comment's author; their IDs are stored
+// in comment.Mentions.
+func (r *CommentRepository) Create(ctx context.Context, comment *models.TaskComment, usernames []string) error {
+	defer observe("comments.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Translate(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_comments (id, task_id, user_id, body, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, comment.ID, comment.TaskID, comment.UserID, comment.Body, comment.CreatedAt)
+	if err != nil {
+		return Translate(err)
+	}
+
+	comment.Mentions = nil
+	if len(usernames) > 0 {
+		err = tx.SelectContext(ctx, &comment.Mentions, `
+			INSERT INTO comment_mentions (comment_id, user_id)
+			SELECT DISTINCT $1::uuid, u.user_id FROM tasks_users u, tasks t
+			WHERE t.id = $2 AND LOWER(u.username) = ANY($3) AND u.user_id <> $4
+				AND (u.user_id = t.user_id
+					OR EXISTS (SELECT 1 FROM task_shares s WHERE s.task_id = t.id AND s.user_id = u.user_id))
+			ON CONFLICT DO NOTHING
+			RETURNING user_id
+		`, comment.ID, comment.TaskID, pq.Array(usernames), comment.UserID)
+		if err != nil {
+			return Translate(err)
+		}
+	}
+	return Translate(tx.Commit())
+}
+
+// List returns a page of a task's comments, oldest first, and the total count
+func (r *CommentRepository) List(ctx context.Context, taskID uuid.UUID, limit, offset int) ([]models.TaskComment, int, error) {
+	defer observe("comments.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	comments := []models.TaskComment{}
+	err := r.db.SelectContext(ctx, &comments, `
+		SELECT * FROM task_comments
+		WHERE task_id = $1
+		ORDER BY created_at ASC, id ASC
+		LIMIT $2 OFFSET $3
+	`, taskID, limit, offset)
+	if err != nil {
+		return nil, 0, Translate(err)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM task_comments WHERE task_id = $1", taskID); err != nil {
+		return nil, 0, Translate(err)
+	}
+	return comments, total, nil
+}
+
+// Delete removes a comment from a task. Callers must check that the user may
+// moderate the task's comments.
+func (r *CommentRepository) Delete(ctx context.Context, commentID, taskID uuid.UUID) error {
+	defer observe("comments.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM task_comments WHERE id = $1 AND task_id = $2", commentID, taskID)
+	if err != nil {
+		return Translate(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return Translate(err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
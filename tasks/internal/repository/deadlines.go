@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// DeadlineRepository reads the recorded due date changes of tasks. Changes are
+// recorded by a trigger on tasks, with the actor and reason the context of
+// the change was tagged with by WithDeadlineChange.
+type DeadlineRepository struct {
+	db *database.DB
+}
+
+// NewDeadlineRepository creates a new deadline repository
+func NewDeadlineRepository(db *database.DB) *DeadlineRepository {
+	return &DeadlineRepository{db: db}
+}
+
+// List returns a page of a task's due date changes, newest first, and the
+// total count
+func (r *DeadlineRepository) List(ctx context.Context, taskID uuid.UUID, limit, offset int) ([]models.DeadlineChange, int, error) {
+	defer observe("deadlines.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	changes := []models.DeadlineChange{}
+	err := r.db.SelectContext(ctx, &changes, `
+		SELECT * FROM deadline_changes
+		WHERE task_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, taskID, limit, offset)
+	if err != nil {
+		return nil, 0, Translate(err)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM deadline_changes WHERE task_id = $1", taskID); err != nil {
+		return nil, 0, Translate(err)
+	}
+	return changes, total, nil
+}
+
+type deadlineChangeKey struct{}
+
+// deadlineChange is who changes a due date and why
+type deadlineChange struct {
+	actorID uuid.UUID
+	reason  string
+}
+
+// WithDeadlineChange returns a context that records due dates changed with it
+// as changed by actorID, for the reason given if any
+func WithDeadlineChange(ctx context.Context, actorID uuid.UUID, reason *string) context.Context {
+	change := deadlineChange{actorID: actorID}
+	if reason != nil {
+		change.reason = *reason
+	}
+	return context.WithValue(ctx, deadlineChangeKey{}, change)
+}
+
+// WithActor returns a context that records changes made with it, to due
+// dates and in task history, as made by actorID. A context already tagged
+// with WithDeadlineChange is returned as it is.
+func WithActor(ctx context.Context, actorID uuid.UUID) context.Context {
+	if _, ok := ctx.Value(deadlineChangeKey{}).(deadlineChange); ok {
+		return ctx
+	}
+	return WithDeadlineChange(ctx, actorID, nil)
+}
+
+// actorFrom returns the actor ctx is tagged with, or nil for an untagged
+// context
+func actorFrom(ctx context.Context) *uuid.UUID {
+	change, ok := ctx.Value(deadlineChangeKey{}).(deadlineChange)
+	if !ok {
+		return nil
+	}
+	return &change.actorID
+}
+
+// tagDeadlineChange sets the actor and reason ctx carries on tx, where the
+// deadline and task history triggers read them. It does nothing for
+// untagged contexts.
+func tagDeadlineChange(ctx context.Context, tx *sqlx.Tx) error {
+	change, ok := ctx.Value(deadlineChangeKey{}).(deadlineChange)
+	if !ok {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx,
+		"SELECT set_config('tasks.actor_id', $1, true), set_config('tasks.deadline_reason', $2, true)",
+		change.actorID.String(), change.reason)
+	return err
+}
+
+// getTagged runs a single-row query like GetContext. When ctx is tagged with
+// a deadline change it runs in a transaction carrying the tag.
+func (r *TaskRepository) getTagged(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if _, ok := ctx.Value(deadlineChangeKey{}).(deadlineChange); !ok {
+		return r.db.GetContext(ctx, dest, query, args...)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.GetContext(ctx, dest, query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// selectTagged runs a query like SelectContext, in a transaction carrying
+// the tag when ctx is tagged
+func (r *TaskRepository) selectTagged(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if _, ok := ctx.Value(deadlineChangeKey{}).(deadlineChange); !ok {
+		return r.db.SelectContext(ctx, dest, query, args...)
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.SelectContext(ctx, dest, query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// DelegationRepository provides persistence for task delegations
+type DelegationRepository struct {
+	db *database.DB
+}
+
+// NewDelegationRepository creates a new delegation repository
+func NewDelegationRepository(db *database.DB) *DelegationRepository {
+	return &DelegationRepository{db: db}
+}
+
+// Create records a pending delegation of a task owned by fromUserID.
+// It returns ErrNotFound if the task is not owned by fromUserID and
+// ErrConflict if the task already has a pending delegation.
+func (r *DelegationRepository) Create(ctx context.Context, taskID, fromUserID, toUserID uuid.UUID, note *string) (*models.TaskDelegation, error) {
+	defer observe("delegations.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var delegation models.TaskDelegation
+	err := r.db.GetContext(ctx, &delegation, `
+		INSERT INTO task_delegations (id, task_id, from_user_id, to_user_id, status, note, created_at)
+		SELECT $1, id, user_id, $4, 'pending', $5, $6
+		FROM tasks
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+		RETURNING *
+	`, uuid.New(), taskID, fromUserID, toUserID, note, time.Now())
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &delegation, nil
+}
+
+// ListPending returns delegations awaiting a response from the given user,
+// newest first
+func (r *DelegationRepository) ListPending(ctx context.Context, toUserID uuid.UUID) ([]models.TaskDelegation, error) {
+	defer observe("delegations.list_pending", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	delegations := []models.TaskDelegation{}
+	err := r.db.SelectContext(ctx, &delegations, `
+		SELECT d.* FROM task_delegations d
+		JOIN tasks t ON t.id = d.task_id AND t.deleted_at IS NULL
+		WHERE d.to_user_id = $1 AND d.status = 'pending'
+		ORDER BY d.created_at DESC
+	`, toUserID)
+	return delegations, Translate(err)
+}
+
+// Respond accepts or declines a pending delegation addressed to toUserID.
+// Accepting shares the task with them with write access in the same
+// transaction. It returns ErrConflict if the task changed owner or was
+// deleted since the delegation was created.
+func (r *DelegationRepository) Respond(ctx context.Context, delegationID, toUserID uuid.UUID, accept bool) (*models.TaskDelegation, error) {
+	defer observe("delegations.respond", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	var delegation models.TaskDelegation
+	err = tx.GetContext(ctx, &delegation,
+		"SELECT * FROM task_delegations WHERE id = $1 AND to_user_id = $2 AND status = 'pending' FOR UPDATE",
+		delegationID, toUserID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	status := models.DelegationDeclined
+	if accept {
+		status = models.DelegationAccepted
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO task_shares (task_id, user_id, permission, created_at)
+			SELECT id, $3, $4, $5 FROM tasks
+			WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+			ON CONFLICT (task_id, user_id) DO UPDATE SET permission = EXCLUDED.permission
+		`, delegation.TaskID, delegation.FromUserID, delegation.ToUserID, models.SharePermissionWrite, time.Now())
+		if err != nil {
+			return nil, Translate(err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return nil, ErrConflict
+		}
+	}
+
+	err = tx.GetContext(ctx, &delegation,
+		"UPDATE task_delegations SET status = $1, responded_at = $2 WHERE id = $3 RETURNING *",
+		status, time.Now(), delegation.ID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, Translate(err)
+	}
+	return &delegation, nil
+}
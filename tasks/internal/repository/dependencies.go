@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// ErrDependencyCycle means adding the dependency would make a task block
+// itself, directly or through other tasks
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// openBlockersQuery counts a task's blockers that are still open
+const openBlockersQuery = `
+	SELECT COUNT(*) FROM task_dependencies d
+	JOIN tasks b ON b.id = d.blocker_id
+	WHERE d.blocked_id = $1 AND b.status NOT IN ('completed', 'cancelled') AND b.deleted_at IS NULL
+`
+
+// AddDependency records that blockerID blocks blockedID, both owned by the
+// user. It returns ErrNotFound if either task is missing, ErrConflict if the
+// dependency already exists and ErrDependencyCycle if it would close a loop.
+func (r *TaskRepository) AddDependency(ctx context.Context, blockerID, blockedID, userID uuid.UUID) (*models.TaskDependency, error) {
+	defer observe("tasks.add_dependency", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	// Serialize the user's dependency changes so two concurrent inserts
+	// cannot each pass the cycle check and close a loop together
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", "task_dependencies:"+userID.String()); err != nil {
+		return nil, Translate(err)
+	}
+
+	var owned int
+	err = tx.GetContext(ctx, &owned,
+		"SELECT COUNT(*) FROM tasks WHERE id IN ($1, $2) AND user_id = $3 AND deleted_at IS NULL",
+		blockerID, blockedID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	if owned != 2 {
+		return nil, ErrNotFound
+	}
+
+	// A cycle forms if the blocked task already blocks the blocker, directly
+	// or transitively
+	var cycle bool
+	err = tx.GetContext(ctx, &cycle, `
+		WITH RECURSIVE downstream(id) AS (
+			SELECT blocked_id FROM task_dependencies WHERE blocker_id = $1
+			UNION
+			SELECT d.blocked_id FROM task_dependencies d JOIN downstream ds ON d.blocker_id = ds.id
+		)
+		SELECT EXISTS(SELECT 1 FROM downstream WHERE id = $2)
+	`, blockedID, blockerID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	if cycle {
+		return nil, ErrDependencyCycle
+	}
+
+	var dependency models.TaskDependency
+	err = tx.GetContext(ctx, &dependency, `
+		INSERT INTO task_dependencies (blocker_id, blocked_id, created_at)
+		VALUES ($1, $2, $3)
+		RETURNING *
+	`, blockerID, blockedID, time.Now())
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, Translate(err)
+	}
+	return &dependency, nil
+}
+
+// RemoveDependency deletes a dependency between two of the user's tasks. It
+// returns ErrNotFound if there is no such dependency.
+func (r *TaskRepository) RemoveDependency(ctx context.Context, blockerID, blockedID, userID uuid.UUID) error {
+	defer observe("tasks.remove_dependency", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM task_dependencies d USING tasks t
+		WHERE d.blocked_id = t.id AND d.blocker_id = $1 AND d.blocked_id = $2 AND t.user_id = $3
+	`, blockerID, blockedID, userID)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListDependencies returns the live tasks blocking a task and the live tasks
+// it blocks
+func (r *TaskRepository) ListDependencies(ctx context.Context, taskID uuid.UUID) (blockedBy, blocking []models.Task, err error) {
+	defer observe("tasks.list_dependencies", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	blockedBy = []models.Task{}
+	err = r.db.SelectContext(ctx, &blockedBy, `
+		SELECT t.* FROM tasks t JOIN task_dependencies d ON d.blocker_id = t.id
+		WHERE d.blocked_id = $1 AND t.deleted_at IS NULL
+		ORDER BY d.created_at, t.id
+	`, taskID)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	blocking = []models.Task{}
+	err = r.db.SelectContext(ctx, &blocking, `
+		SELECT t.* FROM tasks t JOIN task_dependencies d ON d.blocked_id = t.id
+		WHERE d.blocker_id = $1 AND t.deleted_at IS NULL
+		ORDER BY d.created_at, t.id
+	`, taskID)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+	return blockedBy, blocking, nil
+}
+
+// OpenBlockers returns how many of a task's blockers are still open
+func (r *TaskRepository) OpenBlockers(ctx context.Context, taskID uuid.UUID) (int, error) {
+	defer observe("tasks.open_blockers", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	return openBlockers(ctx, r.db, taskID)
+}
+
+// openBlockers counts a task's open blockers using q, which may be a
+// transaction
+func openBlockers(ctx context.Context, q sqlx.QueryerContext, taskID uuid.UUID) (int, error) {
+	var open int
+	if err := sqlx.GetContext(ctx, q, &open, openBlockersQuery, taskID); err != nil {
+		return 0, Translate(err)
+	}
+	return open, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// Duplicate inserts a copied task and its copied subtasks in one transaction.
+// checklistFrom maps the ID of each copy to the task whose checklist it
+// takes. When the copy is a subtask its parent's progress is recomputed and
+// the parent returned.
+func (r *TaskRepository) Duplicate(ctx context.Context, task *models.Task, subtasks []models.Task, checklistFrom map[uuid.UUID]uuid.UUID) (*models.Task, error) {
+	defer observe("tasks.duplicate", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	copies := append([]*models.Task{task}, make([]*models.Task, len(subtasks))...)
+	for i := range subtasks {
+		copies[i+1] = &subtasks[i]
+	}
+	for _, copied := range copies {
+		if err := insertTask(ctx, tx, copied); err != nil {
+			return nil, err
+		}
+		if len(copied.Tags) > 0 {
+			if _, _, err := replaceTaskTags(ctx, tx, copied.ID, copied.Tags); err != nil {
+				return nil, err
+			}
+		}
+		if fromID, ok := checklistFrom[copied.ID]; ok {
+			if err := copyChecklist(ctx, tx, fromID, copied); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var parent *models.Task
+	if task.ParentTaskID != nil {
+		parent = &models.Task{}
+		if err := tx.GetContext(ctx, parent, rollupProgressQuery, *task.ParentTaskID, task.UserID); err != nil {
+			return nil, Translate(err)
+		}
+	}
+
+	return parent, Translate(tx.Commit())
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// Errors returned by the repository layer. Callers should match them with
+// errors.Is rather than inspecting driver-specific errors.
+var (
+	// ErrNotFound means the requested row does not exist or is not visible to the caller
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the write violates a uniqueness or reference constraint
+	ErrConflict = errors.New("conflict")
+	// ErrTransient means the operation failed for a temporary reason and may be retried
+	ErrTransient = errors.New("transient database error")
+	// ErrTimeout means the query did not finish within its deadline
+	ErrTimeout = errors.New("query timed out")
+)
+
+// Translate maps database/sql and PostgreSQL driver errors onto the repository
+// error set, keeping the original error wrapped for logging
+func Translate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "57014": // query_canceled (statement timeout or context deadline)
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		case pqErr.Code == "23505", pqErr.Code == "23503":
+			// unique_violation, foreign_key_violation
+			return fmt.Errorf("%w: %w", ErrConflict, err)
+		case pqErr.Code.Class() == "08", // connection exception
+			pqErr.Code == "40001", // serialization_failure
+			pqErr.Code == "40P01", // deadlock_detected
+			pqErr.Code == "53300", // too_many_connections
+			pqErr.Code == "57P01": // admin_shutdown
+			return fmt.Errorf("%w: %w", ErrTransient, err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) {
+		return fmt.Errorf("%w: %w", ErrTransient, err)
+	}
+
+	return err
+}
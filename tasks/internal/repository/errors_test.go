@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err  error
+		want error
+	}{
+		"no rows":          {sql.ErrNoRows, ErrNotFound},
+		"wrapped no rows":  {fmt.Errorf("get task: %w", sql.ErrNoRows), ErrNotFound},
+		"unique violation": {&pq.Error{Code: "23505"}, ErrConflict},
+		"foreign key":      {&pq.Error{Code: "23503"}, ErrConflict},
+		"connection lost":  {&pq.Error{Code: "08006"}, ErrTransient},
+		"serialization":    {&pq.Error{Code: "40001"}, ErrTransient},
+		"deadlock":         {&pq.Error{Code: "40P01"}, ErrTransient},
+		"too many conns":   {&pq.Error{Code: "53300"}, ErrTransient},
+		"admin shutdown":   {&pq.Error{Code: "57P01"}, ErrTransient},
+		"bad conn":         {driver.ErrBadConn, ErrTransient},
+		"conn done":        {sql.ErrConnDone, ErrTransient},
+		"deadline":         {context.DeadlineExceeded, ErrTimeout},
+		"wrapped deadline": {fmt.Errorf("select: %w", context.DeadlineExceeded), ErrTimeout},
+		"query canceled":   {&pq.Error{Code: "57014"}, ErrTimeout},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := Translate(tc.err)
+			assert.True(t, errors.Is(got, tc.want), "got %v", got)
+			assert.True(t, errors.Is(got, tc.err), "the original error stays wrapped")
+		})
+	}
+}
+
+func TestTranslateLeavesOtherErrors(t *testing.T) {
+	assert.Nil(t, Translate(nil))
+
+	check := &pq.Error{Code: "23514"} // check_violation
+	assert.Equal(t, error(check), Translate(check))
+
+	other := errors.New("boom")
+	got := Translate(other)
+	assert.Equal(t, other, got)
+	for _, typed := range []error{ErrNotFound, ErrConflict, ErrTransient, ErrTimeout} {
+		assert.False(t, errors.Is(got, typed))
+	}
+}
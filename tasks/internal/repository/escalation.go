@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// EscalateOverdue raises the priority of up to limit open tasks one step for
+// each period they stay overdue: tasks due before cutoff that were not
+// escalated since cutoff move from a priority in from to the next one. Each
+// escalation is recorded in the task history. Rows locked by another
+// instance are skipped, so concurrent workers never escalate a task twice.
+func (r *TaskRepository) EscalateOverdue(ctx context.Context, cutoff time.Time, from []string, limit int) ([]models.Task, []models.TaskHistoryEntry, error) {
+	defer observe("tasks.escalate_overdue", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var rows []struct {
+		models.Task
+		PreviousPriority string `db:"previous_priority"`
+	}
+	err = tx.SelectContext(ctx, &rows, `
+		WITH due AS (
+			SELECT id, priority FROM tasks
+			WHERE status IN ('pending', 'in_progress') AND deleted_at IS NULL AND archived_at IS NULL
+				AND priority = ANY($1) AND due_date < $2 AND (escalated_at IS NULL OR escalated_at < $2)
+			ORDER BY due_date
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE tasks t SET
+			priority = CASE due.priority WHEN 'low' THEN 'medium' WHEN 'medium' THEN 'high' ELSE 'urgent' END,
+			escalated_at = $4, updated_at = $4
+		FROM due WHERE t.id = due.id
+		RETURNING t.*, due.priority AS previous_priority
+	`, pq.Array(from), cutoff, limit, now)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	tasks := make([]models.Task, len(rows))
+	entries := make([]models.TaskHistoryEntry, len(rows))
+	for i, row := range rows {
+		previous := row.PreviousPriority
+		priority := row.Priority
+		tasks[i] = row.Task
+		entries[i] = models.TaskHistoryEntry{
+			ID:        uuid.New(),
+			TaskID:    row.ID,
+			Action:    models.HistoryActionEscalated,
+			Field:     "priority",
+			OldValue:  &previous,
+			NewValue:  &priority,
+			CreatedAt: now,
+		}
+	}
+	if err := insertHistory(ctx, tx, entries); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, Translate(err)
+	}
+	return tasks, entries, nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// HistoryRepository reads the recorded changes of tasks. Changes to a task's
+// status, title, owner and trash state are recorded by a trigger on tasks,
+// with the actor the context was tagged with by WithActor; tag changes and
+// escalations are written by the repository method that makes them.
+type HistoryRepository struct {
+	db *database.DB
+}
+
+// NewHistoryRepository creates a new history repository
+func NewHistoryRepository(db *database.DB) *HistoryRepository {
+	return &HistoryRepository{db: db}
+}
+
+// historyCondition matches the history of task $1 and of its subtasks,
+// leaving out subtasks in the trash unless $2 is true
+const historyCondition = `
+	h.task_id = $1 OR (t.parent_task_id = $1 AND ($2 OR t.deleted_at IS NULL))
+`
+
+// List returns a page of the history of a task and its subtasks, newest
+// first, and the total count. The history of trashed subtasks is only
+// included with includeDeleted.
+func (r *HistoryRepository) List(ctx context.Context, taskID uuid.UUID, includeDeleted bool, limit, offset int) ([]models.TaskHistoryEntry, int, error) {
+	defer observe("history.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	entries := []models.TaskHistoryEntry{}
+	err := r.db.SelectContext(ctx, &entries, `
+		SELECT h.* FROM task_history h JOIN tasks t ON t.id = h.task_id
+		WHERE`+historyCondition+`
+		ORDER BY h.created_at DESC, h.id DESC
+		LIMIT $3 OFFSET $4
+	`, taskID, includeDeleted, limit, offset)
+	if err != nil {
+		return nil, 0, Translate(err)
+	}
+
+	var total int
+	err = r.db.GetContext(ctx, &total,
+		"SELECT COUNT(*) FROM task_history h JOIN tasks t ON t.id = h.task_id WHERE"+historyCondition,
+		taskID, includeDeleted)
+	if err != nil {
+		return nil, 0, Translate(err)
+	}
+	return entries, total, nil
+}
+
+// insertHistory records history entries within tx
+func insertHistory(ctx context.Context, tx *sqlx.Tx, entries []models.TaskHistoryEntry) error {
+	for _, entry := range entries {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO task_history (id, task_id, user_id, action, field, old_value, new_value, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, entry.ID, entry.TaskID, entry.UserID, entry.Action, entry.Field, entry.OldValue, entry.NewValue, entry.CreatedAt)
+		if err != nil {
+			return Translate(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskChangesAreRecorded(t *testing.T) {
+	db := testdb.Open(t)
+	owner, other := uuid.New(), uuid.New()
+	task := uuid.New()
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'owner', 'owner@example.com')", []interface{}{owner}},
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'other', 'other@example.com')", []interface{}{other}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Draft')", []interface{}{task, owner}},
+	} {
+		_, err := db.Exec(q.query, q.args...)
+		require.NoError(t, err)
+	}
+
+	tasks := NewTaskRepository(db)
+	ctx := WithActor(context.Background(), owner)
+	_, err := tasks.Update(ctx, task, owner, map[string]interface{}{"title": "Final", "status": "completed", "priority": "high"})
+	require.NoError(t, err)
+	// Untagged changes are the service's own
+	_, err = db.Exec("UPDATE tasks SET user_id = $1 WHERE id = $2", other, task)
+	require.NoError(t, err)
+
+	entries, total, err := NewHistoryRepository(db).List(context.Background(), task, false, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total, "priority is not recorded")
+
+	byField := map[string]models.TaskHistoryEntry{}
+	for _, e := range entries {
+		byField[e.Field] = e
+	}
+	assert.Equal(t, models.HistoryActionUpdated, byField["status"].Action)
+	assert.Equal(t, "pending", *byField["status"].OldValue)
+	assert.Equal(t, "completed", *byField["status"].NewValue)
+	assert.Equal(t, &owner, byField["status"].UserID)
+	assert.Equal(t, "Draft", *byField["title"].OldValue)
+	assert.Equal(t, "Final", *byField["title"].NewValue)
+	assert.Equal(t, models.HistoryActionTransferred, byField["user_id"].Action)
+	assert.Equal(t, other.String(), *byField["user_id"].NewValue)
+	assert.Nil(t, byField["user_id"].UserID)
+}
+
+func TestHistoryIncludesDeletedRelationsOnlyWhenAsked(t *testing.T) {
+	db := testdb.Open(t)
+	owner := uuid.New()
+	parent, kept, trashed := uuid.New(), uuid.New(), uuid.New()
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'owner', 'owner@example.com')", []interface{}{owner}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Parent')", []interface{}{parent, owner}},
+		{"INSERT INTO tasks (id, user_id, parent_task_id, title) VALUES ($1, $2, $3, 'Kept')", []interface{}{kept, owner, parent}},
+		{"INSERT INTO tasks (id, user_id, parent_task_id, title) VALUES ($1, $2, $3, 'Trashed')", []interface{}{trashed, owner, parent}},
+	} {
+		_, err := db.Exec(q.query, q.args...)
+		require.NoError(t, err)
+	}
+
+	tasks := NewTaskRepository(db)
+	ctx := WithActor(context.Background(), owner)
+	for _, id := range []uuid.UUID{parent, kept, trashed} {
+		_, err := tasks.Update(ctx, id, owner, map[string]interface{}{"status": "in_progress"})
+		require.NoError(t, err)
+	}
+	_, err := tasks.Trash(ctx, trashed, owner)
+	require.NoError(t, err)
+
+	history := NewHistoryRepository(db)
+	entries, total, err := history.List(context.Background(), parent, false, 50, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	for _, e := range entries {
+		assert.NotEqual(t, trashed, e.TaskID, "trashed subtask's history was included")
+	}
+
+	entries, total, err = history.List(context.Background(), parent, true, 50, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, total)
+	var deleted *models.TaskHistoryEntry
+	for i := range entries {
+		if entries[i].TaskID == trashed && entries[i].Action == models.HistoryActionDeleted {
+			deleted = &entries[i]
+		}
+	}
+	require.NotNil(t, deleted, "the deletion is recorded")
+	assert.Equal(t, &owner, deleted.UserID)
+
+	// Once restored, the subtask is a live relation again
+	_, err = tasks.Restore(ctx, trashed, owner)
+	require.NoError(t, err)
+	_, total, err = history.List(context.Background(), parent, false, 50, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total, "status, deleted and restored entries of the restored subtask")
+}
+
+// TestUpdateWithTagsIsOneTransaction checks tags change together with the
+// other fields, are recorded with the actor, and are left alone when the
+// field update fails
+func TestUpdateWithTagsIsOneTransaction(t *testing.T) {
+	db := testdb.Open(t)
+	owner, editor := uuid.New(), uuid.New()
+	task := uuid.New()
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'owner', 'owner@example.com')", []interface{}{owner}},
+		{"INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'editor', 'editor@example.com')", []interface{}{editor}},
+		{"INSERT INTO tasks (id, user_id, title) VALUES ($1, $2, 'Draft')", []interface{}{task, owner}},
+	} {
+		_, err := db.Exec(q.query, q.args...)
+		require.NoError(t, err)
+	}
+
+	tasks := NewTaskRepository(db)
+	ctx := WithActor(context.Background(), editor)
+	tagsOf := func() []string {
+		loaded := []models.Task{{ID: task}}
+		require.NoError(t, tasks.AttachTags(context.Background(), loaded))
+		return loaded[0].Tags
+	}
+
+	updated, changed, err := tasks.UpdateWithTags(ctx, task, owner, map[string]interface{}{"title": "Final"}, []string{"work", "home"}, true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Final", updated.Title)
+	assert.Equal(t, []string{"home", "work"}, tagsOf())
+
+	// The same tags and fields change nothing
+	_, changed, err = tasks.UpdateWithTags(ctx, task, owner, map[string]interface{}{"title": "Final"}, []string{"home", "work"}, true)
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// A tag change alone still bumps updated_at
+	before := updated.UpdatedAt
+	updated, changed, err = tasks.UpdateWithTags(ctx, task, owner, map[string]interface{}{"title": "Final"}, []string{"home"}, true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.True(t, updated.UpdatedAt.After(before))
+
+	// A failing field update rolls back the tags with it
+	_, _, err = tasks.UpdateWithTags(ctx, task, owner, map[string]interface{}{"priority": "someday"}, []string{"later"}, true)
+	require.Error(t, err)
+	assert.Equal(t, []string{"home"}, tagsOf())
+
+	_, _, err = tasks.UpdateWithTags(ctx, task, editor, nil, []string{"stolen"}, true)
+	assert.ErrorIs(t, err, ErrNotFound, "only the owner's tasks are updated")
+
+	entries, _, err := NewHistoryRepository(db).List(context.Background(), task, false, 10, 0)
+	require.NoError(t, err)
+	var tagChanges []models.TaskHistoryEntry
+	for _, e := range entries {
+		assert.Equal(t, &editor, e.UserID, e.Field)
+		if e.Field == "tags" {
+			tagChanges = append(tagChanges, e)
+		}
+	}
+	require.Len(t, tagChanges, 2)
+	// Newest first
+	assert.Equal(t, "home, work", *tagChanges[0].OldValue)
+	assert.Equal(t, "home", *tagChanges[0].NewValue)
+	assert.Nil(t, tagChanges[1].OldValue)
+	assert.Equal(t, "home, work", *tagChanges[1].NewValue)
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// IdempotencyRepository provides persistence for idempotency keys
+type IdempotencyRepository struct {
+	db *database.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency key repository
+func NewIdempotencyRepository(db *database.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims a key for a request. Keys created before expiry are
+// forgotten first, along with the user's other expired keys. It returns nil
+// once the key is claimed, or the key's record if another request holds it.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, userID uuid.UUID, key, requestHash string, expiry time.Time) (*models.IdempotencyKey, error) {
+	defer observe("idempotency.reserve", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = $1 AND created_at < $2", userID, expiry); err != nil {
+		return nil, Translate(err)
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (user_id, key, request_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, key) DO NOTHING
+	`, userID, key, requestHash, time.Now())
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	var existing *models.IdempotencyKey
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		existing = &models.IdempotencyKey{}
+		err = tx.GetContext(ctx, existing,
+			"SELECT * FROM idempotency_keys WHERE user_id = $1 AND key = $2", userID, key)
+		if err != nil {
+			return nil, Translate(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, Translate(err)
+	}
+	return existing, nil
+}
+
+// Complete stores the response to the request holding a key
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, response string) error {
+	defer observe("idempotency.complete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE idempotency_keys SET status_code = $1, response = $2 WHERE user_id = $3 AND key = $4",
+		statusCode, response, userID, key)
+	return Translate(err)
+}
+
+// Release gives up a key whose request failed, so a retry can claim it
+func (r *IdempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	defer observe("idempotency.release", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL", userID, key)
+	return Translate(err)
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+)
+
+const defaultSlowQueryThreshold = 500 * time.Millisecond
+
+var (
+	slowQueryOnce      sync.Once
+	slowQueryThreshold time.Duration
+	slowQueryLogger    = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+)
+
+// observe logs a structured warning when a query takes longer than the
+// SLOW_QUERY_THRESHOLD. Use it as: defer observe("tasks.get", time.Now())
+func observe(name string, start time.Time) {
+	slowQueryOnce.Do(func() {
+		slowQueryThreshold = config.Duration("SLOW_QUERY_THRESHOLD", defaultSlowQueryThreshold)
+	})
+
+	elapsed := time.Since(start)
+	if slowQueryThreshold <= 0 || elapsed < slowQueryThreshold {
+		return
+	}
+
+	slowQueryLogger.Warn("slow query",
+		slog.String("query", name),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+		slog.Float64("threshold_ms", float64(slowQueryThreshold.Microseconds())/1000),
+	)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureSlowQueries sets the slow-query threshold and returns the buffer
+// slow-query logs are written to, restoring both when the test ends
+func captureSlowQueries(t *testing.T, threshold time.Duration) *bytes.Buffer {
+	slowQueryOnce.Do(func() {})
+	previousThreshold, previousLogger := slowQueryThreshold, slowQueryLogger
+	t.Cleanup(func() {
+		slowQueryThreshold, slowQueryLogger = previousThreshold, previousLogger
+	})
+
+	var buf bytes.Buffer
+	slowQueryThreshold = threshold
+	slowQueryLogger = slog.New(slog.NewJSONHandler(&buf, nil))
+	return &buf
+}
+
+func TestObserveLogsSlowQuery(t *testing.T) {
+	buf := captureSlowQueries(t, 100*time.Millisecond)
+
+	observe("tasks.get", time.Now().Add(-250*time.Millisecond))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry), buf.String())
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "slow query", entry["msg"])
+	assert.Equal(t, "tasks.get", entry["query"])
+	assert.GreaterOrEqual(t, entry["duration_ms"], float64(250))
+	assert.Equal(t, float64(100), entry["threshold_ms"])
+}
+
+func TestObserveIgnoresFastQuery(t *testing.T) {
+	buf := captureSlowQueries(t, 100*time.Millisecond)
+
+	observe("tasks.get", time.Now())
+	assert.Empty(t, buf.String())
+}
+
+func TestObserveDisabledByZeroThreshold(t *testing.T) {
+	buf := captureSlowQueries(t, 0)
+
+	observe("tasks.get", time.Now().Add(-time.Hour))
+	assert.Empty(t, buf.String())
+}
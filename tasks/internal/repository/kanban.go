@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// Move places a task at the zero-based index of the user's status column,
+// changing its status if needed, and renumbers the column so positions stay
+// dense. An index past the end appends. With cascade the task's open
+// subtasks are completed in the same transaction and returned.
+func (r *TaskRepository) Move(ctx context.Context, taskID, userID uuid.UUID, status string, index int, cascade bool) (*models.Task, []models.Task, error) {
+	defer observe("tasks.move", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	var task models.Task
+	err = tx.GetContext(ctx, &task,
+		"SELECT * FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE", taskID, userID)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	// Locking the column serializes concurrent moves into it
+	var column []uuid.UUID
+	err = tx.SelectContext(ctx, &column, `
+		SELECT id FROM tasks
+		WHERE user_id = $1 AND status = $2 AND id <> $3 AND deleted_at IS NULL AND archived_at IS NULL
+		ORDER BY position, created_at, id
+		FOR UPDATE
+	`, userID, status, taskID)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+	if index > len(column) {
+		index = len(column)
+	}
+	ordered := make([]uuid.UUID, 0, len(column)+1)
+	ordered = append(ordered, column[:index]...)
+	ordered = append(ordered, taskID)
+	ordered = append(ordered, column[index:]...)
+
+	// Only rows whose position changes are written, so their neighbours keep
+	// their updated_at
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tasks t SET position = c.ord - 1
+		FROM unnest($1::uuid[]) WITH ORDINALITY AS c(id, ord)
+		WHERE t.id = c.id AND t.position <> c.ord - 1
+	`, pq.Array(ordered))
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	completed := []models.Task{}
+	if cascade {
+		if completed, err = completeSubtasks(ctx, tx, taskID, userID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = tx.GetContext(ctx, &task,
+		"UPDATE tasks SET status = $1, updated_at = $2 WHERE id = $3 RETURNING *", status, time.Now(), taskID)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+	if cascade {
+		if err := tx.GetContext(ctx, &task, rollupProgressQuery, taskID, userID); err != nil {
+			return nil, nil, Translate(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, Translate(err)
+	}
+	return &task, completed, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// LinkRepository provides persistence for the external links of tasks.
+// Callers must check that the user may access the task.
+type LinkRepository struct {
+	db *database.DB
+}
+
+// NewLinkRepository creates a new link repository
+func NewLinkRepository(db *database.DB) *LinkRepository {
+	return &LinkRepository{db: db}
+}
+
+// Create records a link. It returns ErrConflict if the task already links
+// to the URL.
+func (r *LinkRepository) Create(ctx context.Context, link *models.TaskLink) error {
+	defer observe("links.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO task_links (id, task_id, user_id, url, title, page_title, favicon_url, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, link.ID, link.TaskID, link.UserID, link.URL, link.Title, link.PageTitle, link.FaviconURL, link.CreatedAt)
+	return Translate(err)
+}
+
+// List returns a task's links, oldest first
+func (r *LinkRepository) List(ctx context.Context, taskID uuid.UUID) ([]models.TaskLink, error) {
+	defer observe("links.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	links := []models.TaskLink{}
+	err := r.db.SelectContext(ctx, &links,
+		"SELECT * FROM task_links WHERE task_id = $1 ORDER BY created_at, id", taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return links, nil
+}
+
+// Delete removes one of a task's links
+func (r *LinkRepository) Delete(ctx context.Context, linkID, taskID uuid.UUID) error {
+	defer observe("links.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM task_links WHERE id = $1 AND task_id = $2", linkID, taskID)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
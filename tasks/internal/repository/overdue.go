@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// MarkOverdue flags up to limit open, unarchived tasks that were due before
+// now and returns them. Rows locked by another instance are skipped, so
+// concurrent workers never flag a task twice. Rescheduling or closing a task
+// clears its flag in the database.
+func (r *TaskRepository) MarkOverdue(ctx context.Context, now time.Time, limit int) ([]models.Task, error) {
+	defer observe("tasks.mark_overdue", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tasks := []models.Task{}
+	err := r.db.SelectContext(ctx, &tasks, `
+		WITH due AS (
+			SELECT id FROM tasks
+			WHERE NOT overdue AND status IN ('pending', 'in_progress') AND deleted_at IS NULL
+				AND archived_at IS NULL AND due_date < $1
+			ORDER BY due_date
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE tasks t SET overdue = TRUE
+		FROM due WHERE t.id = due.id
+		RETURNING t.*
+	`, now, limit)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return tasks, nil
+}
+
+// UnmarkOverdue clears a task's overdue flag so the next run flags it again
+func (r *TaskRepository) UnmarkOverdue(ctx context.Context, taskID uuid.UUID) error {
+	defer observe("tasks.unmark_overdue", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE tasks SET overdue = FALSE WHERE id = $1", taskID)
+	return Translate(err)
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// SetPinned pins or unpins a task owned by the given user
+func (r *TaskRepository) SetPinned(ctx context.Context, taskID, userID uuid.UUID, pinned bool) (*models.Task, error) {
+	defer observe("tasks.set_pinned", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var task models.Task
+	err := r.db.GetContext(ctx, &task, `
+		UPDATE tasks SET pinned = $1, updated_at = $2
+		WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL
+		RETURNING *
+	`, pinned, time.Now(), taskID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &task, nil
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// ProjectRepository provides persistence for projects
+type ProjectRepository struct {
+	db *database.DB
+}
+
+// NewProjectRepository creates a new project repository
+func NewProjectRepository(db *database.DB) *ProjectRepository {
+	return &ProjectRepository{db: db}
+}
+
+// projectWithCounts selects projects with the number of live, unarchived
+// tasks in each, matching what the task list shows for the project
+const projectWithCounts = `
+	SELECT p.*,
+		COUNT(t.id) AS task_count,
+		COUNT(t.id) FILTER (WHERE t.status NOT IN ('completed', 'cancelled')) AS open_task_count
+	FROM projects p
+	LEFT JOIN tasks t ON t.project_id = p.id AND t.user_id = p.user_id
+		AND t.deleted_at IS NULL AND t.archived_at IS NULL
+`
+
+// Create inserts a new project
+func (r *ProjectRepository) Create(ctx context.Context, project *models.Project) error {
+	defer observe("projects.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO projects (id, user_id, name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, project.ID, project.UserID, project.Name, project.Description, project.CreatedAt, project.UpdatedAt)
+	return Translate(err)
+}
+
+// List returns a page of the user's projects, ordered by name, and the total
+// count
+func (r *ProjectRepository) List(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Project, int, error) {
+	defer observe("projects.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	projects := []models.Project{}
+	err := r.db.SelectContext(ctx, &projects, projectWithCounts+`
+		WHERE p.user_id = $1
+		GROUP BY p.id
+		ORDER BY p.name ASC, p.id ASC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, Translate(err)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM projects WHERE user_id = $1", userID); err != nil {
+		return nil, 0, Translate(err)
+	}
+	return projects, total, nil
+}
+
+// GetByID returns a project owned by the user with its task counts
+func (r *ProjectRepository) GetByID(ctx context.Context, projectID, userID uuid.UUID) (*models.Project, error) {
+	defer observe("projects.get_by_id", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var project models.Project
+	err := r.db.GetContext(ctx, &project, projectWithCounts+`
+		WHERE p.id = $1 AND p.user_id = $2
+		GROUP BY p.id
+	`, projectID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &project, nil
+}
+
+// Summary rolls up a project's live, unarchived tasks: counts, completion
+// percentage, overdue tasks and the earliest open due date
+func (r *ProjectRepository) Summary(ctx context.Context, projectID, userID uuid.UUID) (*models.ProjectSummary, error) {
+	defer observe("projects.summary", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var summary models.ProjectSummary
+	err := r.db.GetContext(ctx, &summary, `
+		SELECT p.id AS project_id, p.name,
+			COUNT(t.id) AS total_tasks,
+			COUNT(t.id) FILTER (WHERE t.status NOT IN ('completed', 'cancelled')) AS open_tasks,
+			COUNT(t.id) FILTER (WHERE t.status = 'completed') AS completed_tasks,
+			COUNT(t.id) FILTER (WHERE t.status NOT IN ('completed', 'cancelled') AND t.due_date < NOW()) AS overdue_tasks,
+			ROUND(100.0 * COUNT(t.id) FILTER (WHERE t.status = 'completed')
+				/ NULLIF(COUNT(t.id) FILTER (WHERE t.status <> 'cancelled'), 0)) AS percent_complete,
+			MIN(t.due_date) FILTER (WHERE t.status NOT IN ('completed', 'cancelled')) AS next_due_date
+		FROM projects p
+		LEFT JOIN tasks t ON t.project_id = p.id AND t.user_id = p.user_id
+			AND t.deleted_at IS NULL AND t.archived_at IS NULL
+		WHERE p.id = $1 AND p.user_id = $2
+		GROUP BY p.id
+	`, projectID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &summary, nil
+}
+
+// Exists reports whether the user owns the project
+func (r *ProjectRepository) Exists(ctx context.Context, projectID, userID uuid.UUID) (bool, error) {
+	defer observe("projects.exists", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var exists bool
+	err := r.db.GetContext(ctx, &exists,
+		"SELECT EXISTS(SELECT 1 FROM projects WHERE id = $1 AND user_id = $2)", projectID, userID)
+	return exists, Translate(err)
+}
+
+// Update applies column updates to a project owned by the user. It returns
+// ErrNotFound if there is no such project.
+func (r *ProjectRepository) Update(ctx context.Context, projectID, userID uuid.UUID, updates map[string]interface{}) error {
+	defer observe("projects.update", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	sets := make([]string, 0, len(updates)+1)
+	args := make([]interface{}, 0, len(updates)+3)
+	for column, val := range updates {
+		args = append(args, val)
+		sets = append(sets, column+" = $"+strconv.Itoa(len(args)))
+	}
+	args = append(args, time.Now(), projectID, userID)
+	sets = append(sets, "updated_at = $"+strconv.Itoa(len(args)-2))
+
+	query := "UPDATE projects SET " + strings.Join(sets, ", ") +
+		" WHERE id = $" + strconv.Itoa(len(args)-1) + " AND user_id = $" + strconv.Itoa(len(args))
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a project owned by the user. Its tasks are kept and lose
+// their project.
+func (r *ProjectRepository) Delete(ctx context.Context, projectID, userID uuid.UUID) error {
+	defer observe("projects.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM projects WHERE id = $1 AND user_id = $2", projectID, userID)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
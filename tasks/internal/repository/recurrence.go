@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// CompletedRecurring returns completed tasks whose recurrence is active and
+// whose next occurrence has not been created yet, oldest completion first
+func (r *TaskRepository) CompletedRecurring(ctx context.Context, limit int) ([]models.Task, error) {
+	defer observe("tasks.completed_recurring", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	tasks := []models.Task{}
+	err := r.db.SelectContext(ctx, &tasks, `
+		SELECT * FROM tasks
+		WHERE recurrence IS NOT NULL AND status = 'completed' AND NOT recurrence_paused AND deleted_at IS NULL
+		ORDER BY completed_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return tasks, r.AttachTags(ctx, tasks)
+}
+
+// CreateOccurrence inserts the next occurrence of a completed recurring task
+// and moves the recurrence onto it in one transaction. It returns ErrConflict
+// if the completed task no longer holds an active recurrence, e.g. because
+// another instance already created the occurrence.
+func (r *TaskRepository) CreateOccurrence(ctx context.Context, completed uuid.UUID, next *models.Task) error {
+	defer observe("tasks.create_occurrence", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Translate(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE tasks SET recurrence = NULL, recurrence_paused = FALSE
+		WHERE id = $1 AND recurrence IS NOT NULL AND status = 'completed' AND NOT recurrence_paused AND deleted_at IS NULL
+	`, completed)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrConflict
+	}
+
+	if err := insertTask(ctx, tx, next); err != nil {
+		return err
+	}
+	if len(next.Tags) > 0 {
+		if _, _, err := replaceTaskTags(ctx, tx, next.ID, next.Tags); err != nil {
+			return err
+		}
+	}
+
+	return Translate(tx.Commit())
+}
+
+// SetRecurrencePaused pauses or resumes a task's recurrence. It returns
+// ErrNotFound if the user has no recurring task with that ID.
+func (r *TaskRepository) SetRecurrencePaused(ctx context.Context, taskID, userID uuid.UUID, paused bool) (*models.Task, error) {
+	defer observe("tasks.set_recurrence_paused", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var task models.Task
+	err := r.db.GetContext(ctx, &task, `
+		UPDATE tasks SET recurrence_paused = $1, updated_at = $2
+		WHERE id = $3 AND user_id = $4 AND recurrence IS NOT NULL AND deleted_at IS NULL
+		RETURNING *
+	`, paused, time.Now(), taskID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &task, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// ReminderRepository provides persistence for task reminders
+type ReminderRepository struct {
+	db *database.DB
+}
+
+// NewReminderRepository creates a new reminder repository
+func NewReminderRepository(db *database.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// Create schedules a reminder
+func (r *ReminderRepository) Create(ctx context.Context, reminder *models.TaskReminder) error {
+	defer observe("reminders.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO reminders (id, task_id, remind_at, channels, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, reminder.ID, reminder.TaskID, reminder.RemindAt, reminder.Channels, reminder.CreatedAt)
+	return Translate(err)
+}
+
+// List returns a task's reminders, soonest first
+func (r *ReminderRepository) List(ctx context.Context, taskID uuid.UUID) ([]models.TaskReminder, error) {
+	defer observe("reminders.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	reminders := []models.TaskReminder{}
+	err := r.db.SelectContext(ctx, &reminders,
+		"SELECT * FROM reminders WHERE task_id = $1 ORDER BY remind_at, id", taskID)
+	return reminders, Translate(err)
+}
+
+// Delete removes a reminder from a task
+func (r *ReminderRepository) Delete(ctx context.Context, reminderID, taskID uuid.UUID) error {
+	defer observe("reminders.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM reminders WHERE id = $1 AND task_id = $2", reminderID, taskID)
+	if err != nil {
+		return Translate(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return Translate(err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDue marks up to limit reminders due at or before now as fired and
+// returns them. Rows locked by another instance are skipped, so each
+// reminder is claimed once.
+func (r *ReminderRepository) ClaimDue(ctx context.Context, now time.Time, limit int) ([]models.TaskReminder, error) {
+	defer observe("reminders.claim_due", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	reminders := []models.TaskReminder{}
+	err := r.db.SelectContext(ctx, &reminders, `
+		UPDATE reminders SET fired_at = $1
+		WHERE id IN (
+			SELECT id FROM reminders
+			WHERE fired_at IS NULL AND remind_at <= $1
+			ORDER BY remind_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, now, limit)
+	return reminders, Translate(err)
+}
+
+// Release returns a claimed reminder to the queue so it is retried
+func (r *ReminderRepository) Release(ctx context.Context, reminderID uuid.UUID) error {
+	defer observe("reminders.release", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE reminders SET fired_at = NULL WHERE id = $1", reminderID)
+	return Translate(err)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// ShareRepository provides persistence for tasks shared with other users
+type ShareRepository struct {
+	db *database.DB
+}
+
+// NewShareRepository creates a new share repository
+func NewShareRepository(db *database.DB) *ShareRepository {
+	return &ShareRepository{db: db}
+}
+
+// Upsert grants userID the permission on a task owned by ownerID, replacing
+// any permission granted before. It returns ErrNotFound if the task is not
+// owned by ownerID.
+func (r *ShareRepository) Upsert(ctx context.Context, taskID, ownerID, userID uuid.UUID, permission string) (*models.TaskShare, error) {
+	defer observe("shares.upsert", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var share models.TaskShare
+	err := r.db.GetContext(ctx, &share, `
+		INSERT INTO task_shares (task_id, user_id, permission, created_at)
+		SELECT id, $3, $4, $5 FROM tasks
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+		ON CONFLICT (task_id, user_id) DO UPDATE SET permission = EXCLUDED.permission
+		RETURNING *
+	`, taskID, ownerID, userID, permission, time.Now())
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &share, nil
+}
+
+// Delete revokes userID's access to a task owned by ownerID. It returns
+// ErrNotFound if there was no such share.
+func (r *ShareRepository) Delete(ctx context.Context, taskID, ownerID, userID uuid.UUID) error {
+	defer observe("shares.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM task_shares s USING tasks t
+		WHERE s.task_id = t.id AND t.id = $1 AND t.user_id = $2 AND s.user_id = $3
+	`, taskID, ownerID, userID)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetSharedTask returns a task shared with userID and the permission they
+// were granted. It returns ErrNotFound if the task is not shared with them.
+func (r *ShareRepository) GetSharedTask(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, string, error) {
+	defer observe("shares.get_shared_task", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var row struct {
+		models.Task
+		Permission string `db:"share_permission"`
+	}
+	err := r.db.GetContext(ctx, &row, `
+		SELECT t.*, s.permission AS share_permission
+		FROM tasks t JOIN task_shares s ON s.task_id = t.id
+		WHERE t.id = $1 AND s.user_id = $2 AND t.deleted_at IS NULL
+	`, taskID, userID)
+	if err != nil {
+		return nil, "", Translate(err)
+	}
+	return &row.Task, row.Permission, nil
+}
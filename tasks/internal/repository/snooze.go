@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// SetSnoozed snoozes a task owned by the given user until the given time, or
+// wakes it when until is nil
+func (r *TaskRepository) SetSnoozed(ctx context.Context, taskID, userID uuid.UUID, until *time.Time) (*models.Task, error) {
+	defer observe("tasks.set_snoozed", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var task models.Task
+	err := r.db.GetContext(ctx, &task, `
+		UPDATE tasks SET snoozed_until = $1, updated_at = $2
+		WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL
+		RETURNING *
+	`, until, time.Now(), taskID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &task, nil
+}
+
+// WakeSnoozed clears the snooze of up to limit tasks whose snooze ended
+// before now and returns them. Rows locked by another instance are skipped.
+func (r *TaskRepository) WakeSnoozed(ctx context.Context, now time.Time, limit int) ([]models.Task, error) {
+	defer observe("tasks.wake_snoozed", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tasks := []models.Task{}
+	err := r.db.SelectContext(ctx, &tasks, `
+		WITH due AS (
+			SELECT id FROM tasks
+			WHERE snoozed_until <= $1
+			ORDER BY snoozed_until
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE tasks t SET snoozed_until = NULL
+		FROM due WHERE t.id = due.id
+		RETURNING t.*
+	`, now, limit)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return tasks, nil
+}
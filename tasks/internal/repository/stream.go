@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// StreamRepository provides persistence for the task event streams
+type StreamRepository struct {
+	db *database.DB
+}
+
+// NewStreamRepository creates a new stream event repository
+func NewStreamRepository(db *database.DB) *StreamRepository {
+	return &StreamRepository{db: db}
+}
+
+// Append records an event in the stream of each of the users
+func (r *StreamRepository) Append(ctx context.Context, userIDs []uuid.UUID, event models.TaskEvent) error {
+	defer observe("stream.append", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	// created_at is left to the database so Since compares it with the
+	// same clock
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO stream_events (user_id, task_id, event_type, payload)
+		SELECT user_id, $2, $3, $4 FROM UNNEST($1::uuid[]) AS user_id
+	`, pq.Array(userIDs), event.TaskID, event.EventType, string(payload))
+	return Translate(err)
+}
+
+// Since returns up to limit of a user's events after the event with ID
+// after, oldest first. IDs are taken when a row is inserted but become
+// visible when it commits, so a lower ID can appear after a higher one has
+// been read. Only events older than lag are returned, stopping at the first
+// newer one, so a reader paging by ID doesn't skip an event still being
+// committed.
+func (r *StreamRepository) Since(ctx context.Context, userID uuid.UUID, after int64, lag time.Duration, limit int) ([]models.StreamEvent, error) {
+	defer observe("stream.since", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	events := []models.StreamEvent{}
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT * FROM stream_events
+		WHERE user_id = $1 AND id > $2
+		  AND id < COALESCE((
+			SELECT MIN(id) FROM stream_events
+			WHERE user_id = $1 AND id > $2
+			  AND created_at > LOCALTIMESTAMP - make_interval(secs => $3)
+		  ), 9223372036854775807)
+		ORDER BY id
+		LIMIT $4
+	`, userID, after, lag.Seconds(), limit)
+	return events, Translate(err)
+}
+
+// Latest returns the ID of the newest event, so a new stream can start
+// after it. It is 0 while there are none.
+func (r *StreamRepository) Latest(ctx context.Context) (int64, error) {
+	defer observe("stream.latest", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var latest int64
+	err := r.db.GetContext(ctx, &latest, "SELECT COALESCE(MAX(id), 0) FROM stream_events")
+	return latest, Translate(err)
+}
+
+// Prune deletes events recorded before cutoff and returns how many it deleted
+func (r *StreamRepository) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	defer observe("stream.prune", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM stream_events WHERE created_at < $1", cutoff)
+	if err != nil {
+		return 0, Translate(err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStreamSinceHoldsBackRecentEvents checks that events newer than the lag
+// are not read, and that they also hold back any older events with higher
+// IDs, so paging by ID can't pass over one
+func TestStreamSinceHoldsBackRecentEvents(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	userID := uuid.New()
+	_, err := db.Exec("INSERT INTO tasks_users (user_id, username, email) VALUES ($1, 'u', 'u@example.com')", userID)
+	require.NoError(t, err)
+
+	r := NewStreamRepository(db)
+	for _, eventType := range []string{models.EventTaskCreated, models.EventTaskUpdated, models.EventTaskDeleted} {
+		require.NoError(t, r.Append(ctx, []uuid.UUID{userID}, models.TaskEvent{EventType: eventType, TaskID: uuid.New()}))
+	}
+	var ids []int64
+	require.NoError(t, db.Select(&ids, "SELECT id FROM stream_events WHERE user_id = $1 ORDER BY id", userID))
+	require.Len(t, ids, 3)
+
+	events, err := r.Since(ctx, userID, 0, time.Hour, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events, "every event is newer than the lag")
+
+	// The middle event is still recent, as one committing late would be
+	_, err = db.Exec("UPDATE stream_events SET created_at = LOCALTIMESTAMP - INTERVAL '2 hours' WHERE id IN ($1, $2)", ids[0], ids[2])
+	require.NoError(t, err)
+	events, err = r.Since(ctx, userID, 0, time.Hour, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, ids[0], events[0].ID)
+
+	events, err = r.Since(ctx, userID, ids[0], 0, 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, ids[1], events[0].ID)
+	assert.Equal(t, ids[2], events[1].ID)
+}
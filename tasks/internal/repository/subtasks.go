@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// rollupProgressQuery sets a parent's progress to the share of its
+// non-cancelled, non-trashed subtasks that are completed. Progress is left as is when
+// there are none to count.
+const rollupProgressQuery = `
+	UPDATE tasks SET progress = COALESCE((
+		SELECT ROUND(100.0 * COUNT(*) FILTER (WHERE status = 'completed')
+			/ NULLIF(COUNT(*) FILTER (WHERE status <> 'cancelled'), 0))
+		FROM tasks WHERE parent_task_id = $1 AND deleted_at IS NULL
+	), progress)
+	WHERE id = $1 AND user_id = $2
+	RETURNING *
+`
+
+// CreateSubtask inserts a subtask and recomputes its parent's progress in the
+// same transaction, returning the updated parent
+func (r *TaskRepository) CreateSubtask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	defer observe("tasks.create_subtask", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := insertTask(ctx, tx, task); err != nil {
+		return nil, err
+	}
+	if len(task.Tags) > 0 {
+		if _, _, err := replaceTaskTags(ctx, tx, task.ID, task.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	var parent models.Task
+	if err := tx.GetContext(ctx, &parent, rollupProgressQuery, *task.ParentTaskID, task.UserID); err != nil {
+		return nil, Translate(err)
+	}
+
+	return &parent, Translate(tx.Commit())
+}
+
+// CreateWithSubtasks inserts a task and its subtasks in one transaction
+func (r *TaskRepository) CreateWithSubtasks(ctx context.Context, task *models.Task, subtasks []models.Task) error {
+	defer observe("tasks.create_with_subtasks", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := insertTask(ctx, tx, task); err != nil {
+		return err
+	}
+	if len(task.Tags) > 0 {
+		if _, _, err := replaceTaskTags(ctx, tx, task.ID, task.Tags); err != nil {
+			return err
+		}
+	}
+	for i := range subtasks {
+		if err := insertTask(ctx, tx, &subtasks[i]); err != nil {
+			return err
+		}
+		if len(subtasks[i].Tags) > 0 {
+			if _, _, err := replaceTaskTags(ctx, tx, subtasks[i].ID, subtasks[i].Tags); err != nil {
+				return err
+			}
+		}
+	}
+
+	return Translate(tx.Commit())
+}
+
+// ListSubtasks returns the subtasks of a task owned by the user, oldest first
+func (r *TaskRepository) ListSubtasks(ctx context.Context, parentID, userID uuid.UUID) ([]models.Task, error) {
+	defer observe("tasks.list_subtasks", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	subtasks := []models.Task{}
+	err := r.db.SelectContext(ctx, &subtasks,
+		"SELECT * FROM tasks WHERE parent_task_id = $1 AND user_id = $2 AND deleted_at IS NULL ORDER BY created_at ASC",
+		parentID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return subtasks, nil
+}
+
+// SubtaskCounts returns how many subtasks a task has and how many are still open
+func (r *TaskRepository) SubtaskCounts(ctx context.Context, parentID uuid.UUID) (total, open int, err error) {
+	defer observe("tasks.subtask_counts", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var counts struct {
+		Total int `db:"total"`
+		Open  int `db:"open"`
+	}
+	err = r.db.GetContext(ctx, &counts, `
+		SELECT COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status NOT IN ('completed', 'cancelled')) AS open
+		FROM tasks WHERE parent_task_id = $1 AND deleted_at IS NULL
+	`, parentID)
+	if err != nil {
+		return 0, 0, Translate(err)
+	}
+	return counts.Total, counts.Open, nil
+}
+
+// RollupProgress recomputes a parent task's progress from its subtasks
+func (r *TaskRepository) RollupProgress(ctx context.Context, parentID, userID uuid.UUID) (*models.Task, error) {
+	defer observe("tasks.rollup_progress", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var parent models.Task
+	if err := r.db.GetContext(ctx, &parent, rollupProgressQuery, parentID, userID); err != nil {
+		return nil, Translate(err)
+	}
+	return &parent, nil
+}
+
+// UpdateCompletingSubtasks applies updates that complete a parent task and
+// marks its open subtasks completed in the same transaction. Unless tags is
+// nil the parent's tags are set to exactly *tags in that transaction too. It
+// returns the updated parent and the subtasks that were completed.
+func (r *TaskRepository) UpdateCompletingSubtasks(ctx context.Context, taskID, userID uuid.UUID, updates map[string]interface{}, tags *[]string) (*models.Task, []models.Task, error) {
+	defer observe("tasks.update_completing_subtasks", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	completed, err := completeSubtasks(ctx, tx, taskID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tags != nil {
+		if _, err := updateTaskTags(ctx, tx, taskID, *tags); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	query, args := buildTaskUpdate(taskID, userID, updates, false)
+	var task models.Task
+	if err := tx.GetContext(ctx, &task, query, args...); err != nil {
+		return nil, nil, Translate(err)
+	}
+	if err := tx.GetContext(ctx, &task, rollupProgressQuery, taskID, userID); err != nil {
+		return nil, nil, Translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, Translate(err)
+	}
+	return &task, completed, nil
+}
+
+// completeSubtasks marks a parent's open subtasks completed within tx
+func completeSubtasks(ctx context.Context, tx *sqlx.Tx, parentID, userID uuid.UUID) ([]models.Task, error) {
+	completed := []models.Task{}
+	err := tx.SelectContext(ctx, &completed, `
+		UPDATE tasks SET status = 'completed', progress = 100, updated_at = $3
+		WHERE parent_task_id = $1 AND user_id = $2 AND status NOT IN ('completed', 'cancelled') AND deleted_at IS NULL
+		RETURNING *
+	`, parentID, userID, time.Now())
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return completed, nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// UpdateWithTags applies column updates to a task owned by the user and sets
+// its tags to exactly names, in one transaction. With onlyIfChanged the
+// columns are only written when one of them differs, as in UpdateIfChanged.
+// A change of tags alone still bumps updated_at and is recorded in task
+// history with the actor the context was tagged with by WithActor. It reports
+// whether the task changed and returns the current task either way.
+func (r *TaskRepository) UpdateWithTags(ctx context.Context, taskID, userID uuid.UUID, updates map[string]interface{}, names []string, onlyIfChanged bool) (*models.Task, bool, error) {
+	defer observe("tasks.update_with_tags", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return nil, false, Translate(err)
+	}
+
+	// Lock the task first so a missing one is reported before any tag changes
+	var task models.Task
+	err = tx.GetContext(ctx, &task, "SELECT * FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE", taskID, userID)
+	if err != nil {
+		return nil, false, Translate(err)
+	}
+
+	tagsChanged, err := updateTaskTags(ctx, tx, taskID, names)
+	if err != nil {
+		return nil, false, err
+	}
+
+	changed := false
+	if len(updates) > 0 || tagsChanged {
+		query, args := buildTaskUpdate(taskID, userID, updates, onlyIfChanged && !tagsChanged)
+		err = tx.GetContext(ctx, &task, query, args...)
+		switch {
+		case err == nil:
+			changed = true
+		case !errors.Is(err, sql.ErrNoRows):
+			return nil, false, Translate(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, Translate(err)
+	}
+	return &task, changed, nil
+}
+
+// updateTaskTags sets a task's tags within tx like replaceTaskTags and
+// records the change in task history, made by the actor ctx is tagged with
+func updateTaskTags(ctx context.Context, tx *sqlx.Tx, taskID uuid.UUID, names []string) (bool, error) {
+	previous, changed, err := replaceTaskTags(ctx, tx, taskID, names)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	current := slices.Clone(names)
+	slices.Sort(current)
+	err = insertHistory(ctx, tx, []models.TaskHistoryEntry{{
+		ID:        uuid.New(),
+		TaskID:    taskID,
+		UserID:    actorFrom(ctx),
+		Action:    models.HistoryActionUpdated,
+		Field:     "tags",
+		OldValue:  tagList(previous),
+		NewValue:  tagList(current),
+		CreatedAt: time.Now(),
+	}})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tagList renders sorted tag names as a history value, nil for none
+func tagList(names []string) *string {
+	if len(names) == 0 {
+		return nil
+	}
+	list := strings.Join(names, ", ")
+	return &list
+}
+
+// replaceTaskTags sets a task's tags within tx. It returns the tags the task
+// had before, sorted by name, and whether they changed.
+func replaceTaskTags(ctx context.Context, tx *sqlx.Tx, taskID uuid.UUID, names []string) ([]string, bool, error) {
+	var current []string
+	err := tx.SelectContext(ctx, &current, `
+		SELECT t.name FROM task_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.task_id = $1
+		FOR UPDATE OF tt
+	`, taskID)
+	if err != nil {
+		return nil, false, Translate(err)
+	}
+
+	wanted := slices.Clone(names)
+	slices.Sort(wanted)
+	slices.Sort(current)
+	if slices.Equal(wanted, current) {
+		return current, false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM task_tags WHERE task_id = $1", taskID); err != nil {
+		return nil, false, Translate(err)
+	}
+	if len(names) == 0 {
+		return current, true, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tags (name) SELECT unnest($1::text[])
+		ON CONFLICT (name) DO NOTHING
+	`, pq.Array(names))
+	if err != nil {
+		return nil, false, Translate(err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO task_tags (task_id, tag_id)
+		SELECT $1, id FROM tags WHERE name = ANY($2::text[])
+	`, taskID, pq.Array(names))
+	if err != nil {
+		return nil, false, Translate(err)
+	}
+	return current, true, nil
+}
+
+// AttachTags fills in the Tags of each task, sorted by name
+func (r *TaskRepository) AttachTags(ctx context.Context, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	defer observe("tasks.attach_tags", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	ids := make([]uuid.UUID, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+	}
+
+	var rows []struct {
+		TaskID uuid.UUID `db:"task_id"`
+		Name   string    `db:"name"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT tt.task_id, t.name FROM task_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		WHERE tt.task_id = ANY($1::uuid[])
+		ORDER BY t.name
+	`, pq.Array(ids))
+	if err != nil {
+		return Translate(err)
+	}
+
+	byTask := make(map[uuid.UUID][]string, len(tasks))
+	for _, row := range rows {
+		byTask[row.TaskID] = append(byTask[row.TaskID], row.Name)
+	}
+	for i := range tasks {
+		tasks[i].Tags = byTask[tasks[i].ID]
+	}
+	return nil
+}
+
+// TagCounts lists the tags on a user's tasks with how many tasks carry each,
+// most used first
+func (r *TaskRepository) TagCounts(ctx context.Context, userID uuid.UUID) ([]models.TagCount, error) {
+	defer observe("tasks.tag_counts", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	counts := []models.TagCount{}
+	err := r.db.SelectContext(ctx, &counts, `
+		SELECT t.name, COUNT(*) AS count FROM task_tags tt
+		JOIN tags t ON t.id = tt.tag_id
+		JOIN tasks k ON k.id = tt.task_id
+		WHERE k.user_id = $1 AND k.deleted_at IS NULL
+		GROUP BY t.name
+		ORDER BY count DESC, t.name ASC
+	`, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return counts, nil
+}
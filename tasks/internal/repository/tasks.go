@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// TaskRepository provides persistence for tasks
+type TaskRepository struct {
+	db *database.DB
+}
+
+// NewTaskRepository creates a new task repository
+func NewTaskRepository(db *database.DB) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+// insertTaskQuery inserts a task at the end of its status column
+const insertTaskQuery = `
+	INSERT INTO tasks (id, user_id, parent_task_id, project_id, title, description, status, priority, due_date, origin, color, recurrence, created_at, updated_at, metadata, estimate_minutes, actual_minutes, latitude, longitude, place_name, position)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+		(SELECT COALESCE(MAX(position) + 1, 0) FROM tasks WHERE user_id = $2 AND status = $7))
+`
+
+// insertTask inserts a task row inside tx
+func insertTask(ctx context.Context, tx *sqlx.Tx, task *models.Task) error {
+	_, err := tx.ExecContext(ctx, insertTaskQuery,
+		task.ID, task.UserID, task.ParentTaskID, task.ProjectID, task.Title, task.Description, task.Status, task.Priority, task.DueDate, task.Origin, task.Color, task.Recurrence, task.CreatedAt, task.UpdatedAt, task.Metadata,
+		task.EstimateMinutes, task.ActualMinutes, task.Latitude, task.Longitude, task.PlaceName)
+	return Translate(err)
+}
+
+// Create inserts a new task along with its tags
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
+	defer observe("tasks.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := insertTask(ctx, tx, task); err != nil {
+		return err
+	}
+	if len(task.Tags) > 0 {
+		if _, _, err := replaceTaskTags(ctx, tx, task.ID, task.Tags); err != nil {
+			return err
+		}
+	}
+
+	return Translate(tx.Commit())
+}
+
+// CreateMany inserts all tasks and their tags in a single transaction
+func (r *TaskRepository) CreateMany(ctx context.Context, tasks []models.Task) error {
+	defer observe("tasks.create_many", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Translate(err)
+	}
+	defer tx.Rollback()
+
+	for i := range tasks {
+		if err := insertTask(ctx, tx, &tasks[i]); err != nil {
+			return err
+		}
+		if len(tasks[i].Tags) > 0 {
+			if _, _, err := replaceTaskTags(ctx, tx, tasks[i].ID, tasks[i].Tags); err != nil {
+				return err
+			}
+		}
+	}
+
+	return Translate(tx.Commit())
+}
+
+// ListByTitles returns the user's live tasks whose title matches one of the
+// given titles, ignoring case. Titles must already be lowercased.
+func (r *TaskRepository) ListByTitles(ctx context.Context, userID uuid.UUID, titles []string) ([]models.Task, error) {
+	defer observe("tasks.list_by_titles", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	tasks := []models.Task{}
+	err := r.db.SelectContext(ctx, &tasks,
+		"SELECT * FROM tasks WHERE user_id = $1 AND deleted_at IS NULL AND LOWER(title) = ANY($2)",
+		userID, pq.Array(titles))
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return tasks, nil
+}
+
+// GetByID returns a task owned by the given user
+func (r *TaskRepository) GetByID(ctx context.Context, taskID, userID uuid.UUID) (*models.Task, error) {
+	defer observe("tasks.get_by_id", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var task models.Task
+	err := r.db.GetContext(ctx, &task, "SELECT * FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", taskID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &task, nil
+}
+
+// ListByIDs returns the tasks with the given IDs regardless of owner, with
+// their tags. Missing and trashed IDs are left out.
+func (r *TaskRepository) ListByIDs(ctx context.Context, taskIDs []uuid.UUID) ([]models.Task, error) {
+	defer observe("tasks.list_by_ids", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	tasks := []models.Task{}
+	if len(taskIDs) == 0 {
+		return tasks, nil
+	}
+	if err := r.db.SelectContext(ctx, &tasks, "SELECT * FROM tasks WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL", pq.Array(taskIDs)); err != nil {
+		return nil, Translate(err)
+	}
+	return tasks, r.AttachTags(ctx, tasks)
+}
+
+// Update applies the given column updates to a task owned by the user and
+// returns the updated task
+func (r *TaskRepository) Update(ctx context.Context, taskID, userID uuid.UUID, updates map[string]interface{}) (*models.Task, error) {
+	defer observe("tasks.update", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	query, args := buildTaskUpdate(taskID, userID, updates, false)
+
+	var task models.Task
+	if err := r.getTagged(ctx, &task, query, args...); err != nil {
+		return nil, Translate(err)
+	}
+	return &task, nil
+}
+
+// UpdateIfChanged is like Update but skips the write when every value already
+// matches the stored task. It reports whether the task was changed and
+// returns the current task either way.
+func (r *TaskRepository) UpdateIfChanged(ctx context.Context, taskID, userID uuid.UUID, updates map[string]interface{}) (*models.Task, bool, error) {
+	defer observe("tasks.update_if_changed", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	query, args := buildTaskUpdate(taskID, userID, updates, true)
+
+	var task models.Task
+	err := r.getTagged(ctx, &task, query, args...)
+	if err == nil {
+		return &task, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, Translate(err)
+	}
+
+	// No row was updated: either the task doesn't exist or nothing changed
+	err = r.db.GetContext(ctx, &task, "SELECT * FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", taskID, userID)
+	if err != nil {
+		return nil, false, Translate(err)
+	}
+	return &task, false, nil
+}
+
+// Reopen moves a completed or cancelled task back to pending, optionally
+// setting a new due date. It returns ErrConflict if the task is still open.
+func (r *TaskRepository) Reopen(ctx context.Context, taskID, userID uuid.UUID, dueDate *time.Time) (*models.Task, error) {
+	defer observe("tasks.reopen", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var task models.Task
+	err := r.getTagged(ctx, &task, `
+		UPDATE tasks
+		SET status = 'pending', due_date = COALESCE($3, due_date), updated_at = $4
+		WHERE id = $1 AND user_id = $2 AND status IN ('completed', 'cancelled') AND deleted_at IS NULL
+		RETURNING *
+	`, taskID, userID, dueDate, time.Now())
+	if err == nil {
+		return &task, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, Translate(err)
+	}
+
+	// Distinguish a missing task from one that is not closed
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)", taskID, userID); err != nil {
+		return nil, Translate(err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return nil, ErrConflict
+}
+
+// buildTaskUpdate renders an UPDATE ... RETURNING statement for the given
+// columns. With onlyIfChanged the row is only matched when at least one
+// column differs from its new value.
+func buildTaskUpdate(taskID, userID uuid.UUID, updates map[string]interface{}, onlyIfChanged bool) (string, []interface{}) {
+	sets := make([]string, 0, len(updates)+1)
+	changes := make([]string, 0, len(updates))
+	args := make([]interface{}, 0, len(updates)+3)
+	for column, val := range updates {
+		args = append(args, val)
+		placeholder := "$" + strconv.Itoa(len(args))
+		sets = append(sets, column+" = "+placeholder)
+		changes = append(changes, column+" IS DISTINCT FROM "+placeholder)
+	}
+	args = append(args, time.Now())
+	sets = append(sets, "updated_at = $"+strconv.Itoa(len(args)))
+	args = append(args, taskID, userID)
+
+	query := "UPDATE tasks SET " + strings.Join(sets, ", ") +
+		" WHERE id = $" + strconv.Itoa(len(args)-1) + " AND user_id = $" + strconv.Itoa(len(args)) + " AND deleted_at IS NULL"
+	if onlyIfChanged {
+		query += " AND (" + strings.Join(changes, " OR ") + ")"
+	}
+	return query + " RETURNING *", args
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildTaskUpdate(t *testing.T) {
+	taskID, userID := uuid.New(), uuid.New()
+	updates := map[string]interface{}{"title": "Same"}
+
+	query, args := buildTaskUpdate(taskID, userID, updates, false)
+	assert.Equal(t, "UPDATE tasks SET title = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL RETURNING *", query)
+	assert.Len(t, args, 4)
+	assert.Equal(t, []interface{}{taskID, userID}, args[2:])
+
+	query, _ = buildTaskUpdate(taskID, userID, updates, true)
+	assert.Equal(t, "UPDATE tasks SET title = $1, updated_at = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL AND (title IS DISTINCT FROM $1) RETURNING *", query,
+		"a row whose values all match isn't updated")
+}
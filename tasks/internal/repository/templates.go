@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// TemplateRepository provides persistence for task templates
+type TemplateRepository struct {
+	db *database.DB
+}
+
+// NewTemplateRepository creates a new template repository
+func NewTemplateRepository(db *database.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+// Create saves a template
+func (r *TemplateRepository) Create(ctx context.Context, template *models.TaskTemplate) error {
+	defer observe("templates.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO task_templates (id, user_id, title, description, priority, checklist, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, template.ID, template.UserID, template.Title, template.Description, template.Priority, template.Checklist, template.CreatedAt)
+	return Translate(err)
+}
+
+// List returns the user's templates, newest first
+func (r *TemplateRepository) List(ctx context.Context, userID uuid.UUID) ([]models.TaskTemplate, error) {
+	defer observe("templates.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	templates := []models.TaskTemplate{}
+	err := r.db.SelectContext(ctx, &templates,
+		"SELECT * FROM task_templates WHERE user_id = $1 ORDER BY created_at DESC, id", userID)
+	return templates, Translate(err)
+}
+
+// GetByID returns a template owned by the given user
+func (r *TemplateRepository) GetByID(ctx context.Context, templateID, userID uuid.UUID) (*models.TaskTemplate, error) {
+	defer observe("templates.get_by_id", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var template models.TaskTemplate
+	err := r.db.GetContext(ctx, &template,
+		"SELECT * FROM task_templates WHERE id = $1 AND user_id = $2", templateID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &template, nil
+}
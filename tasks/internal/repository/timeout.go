@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+)
+
+const (
+	defaultReadTimeout  = 5 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+)
+
+var (
+	timeoutOnce  sync.Once
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+)
+
+func loadTimeouts() {
+	timeoutOnce.Do(func() {
+		readTimeout = config.Duration("DB_READ_TIMEOUT", defaultReadTimeout)
+		writeTimeout = config.Duration("DB_WRITE_TIMEOUT", defaultWriteTimeout)
+	})
+}
+
+// ReadContext bounds a read query by DB_READ_TIMEOUT
+func ReadContext(parent context.Context) (context.Context, context.CancelFunc) {
+	loadTimeouts()
+	return context.WithTimeout(parent, readTimeout)
+}
+
+// WriteContext bounds a write query or transaction by DB_WRITE_TIMEOUT
+func WriteContext(parent context.Context) (context.Context, context.CancelFunc) {
+	loadTimeouts()
+	return context.WithTimeout(parent, writeTimeout)
+}
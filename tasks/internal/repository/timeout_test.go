@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reloadTimeouts makes the next ReadContext or WriteContext read the
+// environment again
+func reloadTimeouts(t *testing.T) {
+	timeoutOnce = sync.Once{}
+	t.Cleanup(func() { timeoutOnce = sync.Once{} })
+}
+
+func TestReadAndWriteTimeoutsAreSeparate(t *testing.T) {
+	reloadTimeouts(t)
+	t.Setenv("DB_READ_TIMEOUT", "2s")
+	t.Setenv("DB_WRITE_TIMEOUT", "7s")
+
+	for name, tc := range map[string]struct {
+		bound func(context.Context) (context.Context, context.CancelFunc)
+		want  time.Duration
+	}{
+		"read":  {ReadContext, 2 * time.Second},
+		"write": {WriteContext, 7 * time.Second},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := tc.bound(context.Background())
+			defer cancel()
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(tc.want), deadline, time.Second)
+		})
+	}
+}
+
+func TestReadContextKeepsEarlierDeadline(t *testing.T) {
+	reloadTimeouts(t)
+	parent, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	ctx, cancel := ReadContext(parent)
+	defer cancel()
+	parentDeadline, _ := parent.Deadline()
+	deadline, _ := ctx.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+}
+
+func TestSlowQueryTimesOut(t *testing.T) {
+	db := testdb.Open(t)
+	reloadTimeouts(t)
+	t.Setenv("DB_READ_TIMEOUT", "50ms")
+
+	ctx, cancel := ReadContext(context.Background())
+	defer cancel()
+	_, err := db.ExecContext(ctx, "SELECT pg_sleep(5)")
+	assert.ErrorIs(t, Translate(err), ErrTimeout)
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// TimeEntryRepository provides persistence for time tracked on tasks
+type TimeEntryRepository struct {
+	db *database.DB
+}
+
+// NewTimeEntryRepository creates a new time entry repository
+func NewTimeEntryRepository(db *database.DB) *TimeEntryRepository {
+	return &TimeEntryRepository{db: db}
+}
+
+// Start records a running timer. It returns ErrConflict if the user already
+// has a timer running.
+func (r *TimeEntryRepository) Start(ctx context.Context, entry *models.TimeEntry) error {
+	defer observe("time_entries.start", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO time_entries (id, task_id, user_id, started_at) VALUES ($1, $2, $3, $4)",
+		entry.ID, entry.TaskID, entry.UserID, entry.StartedAt)
+	return Translate(err)
+}
+
+// Running returns the user's running timer
+func (r *TimeEntryRepository) Running(ctx context.Context, userID uuid.UUID) (*models.TimeEntry, error) {
+	defer observe("time_entries.running", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var entry models.TimeEntry
+	err := r.db.GetContext(ctx, &entry, "SELECT * FROM time_entries WHERE user_id = $1 AND stopped_at IS NULL", userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &entry, nil
+}
+
+// Stop stops the user's running timer on a task. It returns ErrNotFound if
+// no timer is running on it.
+func (r *TimeEntryRepository) Stop(ctx context.Context, taskID, userID uuid.UUID, stoppedAt time.Time) (*models.TimeEntry, error) {
+	defer observe("time_entries.stop", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var entry models.TimeEntry
+	err := r.db.GetContext(ctx, &entry, `
+		UPDATE time_entries SET stopped_at = $3
+		WHERE task_id = $1 AND user_id = $2 AND stopped_at IS NULL
+		RETURNING *
+	`, taskID, userID, stoppedAt)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &entry, nil
+}
+
+// TaskTotal returns the seconds the user tracked on a task, counting a
+// running timer up to now
+func (r *TimeEntryRepository) TaskTotal(ctx context.Context, taskID, userID uuid.UUID) (int64, error) {
+	defer observe("time_entries.task_total", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var seconds int64
+	err := r.db.GetContext(ctx, &seconds, `
+		SELECT COALESCE(SUM(EXTRACT(EPOCH FROM (COALESCE(stopped_at, $3) - started_at))), 0)::bigint
+		FROM time_entries WHERE task_id = $1 AND user_id = $2
+	`, taskID, userID, time.Now())
+	return seconds, Translate(err)
+}
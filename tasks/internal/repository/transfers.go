@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// TransferRepository provides persistence for task ownership transfers
+type TransferRepository struct {
+	db *database.DB
+}
+
+// NewTransferRepository creates a new transfer repository
+func NewTransferRepository(db *database.DB) *TransferRepository {
+	return &TransferRepository{db: db}
+}
+
+// UserExists reports whether the user is present in the local user cache
+func (r *TransferRepository) UserExists(ctx context.Context, userID uuid.UUID) (bool, error) {
+	defer observe("transfers.user_exists", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM tasks_users WHERE user_id = $1)", userID)
+	return exists, Translate(err)
+}
+
+// Create records a pending transfer of a task owned by fromUserID.
+// It returns ErrNotFound if the task is not owned by fromUserID and
+// ErrConflict if the task already has a pending transfer.
+func (r *TransferRepository) Create(ctx context.Context, taskID, fromUserID, toUserID uuid.UUID) (*models.TaskTransfer, error) {
+	defer observe("transfers.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO task_transfers (id, task_id, from_user_id, to_user_id, status, created_at)
+		SELECT $1, id, user_id, $4, 'pending', $5
+		FROM tasks
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL
+		RETURNING *
+	`
+
+	var transfer models.TaskTransfer
+	err := r.db.GetContext(ctx, &transfer, query, uuid.New(), taskID, fromUserID, toUserID, time.Now())
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &transfer, nil
+}
+
+// ListPending returns transfers awaiting a response from the given user
+func (r *TransferRepository) ListPending(ctx context.Context, toUserID uuid.UUID) ([]models.TaskTransfer, error) {
+	defer observe("transfers.list_pending", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	transfers := []models.TaskTransfer{}
+	err := r.db.SelectContext(ctx, &transfers,
+		"SELECT * FROM task_transfers WHERE to_user_id = $1 AND status = 'pending' ORDER BY created_at DESC", toUserID)
+	return transfers, Translate(err)
+}
+
+// Respond accepts or rejects a pending transfer addressed to toUserID.
+// Accepting moves ownership of the task and its subtasks in the same
+// transaction. It returns ErrConflict if the task changed owner since the
+// transfer was created.
+func (r *TransferRepository) Respond(ctx context.Context, transferID, toUserID uuid.UUID, accept bool) (*models.TaskTransfer, error) {
+	defer observe("transfers.respond", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return nil, Translate(err)
+	}
+
+	var transfer models.TaskTransfer
+	err = tx.GetContext(ctx, &transfer,
+		"SELECT * FROM task_transfers WHERE id = $1 AND to_user_id = $2 AND status = 'pending' FOR UPDATE",
+		transferID, toUserID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	status := models.TransferRejected
+	if accept {
+		status = models.TransferAccepted
+		// Projects belong to the previous owner, so the task leaves its project
+		result, err := tx.ExecContext(ctx,
+			"UPDATE tasks SET user_id = $1, project_id = NULL WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL",
+			transfer.ToUserID, transfer.TaskID, transfer.FromUserID)
+		if err != nil {
+			return nil, Translate(err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return nil, ErrConflict
+		}
+
+		// Subtasks move with their parent
+		_, err = tx.ExecContext(ctx,
+			"UPDATE tasks SET user_id = $1, project_id = NULL WHERE parent_task_id = $2 AND user_id = $3",
+			transfer.ToUserID, transfer.TaskID, transfer.FromUserID)
+		if err != nil {
+			return nil, Translate(err)
+		}
+
+		// The new owner no longer needs a share of their own task
+		_, err = tx.ExecContext(ctx,
+			"DELETE FROM task_shares WHERE task_id = $1 AND user_id = $2", transfer.TaskID, transfer.ToUserID)
+		if err != nil {
+			return nil, Translate(err)
+		}
+	}
+
+	err = tx.GetContext(ctx, &transfer,
+		"UPDATE task_transfers SET status = $1, responded_at = $2 WHERE id = $3 RETURNING *",
+		status, time.Now(), transfer.ID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, Translate(err)
+	}
+	return &transfer, nil
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// Trash moves a task owned by the user, and its subtasks, to the trash and
+// returns every task trashed. Trashed subtasks share their parent's
+// deleted_at so Restore can bring them back together.
+func (r *TaskRepository) Trash(ctx context.Context, taskID, userID uuid.UUID) ([]models.Task, error) {
+	defer observe("tasks.trash", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	trashed := []models.Task{}
+	err := r.selectTagged(ctx, &trashed, `
+		UPDATE tasks SET deleted_at = $1
+		WHERE user_id = $2 AND (id = $3 OR parent_task_id = $3) AND deleted_at IS NULL
+		RETURNING *
+	`, time.Now(), userID, taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	if len(trashed) == 0 {
+		return nil, ErrNotFound
+	}
+	return trashed, nil
+}
+
+// trashRootCondition matches trashed tasks that can be restored on their
+// own: subtasks trashed with their parent are restored through the parent
+const trashRootCondition = `
+	user_id = $1 AND deleted_at IS NOT NULL AND NOT EXISTS (
+		SELECT 1 FROM tasks p WHERE p.id = tasks.parent_task_id AND p.deleted_at IS NOT NULL
+	)
+`
+
+// ListTrash returns a page of the user's trashed tasks, most recently deleted
+// first, and the total count
+func (r *TaskRepository) ListTrash(ctx context.Context, userID uuid.UUID, limit, offset int) ([]models.Task, int, error) {
+	defer observe("tasks.list_trash", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	tasks := []models.Task{}
+	err := r.db.SelectContext(ctx, &tasks,
+		"SELECT * FROM tasks WHERE"+trashRootCondition+"ORDER BY deleted_at DESC, id LIMIT $2 OFFSET $3",
+		userID, limit, offset)
+	if err != nil {
+		return nil, 0, Translate(err)
+	}
+
+	var total int
+	if err := r.db.GetContext(ctx, &total, "SELECT COUNT(*) FROM tasks WHERE"+trashRootCondition, userID); err != nil {
+		return nil, 0, Translate(err)
+	}
+	return tasks, total, nil
+}
+
+// Restore takes a task and the subtasks trashed along with it out of the
+// trash and returns them, the task first. It returns ErrNotFound if the user
+// has no such task in the trash and ErrConflict if it is a subtask whose
+// parent is trashed too.
+func (r *TaskRepository) Restore(ctx context.Context, taskID, userID uuid.UUID) ([]models.Task, error) {
+	defer observe("tasks.restore", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	if err := tagDeadlineChange(ctx, tx); err != nil {
+		return nil, Translate(err)
+	}
+
+	var task models.Task
+	err = tx.GetContext(ctx, &task,
+		"SELECT * FROM tasks WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL FOR UPDATE", taskID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	if task.ParentTaskID != nil {
+		var parentTrashed bool
+		err := tx.GetContext(ctx, &parentTrashed,
+			"SELECT EXISTS(SELECT 1 FROM tasks WHERE id = $1 AND deleted_at IS NOT NULL)", *task.ParentTaskID)
+		if err != nil {
+			return nil, Translate(err)
+		}
+		if parentTrashed {
+			return nil, ErrConflict
+		}
+	}
+
+	restored := []models.Task{}
+	err = tx.SelectContext(ctx, &restored, `
+		UPDATE tasks SET deleted_at = NULL, updated_at = $1
+		WHERE user_id = $2 AND (id = $3 OR (parent_task_id = $3 AND deleted_at = $4))
+		RETURNING *
+	`, time.Now(), userID, taskID, task.DeletedAt)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	for i := range restored {
+		if restored[i].ID == taskID {
+			restored[0], restored[i] = restored[i], restored[0]
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, Translate(err)
+	}
+	return restored, nil
+}
+
+// PurgeTrashed permanently deletes up to limit tasks trashed before cutoff,
+// with their subtasks, and returns how many were deleted and the storage
+// keys of their attachments. Rows locked by another instance are skipped.
+func (r *TaskRepository) PurgeTrashed(ctx context.Context, cutoff time.Time, limit int) (int, []string, error) {
+	defer observe("tasks.purge_trashed", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, nil, Translate(err)
+	}
+	defer tx.Rollback()
+
+	var taskIDs []uuid.UUID
+	err = tx.SelectContext(ctx, &taskIDs, `
+		SELECT id FROM tasks
+		WHERE deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, cutoff, limit)
+	if err != nil {
+		return 0, nil, Translate(err)
+	}
+	if len(taskIDs) == 0 {
+		return 0, nil, nil
+	}
+
+	// Subtasks go with their parent through the foreign key cascade
+	storageKeys := []string{}
+	err = tx.SelectContext(ctx, &storageKeys, `
+		SELECT a.storage_key FROM task_attachments a
+		JOIN tasks t ON t.id = a.task_id
+		WHERE t.id = ANY($1::uuid[]) OR t.parent_task_id = ANY($1::uuid[])
+	`, pq.Array(taskIDs))
+	if err != nil {
+		return 0, nil, Translate(err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = ANY($1::uuid[])", pq.Array(taskIDs)); err != nil {
+		return 0, nil, Translate(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, Translate(err)
+	}
+	return len(taskIDs), storageKeys, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// ViewRepository provides persistence for saved task list views
+type ViewRepository struct {
+	db *database.DB
+}
+
+// NewViewRepository creates a new saved view repository
+func NewViewRepository(db *database.DB) *ViewRepository {
+	return &ViewRepository{db: db}
+}
+
+// Create saves a view. It returns ErrConflict if the user already has a view
+// with the same name.
+func (r *ViewRepository) Create(ctx context.Context, view *models.SavedView) error {
+	defer observe("views.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO saved_views (id, user_id, name, status, priority, tags, sort, sort_order, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, view.ID, view.UserID, view.Name, view.Status, view.Priority, view.Tags, view.Sort, view.Order, view.CreatedAt)
+	return Translate(err)
+}
+
+// List returns the user's views by name
+func (r *ViewRepository) List(ctx context.Context, userID uuid.UUID) ([]models.SavedView, error) {
+	defer observe("views.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	views := []models.SavedView{}
+	err := r.db.SelectContext(ctx, &views,
+		"SELECT * FROM saved_views WHERE user_id = $1 ORDER BY name, id", userID)
+	return views, Translate(err)
+}
+
+// GetByID returns a view owned by the given user
+func (r *ViewRepository) GetByID(ctx context.Context, viewID, userID uuid.UUID) (*models.SavedView, error) {
+	defer observe("views.get_by_id", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var view models.SavedView
+	err := r.db.GetContext(ctx, &view,
+		"SELECT * FROM saved_views WHERE id = $1 AND user_id = $2", viewID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &view, nil
+}
+
+// Delete removes a view owned by the given user
+func (r *ViewRepository) Delete(ctx context.Context, viewID, userID uuid.UUID) error {
+	defer observe("views.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM saved_views WHERE id = $1 AND user_id = $2", viewID, userID)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// WatcherRepository provides persistence for users watching tasks they don't
+// own. Callers must check that the user may access the task.
+type WatcherRepository struct {
+	db *database.DB
+}
+
+// NewWatcherRepository creates a new watcher repository
+func NewWatcherRepository(db *database.DB) *WatcherRepository {
+	return &WatcherRepository{db: db}
+}
+
+// Watch adds userID to a task's watchers. Watching a task again is a no-op.
+func (r *WatcherRepository) Watch(ctx context.Context, taskID, userID uuid.UUID) (*models.TaskWatcher, error) {
+	defer observe("watchers.watch", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	var watcher models.TaskWatcher
+	err := r.db.GetContext(ctx, &watcher, `
+		INSERT INTO task_watchers (task_id, user_id, created_at) VALUES ($1, $2, $3)
+		ON CONFLICT (task_id, user_id) DO UPDATE SET task_id = EXCLUDED.task_id
+		RETURNING *
+	`, taskID, userID, time.Now())
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &watcher, nil
+}
+
+// Unwatch removes userID from a task's watchers. It returns ErrNotFound if
+// they were not watching it.
+func (r *WatcherRepository) Unwatch(ctx context.Context, taskID, userID uuid.UUID) error {
+	defer observe("watchers.unwatch", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM task_watchers WHERE task_id = $1 AND user_id = $2", taskID, userID)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// List returns a task's watchers, oldest first
+func (r *WatcherRepository) List(ctx context.Context, taskID uuid.UUID) ([]models.TaskWatcher, error) {
+	defer observe("watchers.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	watchers := []models.TaskWatcher{}
+	err := r.db.SelectContext(ctx, &watchers,
+		"SELECT * FROM task_watchers WHERE task_id = $1 ORDER BY created_at, user_id", taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return watchers, nil
+}
+
+// Recipients returns the IDs of a task's watchers who should hear about its
+// changes: those who still have it shared with them and don't own it, so
+// revoked shares and transfers stop notifications without extra bookkeeping
+func (r *WatcherRepository) Recipients(ctx context.Context, taskID uuid.UUID) ([]uuid.UUID, error) {
+	defer observe("watchers.recipients", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var ids []uuid.UUID
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT w.user_id FROM task_watchers w
+		JOIN tasks t ON t.id = w.task_id
+		JOIN task_shares s ON s.task_id = w.task_id AND s.user_id = w.user_id
+		WHERE w.task_id = $1 AND w.user_id <> t.user_id
+		ORDER BY w.created_at, w.user_id
+	`, taskID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return ids, nil
+}
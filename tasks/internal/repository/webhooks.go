@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// WebhookRepository provides persistence for webhooks and their deliveries
+type WebhookRepository struct {
+	db *database.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *database.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// ClaimedDelivery is a delivery claimed for sending, with where it goes and
+// the secret it is signed with
+type ClaimedDelivery struct {
+	models.WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+// Create registers a webhook
+func (r *WebhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	defer observe("webhooks.create", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhooks (id, user_id, url, event_types, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, webhook.ID, webhook.UserID, webhook.URL, webhook.EventTypes, webhook.Secret, webhook.CreatedAt)
+	return Translate(err)
+}
+
+// List returns a user's webhooks, oldest first
+func (r *WebhookRepository) List(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	defer observe("webhooks.list", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	webhooks := []models.Webhook{}
+	err := r.db.SelectContext(ctx, &webhooks,
+		"SELECT * FROM webhooks WHERE user_id = $1 ORDER BY created_at", userID)
+	return webhooks, Translate(err)
+}
+
+// Count returns how many webhooks a user has registered
+func (r *WebhookRepository) Count(ctx context.Context, userID uuid.UUID) (int, error) {
+	defer observe("webhooks.count", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var count int
+	err := r.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM webhooks WHERE user_id = $1", userID)
+	return count, Translate(err)
+}
+
+// GetByID returns a user's webhook
+func (r *WebhookRepository) GetByID(ctx context.Context, webhookID, userID uuid.UUID) (*models.Webhook, error) {
+	defer observe("webhooks.get_by_id", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	var webhook models.Webhook
+	err := r.db.GetContext(ctx, &webhook,
+		"SELECT * FROM webhooks WHERE id = $1 AND user_id = $2", webhookID, userID)
+	if err != nil {
+		return nil, Translate(err)
+	}
+	return &webhook, nil
+}
+
+// Update changes a user's webhook URL and/or event types
+func (r *WebhookRepository) Update(ctx context.Context, webhookID, userID uuid.UUID, updates map[string]interface{}) error {
+	defer observe("webhooks.update", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	sets := make([]string, 0, len(updates))
+	args := make([]interface{}, 0, len(updates)+2)
+	for column, val := range updates {
+		args = append(args, val)
+		sets = append(sets, column+" = $"+strconv.Itoa(len(args)))
+	}
+	args = append(args, webhookID, userID)
+
+	query := "UPDATE webhooks SET " + strings.Join(sets, ", ") +
+		" WHERE id = $" + strconv.Itoa(len(args)-1) + " AND user_id = $" + strconv.Itoa(len(args))
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return Translate(err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a user's webhook along with its delivery log
+func (r *WebhookRepository) Delete(ctx context.Context, webhookID, userID uuid.UUID) error {
+	defer observe("webhooks.delete", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND user_id = $2", webhookID, userID)
+	if err != nil {
+		return Translate(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return Translate(err)
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Enqueue queues an event for each of the user's webhooks subscribed to its
+// type, due now
+func (r *WebhookRepository) Enqueue(ctx context.Context, userID uuid.UUID, event models.TaskEvent) error {
+	defer observe("webhooks.enqueue", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (webhook_id, event_type, payload, next_attempt_at, created_at)
+		SELECT id, $2, $3, $4, $4 FROM webhooks
+		WHERE user_id = $1 AND $2 = ANY(event_types)
+	`, userID, event.EventType, string(payload), time.Now())
+	return Translate(err)
+}
+
+// ClaimDue claims up to limit pending deliveries due at or before now. A
+// claimed delivery is put off by lease, so it is retried if the attempt is
+// never recorded. Rows locked by another instance are skipped.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]ClaimedDelivery, error) {
+	defer observe("webhooks.claim_due", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	deliveries := []ClaimedDelivery{}
+	err := r.db.SelectContext(ctx, &deliveries, `
+		UPDATE webhook_deliveries d SET next_attempt_at = $3
+		FROM webhooks w
+		WHERE w.id = d.webhook_id AND d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.*, w.url, w.secret
+	`, now, limit, now.Add(lease))
+	return deliveries, Translate(err)
+}
+
+// RecordAttempt records the outcome of an attempt at a delivery. It stays
+// pending until nextAttempt, or ends with status when nextAttempt is nil.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, deliveryID uuid.UUID, status string, responseStatus *int, errMsg *string, nextAttempt *time.Time) error {
+	defer observe("webhooks.record_attempt", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, response_status = $3, error = $4,
+			next_attempt_at = $5, last_attempt_at = $6
+		WHERE id = $1
+	`, deliveryID, status, responseStatus, errMsg, nextAttempt, time.Now())
+	return Translate(err)
+}
+
+// ListDeliveries returns a page of a webhook's deliveries, newest first,
+// optionally only those with status, and how many there are in all
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status string, page, limit int) ([]models.WebhookDelivery, int, error) {
+	defer observe("webhooks.list_deliveries", time.Now())
+	ctx, cancel := ReadContext(ctx)
+	defer cancel()
+
+	where := "webhook_id = $1 AND ($2 = '' OR status = $2)"
+	var total int
+	if err := r.db.GetContext(ctx, &total,
+		"SELECT COUNT(*) FROM webhook_deliveries WHERE "+where, webhookID, status); err != nil {
+		return nil, 0, Translate(err)
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err := r.db.SelectContext(ctx, &deliveries,
+		"SELECT * FROM webhook_deliveries WHERE "+where+" ORDER BY created_at DESC, id LIMIT $3 OFFSET $4",
+		webhookID, status, limit, (page-1)*limit)
+	return deliveries, total, Translate(err)
+}
+
+// PruneDeliveries deletes finished deliveries created before cutoff and
+// returns how many it deleted
+func (r *WebhookRepository) PruneDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	defer observe("webhooks.prune_deliveries", time.Now())
+	ctx, cancel := WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM webhook_deliveries WHERE status <> 'pending' AND created_at < $1", cutoff)
+	if err != nil {
+		return 0, Translate(err)
+	}
+	return result.RowsAffected()
+}
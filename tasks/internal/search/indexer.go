@@ -0,0 +1,101 @@
+package search
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// Indexer mirrors task mutations into an external search engine
+type Indexer interface {
+	Index(ctx context.Context, task models.Task) error
+	Delete(ctx context.Context, taskID uuid.UUID) error
+}
+
+// NoopIndexer is used when no search engine is configured
+type NoopIndexer struct{}
+
+func (NoopIndexer) Index(ctx context.Context, task models.Task) error  { return nil }
+func (NoopIndexer) Delete(ctx context.Context, taskID uuid.UUID) error { return nil }
+
+// New builds the indexer selected by SEARCH_INDEXER. Anything other than a
+// known backend falls back to the no-op indexer. Real backends are wrapped in
+// an AsyncIndexer so indexing never blocks request handling.
+func New() Indexer {
+	switch backend := config.String("SEARCH_INDEXER", "none"); backend {
+	case "none":
+		return NoopIndexer{}
+	case "meilisearch":
+		log.Println("✅ Search indexing enabled (meilisearch)")
+		return NewAsyncIndexer(NewMeilisearchIndexer(
+			config.String("SEARCH_URL", "http://localhost:7700"),
+			config.String("SEARCH_API_KEY", ""),
+			config.String("SEARCH_INDEX", "tasks"),
+		), config.Int("SEARCH_QUEUE_SIZE", 1000))
+	default:
+		log.Printf("⚠️  Unknown SEARCH_INDEXER %q, search indexing disabled", backend)
+		return NoopIndexer{}
+	}
+}
+
+// indexOp is a queued index or delete operation
+type indexOp struct {
+	task   *models.Task
+	taskID uuid.UUID
+}
+
+// AsyncIndexer queues operations for a background worker so callers never
+// wait on the search engine. Operations are dropped (and logged) when the
+// queue is full.
+type AsyncIndexer struct {
+	next  Indexer
+	queue chan indexOp
+}
+
+// NewAsyncIndexer starts a background worker feeding the given indexer
+func NewAsyncIndexer(next Indexer, size int) *AsyncIndexer {
+	a := &AsyncIndexer{
+		next:  next,
+		queue: make(chan indexOp, size),
+	}
+	go a.run()
+	return a
+}
+
+func (a *AsyncIndexer) Index(ctx context.Context, task models.Task) error {
+	a.enqueue(indexOp{task: &task, taskID: task.ID})
+	return nil
+}
+
+func (a *AsyncIndexer) Delete(ctx context.Context, taskID uuid.UUID) error {
+	a.enqueue(indexOp{taskID: taskID})
+	return nil
+}
+
+func (a *AsyncIndexer) enqueue(op indexOp) {
+	select {
+	case a.queue <- op:
+	default:
+		log.Printf("⚠️  Search index queue full, dropping update for task %s\n", op.taskID)
+	}
+}
+
+func (a *AsyncIndexer) run() {
+	for op := range a.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		var err error
+		if op.task != nil {
+			err = a.next.Index(ctx, *op.task)
+		} else {
+			err = a.next.Delete(ctx, op.taskID)
+		}
+		cancel()
+		if err != nil {
+			log.Printf("❌ Failed to sync task %s to search index: %v\n", op.taskID, err)
+		}
+	}
+}
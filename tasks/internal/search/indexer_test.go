@@ -0,0 +1,136 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingIndexer records operations as "index <id>" or "delete <id>",
+// optionally blocking until release is closed
+type recordingIndexer struct {
+	mu      sync.Mutex
+	ops     []string
+	release chan struct{}
+}
+
+func (r *recordingIndexer) record(op string) {
+	if r.release != nil {
+		<-r.release
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, op)
+}
+
+func (r *recordingIndexer) Index(ctx context.Context, task models.Task) error {
+	r.record("index " + task.ID.String())
+	return nil
+}
+
+func (r *recordingIndexer) Delete(ctx context.Context, taskID uuid.UUID) error {
+	r.record("delete " + taskID.String())
+	return nil
+}
+
+func (r *recordingIndexer) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.ops...)
+}
+
+func TestAsyncIndexerForwardsInOrder(t *testing.T) {
+	next := &recordingIndexer{}
+	indexer := NewAsyncIndexer(next, 10)
+	first, second := uuid.New(), uuid.New()
+
+	require.NoError(t, indexer.Index(context.Background(), models.Task{ID: first}))
+	require.NoError(t, indexer.Index(context.Background(), models.Task{ID: second}))
+	require.NoError(t, indexer.Delete(context.Background(), first))
+
+	want := []string{"index " + first.String(), "index " + second.String(), "delete " + first.String()}
+	assert.Eventually(t, func() bool { return len(next.recorded()) == len(want) }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, want, next.recorded())
+}
+
+func TestAsyncIndexerDropsWhenFull(t *testing.T) {
+	next := &recordingIndexer{release: make(chan struct{})}
+	indexer := NewAsyncIndexer(next, 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// One is taken by the worker, one fills the queue, the rest are dropped
+		for i := 0; i < 5; i++ {
+			indexer.Index(context.Background(), models.Task{ID: uuid.New()})
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a full queue blocked the caller")
+	}
+
+	close(next.release)
+	assert.Eventually(t, func() bool { return len(next.recorded()) == 2 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, next.recorded(), 2)
+}
+
+func TestNewSelectsBackend(t *testing.T) {
+	t.Setenv("SEARCH_INDEXER", "none")
+	assert.Equal(t, NoopIndexer{}, New())
+
+	t.Setenv("SEARCH_INDEXER", "elasticsearch")
+	assert.Equal(t, NoopIndexer{}, New(), "unknown backends are disabled")
+
+	t.Setenv("SEARCH_INDEXER", "meilisearch")
+	assert.IsType(t, &AsyncIndexer{}, New())
+}
+
+func TestMeilisearchIndexer(t *testing.T) {
+	type request struct {
+		method, path, auth string
+		body               []byte
+	}
+	requests := make(chan request, 2)
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{r.Method, r.URL.Path, r.Header.Get("Authorization"), body}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	indexer := NewMeilisearchIndexer(server.URL+"/", "key", "tasks")
+	task := models.Task{ID: uuid.New(), Title: "Find me"}
+
+	require.NoError(t, indexer.Index(context.Background(), task))
+	got := <-requests
+	assert.Equal(t, http.MethodPost, got.method)
+	assert.Equal(t, "/indexes/tasks/documents", got.path)
+	assert.Equal(t, "Bearer key", got.auth)
+	var documents []models.Task
+	require.NoError(t, json.Unmarshal(got.body, &documents))
+	require.Len(t, documents, 1)
+	assert.Equal(t, task.ID, documents[0].ID)
+	assert.Equal(t, "Find me", documents[0].Title)
+
+	status = http.StatusServiceUnavailable
+	err := indexer.Delete(context.Background(), task.ID)
+	got = <-requests
+	assert.Equal(t, http.MethodDelete, got.method)
+	assert.Equal(t, "/indexes/tasks/documents/"+task.ID.String(), got.path)
+	assert.EqualError(t, err, "meilisearch returned 503 Service Unavailable")
+}
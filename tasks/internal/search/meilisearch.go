@@ -0,0 +1,68 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+)
+
+// MeilisearchIndexer writes task documents to a Meilisearch index
+type MeilisearchIndexer struct {
+	baseURL string
+	apiKey  string
+	index   string
+	client  *http.Client
+}
+
+// NewMeilisearchIndexer creates an indexer for the given Meilisearch instance
+func NewMeilisearchIndexer(baseURL, apiKey, index string) *MeilisearchIndexer {
+	return &MeilisearchIndexer{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		index:   index,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Index adds or replaces the task document
+func (m *MeilisearchIndexer) Index(ctx context.Context, task models.Task) error {
+	body, err := json.Marshal([]models.Task{task})
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+	return m.do(ctx, http.MethodPost, "/indexes/"+m.index+"/documents", body)
+}
+
+// Delete removes the task document
+func (m *MeilisearchIndexer) Delete(ctx context.Context, taskID uuid.UUID) error {
+	return m.do(ctx, http.MethodDelete, "/indexes/"+m.index+"/documents/"+taskID.String(), nil)
+}
+
+func (m *MeilisearchIndexer) do(ctx context.Context, method, path string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, m.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("meilisearch returned %s", resp.Status)
+	}
+	return nil
+}
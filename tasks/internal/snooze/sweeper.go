@@ -0,0 +1,94 @@
+package snooze
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/moabdelazem/microservices/tasks/internal/search"
+)
+
+// batchSize bounds how many tasks are woken per query
+const batchSize = 100
+
+// Publisher publishes task change events
+type Publisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// Sweeper clears the snooze of tasks whose snooze has ended. The task list
+// already shows them once the time passes; the sweep keeps snoozed_until
+// accurate and announces the change. Running it on several instances is
+// safe: each task is woken by whichever instance locks it first.
+type Sweeper struct {
+	tasks    *repository.TaskRepository
+	indexer  search.Indexer
+	events   Publisher
+	interval time.Duration
+}
+
+// NewSweeper creates a sweeper that runs every SNOOZE_SWEEP_INTERVAL
+func NewSweeper(db *database.DB, indexer search.Indexer, events Publisher) *Sweeper {
+	return &Sweeper{
+		tasks:    repository.NewTaskRepository(db),
+		indexer:  indexer,
+		events:   events,
+		interval: config.Duration("SNOOZE_SWEEP_INTERVAL", time.Minute),
+	}
+}
+
+// Start runs the sweeper in the background until ctx is done. A zero interval
+// disables it.
+func (s *Sweeper) Start(ctx context.Context) {
+	if s.interval <= 0 {
+		log.Println("⚠️  Snooze sweeper disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.run(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping snooze sweeper...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Snooze sweeper running every %s", s.interval)
+}
+
+// run wakes snoozed tasks until none are left
+func (s *Sweeper) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		tasks, err := s.tasks.WakeSnoozed(ctx, time.Now(), batchSize)
+		if err != nil {
+			log.Printf("❌ Failed to wake snoozed tasks: %v\n", err)
+			return
+		}
+
+		for i := range tasks {
+			task := &tasks[i]
+			s.indexer.Index(ctx, *task)
+			event := models.TaskEvent{
+				EventType: models.EventTaskUpdated,
+				TaskID:    task.ID,
+				UserID:    task.UserID,
+				Task:      task,
+			}
+			if err := s.events.Publish(ctx, event); err != nil {
+				log.Printf("❌ Failed to publish wake-up of task %s: %v\n", task.ID, err)
+			}
+		}
+		if len(tasks) < batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+)
+
+// ErrDisabled is returned by the no-op store when no backend is configured
+var ErrDisabled = errors.New("attachment storage is not configured")
+
+// Store keeps attachment files in object storage
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// PresignGet returns a time-limited download URL that serves the object
+	// as an attachment named filename
+	PresignGet(ctx context.Context, key, filename string, expiry time.Duration) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NoopStore is used when no storage backend is configured
+type NoopStore struct{}
+
+func (NoopStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	return ErrDisabled
+}
+func (NoopStore) PresignGet(ctx context.Context, key, filename string, expiry time.Duration) (string, error) {
+	return "", ErrDisabled
+}
+func (NoopStore) Delete(ctx context.Context, key string) error { return ErrDisabled }
+
+// New builds the store selected by STORAGE_BACKEND. Anything other than a
+// known backend, or a backend that fails to initialize, leaves attachments
+// disabled.
+func New() Store {
+	switch backend := config.String("STORAGE_BACKEND", "none"); backend {
+	case "none":
+		return NoopStore{}
+	case "s3":
+		store, err := NewS3Store(S3Config{
+			Endpoint:  config.String("S3_ENDPOINT", "localhost:9000"),
+			Region:    config.String("S3_REGION", "us-east-1"),
+			Bucket:    config.String("S3_BUCKET", "task-attachments"),
+			AccessKey: config.String("S3_ACCESS_KEY", ""),
+			SecretKey: config.String("S3_SECRET_KEY", ""),
+			UseSSL:    config.Bool("S3_USE_SSL", false),
+		})
+		if err != nil {
+			log.Printf("⚠️  Failed to initialize S3 storage, attachments disabled: %v", err)
+			return NoopStore{}
+		}
+		log.Println("✅ Attachment storage enabled (s3)")
+		return store
+	default:
+		log.Printf("⚠️  Unknown STORAGE_BACKEND %q, attachments disabled", backend)
+		return NoopStore{}
+	}
+}
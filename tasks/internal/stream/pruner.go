@@ -0,0 +1,63 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// Pruner deletes stream events once they are too old to resume from
+type Pruner struct {
+	events    *repository.StreamRepository
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewPruner creates a pruner that runs every STREAM_PRUNE_INTERVAL and
+// deletes events recorded more than STREAM_RETENTION ago
+func NewPruner(db *database.DB) *Pruner {
+	return &Pruner{
+		events:    repository.NewStreamRepository(db),
+		retention: config.Duration("STREAM_RETENTION", 24*time.Hour),
+		interval:  config.Duration("STREAM_PRUNE_INTERVAL", time.Hour),
+	}
+}
+
+// Start runs the pruner in the background until ctx is done. A zero
+// interval disables it.
+func (p *Pruner) Start(ctx context.Context) {
+	if p.interval <= 0 {
+		log.Println("⚠️  Stream event pruner disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.run(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping stream event pruner...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Stream event pruner running every %s (retention %s)", p.interval, p.retention)
+}
+
+func (p *Pruner) run(ctx context.Context) {
+	pruned, err := p.events.Prune(ctx, time.Now().Add(-p.retention))
+	if err != nil {
+		log.Printf("❌ Failed to prune stream events: %v\n", err)
+		return
+	}
+	if pruned > 0 {
+		log.Printf("🗑️  Pruned %d stream events\n", pruned)
+	}
+}
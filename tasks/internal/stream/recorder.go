@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// Publisher publishes task change events
+type Publisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// Recorder records task events in the streams of the users they concern,
+// then passes them on to the next publisher
+type Recorder struct {
+	events *repository.StreamRepository
+	next   Publisher
+}
+
+// NewRecorder creates a recorder in front of next
+func NewRecorder(db *database.DB, next Publisher) *Recorder {
+	return &Recorder{
+		events: repository.NewStreamRepository(db),
+		next:   next,
+	}
+}
+
+// Publish records the event and publishes it. A failure to record is
+// logged rather than returned so the event still reaches the broker.
+func (r *Recorder) Publish(ctx context.Context, event models.TaskEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	recorded := event
+	// Who else was told is routing information, not part of the event
+	recorded.Watchers = nil
+	if err := r.events.Append(ctx, recipients(event), recorded); err != nil {
+		log.Printf("⚠️  Failed to record %s for task %s in streams: %v\n", event.EventType, event.TaskID, err)
+	}
+	return r.next.Publish(ctx, event)
+}
+
+// recipients lists the users an event concerns: the owner, the task's
+// watchers and anyone it names
+func recipients(event models.TaskEvent) []uuid.UUID {
+	users := []uuid.UUID{event.UserID}
+	users = append(users, event.Watchers...)
+	if event.MentionedUserID != nil {
+		users = append(users, *event.MentionedUserID)
+	}
+	if event.Delegation != nil {
+		users = append(users, event.Delegation.ToUserID)
+	}
+
+	seen := make(map[uuid.UUID]bool, len(users))
+	unique := users[:0]
+	for _, user := range users {
+		if !seen[user] {
+			seen[user] = true
+			unique = append(unique, user)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,79 @@
+// Package testdb opens a throwaway copy of the schema for tests that need
+// PostgreSQL. Tests using it are skipped unless TEST_DATABASE_URL is set.
+package testdb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+)
+
+// Open connects to TEST_DATABASE_URL, loads init.sql into a new schema and
+// returns a connection whose search_path is that schema. The schema is
+// dropped when the test ends.
+func Open(t *testing.T) *database.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	admin, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "test_" + hex.EncodeToString(suffix)
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	db, err := sqlx.Connect("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("connect to test schema: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	initSQL, err := os.ReadFile(schemaFile())
+	if err != nil {
+		t.Fatalf("read init.sql: %v", err)
+	}
+	if _, err := db.Exec(string(initSQL)); err != nil {
+		t.Fatalf("load init.sql: %v", err)
+	}
+	return &database.DB{DB: db}
+}
+
+// withSearchPath adds a search_path startup parameter to a URL or
+// key=value connection string
+func withSearchPath(dsn, schema string) string {
+	if !strings.Contains(dsn, "://") {
+		return dsn + " search_path=" + schema
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return dsn
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// schemaFile locates init.sql at the module root
+func schemaFile() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "init.sql")
+}
@@ -0,0 +1,85 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/moabdelazem/microservices/tasks/internal/storage"
+)
+
+// batchSize bounds how many trashed tasks are purged per transaction
+const batchSize = 100
+
+// Purger permanently deletes tasks that have been in the trash longer than
+// the retention period, along with their stored attachments
+type Purger struct {
+	tasks     *repository.TaskRepository
+	store     storage.Store
+	retention time.Duration
+	interval  time.Duration
+}
+
+// NewPurger creates a purger that runs every TRASH_PURGE_INTERVAL and deletes
+// tasks trashed more than TRASH_RETENTION ago
+func NewPurger(db *database.DB, store storage.Store) *Purger {
+	return &Purger{
+		tasks:     repository.NewTaskRepository(db),
+		store:     store,
+		retention: config.Duration("TRASH_RETENTION", 30*24*time.Hour),
+		interval:  config.Duration("TRASH_PURGE_INTERVAL", time.Hour),
+	}
+}
+
+// Start runs the purger in the background until ctx is done. A zero interval
+// disables it.
+func (p *Purger) Start(ctx context.Context) {
+	if p.interval <= 0 {
+		log.Println("⚠️  Trash purger disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			p.run(ctx)
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping trash purger...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Trash purger running every %s (retention %s)", p.interval, p.retention)
+}
+
+// run purges expired tasks in batches until none are left
+func (p *Purger) run(ctx context.Context) {
+	cutoff := time.Now().Add(-p.retention)
+	for ctx.Err() == nil {
+		purged, keys, err := p.tasks.PurgeTrashed(ctx, cutoff, batchSize)
+		if err != nil {
+			log.Printf("❌ Failed to purge trashed tasks: %v\n", err)
+			return
+		}
+		if purged == 0 {
+			return
+		}
+		log.Printf("🗑️  Purged %d trashed tasks\n", purged)
+
+		for _, key := range keys {
+			if err := p.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrDisabled) {
+				log.Printf("⚠️  Failed to delete stored attachment %s: %v\n", key, err)
+			}
+		}
+		if purged < batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package versioning
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header carries the API version: responses name the version that served
+// them, and requests to the unversioned paths may use it to pick one
+const Header = "X-API-Version"
+
+// prefix is the root of every API path; version N is served under /api/vN
+const prefix = "/api"
+
+// Version is one major version of the HTTP API. Breaking changes ship as a
+// new Version alongside the old ones.
+type Version struct {
+	Number int
+	// Routes registers the version's routes relative to its root
+	Routes func(root *gin.RouterGroup)
+}
+
+// Name is the version as it appears in paths and headers, e.g. "v1"
+func (v Version) Name() string {
+	return "v" + strconv.Itoa(v.Number)
+}
+
+// Router serves the versions of the API mounted on a gin engine
+type Router struct {
+	engine   *gin.Engine
+	legacy   int
+	versions map[int]Version
+}
+
+// Mount registers each version's routes under /api/vN. The legacy version
+// is also registered under the unversioned /api paths, which clients used
+// before versioning and which stay as aliases of it.
+func Mount(engine *gin.Engine, legacy int, versions ...Version) *Router {
+	r := &Router{engine: engine, legacy: legacy, versions: make(map[int]Version, len(versions))}
+	for _, version := range versions {
+		r.versions[version.Number] = version
+		version.Routes(engine.Group(prefix+"/"+version.Name(), stamp(version)))
+		if version.Number == legacy {
+			version.Routes(engine.Group(prefix, stamp(version)))
+		}
+	}
+	return r
+}
+
+// stamp names the version in every response it serves
+func stamp(version Version) gin.HandlerFunc {
+	name := version.Name()
+	return func(c *gin.Context) {
+		c.Header(Header, name)
+		c.Next()
+	}
+}
+
+// ServeHTTP routes requests through the engine. A request to an
+// unversioned path that names a version in X-API-Version ("2" or "v2") is
+// served by that version; an unknown version gets 400.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if requested := req.Header.Get(Header); requested != "" && r.unversioned(req.URL.Path) {
+		number, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(requested)), "v"))
+		version, ok := r.versions[number]
+		if err != nil || !ok {
+			r.unsupported(w, requested)
+			return
+		}
+		if version.Number != r.legacy {
+			req.URL.Path = prefix + "/" + version.Name() + strings.TrimPrefix(req.URL.Path, prefix)
+			req.URL.RawPath = ""
+		}
+	}
+	r.engine.ServeHTTP(w, req)
+}
+
+// unversioned reports whether path is an API path without a version
+func (r *Router) unversioned(path string) bool {
+	rest, ok := strings.CutPrefix(path, prefix+"/")
+	if !ok {
+		return false
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	if number, ok := strings.CutPrefix(segment, "v"); ok {
+		if _, err := strconv.Atoi(number); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// unsupported rejects a request for a version the service doesn't serve
+func (r *Router) unsupported(w http.ResponseWriter, requested string) {
+	numbers := make([]int, 0, len(r.versions))
+	for number := range r.versions {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	supported := make([]string, len(numbers))
+	for i, number := range numbers {
+		supported[i] = r.versions[number].Name()
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(gin.H{
+		"error":              "Unsupported API version " + strconv.Quote(requested),
+		"supported_versions": supported,
+	})
+}
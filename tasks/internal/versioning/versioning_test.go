@@ -0,0 +1,129 @@
+package versioning
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter mounts v1 and v2, each answering GET /tasks with its name
+// and v2 alone adding /tasks/board, with v1 as the legacy version
+func newTestRouter() *Router {
+	gin.SetMode(gin.TestMode)
+	answer := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) { c.String(http.StatusOK, name) }
+	}
+	return Mount(gin.New(), 1,
+		Version{Number: 1, Routes: func(root *gin.RouterGroup) {
+			root.GET("/tasks", answer("v1 tasks"))
+		}},
+		Version{Number: 2, Routes: func(root *gin.RouterGroup) {
+			root.GET("/tasks", answer("v2 tasks"))
+			root.GET("/tasks/board", answer("v2 board"))
+		}},
+	)
+}
+
+func get(router http.Handler, path, version string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if version != "" {
+		req.Header.Set(Header, version)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMountServesEachVersion(t *testing.T) {
+	router := newTestRouter()
+	for path, want := range map[string]string{
+		"/api/v1/tasks":       "v1",
+		"/api/v2/tasks":       "v2",
+		"/api/v2/tasks/board": "v2",
+		"/api/tasks":          "v1",
+	} {
+		t.Run(path, func(t *testing.T) {
+			w := get(router, path, "")
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), want)
+			assert.Equal(t, want, w.Header().Get(Header), "responses name the version that served them")
+		})
+	}
+
+	assert.Equal(t, http.StatusNotFound, get(router, "/api/v1/tasks/board", "").Code, "v1 lacks v2's routes")
+	assert.Equal(t, http.StatusNotFound, get(router, "/api/tasks/board", "").Code, "legacy paths alias v1 only")
+}
+
+func TestHeaderSelectsVersionOfUnversionedPaths(t *testing.T) {
+	router := newTestRouter()
+	for requested, want := range map[string]string{
+		"2":    "v2 tasks",
+		"v2":   "v2 tasks",
+		" V2 ": "v2 tasks",
+		"1":    "v1 tasks",
+		"v1":   "v1 tasks",
+	} {
+		t.Run(requested, func(t *testing.T) {
+			w := get(router, "/api/tasks", requested)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, want, w.Body.String())
+		})
+	}
+
+	w := get(router, "/api/tasks/board", "v2")
+	assert.Equal(t, "v2 board", w.Body.String())
+}
+
+func TestHeaderDoesNotOverrideVersionedPaths(t *testing.T) {
+	router := newTestRouter()
+	w := get(router, "/api/v1/tasks", "v2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "v1 tasks", w.Body.String())
+
+	// Even an unsupported version is ignored on a versioned path
+	assert.Equal(t, http.StatusOK, get(router, "/api/v2/tasks", "v9").Code)
+}
+
+func TestUnsupportedVersionIs400(t *testing.T) {
+	router := newTestRouter()
+	for _, requested := range []string{"3", "v0", "latest", "v"} {
+		t.Run(requested, func(t *testing.T) {
+			w := get(router, "/api/tasks", requested)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Contains(t, body["error"], "Unsupported API version")
+			assert.Equal(t, []interface{}{"v1", "v2"}, body["supported_versions"])
+		})
+	}
+}
+
+func TestNonAPIPathsIgnoreHeader(t *testing.T) {
+	router := newTestRouter()
+	router.engine.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := get(router, "/health", "v9")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(Header))
+}
+
+func TestUnversioned(t *testing.T) {
+	r := &Router{}
+	for path, want := range map[string]bool{
+		"/api/tasks":         true,
+		"/api/tasks/v2":      true,
+		"/api/version/tasks": true,
+		"/api/v1/tasks":      false,
+		"/api/v12":           false,
+		"/api":               false,
+		"/health":            false,
+	} {
+		assert.Equal(t, want, r.unversioned(path), path)
+	}
+}
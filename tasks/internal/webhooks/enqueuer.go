@@ -0,0 +1,47 @@
+package webhooks
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+// Publisher publishes task change events
+type Publisher interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+// Enqueuer queues task events for the webhooks of the task's owner, then
+// passes them on to the next publisher. The worker delivers them.
+type Enqueuer struct {
+	webhooks *repository.WebhookRepository
+	next     Publisher
+}
+
+// NewEnqueuer creates an enqueuer in front of next
+func NewEnqueuer(db *database.DB, next Publisher) *Enqueuer {
+	return &Enqueuer{
+		webhooks: repository.NewWebhookRepository(db),
+		next:     next,
+	}
+}
+
+// Publish queues the event and publishes it. A failure to queue is logged
+// rather than returned so the event still reaches the broker.
+func (e *Enqueuer) Publish(ctx context.Context, event models.TaskEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	queued := event
+	// Who else was told is routing information, not part of the event
+	queued.Watchers = nil
+	if err := e.webhooks.Enqueue(ctx, event.UserID, queued); err != nil {
+		log.Printf("⚠️  Failed to queue %s for task %s for webhooks: %v\n", event.EventType, event.TaskID, err)
+	}
+	return e.next.Publish(ctx, event)
+}
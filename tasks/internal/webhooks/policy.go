@@ -0,0 +1,114 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/linkmeta"
+)
+
+// ErrBlockedURL means a webhook URL is not allowed by the policy
+var ErrBlockedURL = errors.New("webhook URL is not allowed")
+
+// Policy decides which URLs webhooks may be registered with and delivered
+// to. Non-public addresses are always refused; WEBHOOK_ALLOWED_HOSTS
+// narrows the hosts further and WEBHOOK_DENIED_CIDRS refuses more ranges.
+type Policy struct {
+	// allowedHosts are exact hosts, or domains with a leading dot or "*."
+	// that match their subdomains; empty allows any host
+	allowedHosts []string
+	deniedNets   []*net.IPNet
+	resolver     *net.Resolver
+}
+
+// NewPolicy reads the policy from WEBHOOK_ALLOWED_HOSTS and
+// WEBHOOK_DENIED_CIDRS. Malformed CIDRs are logged and skipped.
+func NewPolicy() *Policy {
+	p := &Policy{resolver: net.DefaultResolver}
+	for _, host := range config.List("WEBHOOK_ALLOWED_HOSTS") {
+		host = strings.ToLower(strings.TrimPrefix(host, "*"))
+		p.allowedHosts = append(p.allowedHosts, host)
+	}
+	for _, cidr := range config.List("WEBHOOK_DENIED_CIDRS") {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			log.Printf("⚠️  Ignoring invalid WEBHOOK_DENIED_CIDRS entry %q: %v\n", cidr, err)
+			continue
+		}
+		p.deniedNets = append(p.deniedNets, n)
+	}
+	return p
+}
+
+// CheckURL checks a webhook URL's host against the allowlist and resolves
+// it, refusing it if any address it resolves to is blocked. Errors wrap
+// ErrBlockedURL.
+func (p *Policy) CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBlockedURL, err)
+	}
+	host := u.Hostname()
+	if err := p.CheckHost(host); err != nil {
+		return err
+	}
+
+	addrs, err := p.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: %s could not be resolved", ErrBlockedURL, host)
+	}
+	for _, addr := range addrs {
+		if err := p.CheckIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckHost checks a host name against WEBHOOK_ALLOWED_HOSTS
+func (p *Policy) CheckHost(host string) error {
+	if len(p.allowedHosts) == 0 {
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range p.allowedHosts {
+		if host == allowed || (strings.HasPrefix(allowed, ".") && strings.HasSuffix(host, allowed)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in WEBHOOK_ALLOWED_HOSTS", ErrBlockedURL, host)
+}
+
+// CheckIP refuses non-public addresses and those in WEBHOOK_DENIED_CIDRS
+func (p *Policy) CheckIP(ip net.IP) error {
+	if !linkmeta.IsPublic(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrBlockedURL, ip)
+	}
+	for _, n := range p.deniedNets {
+		if n.Contains(ip) {
+			return fmt.Errorf("%w: %s is in a denied range", ErrBlockedURL, ip)
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control that applies CheckIP to every connection,
+// so a host that resolves differently at delivery time is still refused
+func (p *Policy) Control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: %s is not an IP address", ErrBlockedURL, host)
+	}
+	return p.CheckIP(ip)
+}
@@ -0,0 +1,89 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheckURL(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "")
+	t.Setenv("WEBHOOK_DENIED_CIDRS", "203.0.114.0/24, not-a-cidr")
+	p := NewPolicy()
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"https://93.184.216.34/hook", true},
+		{"https://[2606:2800:220:1::1]/hook", true},
+		{"http://127.0.0.1/hook", false},
+		{"http://10.0.0.5:8080/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://100.64.0.1/hook", false},
+		{"http://0.0.0.0/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[::ffff:169.254.169.254]/hook", false},
+		{"http://[::ffff:10.0.0.1]/hook", false},
+		{"http://[64:ff9b::a9fe:a9fe]/hook", false},
+		{"http://[fd00:ec2::254]/hook", false},
+		{"https://203.0.114.10/hook", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := p.CheckURL(context.Background(), tt.url)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrBlockedURL)
+			}
+		})
+	}
+}
+
+func TestPolicyAllowedHosts(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "hooks.example.com,*.partner.io")
+	t.Setenv("WEBHOOK_DENIED_CIDRS", "")
+	p := NewPolicy()
+
+	tests := []struct {
+		host    string
+		allowed bool
+	}{
+		{"hooks.example.com", true},
+		{"HOOKS.example.com.", true},
+		{"api.partner.io", true},
+		{"a.b.partner.io", true},
+		{"example.com", false},
+		{"evil-hooks.example.com", false},
+		{"partner.io", false},
+		{"notpartner.io", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			err := p.CheckHost(tt.host)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrBlockedURL)
+			}
+		})
+	}
+
+	// A listed host still has to resolve to a public address
+	assert.ErrorIs(t, p.CheckURL(context.Background(), "http://10.0.0.1/hook"), ErrBlockedURL)
+}
+
+func TestPolicyControl(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "")
+	t.Setenv("WEBHOOK_DENIED_CIDRS", "")
+	p := NewPolicy()
+
+	assert.NoError(t, p.Control("tcp4", "93.184.216.34:443", nil))
+	assert.ErrorIs(t, p.Control("tcp4", "127.0.0.1:443", nil), ErrBlockedURL)
+	assert.ErrorIs(t, p.Control("tcp6", net.JoinHostPort("::ffff:127.0.0.1", "80"), nil), ErrBlockedURL)
+	assert.True(t, errors.Is(p.Control("tcp", "not-an-ip:80", nil), ErrBlockedURL))
+}
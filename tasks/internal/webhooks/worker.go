@@ -0,0 +1,220 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/moabdelazem/microservices/tasks/internal/config"
+	"github.com/moabdelazem/microservices/tasks/internal/database"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+)
+
+const (
+	// batchSize bounds how many deliveries are claimed per query
+	batchSize = 50
+	// maxErrorLength bounds the error kept for a failed attempt
+	maxErrorLength = 500
+)
+
+// SignatureHeader carries "t=<unix time>,v1=<hex HMAC-SHA256>", the HMAC of
+// "<unix time>.<body>" keyed with the webhook's secret. Receivers should
+// check it and reject old timestamps to stop replays.
+const SignatureHeader = "X-Webhook-Signature"
+
+// Worker delivers queued webhook events, retrying failures with exponential
+// backoff. Deliveries are claimed with SKIP LOCKED, so several instances can
+// run workers side by side.
+type Worker struct {
+	webhooks    *repository.WebhookRepository
+	policy      *Policy
+	client      *http.Client
+	interval    time.Duration
+	maxAttempts int
+	retryBase   time.Duration
+	retryMax    time.Duration
+	retention   time.Duration
+}
+
+// NewWorker creates a worker that polls every WEBHOOK_POLL_INTERVAL
+func NewWorker(db *database.DB) *Worker {
+	timeout := config.Duration("WEBHOOK_TIMEOUT", 10*time.Second)
+	policy := NewPolicy()
+	return &Worker{
+		webhooks: repository.NewWebhookRepository(db),
+		policy:   policy,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// Webhook URLs are user supplied, so every connection is
+				// checked against the policy after name resolution
+				DialContext: (&net.Dialer{
+					Timeout: 5 * time.Second,
+					Control: policy.Control,
+				}).DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConns:        20,
+				IdleConnTimeout:     30 * time.Second,
+			},
+			// A redirect could lead anywhere, so it counts as a failure
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		interval:    config.Duration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		maxAttempts: config.Int("WEBHOOK_MAX_ATTEMPTS", 8),
+		retryBase:   config.Duration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		retryMax:    config.Duration("WEBHOOK_RETRY_MAX", time.Hour),
+		retention:   config.Duration("WEBHOOK_DELIVERY_RETENTION", 30*24*time.Hour),
+	}
+}
+
+// Start runs the worker in the background until ctx is done. A zero interval
+// disables it.
+func (w *Worker) Start(ctx context.Context) {
+	if w.interval <= 0 {
+		log.Println("⚠️  Webhook worker disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		lastPrune := time.Time{}
+		for {
+			w.run(ctx)
+			if time.Since(lastPrune) >= time.Hour {
+				w.prune(ctx)
+				lastPrune = time.Now()
+			}
+			select {
+			case <-ctx.Done():
+				log.Println("Stopping webhook worker...")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	log.Printf("✅ Webhook worker polling every %s", w.interval)
+}
+
+// run sends due deliveries until none are left
+func (w *Worker) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		// An attempt not recorded within the lease is retried
+		due, err := w.webhooks.ClaimDue(ctx, time.Now(), batchSize, w.client.Timeout+time.Minute)
+		if err != nil {
+			log.Printf("❌ Failed to claim webhook deliveries: %v\n", err)
+			return
+		}
+		for i := range due {
+			w.deliver(ctx, &due[i])
+		}
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+// deliver makes one attempt at a delivery and records its outcome
+func (w *Worker) deliver(ctx context.Context, delivery *repository.ClaimedDelivery) {
+	responseStatus, err := w.send(ctx, delivery)
+	attempts := delivery.Attempts + 1
+
+	status := models.DeliverySucceeded
+	var errMsg *string
+	var nextAttempt *time.Time
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		errMsg = &msg
+		status = models.DeliveryFailed
+		if attempts < w.maxAttempts {
+			status = models.DeliveryPending
+			next := time.Now().Add(w.backoff(attempts))
+			nextAttempt = &next
+		}
+		log.Printf("⚠️  Webhook delivery %s failed (attempt %d of %d): %v\n", delivery.ID, attempts, w.maxAttempts, err)
+	}
+
+	if err := w.webhooks.RecordAttempt(ctx, delivery.ID, status, responseStatus, errMsg, nextAttempt); err != nil {
+		log.Printf("❌ Failed to record webhook delivery %s: %v\n", delivery.ID, err)
+	}
+}
+
+// send posts a delivery's payload, signed, to its webhook. Any response
+// other than 2xx is an error.
+func (w *Worker) send(ctx context.Context, delivery *repository.ClaimedDelivery) (*int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	// The allowlist may have changed since the webhook was registered
+	if err := w.policy.CheckHost(req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tasks-service-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, time.Now(), delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// Drained so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return &resp.StatusCode, nil
+}
+
+// backoff returns the wait before the next attempt after attempts failures:
+// WEBHOOK_RETRY_BASE doubled each time, up to WEBHOOK_RETRY_MAX
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.retryBase
+	for i := 1; i < attempts && delay < w.retryMax; i++ {
+		delay *= 2
+	}
+	if delay > w.retryMax {
+		delay = w.retryMax
+	}
+	return delay
+}
+
+// prune deletes finished deliveries older than WEBHOOK_DELIVERY_RETENTION
+func (w *Worker) prune(ctx context.Context) {
+	pruned, err := w.webhooks.PruneDeliveries(ctx, time.Now().Add(-w.retention))
+	if err != nil {
+		log.Printf("❌ Failed to prune webhook deliveries: %v\n", err)
+		return
+	}
+	if pruned > 0 {
+		log.Printf("🗑️  Pruned %d webhook deliveries\n", pruned)
+	}
+}
+
+// Sign returns the signature header value for a payload sent at t
+func Sign(secret string, t time.Time, payload []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,91 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/moabdelazem/microservices/tasks/internal/models"
+	"github.com/moabdelazem/microservices/tasks/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verify checks a signature header the way a receiver should
+func verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) bool {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || now.Sub(time.Unix(unix, 0)).Abs() > tolerance {
+		return false
+	}
+	want, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event_type":"task.created"}`)
+	now := time.Unix(1700000000, 0)
+	header := Sign("secret", now, body)
+
+	assert.True(t, strings.HasPrefix(header, "t=1700000000,v1="))
+	assert.True(t, verify("secret", header, body, now, 5*time.Minute))
+	assert.False(t, verify("other", header, body, now, 5*time.Minute), "wrong secret")
+	assert.False(t, verify("secret", header, []byte(`{"event_type":"task.deleted"}`), now, 5*time.Minute), "tampered body")
+	assert.False(t, verify("secret", header, body, now.Add(time.Hour), 5*time.Minute), "replayed")
+	assert.NotEqual(t, header, Sign("secret", now.Add(time.Second), body), "timestamp is signed")
+}
+
+// TestSendRefusesPrivateAddress checks the dialer refuses a loopback
+// receiver before any request reaches it
+func TestSendRefusesPrivateAddress(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "")
+	t.Setenv("WEBHOOK_DENIED_CIDRS", "")
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	w := NewWorker(nil)
+	_, err := w.send(context.Background(), &repository.ClaimedDelivery{
+		WebhookDelivery: models.WebhookDelivery{ID: uuid.New(), EventType: models.EventTaskCreated, Payload: []byte("{}")},
+		URL:             server.URL,
+		Secret:          "secret",
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrBlockedURL)
+	assert.False(t, hit)
+}
+
+func TestSendChecksAllowedHosts(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "hooks.example.com")
+	w := NewWorker(nil)
+	_, err := w.send(context.Background(), &repository.ClaimedDelivery{
+		WebhookDelivery: models.WebhookDelivery{ID: uuid.New(), Payload: []byte("{}")},
+		URL:             "https://other.example.com/hook",
+	})
+	assert.ErrorIs(t, err, ErrBlockedURL)
+}